@@ -17,6 +17,7 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,18 +26,26 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"path/filepath"
 
+	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
 	dockerreference "github.com/containerd/containerd/reference/docker"
 	"github.com/containerd/nerdctl/pkg/buildkitutil"
 	"github.com/containerd/nerdctl/pkg/defaults"
+	"github.com/containerd/nerdctl/pkg/imgutil/squash"
 	"github.com/containerd/nerdctl/pkg/platformutil"
 	"github.com/containerd/nerdctl/pkg/strutil"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func newBuildCommand() *cobra.Command {
@@ -51,15 +60,16 @@ func newBuildCommand() *cobra.Command {
 	buildCommand.Flags().StringArrayP("tag", "t", nil, "Name and optionally a tag in the 'name:tag' format")
 	buildCommand.Flags().StringP("file", "f", "", "Name of the Dockerfile")
 	buildCommand.Flags().String("target", "", "Set the target build stage to build")
-	buildCommand.Flags().StringArray("build-arg", nil, "Set build-time variables")
+	buildCommand.Flags().StringArray("build-arg", nil, "Set build-time variables (format: KEY=VALUE, or KEY to take the value from the environment)")
 	buildCommand.Flags().Bool("no-cache", false, "Do not use cache when building the image")
+	buildCommand.Flags().StringArray("no-cache-filter", nil, "Do not use cache for the specified stages")
 	buildCommand.Flags().StringP("output", "o", "", "Output destination (format: type=local,dest=path)")
 	buildCommand.Flags().String("progress", "auto", "Set type of progress output (auto, plain, tty). Use plain to show container output")
-	buildCommand.Flags().StringArray("secret", nil, "Secret file to expose to the build: id=mysecret,src=/local/secret")
+	buildCommand.Flags().StringArray("secret", nil, "Secret file or environment variable to expose to the build: id=mysecret,src=/local/secret or id=mysecret,env=SECRET_ENV")
 	buildCommand.Flags().StringArray("ssh", nil, "SSH agent socket or keys to expose to the build (format: default|<id>[=<socket>|<key>[,<key>]])")
 	buildCommand.Flags().BoolP("quiet", "q", false, "Suppress the build output and print image ID on success")
-	buildCommand.Flags().StringArray("cache-from", nil, "External cache sources (eg. user/app:cache, type=local,src=path/to/dir)")
-	buildCommand.Flags().StringArray("cache-to", nil, "Cache export destinations (eg. user/app:cache, type=local,dest=path/to/dir)")
+	buildCommand.Flags().StringArray("cache-from", nil, "External cache sources (eg. user/app:cache, type=registry,ref=user/app:cache, type=local,src=path/to/dir)")
+	buildCommand.Flags().StringArray("cache-to", nil, "Cache export destinations (eg. user/app:cache, type=registry,ref=user/app:cache,mode=max, type=local,dest=path/to/dir, type=inline)")
 	buildCommand.Flags().Bool("rm", true, "Remove intermediate containers after a successful build")
 
 	// #region platform flags
@@ -71,6 +81,14 @@ func newBuildCommand() *cobra.Command {
 	buildCommand.Flags().Bool("ipfs", false, "Allow pulling base images from IPFS")
 	buildCommand.Flags().String("iidfile", "", "Write the image ID to the file")
 	buildCommand.Flags().StringArray("label", nil, "Set metadata for an image")
+	buildCommand.Flags().Bool("squash", false, "Squash all the layers of the image into a single layer")
+	buildCommand.Flags().StringArray("opt", nil, "Set a frontend attribute of BuildKit as-is (format: KEY=VALUE, e.g., \"build-arg:FOO=bar\", \"context:name=docker-image://alpine\"). Advanced, not validated")
+	buildCommand.Flags().StringArray("annotation", nil, "Add annotations to the image (format: [TYPE[,TYPE...]:]KEY=VALUE, TYPE: manifest|index|manifest-descriptor|index-descriptor)")
+
+	// #region attestation flags
+	buildCommand.Flags().String("sbom", "", "Add an SBOM attestation to the image (e.g., \"true\", \"generator=<image>\")")
+	buildCommand.Flags().String("provenance", "", "Add a provenance attestation to the image (e.g., \"true\", \"mode=max\")")
+	// #endregion
 
 	return buildCommand
 }
@@ -128,7 +146,19 @@ func buildAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	platform = strutil.DedupeStrSlice(platform)
+	platform, err = normalizeBuildPlatforms(platform)
+	if err != nil {
+		return err
+	}
+	squashLayers, err := cmd.Flags().GetBool("squash")
+	if err != nil {
+		return err
+	}
+	if squashLayers {
+		if err := validateBuildSquash(cmd, platform); err != nil {
+			return err
+		}
+	}
 
 	buildkitHost, err := getBuildkitHost(cmd)
 	if err != nil {
@@ -142,6 +172,9 @@ func buildAction(cmd *cobra.Command, args []string) error {
 	if cleanup != nil {
 		defer cleanup()
 	}
+	if metaFile != "" {
+		defer os.Remove(metaFile)
+	}
 
 	runIPFSRegistry, err := cmd.Flags().GetBool("ipfs")
 	if err != nil {
@@ -175,10 +208,23 @@ func buildAction(cmd *cobra.Command, args []string) error {
 	} else {
 		buildctlCmd.Stdout = cmd.OutOrStdout()
 	}
-	if !quiet {
+	// the tail of the stderr is also captured for explaining the known errors,
+	// except for the tty progress that needs the console
+	progress, err := cmd.Flags().GetString("progress")
+	if err != nil {
+		return err
+	}
+	buildctlStderr := &tailBuffer{size: buildctlStderrTailSize}
+	if quiet {
+		buildctlCmd.Stderr = buildctlStderr
+	} else if isBuildProgressTTY(progress, cmd.ErrOrStderr()) {
 		buildctlCmd.Stderr = cmd.ErrOrStderr()
+	} else {
+		buildctlCmd.Stderr = io.MultiWriter(cmd.ErrOrStderr(), buildctlStderr)
 	}
 
+	// the layers created before the build are the layers of the base image, which are not squashed
+	buildStart := time.Now()
 	if err := buildctlCmd.Start(); err != nil {
 		return err
 	}
@@ -189,19 +235,36 @@ func buildAction(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		if err = loadImage(buildctlStdout, cmd, args, platMC, quiet); err != nil {
-			return err
+			// loading fails when buildctl fails, so the error of buildctl is checked too
+			buildctlCmd.Process.Kill()
+			buildctlCmd.Wait()
+			return withBuildPlatformHint(err, buildctlStderr.String(), platform)
 		}
 	}
 
 	if err = buildctlCmd.Wait(); err != nil {
-		return err
+		return withBuildPlatformHint(err, buildctlStderr.String(), platform)
+	}
+
+	var squashedTarget *ocispec.Descriptor
+	if squashLayers {
+		target, err := squashBuiltImage(cmd, tags[0], platform, buildStart)
+		if err != nil {
+			return fmt.Errorf("failed to squash image %q: %w", tags[0], err)
+		}
+		squashedTarget = &target
 	}
 
 	iidFile, _ := cmd.Flags().GetString("iidfile")
 	if iidFile != "" {
-		id, err := getDigestFromMetaFile(metaFile)
-		if err != nil {
-			return err
+		var id string
+		if squashedTarget != nil {
+			id = squashedTarget.Digest.String()
+		} else {
+			id, err = getDigestFromMetaFile(metaFile, len(platform) > 1)
+			if err != nil {
+				return err
+			}
 		}
 		if err := os.WriteFile(iidFile, []byte(id), 0600); err != nil {
 			return err
@@ -235,6 +298,91 @@ func buildAction(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// isBuildProgressTTY returns true when buildctl may show the tty progress, which needs stderr to be the console.
+func isBuildProgressTTY(progress string, stderr io.Writer) bool {
+	switch progress {
+	case "tty":
+		return true
+	case "auto", "":
+		f, ok := stderr.(*os.File)
+		return ok && term.IsTerminal(int(f.Fd()))
+	default:
+		return false
+	}
+}
+
+// buildctlStderrTailSize is the size of the tail of the buildctl stderr that is kept for explaining the errors.
+const buildctlStderrTailSize = 64 * 1024
+
+// tailBuffer is an io.Writer that keeps only the last size bytes written to it.
+type tailBuffer struct {
+	size int
+	buf  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) >= b.size {
+		b.buf = append(b.buf[:0], p[len(p)-b.size:]...)
+		return n, nil
+	}
+	if over := len(b.buf) + len(p) - b.size; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	b.buf = append(b.buf, p...)
+	return n, nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
+
+// validateBuildSquash validates the flags for --squash.
+// The squashing is done by nerdctl after loading the image, as BuildKit does not support squashing.
+func validateBuildSquash(cmd *cobra.Command, platform []string) error {
+	if len(platform) > 1 {
+		return errors.New("--squash does not support multi-platform builds")
+	}
+	for _, name := range []string{"output", "sbom", "provenance"} {
+		if v, err := cmd.Flags().GetString(name); err != nil {
+			return err
+		} else if v != "" {
+			return fmt.Errorf("--squash cannot be used with --%s", name)
+		}
+	}
+	tags, err := cmd.Flags().GetStringArray("tag")
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return errors.New("--squash requires --tag")
+	}
+	return nil
+}
+
+// squashBuiltImage squashes the layers of the image built as ref since buildStart, and returns the new target of the image.
+func squashBuiltImage(cmd *cobra.Command, ref string, platform []string, buildStart time.Time) (ocispec.Descriptor, error) {
+	platMC, err := platformutil.NewMatchComparer(false, platform)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	client, ctx, cancel, err := newClient(cmd, containerd.WithDefaultPlatform(platMC))
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer cancel()
+	snapshotter, err := cmd.Flags().GetString("snapshotter")
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return squash.Squash(ctx, client, &squash.Opts{
+		Ref:         ref,
+		Snapshotter: snapshotter,
+		Platform:    platMC,
+		Since:       buildStart,
+	})
+}
+
 func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, args []string) (buildCtlBinary string,
 	buildctlArgs []string, needsLoading bool, metaFile string, tags []string, cleanup func(), err error) {
 	if len(args) < 1 {
@@ -250,6 +398,11 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		return "", nil, false, "", nil, nil, err
 	}
 
+	attestOpts, err := generateAttestationOpts(cmd)
+	if err != nil {
+		return "", nil, false, "", nil, nil, err
+	}
+
 	output, err := cmd.Flags().GetString("output")
 	if err != nil {
 		return "", nil, false, "", nil, nil, err
@@ -280,18 +433,55 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 			output = "type=image,unpack=true" // ensure the target stage is unlazied (needed for any snapshotters)
 		} else {
 			output = "type=docker"
-			if len(platform) > 1 {
+			if len(platform) > 1 || len(attestOpts) > 0 {
 				// For avoiding `error: failed to solve: docker exporter does not currently support exporting manifest lists`
+				// (attestations are stored as extra manifests in the index)
 				// TODO: consider using type=oci for single-platform build too
 				output = "type=oci"
 			}
 			needsLoading = true
 		}
 	} else {
+		output, err = normalizeBuildOutput(output)
+		if err != nil {
+			return "", nil, false, "", nil, nil, err
+		}
+		if len(platform) > 1 && strings.Contains(output, "type=docker") {
+			return "", nil, false, "", nil, nil, fmt.Errorf("invalid --output %q: type=docker does not support multi-platform builds, use type=oci instead", output)
+		}
+		if strings.Contains(output, "push=true") {
+			insecure, err := cmd.Flags().GetBool("insecure-registry")
+			if err != nil {
+				return "", nil, false, "", nil, nil, err
+			}
+			if insecure && !strings.Contains(output, "registry.insecure=") {
+				output += ",registry.insecure=true"
+			}
+		}
 		if strings.Contains(output, "type=docker") || strings.Contains(output, "type=oci") {
 			needsLoading = true
 		}
 	}
+	annotations, err := cmd.Flags().GetStringArray("annotation")
+	if err != nil {
+		return "", nil, false, "", nil, nil, err
+	}
+	if annotations = strutil.DedupeStrSlice(annotations); len(annotations) > 0 {
+		if strings.Contains(output, "type=local") || strings.Contains(output, "type=tar") {
+			return "", nil, false, "", nil, nil, errors.New("--annotation is only supported for image outputs (type=image, type=docker, type=oci)")
+		}
+		for _, a := range annotations {
+			attrs, err := parseAnnotationFlag(a)
+			if err != nil {
+				return "", nil, false, "", nil, nil, err
+			}
+			output += "," + strings.Join(attrs, ",")
+		}
+		if !strings.Contains(output, "oci-mediatypes=") {
+			// Docker media types cannot hold annotations
+			output += ",oci-mediatypes=true"
+		}
+	}
 	tagValue, err := cmd.Flags().GetStringArray("tag")
 	if err != nil {
 		return "", nil, false, "", nil, nil, err
@@ -303,7 +493,10 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		if err != nil {
 			return "", nil, false, "", nil, nil, err
 		}
-		output += ",name=" + dockerreference.TagNameOnly(named).String()
+		// the local and tar exporters do not produce images, and "name=" in --output takes precedence over --tag
+		if !strings.Contains(output, "type=local") && !strings.Contains(output, "type=tar") && !strings.Contains(output, "name=") {
+			output += ",name=" + dockerreference.TagNameOnly(named).String()
+		}
 
 		// pick the first tag and add it to output
 		for idx, tag := range tags {
@@ -370,6 +563,9 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		return "", nil, false, "", nil, cleanup, err
 	}
 	if target != "" {
+		if err := validateBuildTarget(filepath.Join(absDir, file), target); err != nil {
+			return "", nil, false, "", nil, cleanup, err
+		}
 		buildctlArgs = append(buildctlArgs, "--opt=target="+target)
 	}
 
@@ -382,21 +578,33 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		return "", nil, false, "", nil, cleanup, err
 	}
 	for _, ba := range strutil.DedupeStrSlice(buildArgsValue) {
+		ba, ok := resolveBuildArg(ba)
+		if !ok {
+			continue
+		}
 		buildctlArgs = append(buildctlArgs, "--opt=build-arg:"+ba)
 
 		// Support `--build-arg BUILDKIT_INLINE_CACHE=1` for compatibility with `docker buildx build`
 		// https://github.com/docker/buildx/blob/v0.6.3/docs/reference/buildx_build.md#-export-build-cache-to-an-external-cache-destination---cache-to
-		if strings.HasPrefix(ba, "BUILDKIT_INLINE_CACHE=") {
-			bic := strings.TrimPrefix(ba, "BUILDKIT_INLINE_CACHE=")
-			bicParsed, err := strconv.ParseBool(bic)
-			if err == nil {
-				if bicParsed {
-					buildctlArgs = append(buildctlArgs, "--export-cache=type=inline")
-				}
-			} else {
-				logrus.WithError(err).Warnf("invalid BUILDKIT_INLINE_CACHE: %q", bic)
-			}
+		if inlineCacheEnabled(ba) {
+			buildctlArgs = append(buildctlArgs, "--export-cache=type=inline")
+		}
+	}
+
+	optValue, err := cmd.Flags().GetStringArray("opt")
+	if err != nil {
+		return "", nil, false, "", nil, cleanup, err
+	}
+	optArgs, err := buildctlOptArgs(strutil.DedupeStrSlice(optValue))
+	if err != nil {
+		return "", nil, false, "", nil, cleanup, err
+	}
+	for _, a := range optArgs {
+		// the inline cache export may be already enabled by `--build-arg BUILDKIT_INLINE_CACHE=1`
+		if strings.HasPrefix(a, "--export-cache=") && strutil.InStringSlice(buildctlArgs, a) {
+			continue
 		}
+		buildctlArgs = append(buildctlArgs, a)
 	}
 
 	labels, err := cmd.Flags().GetStringArray("label")
@@ -408,12 +616,24 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		buildctlArgs = append(buildctlArgs, "--opt=label:"+l)
 	}
 
+	buildctlArgs = append(buildctlArgs, attestOpts...)
+
 	noCache, err := cmd.Flags().GetBool("no-cache")
 	if err != nil {
 		return "", nil, false, "", nil, cleanup, err
 	}
+	noCacheFilter, err := cmd.Flags().GetStringArray("no-cache-filter")
+	if err != nil {
+		return "", nil, false, "", nil, cleanup, err
+	}
 	if noCache {
+		if len(noCacheFilter) > 0 {
+			logrus.Warn("ignoring --no-cache-filter, as --no-cache disables the cache for all the stages")
+		}
 		buildctlArgs = append(buildctlArgs, "--no-cache")
+	} else if len(noCacheFilter) > 0 {
+		// the dockerfile frontend accepts the comma-separated stage names as "no-cache"
+		buildctlArgs = append(buildctlArgs, "--opt=no-cache="+strings.Join(strutil.DedupeStrSlice(noCacheFilter), ","))
 	}
 
 	secretValue, err := cmd.Flags().GetStringArray("secret")
@@ -421,6 +641,9 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		return "", nil, false, "", nil, cleanup, err
 	}
 	for _, s := range strutil.DedupeStrSlice(secretValue) {
+		if err := validateBuildSecret(s); err != nil {
+			return "", nil, false, "", nil, cleanup, err
+		}
 		buildctlArgs = append(buildctlArgs, "--secret="+s)
 	}
 
@@ -429,6 +652,9 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		return "", nil, false, "", nil, cleanup, err
 	}
 	for _, s := range strutil.DedupeStrSlice(sshValue) {
+		if err := validateBuildSSH(s); err != nil {
+			return "", nil, false, "", nil, cleanup, err
+		}
 		buildctlArgs = append(buildctlArgs, "--ssh="+s)
 	}
 
@@ -437,10 +663,11 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		return "", nil, false, "", nil, cleanup, err
 	}
 	for _, s := range strutil.DedupeStrSlice(cacheFrom) {
-		if !strings.Contains(s, "type=") {
-			s = "type=registry,ref=" + s
+		c, err := parseBuildCache(s, false)
+		if err != nil {
+			return "", nil, false, "", nil, cleanup, err
 		}
-		buildctlArgs = append(buildctlArgs, "--import-cache="+s)
+		buildctlArgs = append(buildctlArgs, "--import-cache="+c)
 	}
 
 	cacheTo, err := cmd.Flags().GetStringArray("cache-to")
@@ -448,10 +675,16 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 		return "", nil, false, "", nil, cleanup, err
 	}
 	for _, s := range strutil.DedupeStrSlice(cacheTo) {
-		if !strings.Contains(s, "type=") {
-			s = "type=registry,ref=" + s
+		c, err := parseBuildCache(s, true)
+		if err != nil {
+			return "", nil, false, "", nil, cleanup, err
 		}
-		buildctlArgs = append(buildctlArgs, "--export-cache="+s)
+		a := "--export-cache=" + c
+		// the inline cache export may be already enabled by `--build-arg BUILDKIT_INLINE_CACHE=1`
+		if strutil.InStringSlice(buildctlArgs, a) {
+			continue
+		}
+		buildctlArgs = append(buildctlArgs, a)
 	}
 
 	rm, err := cmd.Flags().GetBool("rm")
@@ -479,26 +712,401 @@ func generateBuildctlArgs(cmd *cobra.Command, buildkitHost string, platform, arg
 	return buildctlBinary, buildctlArgs, needsLoading, metaFile, tags, cleanup, nil
 }
 
-func getDigestFromMetaFile(path string) (string, error) {
+// validateBuildSecret validates the value of `--secret`, in the same format as `docker buildx build --secret`:
+// "id=ID,src=PATH" (type=file) or "id=ID,env=NAME" (type=env).
+// For type=env, the name of the environment variable defaults to the id.
+// The secret itself is read by buildctl, so that the value is never included in the args.
+func validateBuildSecret(s string) error {
+	m, err := strutil.ParseCSVMap(s)
+	if err != nil {
+		return fmt.Errorf("invalid secret %q: %w", s, err)
+	}
+	for k := range m {
+		switch k {
+		case "id", "type", "src", "source", "env":
+		default:
+			return fmt.Errorf("invalid secret %q: unknown key %q", s, k)
+		}
+	}
+	src := m["src"]
+	if src == "" {
+		src = m["source"]
+	}
+	env := m["env"]
+	switch typ := m["type"]; {
+	case typ == "env" || (typ == "" && env != "" && src == ""):
+		if env == "" {
+			env = m["id"]
+		}
+		if env == "" {
+			return fmt.Errorf("invalid secret %q: either env or id must be specified", s)
+		}
+		if _, ok := os.LookupEnv(env); !ok {
+			return fmt.Errorf("invalid secret %q: environment variable %q is not set", s, env)
+		}
+	case typ == "file" || typ == "":
+		if src == "" {
+			return fmt.Errorf("invalid secret %q: either src or env must be specified", s)
+		}
+		if _, err := os.Stat(src); err != nil {
+			return fmt.Errorf("invalid secret %q: %w", s, err)
+		}
+	default:
+		return fmt.Errorf("invalid secret %q: unknown type %q (expected \"file\" or \"env\")", s, typ)
+	}
+	return nil
+}
+
+// validateBuildTarget validates that the Dockerfile has the stage named target,
+// so that a typo fails with the list of the available stages.
+// The validation is skipped when the Dockerfile cannot be read.
+func validateBuildTarget(dockerfile, target string) error {
+	f, err := os.Open(dockerfile)
+	if err != nil {
+		logrus.WithError(err).Debugf("skipping the validation of the target %q", target)
+		return nil
+	}
+	defer f.Close()
+	stages, err := dockerfileStages(f)
+	if err != nil {
+		logrus.WithError(err).Debugf("skipping the validation of the target %q", target)
+		return nil
+	}
+	for _, stage := range stages {
+		// stage names are case-insensitive
+		if strings.EqualFold(stage, target) {
+			return nil
+		}
+	}
+	if len(stages) == 0 {
+		return fmt.Errorf("target stage %q could not be found: the Dockerfile has no named stages (\"FROM <image> AS <name>\")", target)
+	}
+	return fmt.Errorf("target stage %q could not be found (available stages: %s)", target, strings.Join(stages, ", "))
+}
+
+// dockerfileStages returns the names of the stages ("FROM <image> AS <name>") of the Dockerfile, in order.
+// Line continuations with the default escape character (a backslash) are supported.
+func dockerfileStages(r io.Reader) ([]string, error) {
+	var (
+		stages []string
+		line   string
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(l, "#") {
+			continue
+		}
+		if strings.HasSuffix(l, "\\") {
+			line += strings.TrimSuffix(l, "\\") + " "
+			continue
+		}
+		line += l
+		fields := strings.Fields(line)
+		line = ""
+		if len(fields) == 0 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+		// skip flags such as "--platform=..."
+		args := fields[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+			stages = append(stages, args[2])
+		}
+	}
+	return stages, sc.Err()
+}
+
+// parseBuildCache parses the value of `--cache-from` (export=false) or `--cache-to` (export=true),
+// in the same format as `docker buildx build`, and returns the value for buildctl.
+// A value without "=" is a shorthand of "type=registry,ref=<value>".
+// The type defaults to "registry" when it is omitted.
+func parseBuildCache(s string, export bool) (string, error) {
+	flagName := "--cache-from"
+	if export {
+		flagName = "--cache-to"
+	}
+	if !strings.Contains(s, "=") {
+		if s == "" {
+			return "", fmt.Errorf("invalid %s: empty value", flagName)
+		}
+		return "type=registry,ref=" + s, nil
+	}
+	m, err := strutil.ParseCSVMap(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %w", flagName, s, err)
+	}
+	typ, ok := m["type"]
+	if !ok {
+		typ = "registry"
+		s = "type=registry," + s
+	}
+	switch typ {
+	case "registry":
+		if m["ref"] == "" {
+			return "", fmt.Errorf("invalid %s %q: ref must be specified for type=registry", flagName, s)
+		}
+	case "local":
+		key := "src"
+		if export {
+			key = "dest"
+		}
+		if m[key] == "" {
+			return "", fmt.Errorf("invalid %s %q: %s must be specified for type=local", flagName, s, key)
+		}
+	case "inline":
+		if !export {
+			return "", fmt.Errorf("invalid %s %q: type=inline is only supported for --cache-to (use type=registry to import the inline cache)", flagName, s)
+		}
+	case "gha", "s3", "azblob":
+		// passed to BuildKit as-is
+	default:
+		return "", fmt.Errorf("invalid %s %q: unknown type %q (expected \"registry\", \"local\", \"inline\", \"gha\", \"s3\", or \"azblob\")", flagName, s, typ)
+	}
+	if mode, ok := m["mode"]; ok && mode != "min" && mode != "max" {
+		return "", fmt.Errorf("invalid %s %q: unknown mode %q (expected \"min\" or \"max\")", flagName, s, mode)
+	}
+	return s, nil
+}
+
+// validateBuildSSH validates the value of `--ssh`, in the same format as `docker buildx build --ssh`:
+// "default|<id>[=<socket>|<key>[,<key>]]".
+// When no path is specified, the SSH agent socket is taken from $SSH_AUTH_SOCK (by buildctl).
+func validateBuildSSH(s string) error {
+	id, paths := s, ""
+	if i := strings.Index(s, "="); i >= 0 {
+		id, paths = s[:i], s[i+1:]
+	}
+	if id == "" {
+		return fmt.Errorf("invalid ssh %q: empty id (expected default|<id>[=<socket>|<key>[,<key>]])", s)
+	}
+	if paths == "" {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return fmt.Errorf("invalid ssh %q: SSH_AUTH_SOCK is not set (Hint: start ssh-agent, or specify the socket or the keys like %s=<socket>)", s, id)
+		}
+		if _, err := os.Stat(sock); err != nil {
+			return fmt.Errorf("invalid ssh %q: SSH agent socket (SSH_AUTH_SOCK) is unavailable: %w", s, err)
+		}
+		return nil
+	}
+	for _, p := range strings.Split(paths, ",") {
+		if _, err := os.Stat(p); err != nil {
+			return fmt.Errorf("invalid ssh %q: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// buildctlOptArgs converts the values of `--opt` ("KEY=VALUE") into buildctl "--opt=KEY=VALUE" args.
+// The values are forwarded to the frontend attributes as-is, without validation.
+// "BUILDKIT_INLINE_CACHE=1" (with or without the "build-arg:" prefix) also enables the inline cache export,
+// as `--build-arg BUILDKIT_INLINE_CACHE=1` does.
+func buildctlOptArgs(opts []string) ([]string, error) {
+	var args []string
+	for _, o := range opts {
+		if i := strings.Index(o, "="); i <= 0 {
+			return nil, fmt.Errorf("invalid --opt %q, expected KEY=VALUE", o)
+		}
+		args = append(args, "--opt="+o)
+		if inlineCacheEnabled(strings.TrimPrefix(o, "build-arg:")) {
+			args = append(args, "--export-cache=type=inline")
+		}
+	}
+	return args, nil
+}
+
+// inlineCacheEnabled returns true for a build arg "BUILDKIT_INLINE_CACHE=<true value>".
+func inlineCacheEnabled(ba string) bool {
+	if !strings.HasPrefix(ba, "BUILDKIT_INLINE_CACHE=") {
+		return false
+	}
+	bic := strings.TrimPrefix(ba, "BUILDKIT_INLINE_CACHE=")
+	bicParsed, err := strconv.ParseBool(bic)
+	if err != nil {
+		logrus.WithError(err).Warnf("invalid BUILDKIT_INLINE_CACHE: %q", bic)
+		return false
+	}
+	return bicParsed
+}
+
+// resolveBuildArg resolves `--build-arg KEY` (without "=") to "KEY=VALUE" with the value of the environment variable,
+// as `docker build` does. resolveBuildArg returns false when the environment variable is not set,
+// so that the default value in the Dockerfile is used.
+func resolveBuildArg(ba string) (string, bool) {
+	if strings.Contains(ba, "=") {
+		return ba, true
+	}
+	v, ok := os.LookupEnv(ba)
+	if !ok {
+		logrus.Debugf("ignoring build-arg %q, as the environment variable is not set", ba)
+		return "", false
+	}
+	return ba + "=" + v, true
+}
+
+// generateAttestationOpts converts --sbom and --provenance into buildctl "--opt=attest:<type>=<params>" args.
+// Like `docker buildx build`, "true" enables the attestation with the default params, and "false" disables it.
+func generateAttestationOpts(cmd *cobra.Command) ([]string, error) {
+	var opts []string
+	for _, attestType := range []string{"sbom", "provenance"} {
+		v, err := cmd.Flags().GetString(attestType)
+		if err != nil {
+			return nil, err
+		}
+		opt, err := parseAttestationFlag(attestType, v)
+		if err != nil {
+			return nil, err
+		}
+		if opt != "" {
+			opts = append(opts, opt)
+		}
+	}
+	return opts, nil
+}
+
+func parseAttestationFlag(attestType, v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	params := v
+	if b, err := strconv.ParseBool(v); err == nil {
+		if !b {
+			return "", nil
+		}
+		params = ""
+	} else {
+		for _, kv := range strings.Split(v, ",") {
+			if !strings.Contains(kv, "=") {
+				return "", fmt.Errorf("invalid --%s value %q: expected \"true\", \"false\", or comma-separated key=value pairs", attestType, v)
+			}
+		}
+	}
+	return "--opt=attest:" + attestType + "=" + params, nil
+}
+
+// normalizeBuildPlatforms normalizes the values of --platform (e.g., "amd64" to "linux/amd64"),
+// so that invalid platforms fail before running BuildKit.
+func normalizeBuildPlatforms(ss []string) ([]string, error) {
+	var res []string
+	for _, s := range ss {
+		p, err := platformutil.NormalizeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid platform %q: %w", s, err)
+		}
+		res = append(res, p)
+	}
+	return strutil.DedupeStrSlice(res), nil
+}
+
+// withBuildPlatformHint explains err when buildctl failed because a base image does not provide
+// all of the requested platforms.
+func withBuildPlatformHint(err error, buildctlStderr string, platform []string) error {
+	if !strings.Contains(buildctlStderr, "no match for platform in manifest") {
+		return err
+	}
+	requested := platforms.DefaultString()
+	if len(platform) > 0 {
+		requested = strings.Join(platform, ", ")
+	}
+	return fmt.Errorf("a base image does not provide the requested platform (%s): %w", requested, err)
+}
+
+// normalizeBuildOutput normalizes the --output value, like `docker buildx build`.
+// "-" is a shorthand for "type=tar,dest=-", and a value without "=" is a shorthand for "type=local,dest=<value>".
+func normalizeBuildOutput(output string) (string, error) {
+	if output == "-" {
+		return "type=tar,dest=-", nil
+	}
+	if !strings.Contains(output, "=") {
+		return "type=local,dest=" + output, nil
+	}
+	if strings.Contains(output, "type=registry") {
+		// "type=registry" is a shorthand for "type=image,push=true"
+		output = strings.Replace(output, "type=registry", "type=image", 1)
+		if !strings.Contains(output, "push=") {
+			output += ",push=true"
+		}
+	}
+	if strings.Contains(output, "type=tar") && !strings.Contains(output, "dest=") {
+		return "", fmt.Errorf("invalid --output %q: type=tar requires dest=<path> (use dest=- for stdout)", output)
+	}
+	return output, nil
+}
+
+// parseAnnotationFlag converts an --annotation value into BuildKit image exporter attributes.
+// Like `docker buildx build`, the annotation can be prefixed with comma-separated types,
+// e.g. "index,manifest:key=value". The default type is "manifest".
+func parseAnnotationFlag(v string) ([]string, error) {
+	kv := strings.SplitN(v, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return nil, fmt.Errorf("invalid --annotation value %q: expected [TYPE[,TYPE...]:]KEY=VALUE", v)
+	}
+	key, value := kv[0], kv[1]
+	types := []string{"manifest"}
+	if i := strings.Index(key, ":"); i >= 0 {
+		types, key = strings.Split(key[:i], ","), key[i+1:]
+		if key == "" {
+			return nil, fmt.Errorf("invalid --annotation value %q: empty key", v)
+		}
+	}
+	var attrs []string
+	for _, t := range types {
+		switch t {
+		case "manifest", "index", "manifest-descriptor", "index-descriptor":
+		default:
+			return nil, fmt.Errorf("invalid --annotation type %q (valid types: manifest, index, manifest-descriptor, index-descriptor)", t)
+		}
+		attr := "annotation-" + t + "." + key + "=" + value
+		// the output is parsed as CSV by buildctl
+		if strings.ContainsAny(attr, ",\"") {
+			attr = "\"" + strings.ReplaceAll(attr, "\"", "\"\"") + "\""
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+// getDigestFromMetaFile returns the digest of the image written by the exporter in the BuildKit metadata file.
+// For multi-platform builds, the digest is the digest of the image index, not of a platform-specific manifest.
+func getDigestFromMetaFile(path string, multiPlatform bool) (string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return "", err
 	}
-	defer os.Remove(path)
 
 	metadata := map[string]json.RawMessage{}
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		logrus.WithError(err).Errorf("failed to unmarshal metadata file %s", path)
 		return "", err
 	}
+	// containerimage.descriptor is preferred, as it also reports the media type
+	if descRaw, ok := metadata["containerimage.descriptor"]; ok {
+		var desc ocispec.Descriptor
+		if err := json.Unmarshal(descRaw, &desc); err != nil {
+			return "", fmt.Errorf("failed to unmarshal containerimage.descriptor: %w", err)
+		}
+		if multiPlatform && !images.IsIndexType(desc.MediaType) {
+			return "", fmt.Errorf("expected an image index for the multi-platform build, got %q", desc.MediaType)
+		}
+		if err := desc.Digest.Validate(); err != nil {
+			return "", fmt.Errorf("invalid digest %q in containerimage.descriptor: %w", desc.Digest, err)
+		}
+		return desc.Digest.String(), nil
+	}
 	digestRaw, ok := metadata["containerimage.digest"]
 	if !ok {
 		return "", errors.New("failed to find containerimage.digest in metadata file")
 	}
-	var digest string
-	if err := json.Unmarshal(digestRaw, &digest); err != nil {
+	var dgst digest.Digest
+	if err := json.Unmarshal(digestRaw, &dgst); err != nil {
 		logrus.WithError(err).Errorf("failed to unmarshal digset")
 		return "", err
 	}
-	return digest, nil
+	if err := dgst.Validate(); err != nil {
+		return "", fmt.Errorf("invalid digest %q in containerimage.digest: %w", dgst, err)
+	}
+	return dgst.String(), nil
 }
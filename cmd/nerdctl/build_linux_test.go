@@ -0,0 +1,90 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"github.com/containerd/nerdctl/pkg/testutil/testregistry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestBuildWithAnnotation(t *testing.T) {
+	testutil.DockerIncompatible(t) // `docker build` lacks --annotation (only `docker buildx build` has it)
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	reg := testregistry.NewPlainHTTP(base, 5000)
+	defer reg.Cleanup()
+
+	repo := testutil.Identifier(t)
+	imageName := fmt.Sprintf("127.0.0.1:%d/%s:latest", reg.ListenPort, repo)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+CMD ["echo", "nerdctl-build-test-string"]
+	`, testutil.CommonImage)
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-t", imageName, "--annotation", "org.example.foo=bar", "--annotation", "manifest:org.example.baz=qux", buildCtx).AssertOK()
+	base.Cmd("push", imageName).AssertOK()
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/v2/%s/manifests/latest", reg.ListenPort, repo), nil)
+	assert.NilError(t, err)
+	req.Header.Set("Accept", strings.Join([]string{ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex}, ","))
+	resp, err := http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var manifest ocispec.Manifest
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&manifest))
+	assert.Equal(t, "bar", manifest.Annotations["org.example.foo"])
+	assert.Equal(t, "qux", manifest.Annotations["org.example.baz"])
+}
+
+func TestBuildOutputRegistry(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	reg := testregistry.NewPlainHTTP(base, 5000)
+	defer reg.Cleanup()
+
+	imageName := fmt.Sprintf("127.0.0.1:%d/%s:latest", reg.ListenPort, testutil.Identifier(t))
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+CMD ["echo", "nerdctl-build-test-string"]
+	`, testutil.CommonImage)
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-o", "type=registry,name="+imageName, buildCtx).AssertOK()
+	base.Cmd("rmi", imageName).Run()
+	base.Cmd("pull", imageName).AssertOK()
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("nerdctl-build-test-string\n")
+}
@@ -17,7 +17,12 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -204,6 +209,288 @@ COPY %s /`,
 	assert.Equal(t, string(data), testContent)
 }
 
+func TestBuildNoCache(t *testing.T) {
+	testutil.DockerIncompatible(t) // non-buildx version of `docker build` lacks --no-cache-filter
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s AS a
+RUN cat /proc/sys/kernel/random/uuid > /a
+FROM %s AS b
+RUN cat /proc/sys/kernel/random/uuid > /b
+FROM %s
+COPY --from=a /a /a
+COPY --from=b /b /b
+CMD ["cat", "/a", "/b"]
+	`, testutil.CommonImage, testutil.CommonImage, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	// build returns the uuids generated in the stage "a" and "b"
+	build := func(args ...string) (string, string) {
+		base.Cmd(append(append([]string{"build", "-t", imageName}, args...), buildCtx)...).AssertOK()
+		out := base.Cmd("run", "--rm", imageName).Run().Stdout()
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		assert.Equal(t, 2, len(lines), out)
+		return lines[0], lines[1]
+	}
+
+	a0, b0 := build()
+	a1, b1 := build()
+	assert.Equal(t, a0, a1)
+	assert.Equal(t, b0, b1)
+
+	a2, b2 := build("--no-cache-filter", "a")
+	assert.Assert(t, a1 != a2)
+	assert.Equal(t, b1, b2)
+
+	a3, b3 := build("--no-cache")
+	assert.Assert(t, a2 != a3)
+	assert.Assert(t, b2 != b3)
+}
+
+func TestDockerfileStages(t *testing.T) {
+	dockerfile := `# syntax=docker/dockerfile:1
+FROM golang AS builder
+RUN go build
+from --platform=$BUILDPLATFORM alpine as Tools
+FROM \
+  busybox \
+  AS runtime
+# FROM foo AS commented
+FROM scratch
+COPY --from=builder /out /
+`
+	stages, err := dockerfileStages(strings.NewReader(dockerfile))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"builder", "Tools", "runtime"}, stages)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Dockerfile")
+	assert.NilError(t, os.WriteFile(path, []byte(dockerfile), 0644))
+	assert.NilError(t, validateBuildTarget(path, "builder"))
+	assert.NilError(t, validateBuildTarget(path, "tools"))
+	assert.ErrorContains(t, validateBuildTarget(path, "build"), `target stage "build" could not be found (available stages: builder, Tools, runtime)`)
+
+	assert.NilError(t, os.WriteFile(path, []byte("FROM scratch\n"), 0644))
+	assert.ErrorContains(t, validateBuildTarget(path, "builder"), "no named stages")
+}
+
+func TestBuildWithTarget(t *testing.T) {
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s AS builder
+RUN echo builder > /stage
+CMD ["cat", "/stage"]
+FROM %s AS runtime
+RUN echo runtime > /stage
+CMD ["cat", "/stage"]
+	`, testutil.CommonImage, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-t", imageName, "--target", "builder", buildCtx).AssertOK()
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("builder\n")
+	base.Cmd("build", "-t", imageName, buildCtx).AssertOK()
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("runtime\n")
+	base.Cmd("build", "-t", imageName, "--target", "nonexistent", buildCtx).AssertFail()
+}
+
+func TestBuildTar(t *testing.T) {
+	t.Parallel()
+	testutil.DockerIncompatible(t)
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	const testFileName = "nerdctl-build-test"
+	const testContent = "nerdctl"
+
+	dockerfile := fmt.Sprintf(`FROM scratch
+COPY %s /`,
+		testFileName)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	assert.NilError(t, os.WriteFile(filepath.Join(buildCtx, testFileName), []byte(testContent), 0644))
+
+	assertTarContains := func(r io.Reader) {
+		t.Helper()
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			assert.NilError(t, err, "%q not found in the tar", testFileName)
+			if strings.TrimPrefix(hdr.Name, "/") == testFileName {
+				data, err := io.ReadAll(tr)
+				assert.NilError(t, err)
+				assert.Equal(t, testContent, string(data))
+				return
+			}
+		}
+	}
+
+	tarPath := filepath.Join(t.TempDir(), "out.tar")
+	base.Cmd("build", "-o", "type=tar,dest="+tarPath, buildCtx).AssertOK()
+	f, err := os.Open(tarPath)
+	assert.NilError(t, err)
+	defer f.Close()
+	assertTarContains(f)
+
+	res := base.Cmd("build", "-o", "type=tar,dest=-", buildCtx).Run()
+	assert.Equal(t, 0, res.ExitCode, res.Stderr())
+	assertTarContains(strings.NewReader(res.Stdout()))
+
+	base.Cmd("build", "-o", "type=tar", buildCtx).AssertFail()
+}
+
+func TestBuildctlOptArgs(t *testing.T) {
+	testCases := []struct {
+		opts     []string
+		expected []string
+		err      bool
+	}{
+		{nil, nil, false},
+		{[]string{"build-arg:FOO=bar"}, []string{"--opt=build-arg:FOO=bar"}, false},
+		{[]string{"context:alpine=docker-image://alpine:3.16", "FOO="}, []string{"--opt=context:alpine=docker-image://alpine:3.16", "--opt=FOO="}, false},
+		{[]string{"BUILDKIT_INLINE_CACHE=1"}, []string{"--opt=BUILDKIT_INLINE_CACHE=1", "--export-cache=type=inline"}, false},
+		{[]string{"build-arg:BUILDKIT_INLINE_CACHE=true"}, []string{"--opt=build-arg:BUILDKIT_INLINE_CACHE=true", "--export-cache=type=inline"}, false},
+		{[]string{"build-arg:BUILDKIT_INLINE_CACHE=0"}, []string{"--opt=build-arg:BUILDKIT_INLINE_CACHE=0"}, false},
+		{[]string{"FOO"}, nil, true},
+		{[]string{"=bar"}, nil, true},
+	}
+	for _, tc := range testCases {
+		args, err := buildctlOptArgs(tc.opts)
+		if tc.err {
+			assert.Assert(t, err != nil, "expected an error for %v", tc.opts)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, tc.expected, args)
+	}
+}
+
+func TestResolveBuildArg(t *testing.T) {
+	t.Setenv("NERDCTL_TEST_BUILD_ARG", "from-env")
+	t.Setenv("NERDCTL_TEST_BUILD_ARG_EMPTY", "")
+
+	testCases := []struct {
+		ba       string
+		expected string
+		ok       bool
+	}{
+		{"FOO=bar", "FOO=bar", true},
+		{"FOO=", "FOO=", true},
+		{"FOO=bar=baz", "FOO=bar=baz", true},
+		{"NERDCTL_TEST_BUILD_ARG", "NERDCTL_TEST_BUILD_ARG=from-env", true},
+		{"NERDCTL_TEST_BUILD_ARG_EMPTY", "NERDCTL_TEST_BUILD_ARG_EMPTY=", true},
+		{"NERDCTL_TEST_BUILD_ARG_UNSET", "", false},
+	}
+	for _, tc := range testCases {
+		ba, ok := resolveBuildArg(tc.ba)
+		assert.Equal(t, tc.ok, ok, tc.ba)
+		assert.Equal(t, tc.expected, ba, tc.ba)
+	}
+}
+
+func TestBuildWithBuildArgFromEnv(t *testing.T) {
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+ARG FOO
+ARG BAR=default
+RUN echo "$FOO $BAR" > /args
+CMD ["cat", "/args"]
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Env = append(os.Environ(), "FOO=from-env")
+	base.Cmd("build", "-t", imageName, "--build-arg", "FOO", "--build-arg", "BAR", buildCtx).AssertOK()
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("from-env default\n")
+}
+
+func TestNormalizeBuildPlatforms(t *testing.T) {
+	platforms, err := normalizeBuildPlatforms([]string{"amd64", "linux/amd64", "linux/arm64", "arm"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"linux/amd64", "linux/arm64", "linux/arm/v7"}, platforms)
+
+	_, err = normalizeBuildPlatforms([]string{"linux/amd64", "foo/bar/baz/qux"})
+	assert.ErrorContains(t, err, "invalid platform")
+}
+
+func TestWithBuildPlatformHint(t *testing.T) {
+	err := errors.New("exit status 1")
+	assert.Equal(t, err, withBuildPlatformHint(err, "error: failed to solve: foo", []string{"linux/amd64"}))
+	hinted := withBuildPlatformHint(err, "error: failed to solve: alpine: no match for platform in manifest sha256:deadbeef: not found", []string{"linux/amd64", "linux/mips64le"})
+	assert.ErrorContains(t, hinted, "a base image does not provide the requested platform (linux/amd64, linux/mips64le)")
+	assert.Assert(t, errors.Is(hinted, err))
+}
+
+func TestBuildWithOpt(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks `build --opt`
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+ARG FOO
+RUN echo $FOO > /foo
+CMD ["cat", "/foo"]
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-t", imageName, "--opt", "build-arg:FOO=bar", "--opt", "BUILDKIT_INLINE_CACHE=1", buildCtx).AssertOK()
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("bar\n")
+	base.Cmd("build", "-t", imageName, "--opt", "FOO", buildCtx).AssertFail()
+}
+
+func TestNormalizeBuildOutput(t *testing.T) {
+	testCases := []struct {
+		output   string
+		expected string
+		err      bool
+	}{
+		{"-", "type=tar,dest=-", false},
+		{"out", "type=local,dest=out", false},
+		{"type=tar,dest=out.tar", "type=tar,dest=out.tar", false},
+		{"type=tar", "", true},
+		{"type=docker", "type=docker", false},
+		{"type=registry,name=example.com/foo", "type=image,name=example.com/foo,push=true", false},
+		{"type=registry,name=example.com/foo,push=false", "type=image,name=example.com/foo,push=false", false},
+	}
+	for _, tc := range testCases {
+		output, err := normalizeBuildOutput(tc.output)
+		if tc.err {
+			assert.Assert(t, err != nil, "expected an error for %q", tc.output)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, output)
+	}
+}
+
 func createBuildContext(dockerfile string) (string, error) {
 	tmpDir, err := os.MkdirTemp("", "nerdctl-build-test")
 	if err != nil {
@@ -242,6 +529,298 @@ CMD ["echo", "nerdctl-build-test-string"]
 	base.Cmd("run", "--rm", string(imageID)).AssertOutExactly("nerdctl-build-test-string\n")
 }
 
+func TestBuildSquash(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+RUN echo foo > /foo
+RUN echo bar > /bar && rm /foo
+CMD ["cat", "/bar"]
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	fileName := filepath.Join(t.TempDir(), "id.txt")
+
+	base.Cmd("build", "--squash", buildCtx).AssertErrContains("--squash requires --tag")
+	base.Cmd("build", "--squash", "-t", imageName, "--output", "type=local,dest="+t.TempDir(), buildCtx).AssertErrContains("cannot be used with --output")
+
+	base.Cmd("build", "--squash", "-t", imageName, "--iidfile", fileName, buildCtx).AssertOK()
+	// the layers of the base image are kept, and the two RUN layers are squashed into one
+	var baseLayers, squashedLayers []string
+	assert.NilError(t, json.Unmarshal([]byte(base.Cmd("image", "inspect", "--format", "{{json .RootFS.Layers}}", testutil.CommonImage).Out()), &baseLayers))
+	assert.NilError(t, json.Unmarshal([]byte(base.Cmd("image", "inspect", "--format", "{{json .RootFS.Layers}}", imageName).Out()), &squashedLayers))
+	assert.Equal(t, len(baseLayers)+1, len(squashedLayers))
+	assert.DeepEqual(t, baseLayers, squashedLayers[:len(baseLayers)])
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("bar\n")
+	base.Cmd("run", "--rm", imageName, "test", "!", "-e", "/foo").AssertOK()
+
+	imageID, err := os.ReadFile(fileName)
+	assert.NilError(t, err)
+	base.Cmd("run", "--rm", string(imageID)).AssertOutExactly("bar\n")
+}
+
+func TestIsBuildProgressTTY(t *testing.T) {
+	var stderr bytes.Buffer // not a terminal
+	assert.Equal(t, true, isBuildProgressTTY("tty", &stderr))
+	for _, progress := range []string{"", "auto", "plain", "rawjson"} {
+		assert.Equal(t, false, isBuildProgressTTY(progress, &stderr), progress)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{size: 8}
+	for _, tc := range []struct {
+		write    string
+		expected string
+	}{
+		{"abc", "abc"},
+		{"defgh", "abcdefgh"},
+		{"ij", "cdefghij"},
+		{"0123456789", "23456789"},
+	} {
+		n, err := b.Write([]byte(tc.write))
+		assert.NilError(t, err)
+		assert.Equal(t, len(tc.write), n)
+		assert.Equal(t, tc.expected, b.String())
+	}
+}
+
+func TestBuildProgressPlain(t *testing.T) {
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+RUN echo nerdctl-build-progress-plain
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	res := base.Cmd("build", "--progress=plain", "--no-cache", "-t", imageName, buildCtx).Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	// the steps are shown in the plain progress, without ANSI escape sequences
+	assert.Assert(t, strings.Contains(res.Combined(), "nerdctl-build-progress-plain"), res.Combined())
+	assert.Assert(t, !strings.Contains(res.Combined(), "\x1b["), res.Combined())
+
+	base.Cmd("build", "--progress=fancy", "-t", imageName, buildCtx).AssertFail()
+}
+
+func TestValidateBuildSecret(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	assert.NilError(t, os.WriteFile(secretFile, []byte("hello"), 0600))
+	t.Setenv("NERDCTL_TEST_SECRET", "hello")
+
+	testCases := []struct {
+		secret string
+		err    string
+	}{
+		{"id=mysecret,src=" + secretFile, ""},
+		{"id=mysecret,source=" + secretFile, ""},
+		{"id=mysecret,type=file,src=" + secretFile, ""},
+		{"id=mysecret,env=NERDCTL_TEST_SECRET", ""},
+		{"id=NERDCTL_TEST_SECRET,type=env", ""},
+		{"id=mysecret,src=/nonexistent", "no such file or directory"},
+		{"id=mysecret,env=NERDCTL_TEST_NONEXISTENT", "is not set"},
+		{"id=NERDCTL_TEST_NONEXISTENT,type=env", "is not set"},
+		{"id=mysecret", "either src or env must be specified"},
+		{"id=mysecret,type=foo,src=" + secretFile, "unknown type"},
+		{"id=mysecret,foo=bar", "unknown key"},
+	}
+	for _, tc := range testCases {
+		err := validateBuildSecret(tc.secret)
+		if tc.err == "" {
+			assert.NilError(t, err, tc.secret)
+		} else {
+			assert.ErrorContains(t, err, tc.err, tc.secret)
+		}
+	}
+}
+
+func TestValidateBuildSSH(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+	assert.NilError(t, os.WriteFile(key, []byte("dummy"), 0600))
+	sock := filepath.Join(dir, "agent.sock")
+	assert.NilError(t, os.WriteFile(sock, nil, 0600)) // only the existence is checked
+
+	t.Setenv("SSH_AUTH_SOCK", sock)
+	assert.NilError(t, validateBuildSSH("default"))
+	assert.NilError(t, validateBuildSSH("mykey"))
+	assert.NilError(t, validateBuildSSH("mykey="+key))
+	assert.NilError(t, validateBuildSSH("mykey="+key+","+key))
+	assert.NilError(t, validateBuildSSH("default="+sock))
+	assert.ErrorContains(t, validateBuildSSH("mykey="+key+",/nonexistent"), "no such file or directory")
+	assert.ErrorContains(t, validateBuildSSH("=foo"), "empty id")
+
+	t.Setenv("SSH_AUTH_SOCK", filepath.Join(dir, "nonexistent.sock"))
+	assert.ErrorContains(t, validateBuildSSH("default"), "unavailable")
+	assert.NilError(t, validateBuildSSH("mykey="+key))
+
+	t.Setenv("SSH_AUTH_SOCK", "")
+	assert.ErrorContains(t, validateBuildSSH("default"), "SSH_AUTH_SOCK is not set")
+}
+
+func TestParseBuildCache(t *testing.T) {
+	testCases := []struct {
+		s        string
+		export   bool
+		expected string
+		err      string
+	}{
+		{"user/app:cache", false, "type=registry,ref=user/app:cache", ""},
+		{"user/app:cache", true, "type=registry,ref=user/app:cache", ""},
+		{"type=registry,ref=user/app:cache", false, "type=registry,ref=user/app:cache", ""},
+		{"type=registry,ref=user/app:cache,mode=max", true, "type=registry,ref=user/app:cache,mode=max", ""},
+		{"ref=user/app:cache,mode=max", true, "type=registry,ref=user/app:cache,mode=max", ""},
+		{"type=local,src=/tmp/cache", false, "type=local,src=/tmp/cache", ""},
+		{"type=local,dest=/tmp/cache,mode=min", true, "type=local,dest=/tmp/cache,mode=min", ""},
+		{"type=inline", true, "type=inline", ""},
+		{"type=gha,scope=foo", true, "type=gha,scope=foo", ""},
+		{"", false, "", "empty value"},
+		{"type=registry", false, "", "ref must be specified"},
+		{"type=local,dest=/tmp/cache", false, "", "src must be specified"},
+		{"type=local,src=/tmp/cache", true, "", "dest must be specified"},
+		{"type=inline", false, "", "only supported for --cache-to"},
+		{"type=foo", true, "", "unknown type"},
+		{"type=registry,ref=user/app:cache,mode=foo", true, "", "unknown mode"},
+	}
+	for _, tc := range testCases {
+		c, err := parseBuildCache(tc.s, tc.export)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, c)
+	}
+}
+
+func TestBuildWithLocalCache(t *testing.T) {
+	testutil.DockerIncompatible(t) // non-buildx version of `docker build` lacks --cache-to
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+RUN echo hello > /hello
+CMD ["cat", "/hello"]
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	cacheDir := t.TempDir()
+
+	base.Cmd("build", "-t", imageName, "--cache-to", "type=local,dest="+cacheDir+",mode=max", buildCtx).AssertOK()
+	_, err = os.Stat(filepath.Join(cacheDir, "index.json"))
+	assert.NilError(t, err)
+
+	base.Cmd("builder", "prune").AssertOK()
+	base.Cmd("build", "-t", imageName, "--cache-from", "type=local,src="+cacheDir, buildCtx).AssertOK()
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("hello\n")
+
+	base.Cmd("build", "-t", imageName, "--cache-from", "type=inline", buildCtx).AssertFail()
+}
+
+func TestBuildWithSecret(t *testing.T) {
+	testutil.DockerIncompatible(t) // non-buildx version of `docker build` lacks the env= form
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+RUN --mount=type=secret,id=file-secret --mount=type=secret,id=env-secret \
+	cat /run/secrets/file-secret /run/secrets/env-secret > /secrets
+CMD ["cat", "/secrets"]
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	assert.NilError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0600))
+
+	base.Env = append(os.Environ(), "NERDCTL_TEST_BUILD_SECRET=from-env")
+	base.Cmd("build", "-t", imageName,
+		"--secret", "id=file-secret,src="+secretFile,
+		"--secret", "id=env-secret,env=NERDCTL_TEST_BUILD_SECRET",
+		buildCtx).AssertOK()
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("from-file\nfrom-env")
+
+	// the build fails before running BuildKit when the secret does not exist
+	base.Cmd("build", "-t", imageName, "--secret", "id=env-secret,env=NERDCTL_TEST_NONEXISTENT", buildCtx).AssertErrContains("is not set")
+}
+
+func TestGetDigestFromMetaFile(t *testing.T) {
+	const (
+		indexDigest    = "sha256:b6c0a6d6b3d4e3d95e7d0a0e43b5c1a6dbb3c9d1f7e8c6f4d3b2a1e0f9e8d7c6"
+		manifestDigest = "sha256:1d2c3b4a5f6e7d8c9b0a1f2e3d4c5b6a7f8e9d0c1b2a3f4e5d6c7b8a9f0e1d2c"
+	)
+	testCases := []struct {
+		name          string
+		metadata      string
+		multiPlatform bool
+		expected      string
+		err           string
+	}{
+		{
+			name:     "digest only",
+			metadata: `{"containerimage.digest":"` + manifestDigest + `"}`,
+			expected: manifestDigest,
+		},
+		{
+			name:          "index descriptor",
+			metadata:      `{"containerimage.digest":"` + indexDigest + `","containerimage.descriptor":{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"` + indexDigest + `","size":1234}}`,
+			multiPlatform: true,
+			expected:      indexDigest,
+		},
+		{
+			name:          "manifest descriptor for multi-platform",
+			metadata:      `{"containerimage.descriptor":{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"` + manifestDigest + `","size":1234}}`,
+			multiPlatform: true,
+			err:           "expected an image index",
+		},
+		{
+			name:     "invalid digest",
+			metadata: `{"containerimage.digest":"sha256:foo"}`,
+			err:      "invalid digest",
+		},
+		{
+			name:     "no digest",
+			metadata: `{}`,
+			err:      "failed to find containerimage.digest",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "metadata.json")
+			assert.NilError(t, os.WriteFile(path, []byte(tc.metadata), 0600))
+			got, err := getDigestFromMetaFile(path, tc.multiPlatform)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
 func TestBuildWithLabels(t *testing.T) {
 	t.Parallel()
 	testutil.RequiresBuild(t)
@@ -287,3 +866,75 @@ CMD ["echo", "nerdctl-build-test-string"]
 	base.Cmd("run", "--rm", imgWithNoTag).AssertOutExactly("nerdctl-build-test-string\n")
 	base.Cmd("run", "--rm", imgWithCustomTag).AssertOutExactly("nerdctl-build-test-string\n")
 }
+
+func TestBuildAttestation(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+CMD ["echo", "nerdctl-build-test-string"]
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-t", imageName, "--sbom=true", "--provenance=mode=max", buildCtx).AssertOK()
+	// attestations are stored in the index as manifests with the "vnd.docker.reference.type" annotation
+	base.Cmd("image", "inspect", "--mode=native", "--format={{json .Index.Manifests}}", imageName).AssertOutContains("attestation-manifest")
+	base.Cmd("run", "--rm", imageName).AssertOutExactly("nerdctl-build-test-string\n")
+}
+
+func TestParseAttestationFlag(t *testing.T) {
+	testCases := []struct {
+		attestType string
+		value      string
+		expected   string
+		err        bool
+	}{
+		{"sbom", "", "", false},
+		{"sbom", "true", "--opt=attest:sbom=", false},
+		{"sbom", "false", "", false},
+		{"sbom", "generator=example.com/generator", "--opt=attest:sbom=generator=example.com/generator", false},
+		{"provenance", "mode=max", "--opt=attest:provenance=mode=max", false},
+		{"provenance", "max", "", true},
+	}
+	for _, tc := range testCases {
+		opt, err := parseAttestationFlag(tc.attestType, tc.value)
+		if tc.err {
+			assert.Assert(t, err != nil, "expected an error for %q", tc.value)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, opt)
+	}
+}
+
+func TestParseAnnotationFlag(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected []string
+		err      bool
+	}{
+		{"foo=bar", []string{"annotation-manifest.foo=bar"}, false},
+		{"index:foo=bar", []string{"annotation-index.foo=bar"}, false},
+		{"manifest,index-descriptor:org.example.foo=bar=baz", []string{"annotation-manifest.org.example.foo=bar=baz", "annotation-index-descriptor.org.example.foo=bar=baz"}, false},
+		{"foo=a,b", []string{`"annotation-manifest.foo=a,b"`}, false},
+		{"foo", nil, true},
+		{"config:foo=bar", nil, true},
+		{"index:=bar", nil, true},
+	}
+	for _, tc := range testCases {
+		attrs, err := parseAnnotationFlag(tc.value)
+		if tc.err {
+			assert.Assert(t, err != nil, "expected an error for %q", tc.value)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, tc.expected, attrs)
+	}
+}
@@ -17,12 +17,17 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/containerd/nerdctl/pkg/buildkitutil"
 	"github.com/containerd/nerdctl/pkg/defaults"
+	"github.com/docker/go-units"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -55,25 +60,98 @@ func newBuilderPruneCommand() *cobra.Command {
 	}
 
 	AddStringFlag(buildPruneCommand, "buildkit-host", nil, defaults.BuildKitHost(), "BUILDKIT_HOST", "BuildKit address")
+	addPruneDryRunFlag(buildPruneCommand)
 	return buildPruneCommand
 }
 
 func builderPruneAction(cmd *cobra.Command, args []string) error {
-	buildkitHost, err := getBuildkitHost(cmd)
+	dryRun, err := cmd.Flags().GetBool("dry-run")
 	if err != nil {
 		return err
 	}
-	buildctlBinary, err := buildkitutil.BuildctlBinary()
+	reclaimable, err := pruneBuildCache(cmd, dryRun)
 	if err != nil {
 		return err
 	}
+	if dryRun {
+		printPruneTotal(cmd.OutOrStdout(), reclaimable, dryRun)
+	}
+	return nil
+}
+
+// pruneBuildCache runs `buildctl prune` with the additional args.
+// pruneBuildCache returns the reclaimed size reported by buildctl.
+//
+// With dryRun, pruneBuildCache runs `buildctl du` instead, and returns the reclaimable size.
+// buildctl does not have the equivalent of `prune --keep-duration` for `du`, so the args are ignored on dry-run.
+func pruneBuildCache(cmd *cobra.Command, dryRun bool, pruneArgs ...string) (int64, error) {
+	buildkitHost, err := getBuildkitHost(cmd)
+	if err != nil {
+		return 0, err
+	}
+	buildctlBinary, err := buildkitutil.BuildctlBinary()
+	if err != nil {
+		return 0, err
+	}
 	buildctlArgs := buildkitutil.BuildctlBaseArgs(buildkitHost)
-	buildctlArgs = append(buildctlArgs, "prune")
+	if dryRun {
+		if len(pruneArgs) > 0 {
+			logrus.Warnf("the reclaimable size of the build cache does not take %v into account", pruneArgs)
+		}
+		buildctlArgs = append(buildctlArgs, "du")
+	} else {
+		buildctlArgs = append(buildctlArgs, "prune")
+		buildctlArgs = append(buildctlArgs, pruneArgs...)
+	}
 	logrus.Debugf("running %s %v", buildctlBinary, buildctlArgs)
 	buildctlCmd := exec.Command(buildctlBinary, buildctlArgs...)
 	buildctlCmd.Env = os.Environ()
-	buildctlCmd.Stdout = cmd.OutOrStdout()
-	return buildctlCmd.Run()
+	var stdout bytes.Buffer
+	if dryRun {
+		// the output of `buildctl du` contains the records in use as well
+		buildctlCmd.Stdout = &stdout
+	} else {
+		buildctlCmd.Stdout = io.MultiWriter(cmd.OutOrStdout(), &stdout)
+	}
+	if err := buildctlCmd.Run(); err != nil {
+		return 0, err
+	}
+	if dryRun {
+		reclaimable, err := parseBuildctlSize(&stdout, "Reclaimable:")
+		if err != nil {
+			logrus.WithError(err).Warn("failed to parse the reclaimable size of the build cache")
+			return 0, nil
+		}
+		return reclaimable, nil
+	}
+	reclaimed, err := parseBuildCachePruneTotal(&stdout)
+	if err != nil {
+		logrus.WithError(err).Warn("failed to parse the reclaimed size of the build cache")
+		return 0, nil
+	}
+	return reclaimed, nil
+}
+
+// parseBuildCachePruneTotal parses the "Total:" line of the `buildctl prune` output.
+// The size is rounded by buildctl, so the result is approximate.
+func parseBuildCachePruneTotal(r io.Reader) (int64, error) {
+	return parseBuildctlSize(r, "Total:")
+}
+
+// parseBuildctlSize parses the size in the first line with the prefix, e.g., "Reclaimable:" of `buildctl du`.
+func parseBuildctlSize(r io.Reader, prefix string) (int64, error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		return units.RAMInBytes(strings.TrimSpace(strings.TrimPrefix(line, prefix)))
+	}
+	if err := sc.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no %q line in the buildctl output", prefix)
 }
 
 func newBuilderDebugCommand() *cobra.Command {
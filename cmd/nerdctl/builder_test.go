@@ -0,0 +1,75 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseBuildCachePruneTotal(t *testing.T) {
+	testCases := []struct {
+		output   string
+		expected int64
+		err      string
+	}{
+		{
+			output:   "Total:\t0.00B\n",
+			expected: 0,
+		},
+		{
+			output: "ID\tRECLAIMABLE\tSIZE\tLAST ACCESSED\n" +
+				"k2hg2gn1sh1qf3n0bxiwbaa9w\ttrue\t8.19kB\t\n" +
+				"Total:\t1.50MiB\n",
+			expected: 1536 * 1024,
+		},
+		{
+			output: "",
+			err:    "no \"Total:\" line",
+		},
+		{
+			output: "Total:\tfoo\n",
+			err:    "invalid size",
+		},
+	}
+	for _, tc := range testCases {
+		got, err := parseBuildCachePruneTotal(strings.NewReader(tc.output))
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, got)
+	}
+}
+
+func TestParseBuildctlSize(t *testing.T) {
+	output := "ID\tRECLAIMABLE\tSIZE\tLAST ACCESSED\n" +
+		"k2hg2gn1sh1qf3n0bxiwbaa9w\tfalse\t8.19kB\t\n" +
+		"Shared:\t1.00MiB\n" +
+		"Private:\t2.00MiB\n" +
+		"Reclaimable:\t2.00MiB\n" +
+		"Total:\t3.00MiB\n"
+	got, err := parseBuildctlSize(strings.NewReader(output), "Reclaimable:")
+	assert.NilError(t, err)
+	assert.Equal(t, int64(2*1024*1024), got)
+
+	_, err = parseBuildctlSize(strings.NewReader(""), "Reclaimable:")
+	assert.ErrorContains(t, err, "no \"Reclaimable:\" line")
+}
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
 )
 
 func TestCommit(t *testing.T) {
@@ -47,8 +48,54 @@ func TestCommit(t *testing.T) {
 			"-c", `ENTRYPOINT ["cat"]`,
 			fmt.Sprintf("--pause=%s", pause),
 			testContainer, testImage).AssertOK()
+		// the container is resumed after committing
+		assert.Equal(t, "running", base.InspectContainer(testContainer).State.Status)
 		base.Cmd("run", "--rm", testImage).AssertOutExactly("hello-test-commit\n")
 		base.Cmd("rm", "-f", testContainer).Run()
 		base.Cmd("rmi", testImage).Run()
 	}
 }
+
+func TestCommitAuthorMessage(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	testContainer := testutil.Identifier(t)
+	testImage := testutil.Identifier(t) + "-img"
+	defer base.Cmd("rm", "-f", testContainer).Run()
+	defer base.Cmd("rmi", testImage).Run()
+
+	const (
+		author  = "nerdctl contributor <nerdctl-dev@example.com>"
+		message = "test commit message"
+	)
+	base.Cmd("run", "--name", testContainer, testutil.CommonImage, "sh", "-c", "echo hello > /foo").AssertOK()
+	base.Cmd("commit", "--author", author, "--message", message, testContainer, testImage).AssertOK()
+	base.Cmd("image", "inspect", "--format", "{{.Author}}", testImage).AssertOutExactly(author + "\n")
+	base.Cmd("image", "inspect", "--format", "{{.Comment}}", testImage).AssertOutExactly(message + "\n")
+	base.Cmd("history", "--no-trunc", "--format", "{{.CreatedBy}}|{{.Comment}}", testImage).AssertOutContains("sh -c echo hello > /foo|" + message)
+}
+
+func TestCommitNotRunning(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	switch base.Info().CgroupDriver {
+	case "none", "":
+		t.Skip("requires cgroup (for pausing)")
+	}
+	pausedContainer := testutil.Identifier(t) + "-paused"
+	createdContainer := testutil.Identifier(t) + "-created"
+	testImage := testutil.Identifier(t) + "-img"
+	defer base.Cmd("rm", "-f", pausedContainer, createdContainer).Run()
+	defer base.Cmd("rmi", testImage).Run()
+
+	// a paused container is committed as-is, and is kept paused
+	base.Cmd("run", "-d", "--name", pausedContainer, testutil.CommonImage, "sleep", "infinity").AssertOK()
+	base.Cmd("pause", pausedContainer).AssertOK()
+	base.Cmd("commit", pausedContainer, testImage).AssertOK()
+	assert.Equal(t, "paused", base.InspectContainer(pausedContainer).State.Status)
+	base.Cmd("rmi", testImage).AssertOK()
+
+	// a container without a task can be committed too
+	base.Cmd("create", "--name", createdContainer, testutil.CommonImage, "sleep", "infinity").AssertOK()
+	base.Cmd("commit", createdContainer, testImage).AssertOK()
+}
@@ -180,7 +180,7 @@ func getComposer(cmd *cobra.Command, client *containerd.Client) (*composer.Compo
 				pullMode, ocispecPlatforms, nil, quiet)
 		} else {
 			_, imgErr = imgutil.EnsureImage(ctx, client, cmd.OutOrStdout(), cmd.ErrOrStderr(), snapshotter, imageName,
-				pullMode, insecure, hostsDirs, ocispecPlatforms, nil, quiet)
+				pullMode, insecure, hostsDirs, ocispecPlatforms, nil, quiet, nil)
 		}
 		return imgErr
 	}
@@ -17,6 +17,11 @@
 package main
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
 	"github.com/spf13/cobra"
 )
 
@@ -60,3 +65,34 @@ func containerLsCommand() *cobra.Command {
 	x.Aliases = []string{"list"}
 	return x
 }
+
+// errContainerNotRunning is returned by ensureRunning for a created or stopped container
+type errContainerNotRunning struct {
+	id     string
+	status containerd.ProcessStatus
+}
+
+func (e errContainerNotRunning) Error() string {
+	return fmt.Sprintf("container %s is not running (status: %s)", e.id, e.status)
+}
+
+// ensureRunning returns the task of the container, along with its status.
+// Paused containers are regarded as running, so the callers have to check the status if they need to.
+func ensureRunning(ctx context.Context, container containerd.Container) (containerd.Task, containerd.Status, error) {
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, containerd.Status{}, errContainerNotRunning{id: container.ID(), status: containerd.Created}
+		}
+		return nil, containerd.Status{}, err
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return nil, containerd.Status{}, err
+	}
+	switch status.Status {
+	case containerd.Created, containerd.Stopped:
+		return nil, status, errContainerNotRunning{id: container.ID(), status: status.Status}
+	}
+	return task, status, nil
+}
@@ -47,6 +47,7 @@ func newContainerInspectCommand() *cobra.Command {
 	containerInspectCommand.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"json"}, cobra.ShellCompDirectiveNoFileComp
 	})
+	containerInspectCommand.Flags().Bool("pretty", false, "Indent the output of the `json` template function (same as `prettyjson`)")
 	return containerInspectCommand
 }
 
@@ -62,6 +62,8 @@ func TestContainerInspectContainsMounts(t *testing.T) {
 		"--tmpfs", "/app1:size=64m",
 		"--mount", "type=bind,src=/tmp,dst=/app2,ro",
 		"--mount", fmt.Sprintf("type=volume,src=%s,dst=/app3,readonly=false", testVolume),
+		"-v", "/tmp:/app4",
+		"-v", testVolume+":/app5:ro",
 		testutil.NginxAlpineImage).AssertOK()
 
 	inspect := base.InspectContainer(testContainer)
@@ -100,7 +102,9 @@ func TestContainerInspectContainsMounts(t *testing.T) {
 				Source:      "/tmp",
 				Destination: "/app2",
 				Driver:      "",
+				Mode:        "ro,rbind",
 				RW:          false,
+				Propagation: "rprivate",
 			},
 		},
 
@@ -116,6 +120,32 @@ func TestContainerInspectContainsMounts(t *testing.T) {
 				RW:          true,
 			},
 		},
+
+		// bind (-v)
+		{
+			dest: "/app4",
+			mountPoint: dockercompat.MountPoint{
+				Type:        "bind",
+				Source:      "/tmp",
+				Destination: "/app4",
+				RW:          true,
+				Propagation: "rprivate",
+			},
+		},
+
+		// named volume (-v)
+		{
+			dest: "/app5",
+			mountPoint: dockercompat.MountPoint{
+				Type:        "volume",
+				Name:        testVolume,
+				Source:      namedVolumeSource,
+				Destination: "/app5",
+				Driver:      localDriver,
+				Mode:        "ro",
+				RW:          false,
+			},
+		},
 	}
 
 	for i := range expected {
@@ -129,6 +159,10 @@ func TestContainerInspectContainsMounts(t *testing.T) {
 		assert.Equal(base.T, testCase.mountPoint.Driver, mountPoint.Driver)
 		assert.Equal(base.T, testCase.mountPoint.RW, mountPoint.RW)
 		assert.Equal(base.T, testCase.mountPoint.Destination, mountPoint.Destination)
+		assert.Equal(base.T, testCase.mountPoint.Propagation, mountPoint.Propagation)
+		if testCase.mountPoint.Mode != "" {
+			assert.Equal(base.T, testCase.mountPoint.Mode, mountPoint.Mode)
+		}
 
 		if testCase.mountPoint.Source != "" {
 			assert.Equal(base.T, testCase.mountPoint.Source, mountPoint.Source)
@@ -138,3 +172,19 @@ func TestContainerInspectContainsMounts(t *testing.T) {
 		}
 	}
 }
+
+func TestContainerInspectFormatJSON(t *testing.T) {
+	testContainer := testutil.Identifier(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("rm", "-f", testContainer).Run()
+	base.Cmd("create", "--name", testContainer, testutil.AlpineImage, "echo", "foo", "bar").AssertOK()
+
+	base.Cmd("inspect", "--format", "path={{json .Path}} args={{json .Args}}", testContainer).
+		AssertOutExactly("path=\"echo\" args=[\"foo\",\"bar\"]\n")
+
+	testutil.DockerIncompatible(t) // Docker lacks `prettyjson` and `--pretty`
+	const expectedPretty = "args=[\n    \"foo\",\n    \"bar\"\n]\n"
+	base.Cmd("inspect", "--format", "args={{prettyjson .Args}}", testContainer).AssertOutExactly(expectedPretty)
+	base.Cmd("inspect", "--pretty", "--format", "args={{json .Args}}", testContainer).AssertOutExactly(expectedPretty)
+	base.Cmd("container", "inspect", "--pretty", "--format", "args={{json .Args}}", testContainer).AssertOutExactly(expectedPretty)
+}
@@ -0,0 +1,54 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+)
+
+func TestEnsureRunningStoppedContainer(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+
+	base.Cmd("run", "--name", tID, testutil.CommonImage, "true").AssertOK()
+	defer base.Cmd("rm", "-f", tID).Run()
+
+	base.Cmd("exec", tID, "true").AssertErrContains("is not running")
+	base.Cmd("kill", tID).AssertErrContains("is not running")
+	base.Cmd("pause", tID).AssertErrContains("is not running")
+	base.Cmd("top", tID).AssertErrContains("is not running")
+}
+
+func TestEnsureRunningPausedContainer(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+	switch base.Info().CgroupDriver {
+	case "none", "":
+		t.Skip("requires cgroup (for pausing)")
+	}
+	tID := testutil.Identifier(t)
+
+	base.Cmd("run", "-d", "--name", tID, testutil.CommonImage, "sleep", "infinity").AssertOK()
+	defer base.Cmd("rm", "-f", tID).Run()
+	base.Cmd("pause", tID).AssertOK()
+
+	base.Cmd("exec", tID, "true").AssertErrContains("is paused")
+	base.Cmd("top", tID).AssertOutContains("sleep")
+}
@@ -17,10 +17,18 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/continuity/fs"
+	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/containerd/nerdctl/pkg/mountutil/volumestore"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -35,6 +43,11 @@ func newContainerPruneCommand() *cobra.Command {
 		SilenceErrors: true,
 	}
 	containerPruneCommand.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
+	containerPruneCommand.Flags().Bool("volumes", false, "Remove anonymous volumes associated with the pruned containers")
+	containerPruneCommand.Flags().String("protect-label", labels.PruneProtect, "Do not remove containers with this label set to false (e.g. \"--label "+labels.PruneProtect+"=false\")")
+	containerPruneCommand.Flags().Bool("force-labels", false, "Remove protected containers too, ignoring --protect-label")
+	containerPruneCommand.Flags().StringArray("filter", nil, "Provide filter values (e.g. \"until=24h\", \"label=foo=bar\")")
+	addPruneDryRunFlag(containerPruneCommand)
 	return containerPruneCommand
 }
 
@@ -50,9 +63,49 @@ func containerPruneAction(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	if !force {
+	volumes, err := cmd.Flags().GetBool("volumes")
+	if err != nil {
+		return err
+	}
+
+	protectLabel, err := cmd.Flags().GetString("protect-label")
+	if err != nil {
+		return err
+	}
+	forceLabels, err := cmd.Flags().GetBool("force-labels")
+	if err != nil {
+		return err
+	}
+	if forceLabels {
+		protectLabel = ""
+	}
+
+	filterFlags, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	filters, err := parsePruneFilters(filterFlags)
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	if !force && !dryRun {
 		var confirm string
-		fmt.Fprintf(cmd.OutOrStdout(), "%s", "WARNING! This will remove all stopped containers.\nAre you sure you want to continue? [y/N] ")
+		msg := "This will remove all stopped containers."
+		if volumes {
+			msg += "\nAnonymous volumes associated with them will be removed too."
+		}
+		if len(filterFlags) > 0 {
+			msg += "\n  Containers to be pruned will be filtered with:"
+			for _, f := range filterFlags {
+				msg += "\n  - " + f
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "WARNING! %s\nAre you sure you want to continue? [y/N] ", msg)
 		fmt.Fscanf(cmd.InOrStdin(), "%s", &confirm)
 
 		if strings.ToLower(confirm) != "y" {
@@ -60,21 +113,97 @@ func containerPruneAction(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
-	ns, err := cmd.Flags().GetString("namespace")
+	_, reclaimed, err := pruneContainers(ctx, cmd, client, volumes, filters, protectLabel, dryRun)
 	if err != nil {
 		return err
 	}
+	if dryRun || volumes {
+		printPruneTotal(cmd.OutOrStdout(), reclaimed, dryRun)
+	}
+	return nil
+}
+
+// pruneContainers removes the stopped containers that match the filters (can be nil), and prints the removed ones.
+// Containers with protectLabel set to false are skipped, unless protectLabel is empty.
+// pruneContainers returns the IDs of the removed containers, and the total size of their writable layers
+// and anonymous volumes.
+// With dryRun, pruneContainers only prints the containers that would be removed, and returns them and their size.
+func pruneContainers(ctx context.Context, cmd *cobra.Command, client *containerd.Client, volumes bool, filters *pruneFilters, protectLabel string, dryRun bool) (map[string]struct{}, int64, error) {
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return nil, 0, err
+	}
 
 	containers, err := client.Containers(ctx)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
-	var deleted []string
+	var volStore volumestore.VolumeStore
+	if volumes {
+		volStore, err = getVolumeStore(cmd)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var (
+		deleted        []string
+		deletedVolumes []string
+		reclaimed      int64
+	)
 	for _, container := range containers {
-		err = removeContainer(cmd, ctx, container, ns, false, true)
+		info, err := container.Info(ctx, containerd.WithoutRefreshedMetadata)
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to inspect container %s", container.ID())
+			continue
+		}
+		if !filters.match(info.CreatedAt, info.Labels) {
+			continue
+		}
+		if isPruneProtected(info.Labels, protectLabel) {
+			logrus.Debugf("skipping container %s protected by label %q", container.ID(), protectLabel)
+			continue
+		}
+		var anonVolumes []string
+		var anonVolumeSizes map[string]int64
+		if volumes {
+			anonVolumes, anonVolumeSizes, err = inspectAnonymousVolumes(ctx, container, volStore)
+			if err != nil {
+				logrus.WithError(err).Warnf("failed to inspect anonymous volumes of container %s", container.ID())
+			}
+		}
+		var rwSize int64
+		if info.Snapshotter != "" && info.SnapshotKey != "" {
+			if usage, err := client.SnapshotService(info.Snapshotter).Usage(ctx, info.SnapshotKey); err == nil {
+				rwSize = usage.Size
+			}
+		}
+		if dryRun {
+			if isContainerStopped(ctx, container) {
+				deleted = append(deleted, container.ID())
+				reclaimed += rwSize
+				for _, name := range anonVolumes {
+					deletedVolumes = append(deletedVolumes, name)
+					reclaimed += anonVolumeSizes[name]
+				}
+			}
+			continue
+		}
+		// the anonymous volumes are removed here rather than by removeContainer,
+		// so that only the volumes actually removed are reported
+		err = removeContainer(cmd, ctx, container, ns, false, false)
 		if err == nil {
 			deleted = append(deleted, container.ID())
+			reclaimed += rwSize
+			for _, name := range anonVolumes {
+				if _, err := volStore.Remove([]string{name}, false); err != nil {
+					logrus.WithError(err).Warnf("failed to remove anonymous volume %s of container %s", name, container.ID())
+					continue
+				}
+				deletedVolumes = append(deletedVolumes, name)
+				reclaimed += anonVolumeSizes[name]
+			}
 			continue
 		}
 		if errors.As(err, &statusError{}) {
@@ -83,12 +212,81 @@ func containerPruneAction(cmd *cobra.Command, _ []string) error {
 		logrus.WithError(err).Warnf("failed to remove container %s", container.ID())
 	}
 
+	verb := pruneVerb(dryRun)
+	pruned := make(map[string]struct{}, len(deleted))
 	if len(deleted) > 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), "Deleted Containers:")
+		fmt.Fprintf(cmd.OutOrStdout(), "%s Containers:\n", verb)
 		for _, id := range deleted {
 			fmt.Fprintln(cmd.OutOrStdout(), id)
+			pruned[id] = struct{}{}
+		}
+	}
+	if len(deletedVolumes) > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s Volumes:\n", verb)
+		for _, name := range deletedVolumes {
+			fmt.Fprintln(cmd.OutOrStdout(), name)
 		}
 	}
+	return pruned, reclaimed, nil
+}
 
-	return nil
+// isContainerStopped returns true when the container would be removed by removeContainer without force,
+// i.e., the container does not have a task, or the task is created or stopped.
+func isContainerStopped(ctx context.Context, container containerd.Container) bool {
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return errdefs.IsNotFound(err)
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return errdefs.IsNotFound(err)
+	}
+	return status.Status == containerd.Created || status.Status == containerd.Stopped
+}
+
+// isPruneProtected returns true when the label protectLabel is set to a false value, such as "false" and "0".
+func isPruneProtected(l map[string]string, protectLabel string) bool {
+	if protectLabel == "" {
+		return false
+	}
+	v, ok := l[protectLabel]
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	return err == nil && !b
+}
+
+// inspectAnonymousVolumes returns the names and the disk usage of the anonymous volumes of the container.
+// Named volumes are never included.
+func inspectAnonymousVolumes(ctx context.Context, container containerd.Container, volStore volumestore.VolumeStore) ([]string, map[string]int64, error) {
+	l, err := container.Labels(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	anonVolumesJSON, ok := l[labels.AnonymousVolumes]
+	if !ok {
+		return nil, nil, nil
+	}
+	var anonVolumes []string
+	if err := json.Unmarshal([]byte(anonVolumesJSON), &anonVolumes); err != nil {
+		return nil, nil, err
+	}
+	sizes := make(map[string]int64, len(anonVolumes))
+	for _, name := range anonVolumes {
+		vol, err := volStore.Get(name)
+		if err != nil {
+			return anonVolumes, sizes, err
+		}
+		// the mountpoint of a volume created with a driver plugin is not known on the host
+		if vol.Mountpoint == "" {
+			continue
+		}
+		usage, err := fs.DiskUsage(ctx, vol.Mountpoint)
+		if err != nil {
+			return anonVolumes, sizes, err
+		}
+		sizes[name] = usage.Size
+	}
+	return anonVolumes, sizes, nil
 }
@@ -17,9 +17,11 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
 )
 
 func TestPruneContainer(t *testing.T) {
@@ -40,3 +42,112 @@ func TestPruneContainer(t *testing.T) {
 	base.Cmd("container", "prune", "-f").AssertOK()
 	base.Cmd("inspect", tID+"-1").AssertFail()
 }
+
+func TestPruneContainerWithVolumes(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks `container prune --volumes`
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	namedVolume := tID + "-named"
+	defer base.Cmd("volume", "rm", "-f", namedVolume).Run()
+
+	base.Cmd("run", "--name", tID+"-1", "-v", "/anon", "-v", namedVolume+":/named", testutil.CommonImage,
+		"sh", "-c", "echo foo > /anon/foo; echo bar > /named/bar").AssertOK()
+	defer base.Cmd("rm", "-f", tID+"-1").Run()
+	anonVolume := ""
+	for _, m := range base.InspectContainer(tID + "-1").Mounts {
+		if m.Destination == "/anon" {
+			anonVolume = m.Name
+		}
+	}
+	assert.Assert(t, anonVolume != "")
+
+	base.Cmd("container", "prune", "-f", "--volumes").AssertOutContains(anonVolume)
+	base.Cmd("inspect", tID+"-1").AssertFail()
+	base.Cmd("volume", "inspect", anonVolume).AssertFail()
+	// named volumes are never removed
+	base.Cmd("volume", "inspect", namedVolume).AssertOK()
+}
+
+func TestPruneContainerKeepsVolumesByDefault(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+
+	base.Cmd("run", "--name", tID, "-v", "/anon", testutil.CommonImage, "true").AssertOK()
+	defer base.Cmd("rm", "-f", tID).Run()
+	anonVolume := base.InspectContainer(tID).Mounts[0].Name
+	defer base.Cmd("volume", "rm", "-f", anonVolume).Run()
+
+	base.Cmd("container", "prune", "-f").AssertOK()
+	base.Cmd("inspect", tID).AssertFail()
+	base.Cmd("volume", "inspect", anonVolume).AssertOK()
+}
+
+func TestPruneContainerProtectedByLabel(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks the protection label
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+
+	base.Cmd("create", "--name", tID+"-protected", "--label", "nerdctl/prune=false", testutil.CommonImage, "true").AssertOK()
+	defer base.Cmd("rm", "-f", tID+"-protected").Run()
+	base.Cmd("create", "--name", tID+"-custom", "--label", "com.example.keep=false", testutil.CommonImage, "true").AssertOK()
+	defer base.Cmd("rm", "-f", tID+"-custom").Run()
+	base.Cmd("create", "--name", tID+"-unprotected", "--label", "nerdctl/prune=true", testutil.CommonImage, "true").AssertOK()
+	defer base.Cmd("rm", "-f", tID+"-unprotected").Run()
+
+	base.Cmd("container", "prune", "-f").AssertOK()
+	base.Cmd("inspect", tID+"-protected").AssertOK()
+	base.Cmd("inspect", tID+"-unprotected").AssertFail()
+	base.Cmd("inspect", tID+"-custom").AssertFail()
+
+	base.Cmd("create", "--name", tID+"-custom", "--label", "com.example.keep=false", testutil.CommonImage, "true").AssertOK()
+	base.Cmd("container", "prune", "-f", "--protect-label", "com.example.keep").AssertOK()
+	base.Cmd("inspect", tID+"-custom").AssertOK()
+	base.Cmd("inspect", tID+"-protected").AssertFail()
+
+	base.Cmd("container", "prune", "-f", "--protect-label", "com.example.keep", "--force-labels").AssertOK()
+	base.Cmd("inspect", tID+"-custom").AssertFail()
+}
+
+func TestPruneContainerWithFilter(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+
+	base.Cmd("create", "--name", tID+"-1", "--label", "group="+tID+"-1", testutil.CommonImage, "true").AssertOK()
+	defer base.Cmd("rm", "-f", tID+"-1").Run()
+	base.Cmd("create", "--name", tID+"-2", "--label", "group="+tID+"-2", testutil.CommonImage, "true").AssertOK()
+	defer base.Cmd("rm", "-f", tID+"-2").Run()
+
+	// the containers were created less than 1h ago
+	base.Cmd("container", "prune", "-f", "--filter", "until=1h").AssertOK()
+	base.Cmd("inspect", tID+"-1").AssertOK()
+	base.Cmd("inspect", tID+"-2").AssertOK()
+
+	base.Cmd("container", "prune", "-f", "--filter", "label=group="+tID+"-1").AssertOK()
+	base.Cmd("inspect", tID+"-1").AssertFail()
+	base.Cmd("inspect", tID+"-2").AssertOK()
+
+	base.Cmd("container", "prune", "-f", "--filter", "foo=bar").AssertFail()
+}
+
+func TestPruneContainerDryRun(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks `container prune --dry-run`
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+
+	base.Cmd("run", "-d", "--name", tID+"-running", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	defer base.Cmd("rm", "-f", tID+"-running").Run()
+	base.Cmd("create", "--name", tID+"-created", testutil.CommonImage, "true").AssertOK()
+	defer base.Cmd("rm", "-f", tID+"-created").Run()
+	runningID := base.InspectContainer(tID + "-running").ID
+	createdID := base.InspectContainer(tID + "-created").ID
+
+	res := base.Cmd("container", "prune", "--dry-run").Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), createdID), res.Stdout())
+	assert.Assert(t, !strings.Contains(res.Stdout(), runningID), res.Stdout())
+	assert.Assert(t, strings.Contains(res.Stdout(), "Total reclaimable space"), res.Stdout())
+
+	// nothing is removed
+	base.Cmd("inspect", tID+"-running").AssertOK()
+	base.Cmd("inspect", tID+"-created").AssertOK()
+}
@@ -0,0 +1,43 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestIsPruneProtected(t *testing.T) {
+	const key = "nerdctl/prune"
+	testCases := []struct {
+		labels       map[string]string
+		protectLabel string
+		expected     bool
+	}{
+		{labels: nil, protectLabel: key, expected: false},
+		{labels: map[string]string{key: "false"}, protectLabel: key, expected: true},
+		{labels: map[string]string{key: "0"}, protectLabel: key, expected: true},
+		{labels: map[string]string{key: "true"}, protectLabel: key, expected: false},
+		{labels: map[string]string{key: "invalid"}, protectLabel: key, expected: false},
+		{labels: map[string]string{key: "false"}, protectLabel: "", expected: false},
+		{labels: map[string]string{key: "false"}, protectLabel: "com.example.keep", expected: false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, isPruneProtected(tc.labels, tc.protectLabel), "%+v", tc)
+	}
+}
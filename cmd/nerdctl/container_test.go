@@ -0,0 +1,81 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/errdefs"
+	"gotest.tools/v3/assert"
+)
+
+// fakeContainer implements only the methods of containerd.Container that are used by ensureRunning
+type fakeContainer struct {
+	containerd.Container
+	task containerd.Task
+}
+
+func (c *fakeContainer) ID() string {
+	return "fake"
+}
+
+func (c *fakeContainer) Task(context.Context, cio.Attach) (containerd.Task, error) {
+	if c.task == nil {
+		return nil, errdefs.ErrNotFound
+	}
+	return c.task, nil
+}
+
+// fakeTask implements only the methods of containerd.Task that are used by ensureRunning
+type fakeTask struct {
+	containerd.Task
+	status containerd.ProcessStatus
+}
+
+func (t *fakeTask) Status(context.Context) (containerd.Status, error) {
+	return containerd.Status{Status: t.status}, nil
+}
+
+func TestEnsureRunning(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// no task
+	_, _, err := ensureRunning(ctx, &fakeContainer{})
+	var notRunning errContainerNotRunning
+	assert.Assert(t, errors.As(err, &notRunning))
+	assert.Equal(t, containerd.Created, notRunning.status)
+
+	for _, s := range []containerd.ProcessStatus{containerd.Created, containerd.Stopped} {
+		_, _, err := ensureRunning(ctx, &fakeContainer{task: &fakeTask{status: s}})
+		var notRunning errContainerNotRunning
+		assert.Assert(t, errors.As(err, &notRunning), "status %s", s)
+		assert.Equal(t, s, notRunning.status)
+	}
+
+	// paused containers are regarded as running
+	for _, s := range []containerd.ProcessStatus{containerd.Running, containerd.Paused, containerd.Pausing} {
+		task, status, err := ensureRunning(ctx, &fakeContainer{task: &fakeTask{status: s}})
+		assert.NilError(t, err)
+		assert.Assert(t, task != nil)
+		assert.Equal(t, s, status.Status)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/containerd/nerdctl/pkg/platformutil"
 	"github.com/spf13/cobra"
 )
 
@@ -54,6 +55,9 @@ func createAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := platformutil.CheckBinfmt(platform); err != nil {
+		return err
+	}
 	client, ctx, cancel, err := newClientWithPlatform(cmd, platform)
 	if err != nil {
 		return err
@@ -28,6 +28,7 @@ import (
 	"github.com/containerd/containerd/cio"
 	"github.com/containerd/containerd/cmd/ctr/commands"
 	"github.com/containerd/containerd/cmd/ctr/commands/tasks"
+	"github.com/containerd/containerd/oci"
 	"github.com/containerd/nerdctl/pkg/idgen"
 	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
 	"github.com/containerd/nerdctl/pkg/strutil"
@@ -123,15 +124,33 @@ func execActionWithContainer(ctx context.Context, cmd *cobra.Command, args []str
 		}
 	}
 
-	pspec, err := generateExecProcessSpec(ctx, cmd, args, container, client)
+	task, taskStatus, err := ensureRunning(ctx, container)
 	if err != nil {
 		return err
 	}
+	if taskStatus.Status == containerd.Paused || taskStatus.Status == containerd.Pausing {
+		return fmt.Errorf("container %s is paused, unpause the container before exec", container.ID())
+	}
 
-	task, err := container.Task(ctx, nil)
+	pspec, err := generateExecProcessSpec(ctx, cmd, args, container, client)
 	if err != nil {
 		return err
 	}
+
+	var con console.Console
+	if flagT {
+		con = console.Current()
+		defer con.Reset()
+		if err := con.SetRaw(); err != nil {
+			return err
+		}
+		// the initial size is set in the spec, as the process cannot be resized before it is started
+		if size, err := con.Size(); err == nil {
+			pspec.ConsoleSize = &specs.Box{Height: uint(size.Height), Width: uint(size.Width)}
+		} else {
+			logrus.WithError(err).Warn("failed to get the console size")
+		}
+	}
 	var (
 		ioCreator cio.Creator
 		in        io.Reader
@@ -167,31 +186,21 @@ func execActionWithContainer(ctx context.Context, cmd *cobra.Command, args []str
 		return err
 	}
 
-	var con console.Console
-	if flagT {
-		con = console.Current()
-		defer con.Reset()
-		if err := con.SetRaw(); err != nil {
-			return err
-		}
-	}
-	if !flagD {
-		if flagT {
-			if err := tasks.HandleConsoleResize(ctx, process, con); err != nil {
-				logrus.WithError(err).Error("console resize")
-			}
-		} else {
-			sigc := commands.ForwardAllSignals(ctx, process)
-			defer commands.StopCatch(sigc)
-		}
-	}
-
 	if err := process.Start(ctx); err != nil {
 		return err
 	}
 	if flagD {
 		return nil
 	}
+	// the resize is propagated after starting the process, in the same way as `nerdctl run`
+	if flagT {
+		if err := tasks.HandleConsoleResize(ctx, process, con); err != nil {
+			logrus.WithError(err).Error("console resize")
+		}
+	} else {
+		sigc := commands.ForwardAllSignals(ctx, process)
+		defer commands.StopCatch(sigc)
+	}
 	status := <-statusC
 	code, _, err := status.Result()
 	if err != nil {
@@ -248,13 +257,20 @@ func generateExecProcessSpec(ctx context.Context, cmd *cobra.Command, args []str
 		if err != nil {
 			return nil, err
 		}
-		pspec.Env = append(pspec.Env, env...)
+		// same precedence as `nerdctl run`: image < env-file < env
+		if err := oci.WithEnv(env)(ctx, client, nil, spec); err != nil {
+			return nil, err
+		}
 	}
 	env, err := cmd.Flags().GetStringArray("env")
 	if err != nil {
 		return nil, err
 	}
-	pspec.Env = append(pspec.Env, strutil.DedupeStrSlice(env)...)
+	if env := strutil.DedupeStrSlice(env); len(env) > 0 {
+		if err := oci.WithEnv(env)(ctx, client, nil, spec); err != nil {
+			return nil, err
+		}
+	}
 
 	privileged, err := cmd.Flags().GetBool("privileged")
 	if err != nil {
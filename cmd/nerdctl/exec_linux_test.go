@@ -17,9 +17,11 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
 )
 
 func TestExecWithUser(t *testing.T) {
@@ -49,6 +51,25 @@ func TestExecWithUser(t *testing.T) {
 	}
 }
 
+// TestExecWithNamedUserGroup tests that the user and the group names are resolved
+// against /etc/passwd and /etc/group of the container, including the entries added after the start.
+func TestExecWithNamedUserGroup(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	testContainer := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", testContainer).Run()
+
+	base.Cmd("run", "-d", "--name", testContainer, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	base.Cmd("exec", testContainer, "sh", "-euxc",
+		"addgroup -g 2000 appgroup && adduser -D -u 2000 -G appgroup -h /home/appuser appuser && addgroup -g 2001 othergroup").AssertOK()
+
+	base.Cmd("exec", "--user", "appuser", testContainer, "id").AssertOutContains("uid=2000(appuser) gid=2000(appgroup)")
+	base.Cmd("exec", "--user", "appuser:othergroup", testContainer, "id").AssertOutContains("uid=2000(appuser) gid=2001(othergroup)")
+	base.Cmd("exec", "--user", "2000:othergroup", testContainer, "id").AssertOutContains("uid=2000(appuser) gid=2001(othergroup)")
+	base.Cmd("exec", "--user", "appuser", testContainer, "sh", "-c", "echo -n $HOME").AssertOutExactly("/home/appuser")
+	base.Cmd("exec", "--user", "nosuchuser", testContainer, "id").AssertFail()
+}
+
 func TestExecTTY(t *testing.T) {
 	t.Parallel()
 	base := testutil.NewBase(t)
@@ -69,3 +90,23 @@ func TestExecTTY(t *testing.T) {
 	base.Cmd("exec", "-i", testContainer, "stty").AssertFail()
 	base.Cmd("exec", testContainer, "stty").AssertFail()
 }
+
+func TestExecTTYResize(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	if testutil.GetTarget() == testutil.Nerdctl {
+		testutil.RequireDaemonVersion(base, ">= 1.6.0-0")
+	}
+
+	testContainer := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", testContainer).Run()
+	base.Cmd("run", "-d", "--name", testContainer, testutil.CommonImage, "sleep", "infinity").AssertOK()
+
+	// The helper sets the initial size of the tty emulated by unbuffer(1), runs `nerdctl exec` ("$0" "$@") in the background,
+	// and then resizes the tty and sends SIGWINCH, as terminal emulators do.
+	helper := []string{"unbuffer", "sh", "-c", `stty rows 37 cols 123; "$0" "$@" & pid=$!; sleep 2; stty rows 41 cols 97; kill -WINCH $pid; wait $pid`}
+	res := base.CmdWithHelper(helper, "exec", "-t", testContainer, "sh", "-c", "stty size; sleep 4; stty size").Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), "37 123"), res.Stdout())
+	assert.Assert(t, strings.Contains(res.Stdout(), "41 97"), res.Stdout())
+}
@@ -17,10 +17,13 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
 )
 
 func TestExec(t *testing.T) {
@@ -63,3 +66,22 @@ func TestExecStdin(t *testing.T) {
 	}
 	base.Cmd("exec", "-i", testContainer, "cat").CmdOption(opts...).AssertOutExactly(testStr)
 }
+
+func TestExecEnvFile(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	testContainer := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", testContainer).Run()
+
+	envFile := filepath.Join(t.TempDir(), "vars.env")
+	err := os.WriteFile(envFile, []byte("# this is a comment line\nTESTKEY1=FROMFILE1\nTESTKEY2=FROMFILE2\nTESTKEY3=FROMFILE3"), 0644)
+	assert.NilError(t, err)
+
+	base.Cmd("run", "-d", "--name", testContainer, "-e", "TESTKEY3=FROMRUN", testutil.CommonImage, "sleep", "1h").AssertOK()
+
+	base.Cmd("exec", "--env-file", envFile, testContainer, "sh", "-c", "echo -n $TESTKEY1").AssertOutExactly("FROMFILE1")
+	// env-file < env
+	base.Cmd("exec", "--env-file", envFile, "-e", "TESTKEY2=FROMFLAG", testContainer, "sh", "-c", "echo -n $TESTKEY2").AssertOutExactly("FROMFLAG")
+	// the container env < env-file
+	base.Cmd("exec", "--env-file", envFile, testContainer, "sh", "-c", "echo -n $TESTKEY3").AssertOutExactly("FROMFILE3")
+}
@@ -21,12 +21,40 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/docker/cli/templates"
 	"github.com/spf13/cobra"
 )
 
+// sortByKey sorts the slice by the key in ascending order, or in descending order when reverse is true.
+// lessFuncs maps each of the valid keys to the less function of the elements i and j of the slice.
+// The sort is stable, so the elements with the same key are kept in the listed order.
+// An empty key keeps the slice as is.
+func sortByKey(slice interface{}, key string, reverse bool, lessFuncs map[string]func(i, j int) bool) error {
+	if key == "" {
+		return nil
+	}
+	less, ok := lessFuncs[key]
+	if !ok {
+		var keys []string
+		for k := range lessFuncs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("invalid sort key %q, expected one of %v", key, keys)
+	}
+	sort.SliceStable(slice, func(i, j int) bool {
+		if reverse {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+	return nil
+}
+
 // Flusher is implemented by text/tabwriter.Writer
 type Flusher interface {
 	Flush() error
@@ -49,6 +77,7 @@ func formatLabels(labels map[string]string) string {
 //
 // --format='' (default): JSON
 // --format='{{json .}}': JSON lines
+// --format='{{json .}}' --pretty: indented JSON
 //
 // formatSlice is expected to be only used for `nerdctl OBJECT inspect` commands.
 func formatSlice(cmd *cobra.Command, x []interface{}) error {
@@ -72,6 +101,16 @@ func formatSlice(cmd *cobra.Command, x []interface{}) error {
 		if err != nil {
 			return err
 		}
+		// --pretty is not defined for some inspect commands
+		if cmd.Flags().Lookup("pretty") != nil {
+			pretty, err := cmd.Flags().GetBool("pretty")
+			if err != nil {
+				return err
+			}
+			if pretty {
+				tmpl.Funcs(template.FuncMap{"json": prettyJSON})
+			}
+		}
 		for _, f := range x {
 			var b bytes.Buffer
 			if err := tmpl.Execute(&b, f); err != nil {
@@ -113,8 +152,27 @@ func tryRawFormat(b *bytes.Buffer, f interface{}, tmpl *template.Template) error
 	return nil
 }
 
-// parseTemplate wraps github.com/docker/cli/templates.Parse() to allow `json` as an alias of `{{json .}}`.
-// parseTemplate can be removed when https://github.com/docker/cli/pull/3355 gets merged and tagged (Docker 22.XX).
+// templateFuncs are the template functions in addition to the functions of github.com/docker/cli/templates,
+// such as `json`.
+var templateFuncs = template.FuncMap{
+	"prettyjson": prettyJSON,
+}
+
+// prettyJSON is similar to the `json` template function but indents the output.
+func prettyJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// parseTemplate wraps github.com/docker/cli/templates.Parse() to allow `json` as an alias of `{{json .}}`,
+// and to add templateFuncs.
+// The alias can be removed when https://github.com/docker/cli/pull/3355 gets merged and tagged (Docker 22.XX).
 func parseTemplate(format string) (*template.Template, error) {
 	aliases := map[string]string{
 		"json": "{{json .}}",
@@ -122,5 +180,5 @@ func parseTemplate(format string) (*template.Template, error) {
 	if alias, ok := aliases[format]; ok {
 		format = alias
 	}
-	return templates.Parse(format)
+	return templates.New("").Funcs(templateFuncs).Parse(format)
 }
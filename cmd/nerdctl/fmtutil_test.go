@@ -0,0 +1,90 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseTemplate(t *testing.T) {
+	x := struct {
+		Name   string
+		Labels map[string]string
+	}{
+		Name:   "foo",
+		Labels: map[string]string{"a": "<b>"},
+	}
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{"json", `{"Name":"foo","Labels":{"a":"<b>"}}`},
+		{"name={{json .Name}}", `name="foo"`},
+		{"labels={{json .Labels}}", `labels={"a":"<b>"}`},
+		{"labels={{prettyjson .Labels}}", "labels={\n    \"a\": \"<b>\"\n}"},
+	}
+	for _, tc := range testCases {
+		tmpl, err := parseTemplate(tc.format)
+		assert.NilError(t, err)
+		var b bytes.Buffer
+		assert.NilError(t, tmpl.Execute(&b, x))
+		assert.Equal(t, tc.expected, b.String())
+	}
+}
+
+func TestSortByKey(t *testing.T) {
+	type item struct {
+		name string
+		size int
+	}
+	items := []item{{"b", 10}, {"a", 30}, {"c", 10}}
+	testCases := []struct {
+		key      string
+		reverse  bool
+		expected string
+	}{
+		{key: "", expected: "b,a,c"},
+		{key: "", reverse: true, expected: "b,a,c"},
+		{key: "name", expected: "a,b,c"},
+		{key: "name", reverse: true, expected: "c,b,a"},
+		// stable for the same size
+		{key: "size", expected: "b,c,a"},
+		{key: "size", reverse: true, expected: "a,b,c"},
+	}
+	for _, tc := range testCases {
+		sorted := append([]item{}, items...)
+		err := sortByKey(sorted, tc.key, tc.reverse, map[string]func(i, j int) bool{
+			"name": func(i, j int) bool { return sorted[i].name < sorted[j].name },
+			"size": func(i, j int) bool { return sorted[i].size < sorted[j].size },
+		})
+		assert.NilError(t, err)
+		var names []string
+		for _, x := range sorted {
+			names = append(names, x.name)
+		}
+		assert.Equal(t, tc.expected, strings.Join(names, ","), "key=%q, reverse=%v", tc.key, tc.reverse)
+	}
+	err := sortByKey(items, "unknown", false, map[string]func(i, j int) bool{
+		"size": func(i, j int) bool { return items[i].size < items[j].size },
+		"name": func(i, j int) bool { return items[i].name < items[j].name },
+	})
+	assert.ErrorContains(t, err, `invalid sort key "unknown", expected one of [name size]`)
+}
@@ -45,6 +45,9 @@ func newImageCommand() *cobra.Command {
 		newImageEncryptCommand(),
 		newImageDecryptCommand(),
 		newImagePruneCommand(),
+		newImageUnpackCommand(),
+		newImageSignCommand(),
+		newImageVerifyCommand(),
 	)
 	return cmd
 }
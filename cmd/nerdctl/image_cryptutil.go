@@ -18,15 +18,20 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/images/converter"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/imgcrypt"
 	"github.com/containerd/imgcrypt/images/encryption"
 	"github.com/containerd/imgcrypt/images/encryption/parsehelpers"
 	"github.com/containerd/nerdctl/pkg/platformutil"
 	"github.com/containerd/nerdctl/pkg/referenceutil"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
 )
@@ -53,6 +58,8 @@ func registerImgcryptFlags(cmd *cobra.Command, encrypt bool) {
 	// While --recipient can be specified only for `nerdctl image encrypt`,
 	// --dec-recipient can be specified for both `nerdctl image encrypt` and `nerdctl image decrypt`.
 	flags.StringSlice("dec-recipient", []string{}, "Recipient of the image; used only for PKCS7 and must be an x509 certificate")
+	// layer is defined as IntSlice, to allow specifying "--layer=0,-1"
+	flags.IntSlice("layer", []int{}, "The layer to encrypt or decrypt; this must be either the layer number or a negative number starting with -1 for topmost layer (default: all layers)")
 
 	if encrypt {
 		// recipient is defined as StringSlice, not StringArray, to allow specifying "--recipient=jwe:FILE1,jwe:FILE2"
@@ -96,6 +103,25 @@ func parseImgcryptFlags(cmd *cobra.Command, encrypt bool) (parsehelpers.EncArgs,
 	return a, nil
 }
 
+// generateDecryptPayload returns the imgcrypt payload for `nerdctl run --decryption-key`.
+// Returns nil when no key is specified, so that only the keys configured in the daemon side are used.
+func generateDecryptPayload(cmd *cobra.Command) (*imgcrypt.Payload, error) {
+	keys, err := cmd.Flags().GetStringSlice("decryption-key")
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	cc, err := parsehelpers.CreateDecryptCryptoConfig(parsehelpers.EncArgs{Key: keys}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse --decryption-key: %w", err)
+	}
+	return &imgcrypt.Payload{
+		DecryptConfig: *cc.DecryptConfig,
+	}, nil
+}
+
 func getImgcryptAction(encrypt bool) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		var convertOpts = []converter.Opt{}
@@ -150,8 +176,13 @@ func getImgcryptAction(encrypt bool) func(cmd *cobra.Command, args []string) err
 		if err != nil {
 			return err
 		}
-		layerFilter := func(desc ocispec.Descriptor) bool {
-			return true
+		layers, err := cmd.Flags().GetIntSlice("layer")
+		if err != nil {
+			return err
+		}
+		layerFilter, err := createLayerFilter(ctx, client.ContentStore(), srcImg.Target, platMC, layers)
+		if err != nil {
+			return err
 		}
 		var convertFunc converter.ConvertFunc
 		if encrypt {
@@ -181,6 +212,51 @@ func getImgcryptAction(encrypt bool) func(cmd *cobra.Command, args []string) err
 	}
 }
 
+// createLayerFilter returns a filter that matches the layers specified by the indices.
+// A negative index counts from the topmost layer (-1).
+// When no index is specified, the filter matches all the layers.
+//
+// From https://github.com/containerd/imgcrypt/blob/v1.1.2/cmd/ctr/commands/images/crypt_utils.go#L79-L96
+func createLayerFilter(ctx context.Context, provider content.Provider, imageTarget ocispec.Descriptor, platMC platforms.MatchComparer, layers []int) (func(desc ocispec.Descriptor) bool, error) {
+	if len(layers) == 0 {
+		return func(desc ocispec.Descriptor) bool {
+			return true
+		}, nil
+	}
+	selected := make(map[digest.Digest]struct{})
+	err := images.Walk(ctx, images.Handlers(images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if !images.IsManifestType(desc.MediaType) {
+			return nil, nil
+		}
+		b, err := content.ReadBlob(ctx, provider, desc)
+		if err != nil {
+			return nil, err
+		}
+		var mani ocispec.Manifest
+		if err := json.Unmarshal(b, &mani); err != nil {
+			return nil, err
+		}
+		for _, l := range layers {
+			i := l
+			if i < 0 {
+				i += len(mani.Layers)
+			}
+			if i < 0 || i >= len(mani.Layers) {
+				return nil, fmt.Errorf("layer %d is out of range (manifest %s has %d layers)", l, desc.Digest, len(mani.Layers))
+			}
+			selected[mani.Layers[i].Digest] = struct{}{}
+		}
+		return nil, nil
+	}), images.FilterPlatforms(images.ChildrenHandler(provider), platMC)), imageTarget)
+	if err != nil {
+		return nil, err
+	}
+	return func(desc ocispec.Descriptor) bool {
+		_, ok := selected[desc.Digest]
+		return ok
+	}, nil
+}
+
 func composeConvertFunc(a, b converter.ConvertFunc) converter.ConvertFunc {
 	return func(ctx context.Context, cs content.Store, desc ocispec.Descriptor) (*ocispec.Descriptor, error) {
 		newDesc, err := a(ctx, cs, desc)
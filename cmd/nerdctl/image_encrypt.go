@@ -28,6 +28,8 @@ The following protocol prefixes are supported:
 - jwe:<public-key-file-path>
 - pkcs7:<x509-file-path>
 
+Use '--layer' to define the layers to encrypt, e.g., '--layer=-1' for the topmost layer. Defaults to all the layers.
+
 Use '--platform' to define the platforms to encrypt. Defaults to the host platform.
 When '--all-platforms' is given all images in a manifest list must be available.
 Unspecified platforms are omitted from the output image.
@@ -17,15 +17,18 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/buildkitutil"
 	"github.com/containerd/nerdctl/pkg/testutil"
 	"github.com/containerd/nerdctl/pkg/testutil/testregistry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gotest.tools/v3/assert"
 )
 
@@ -96,3 +99,69 @@ func TestImageEncryptJWE(t *testing.T) {
 	base.Cmd("image", "decrypt", "--key="+keyPair.pub, encryptImageRef, decryptImageRef).AssertFail() // decryption needs prv key, not pub key
 	base.Cmd("image", "decrypt", "--key="+keyPair.prv, encryptImageRef, decryptImageRef).AssertOK()
 }
+
+func TestImageEncryptJWELayer(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	keyPair := newJWEKeyPair(t)
+	defer keyPair.cleanup()
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	layerMediaTypes := func(imageRef string) []string {
+		var layers []ocispec.Descriptor
+		out := base.Cmd("image", "inspect", "--mode=native", "--format={{json .Manifest.Layers}}", imageRef).Out()
+		assert.NilError(t, json.Unmarshal([]byte(out), &layers))
+		var mediaTypes []string
+		for _, l := range layers {
+			mediaTypes = append(mediaTypes, l.MediaType)
+		}
+		return mediaTypes
+	}
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	baseMediaTypes := layerMediaTypes(testutil.CommonImage)
+	// the committed image has one more layer on top of the layers of the common image
+	multiLayerImageRef := tID + ":multi-layer"
+	defer base.Cmd("rmi", multiLayerImageRef).Run()
+	defer base.Cmd("rm", "-f", tID).Run()
+	base.Cmd("run", "--name", tID, testutil.CommonImage, "sh", "-c", "echo "+tID+" > /unique").AssertOK()
+	base.Cmd("commit", tID, multiLayerImageRef).AssertOK()
+	encryptImageRef := tID + ":encrypted"
+	defer base.Cmd("rmi", encryptImageRef).Run()
+	base.Cmd("image", "encrypt", "--recipient=jwe:"+keyPair.pub, "--layer=42", multiLayerImageRef, encryptImageRef).AssertFail()
+	base.Cmd("image", "encrypt", "--recipient=jwe:"+keyPair.pub, "--layer=-1", multiLayerImageRef, encryptImageRef).AssertOK()
+	encryptedMediaTypes := layerMediaTypes(encryptImageRef)
+	assert.Equal(t, len(baseMediaTypes)+1, len(encryptedMediaTypes))
+	for i, mediaType := range encryptedMediaTypes {
+		// only the topmost layer is encrypted
+		isTopmost := i == len(encryptedMediaTypes)-1
+		assert.Equal(t, isTopmost, strings.HasSuffix(mediaType, "+encrypted"), "layer %d has media type %q", i, mediaType)
+	}
+	base.Cmd("image", "inspect", "--mode=native", "--format={{json .Manifest.Layers}}", encryptImageRef).AssertOutContains("org.opencontainers.image.enc.keys.jwe")
+	decryptImageRef := tID + ":decrypted"
+	defer base.Cmd("rmi", decryptImageRef).Run()
+	base.Cmd("image", "decrypt", "--key="+keyPair.prv, "--layer=-1", encryptImageRef, decryptImageRef).AssertOK()
+	for i, mediaType := range layerMediaTypes(decryptImageRef) {
+		assert.Assert(t, !strings.HasSuffix(mediaType, "+encrypted"), "layer %d has media type %q", i, mediaType)
+	}
+	base.Cmd("image", "inspect", "--mode=native", "--format={{json .Manifest.Layers}}", decryptImageRef).AssertOutNotContains("org.opencontainers.image.enc.keys.jwe")
+	base.Cmd("run", "--rm", decryptImageRef, "cat", "/unique").AssertOutExactly(tID + "\n")
+}
+
+func TestRunEncryptedImageWithDecryptionKey(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	keyPair := newJWEKeyPair(t)
+	defer keyPair.cleanup()
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	reg := testregistry.NewPlainHTTP(base, 5000)
+	defer reg.Cleanup()
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	encryptImageRef := fmt.Sprintf("127.0.0.1:%d/%s:encrypted", reg.ListenPort, tID)
+	defer base.Cmd("rmi", encryptImageRef).Run()
+	base.Cmd("image", "encrypt", "--recipient=jwe:"+keyPair.pub, testutil.CommonImage, encryptImageRef).AssertOK()
+	base.Cmd("push", encryptImageRef).AssertOK()
+	// remove all local images (in the nerdctl-test namespace), to ensure that we do not have snapshots of the original image.
+	rmiAll(base)
+	base.Cmd("pull", "--unpack=false", encryptImageRef).AssertOK()
+	base.Cmd("run", "--rm", "--decryption-key="+keyPair.pub, encryptImageRef, "echo", "decrypted").AssertFail() // decryption needs prv key, not pub key
+	base.Cmd("run", "--rm", "--decryption-key="+keyPair.prv, encryptImageRef, "echo", "decrypted").AssertOutContains("decrypted")
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/nerdctl/pkg/idutil/imagewalker"
 	"github.com/containerd/nerdctl/pkg/imageinspector"
@@ -49,6 +50,7 @@ func newImageInspectCommand() *cobra.Command {
 	imageInspectCommand.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"json"}, cobra.ShellCompDirectiveNoFileComp
 	})
+	imageInspectCommand.Flags().Bool("pretty", false, "Indent the output of the `json` template function (same as `prettyjson`)")
 
 	// #region platform flags
 	imageInspectCommand.Flags().String("platform", "", "Inspect a specific platform") // not a slice, and there is no --all-platforms
@@ -108,6 +110,11 @@ func imageInspectActionWithPlatform(cmd *cobra.Command, args []string, platform
 				if err != nil {
 					return err
 				}
+				names, err := imageNames(ctx, client, found.Image)
+				if err != nil {
+					return err
+				}
+				d.RepoTags, d.RepoDigests = dockercompat.RepoTagsAndDigests(names, found.Image.Target.Digest)
 				f.entries = append(f.entries, d)
 			default:
 				return fmt.Errorf("unknown mode %q", f.mode)
@@ -132,6 +139,21 @@ func imageInspectActionWithPlatform(cmd *cobra.Command, args []string, platform
 	return formatSlice(cmd, f.entries)
 }
 
+// imageNames returns the names of the images that share the same target as img, beginning with the name of img.
+func imageNames(ctx context.Context, client *containerd.Client, img images.Image) ([]string, error) {
+	imgs, err := client.ImageService().List(ctx, fmt.Sprintf("target.digest==%s", img.Target.Digest))
+	if err != nil {
+		return nil, err
+	}
+	names := []string{img.Name}
+	for _, x := range imgs {
+		if x.Name != img.Name {
+			names = append(names, x.Name)
+		}
+	}
+	return names, nil
+}
+
 type imageInspector struct {
 	mode    string
 	entries []interface{}
@@ -17,8 +17,10 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/containerd/nerdctl/pkg/strutil"
 	"github.com/containerd/nerdctl/pkg/testutil"
 	"gotest.tools/v3/assert"
 )
@@ -44,3 +46,25 @@ func TestImageInspectWithFormat(t *testing.T) {
 	// test typedFormat support
 	base.Cmd("image", "inspect", testutil.CommonImage, "--format", "{{.ID}}").AssertOK()
 }
+
+func TestImageInspectRepoTagsAndDigests(t *testing.T) {
+	base := testutil.NewBase(t)
+	tagged := testutil.Identifier(t) + ":test"
+	defer base.Cmd("rmi", tagged).Run()
+
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	repoDigests := base.InspectImage(testutil.CommonImage).RepoDigests
+	assert.Assert(t, len(repoDigests) > 0)
+	byDigest := repoDigests[0]
+	assert.Assert(t, strings.Contains(byDigest, "@sha256:"), byDigest)
+
+	base.Cmd("pull", byDigest).AssertOK()
+	base.Cmd("tag", testutil.CommonImage, tagged).AssertOK()
+
+	inspect := base.InspectImage(tagged)
+	assert.Assert(t, strutil.InStringSlice(inspect.RepoTags, tagged), "RepoTags: %v", inspect.RepoTags)
+	assert.Assert(t, strutil.InStringSlice(inspect.RepoDigests, byDigest), "RepoDigests: %v", inspect.RepoDigests)
+	for _, repoTag := range inspect.RepoTags {
+		assert.Assert(t, !strings.Contains(repoTag, "@"), "RepoTags must not contain digests: %v", inspect.RepoTags)
+	}
+}
@@ -17,11 +17,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/platforms"
+	refdocker "github.com/containerd/containerd/reference/docker"
+	"github.com/containerd/nerdctl/pkg/defaults"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -38,6 +47,10 @@ func newImagePruneCommand() *cobra.Command {
 
 	imagePruneCommand.Flags().BoolP("all", "a", false, "Remove all unused images, not just dangling ones")
 	imagePruneCommand.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
+	imagePruneCommand.Flags().StringArray("filter", nil, "Provide filter values (e.g. \"until=24h\", \"label=foo=bar\")")
+	imagePruneCommand.Flags().Bool("build-cache", false, "Also prune the BuildKit build cache")
+	addPruneDryRunFlag(imagePruneCommand)
+	AddStringFlag(imagePruneCommand, "buildkit-host", nil, defaults.BuildKitHost(), "BUILDKIT_HOST", "BuildKit address")
 	return imagePruneCommand
 }
 
@@ -53,20 +66,42 @@ func imagePruneAction(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	if !all {
-		logrus.Warn("Currently, `nerdctl image prune` requires --all to be specified. Skip pruning.")
-		// NOP
-		return nil
-	}
-
 	force, err := cmd.Flags().GetBool("force")
 	if err != nil {
 		return err
 	}
+	buildCache, err := cmd.Flags().GetBool("build-cache")
+	if err != nil {
+		return err
+	}
+	filterFlags, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	filters, err := parsePruneFilters(filterFlags)
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
 
-	if !force {
+	if !force && !dryRun {
 		var confirm string
-		msg := "This will remove all images without at least one container associated to them."
+		msg := "This will remove all dangling images."
+		if all {
+			msg = "This will remove all images without at least one container associated to them."
+		}
+		if buildCache {
+			msg += "\nThe build cache will be removed as well."
+		}
+		if len(filterFlags) > 0 {
+			msg += "\n  Images to be pruned will be filtered with:"
+			for _, f := range filterFlags {
+				msg += "\n  - " + f
+			}
+		}
 		msg += "\nAre you sure you want to continue? [y/N] "
 
 		fmt.Fprintf(cmd.OutOrStdout(), "WARNING! %s", msg)
@@ -76,41 +111,186 @@ func imagePruneAction(cmd *cobra.Command, _ []string) error {
 			return nil
 		}
 	}
-	var (
-		imageStore     = client.ImageService()
-		contentStore   = client.ContentStore()
-		containerStore = client.ContainerService()
-	)
-	imageList, err := imageStore.List(ctx)
+	reclaimed, err := pruneImages(ctx, cmd, client, filters, nil, all, dryRun)
 	if err != nil {
 		return err
 	}
-	containerList, err := containerStore.List(ctx)
+	if buildCache {
+		if len(filters.labels) > 0 {
+			// the build cache does not have labels
+			logrus.Warn("the build cache is not pruned when label filters are specified")
+		} else {
+			var pruneArgs []string
+			if !filters.until.IsZero() {
+				pruneArgs = append(pruneArgs, "--keep-duration", time.Since(filters.until).Round(time.Second).String())
+			}
+			buildCacheReclaimed, err := pruneBuildCache(cmd, dryRun, pruneArgs...)
+			if err != nil {
+				return err
+			}
+			reclaimed += buildCacheReclaimed
+		}
+	}
+	printPruneTotal(cmd.OutOrStdout(), reclaimed, dryRun)
+	return nil
+}
+
+// pruneImages removes the images that match the filters (can be nil) and are not used by any container.
+// Unless all is set, only the dangling images are removed.
+// pruneImages returns the size of the content that was freed by the removal.
+// With dryRun, pruneImages only prints the images that would be removed, and returns the size of the content
+// that is not shared with the remaining images.
+func pruneImages(ctx context.Context, cmd *cobra.Command, client *containerd.Client, filters *pruneFilters, pruned map[string]struct{}, all, dryRun bool) (int64, error) {
+	var (
+		imageStore   = client.ImageService()
+		contentStore = client.ContentStore()
+	)
+	prunable, kept, err := selectPrunableImages(ctx, client, filters, pruned, all)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	usedImages := make(map[string]struct{})
-	for _, container := range containerList {
-		usedImages[container.Image] = struct{}{}
+	if dryRun {
+		for _, image := range prunable {
+			fmt.Fprintf(cmd.OutOrStdout(), "Would Untag: %s\n", image.Name)
+		}
+		return imagesReclaimableSize(ctx, contentStore, prunable, kept)
+	}
+	sizeBefore, err := contentStoreSize(ctx, contentStore)
+	if err != nil {
+		return 0, err
 	}
 
 	delOpts := []images.DeleteOpt{images.SynchronousDelete()}
-	for _, image := range imageList {
-		if _, ok := usedImages[image.Name]; ok {
-			continue
-		}
-
+	for _, image := range prunable {
 		digests, err := image.RootFS(ctx, contentStore, platforms.DefaultStrict())
 		if err != nil {
 			logrus.WithError(err).Warnf("failed to enumerate rootfs")
 		}
 		if err := imageStore.Delete(ctx, image.Name, delOpts...); err != nil {
 			logrus.WithError(err).Warnf("failed to delete image %s", image.Name)
+			continue
 		}
 		fmt.Fprintf(cmd.OutOrStdout(), "Untagged: %s\n", image.Name)
 		for _, digest := range digests {
 			fmt.Fprintf(cmd.OutOrStdout(), "Deleted: %s\n", digest)
 		}
 	}
-	return nil
+
+	sizeAfter, err := contentStoreSize(ctx, contentStore)
+	if err != nil {
+		return 0, err
+	}
+	if sizeAfter > sizeBefore {
+		// the content store was modified concurrently
+		return 0, nil
+	}
+	return sizeBefore - sizeAfter, nil
+}
+
+// selectPrunableImages splits the images into the ones that match the filters (can be nil)
+// and are not used by any container except the ones in pruned, and the others.
+// Unless all is set, only the dangling images are prunable.
+func selectPrunableImages(ctx context.Context, client *containerd.Client, filters *pruneFilters, pruned map[string]struct{}, all bool) (prunable, kept []images.Image, err error) {
+	imageList, err := client.ImageService().List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	containerList, err := client.ContainerService().List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	usedImages := make(map[string]struct{})
+	for _, container := range containerList {
+		if _, ok := pruned[container.ID]; ok {
+			continue
+		}
+		usedImages[container.Image] = struct{}{}
+	}
+	for _, image := range imageList {
+		if _, ok := usedImages[image.Name]; ok {
+			kept = append(kept, image)
+			continue
+		}
+		if !all && !isDanglingImage(image.Name) {
+			kept = append(kept, image)
+			continue
+		}
+		if filters != nil {
+			var imageLabels map[string]string
+			if len(filters.labels) > 0 {
+				// like Docker, the labels in the image config are used
+				if spec, err := containerd.NewImage(client, image).Spec(ctx); err == nil {
+					imageLabels = spec.Config.Labels
+				}
+			}
+			if !filters.match(image.CreatedAt, imageLabels) {
+				kept = append(kept, image)
+				continue
+			}
+		}
+		prunable = append(prunable, image)
+	}
+	return prunable, kept, nil
+}
+
+// isDanglingImage returns true for the images without a repository name,
+// which are shown as "<none>:<none>" in `nerdctl images`.
+func isDanglingImage(name string) bool {
+	_, err := refdocker.ParseDockerRef(name)
+	return err != nil
+}
+
+// imagesReclaimableSize returns the size of the content referenced by the prunable images,
+// excluding the content shared with the kept images.
+func imagesReclaimableSize(ctx context.Context, cs content.Store, prunable, kept []images.Image) (int64, error) {
+	prunableSizes, err := imageContentSizes(ctx, cs, prunable)
+	if err != nil {
+		return 0, err
+	}
+	keptSizes, err := imageContentSizes(ctx, cs, kept)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for dgst, s := range prunableSizes {
+		if _, ok := keptSizes[dgst]; !ok {
+			size += s
+		}
+	}
+	return size, nil
+}
+
+// imageContentSizes returns the sizes of the blobs referenced by the images.
+// The blobs missing in the content store, e.g., the layers of the other platforms, are ignored.
+func imageContentSizes(ctx context.Context, cs content.Store, imgs []images.Image) (map[digest.Digest]int64, error) {
+	sizes := make(map[digest.Digest]int64)
+	handler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if _, ok := sizes[desc.Digest]; ok {
+			return nil, images.ErrSkipDesc
+		}
+		info, err := cs.Info(ctx, desc.Digest)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return nil, images.ErrSkipDesc
+			}
+			return nil, err
+		}
+		sizes[desc.Digest] = info.Size
+		return images.Children(ctx, cs, desc)
+	})
+	for _, img := range imgs {
+		if err := images.Walk(ctx, handler, img.Target); err != nil {
+			return nil, err
+		}
+	}
+	return sizes, nil
+}
+
+func contentStoreSize(ctx context.Context, cs content.Store) (int64, error) {
+	var size int64
+	err := cs.Walk(ctx, func(info content.Info) error {
+		size += info.Size
+		return nil
+	})
+	return size, err
 }
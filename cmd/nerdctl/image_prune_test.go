@@ -19,6 +19,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/testutil"
@@ -50,6 +51,110 @@ func TestImagePrune(t *testing.T) {
 	base.Cmd("images").AssertOutContains(imageName)
 
 	base.Cmd("rm", "-f", tID).AssertOK()
+	// the tagged image is not dangling
+	base.Cmd("image", "prune", "--force").AssertNoOut(imageName)
+	base.Cmd("images").AssertOutContains(imageName)
 	base.Cmd("image", "prune", "--force", "--all").AssertOutContains(imageName)
 	base.Cmd("images").AssertNoOut(imageName)
 }
+
+func TestIsDanglingImage(t *testing.T) {
+	assert.Equal(t, false, isDanglingImage("docker.io/library/alpine:latest"))
+	assert.Equal(t, false, isDanglingImage("docker.io/library/alpine@sha256:82d1e9d7ed48a7523bdebc18cf6290bdb97b82302a8a9c27d4fe885949ea94d1"))
+	assert.Equal(t, true, isDanglingImage("@sha256:82d1e9d7ed48a7523bdebc18cf6290bdb97b82302a8a9c27d4fe885949ea94d1"))
+	assert.Equal(t, true, isDanglingImage(""))
+}
+
+func TestImagePruneBuildCache(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	testutil.RequiresBuild(t)
+
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+RUN echo nerdctl-test-image-prune-build-cache > /hello`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-t", imageName, buildCtx).AssertOK()
+
+	// without --build-cache, the build cache is left as is
+	base.Cmd("image", "prune", "--force", "--all").AssertOutContains(imageName)
+	base.Cmd("build", "-t", imageName, buildCtx).AssertOK()
+
+	res := base.Cmd("image", "prune", "--force", "--all", "--build-cache").Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), imageName), res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), "Total reclaimed space"), res.Combined())
+	reclaimed, err := parseBuildCachePruneTotal(strings.NewReader(res.Stdout()))
+	assert.NilError(t, err)
+	assert.Assert(t, reclaimed > 0, res.Combined())
+
+	// the build cache is already empty
+	res = base.Cmd("builder", "prune").Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	reclaimed, err = parseBuildCachePruneTotal(strings.NewReader(res.Stdout()))
+	assert.NilError(t, err)
+	assert.Equal(t, int64(0), reclaimed)
+}
+
+func TestImagePruneWithFilter(t *testing.T) {
+	testutil.RequiresBuild(t)
+
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	id := testutil.Identifier(t)
+	matched, unmatched := id+"-matched", id+"-unmatched"
+	defer base.Cmd("rmi", matched, unmatched).Run()
+
+	for _, name := range []string{matched, unmatched} {
+		dockerfile := fmt.Sprintf(`FROM %s
+LABEL project=%s`, testutil.CommonImage, name)
+		buildCtx, err := createBuildContext(dockerfile)
+		assert.NilError(t, err)
+		defer os.RemoveAll(buildCtx)
+		base.Cmd("build", "-t", name, buildCtx).AssertOK()
+	}
+
+	base.Cmd("image", "prune", "--force", "--all", "--filter", "project="+matched).AssertFail()
+	// the images were created just now
+	base.Cmd("image", "prune", "--force", "--all", "--filter", "label=project="+matched, "--filter", "until=1h").AssertNoOut(matched)
+	base.Cmd("images").AssertOutContains(matched)
+
+	res := base.Cmd("image", "prune", "--force", "--all", "--filter", "label=project="+matched).Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), matched), res.Combined())
+	assert.Assert(t, !strings.Contains(res.Stdout(), unmatched), res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), "Total reclaimed space"), res.Combined())
+	base.Cmd("images").AssertNoOut(matched)
+	base.Cmd("images").AssertOutContains(unmatched)
+}
+
+func TestImagePruneDryRun(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks `image prune --dry-run`
+	testutil.RequiresBuild(t)
+
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+LABEL project=%s`, testutil.CommonImage, imageName)
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	base.Cmd("build", "-t", imageName, buildCtx).AssertOK()
+
+	res := base.Cmd("image", "prune", "--all", "--dry-run", "--filter", "label=project="+imageName).Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), "Would Untag: "), res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), imageName), res.Combined())
+	assert.Assert(t, strings.Contains(res.Stdout(), "Total reclaimable space"), res.Combined())
+	base.Cmd("images").AssertOutContains(imageName)
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/nerdctl/pkg/imgutil"
+	"github.com/containerd/nerdctl/pkg/referenceutil"
+	"github.com/spf13/cobra"
+)
+
+const imageSignHelp = `Sign an image that has already been pushed to a registry.
+
+e.g., 'nerdctl image sign --cosign-key cosign.key example.com/foo:latest'
+
+The signature is stored in the registry, next to the image.
+Requires cosign executable in $PATH.
+`
+
+func newImageSignCommand() *cobra.Command {
+	var imageSignCommand = &cobra.Command{
+		Use:               "sign [flags] IMAGE",
+		Short:             "Sign an image in a registry",
+		Long:              imageSignHelp,
+		Args:              cobra.ExactArgs(1),
+		RunE:              imageSignAction,
+		ValidArgsFunction: imageSignShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	imageSignCommand.Flags().String("sign", "cosign", "Signer to use (cosign)")
+	imageSignCommand.RegisterFlagCompletionFunc("sign", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"cosign"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	imageSignCommand.Flags().String("cosign-key", "", "Path to the private key file, KMS URI or Kubernetes Secret for --sign=cosign")
+
+	return imageSignCommand
+}
+
+func imageSignAction(cmd *cobra.Command, args []string) error {
+	rawRef := args[0]
+	hostsDirs, err := cmd.Flags().GetStringSlice("hosts-dir")
+	if err != nil {
+		return err
+	}
+	insecure, err := cmd.Flags().GetBool("insecure-registry")
+	if err != nil {
+		return err
+	}
+	signer, err := cmd.Flags().GetString("sign")
+	if err != nil {
+		return err
+	}
+	if _, _, err := referenceutil.ParseIPFSRefWithScheme(rawRef); err == nil {
+		return errors.New("signing IPFS images is not supported as of now")
+	}
+
+	switch signer {
+	case "cosign":
+		keyRef, err := cmd.Flags().GetString("cosign-key")
+		if err != nil {
+			return err
+		}
+		// TODO: support the keyless mode (as `nerdctl push --sign=cosign` does)
+		if keyRef == "" {
+			return errors.New("--cosign-key must be specified")
+		}
+		// Sign the digest rather than the tag, so that the tag cannot be moved between resolving and signing
+		ref := rawRef
+		if !strings.Contains(ref, "@") {
+			digest, err := imgutil.ResolveDigest(cmd.Context(), rawRef, insecure, hostsDirs)
+			if err != nil {
+				return fmt.Errorf("failed to resolve the digest of %q (Hint: the image must be pushed before signing): %w", rawRef, err)
+			}
+			ref += "@" + digest
+		}
+		if err := signCosign(ref, keyRef, insecure); err != nil {
+			return fmt.Errorf("failed to sign %q with cosign: %w", rawRef, err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), ref)
+	default:
+		return fmt.Errorf("no signers found: %s", signer)
+	}
+	return nil
+}
+
+func imageSignShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// show image names
+	return shellCompleteImageNames(cmd)
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/nerdctl/pkg/idutil/imagewalker"
+	"github.com/containerd/nerdctl/pkg/imgutil"
+
+	"github.com/spf13/cobra"
+)
+
+const imageUnpackHelp = `Unpack an already pulled image into a snapshotter.
+
+e.g., 'nerdctl --snapshotter=stargz image unpack example.com/foo:latest'
+
+Layers that are already present in the snapshotter are skipped.
+Use 'nerdctl pull --unpack=false' to fetch an image without unpacking it.
+`
+
+func newImageUnpackCommand() *cobra.Command {
+	var imageUnpackCommand = &cobra.Command{
+		Use:               "unpack [flags] IMAGE",
+		Short:             "Unpack an image into a snapshotter",
+		Long:              imageUnpackHelp,
+		Args:              cobra.ExactArgs(1),
+		RunE:              imageUnpackAction,
+		ValidArgsFunction: imageUnpackShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	// #region platform flags
+	imageUnpackCommand.Flags().String("platform", "", "Unpack content for a specific platform") // not a slice, and there is no --all-platforms
+	imageUnpackCommand.RegisterFlagCompletionFunc("platform", shellCompletePlatforms)
+	// #endregion
+
+	return imageUnpackCommand
+}
+
+func imageUnpackAction(cmd *cobra.Command, args []string) error {
+	snapshotter, err := cmd.Flags().GetString("snapshotter")
+	if err != nil {
+		return err
+	}
+	platform, err := cmd.Flags().GetString("platform")
+	if err != nil {
+		return err
+	}
+	platMC := platforms.DefaultStrict()
+	if platform != "" {
+		p, err := platforms.Parse(platform)
+		if err != nil {
+			return err
+		}
+		platMC = platforms.OnlyStrict(p)
+	}
+
+	client, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	var srcImage *containerd.Image
+	walker := &imagewalker.ImageWalker{
+		Client: client,
+		OnFound: func(ctx context.Context, found imagewalker.Found) error {
+			if srcImage == nil {
+				img := containerd.NewImageWithPlatform(client, found.Image, platMC)
+				srcImage = &img
+			}
+			return nil
+		},
+	}
+	n, err := walker.Walk(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if n == 0 || srcImage == nil {
+		return fmt.Errorf("no such image: %s", args[0])
+	}
+	return imgutil.Unpack(ctx, client, cmd.OutOrStdout(), *srcImage, snapshotter, nil)
+}
+
+func imageUnpackShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// show image names
+	return shellCompleteImageNames(cmd)
+}
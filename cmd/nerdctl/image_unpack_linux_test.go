@@ -0,0 +1,35 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+)
+
+func TestImageUnpackOverlayfs(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+	testutil.RequireContainerdPlugin(base, "io.containerd.snapshotter.v1", "overlayfs", nil)
+	base.Cmd("rmi", testutil.CommonImage).Run()
+	base.Cmd("pull", "--unpack=false", testutil.CommonImage).AssertOK()
+	base.Cmd("--snapshotter=overlayfs", "image", "unpack", testutil.CommonImage).AssertOutContains("unpacked")
+	// the second unpack is a no-op
+	base.Cmd("--snapshotter=overlayfs", "image", "unpack", testutil.CommonImage).AssertOutContains("already exists")
+	base.Cmd("--snapshotter=overlayfs", "run", "--rm", "--pull=never", testutil.CommonImage, "echo", "foo").AssertOutContains("foo")
+}
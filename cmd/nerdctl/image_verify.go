@@ -0,0 +1,95 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/containerd/nerdctl/pkg/referenceutil"
+	"github.com/spf13/cobra"
+)
+
+const imageVerifyHelp = `Verify the signature of an image in a registry.
+
+e.g., 'nerdctl image verify --cosign-key cosign.pub example.com/foo:latest'
+
+The image is not pulled. Without --cosign-key, cosign is run in the keyless mode.
+Requires cosign executable in $PATH.
+`
+
+func newImageVerifyCommand() *cobra.Command {
+	var imageVerifyCommand = &cobra.Command{
+		Use:               "verify [flags] IMAGE",
+		Short:             "Verify the signature of an image",
+		Long:              imageVerifyHelp,
+		Args:              cobra.ExactArgs(1),
+		RunE:              imageVerifyAction,
+		ValidArgsFunction: imageVerifyShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	imageVerifyCommand.Flags().String("verify", "cosign", "Verifier to use (cosign)")
+	imageVerifyCommand.RegisterFlagCompletionFunc("verify", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"cosign"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	imageVerifyCommand.Flags().String("cosign-key", "", "Path to the public key file, KMS, URI or Kubernetes Secret for --verify=cosign")
+
+	return imageVerifyCommand
+}
+
+func imageVerifyAction(cmd *cobra.Command, args []string) error {
+	rawRef := args[0]
+	hostsDirs, err := cmd.Flags().GetStringSlice("hosts-dir")
+	if err != nil {
+		return err
+	}
+	insecure, err := cmd.Flags().GetBool("insecure-registry")
+	if err != nil {
+		return err
+	}
+	verifier, err := cmd.Flags().GetString("verify")
+	if err != nil {
+		return err
+	}
+	if _, _, err := referenceutil.ParseIPFSRefWithScheme(rawRef); err == nil {
+		return errors.New("verifying IPFS images is not supported as of now")
+	}
+
+	var ref string
+	switch verifier {
+	case "cosign":
+		keyRef, err := cmd.Flags().GetString("cosign-key")
+		if err != nil {
+			return err
+		}
+		ref, err = verifyCosign(cmd.Context(), rawRef, keyRef, insecure, hostsDirs)
+		if err != nil {
+			return fmt.Errorf("failed to verify %q with cosign: %w", rawRef, err)
+		}
+	default:
+		return fmt.Errorf("no verifier found: %s", verifier)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), ref)
+	return nil
+}
+
+func imageVerifyShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// show image names
+	return shellCompleteImageNames(cmd)
+}
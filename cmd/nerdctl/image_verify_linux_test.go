@@ -0,0 +1,63 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"github.com/containerd/nerdctl/pkg/testutil/testregistry"
+	"gotest.tools/v3/assert"
+)
+
+func TestImageVerifyCommandWithCosign(t *testing.T) {
+	if _, err := exec.LookPath("cosign"); err != nil {
+		t.Skip()
+	}
+	testutil.DockerIncompatible(t)
+	testutil.RequiresBuild(t)
+	t.Setenv("COSIGN_PASSWORD", "1")
+	keyPair := newCosignKeyPair(t, "cosign-key-pair")
+	defer keyPair.cleanup()
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	tID := testutil.Identifier(t)
+	reg := testregistry.NewPlainHTTP(base, 5000)
+	defer reg.Cleanup()
+	unsignedImageRef := fmt.Sprintf("127.0.0.1:%d/%s:unsigned", reg.ListenPort, tID)
+	signedImageRef := fmt.Sprintf("127.0.0.1:%d/%s:signed", reg.ListenPort, tID)
+
+	dockerfile := fmt.Sprintf(`FROM %s
+CMD ["echo", "nerdctl-build-test-string"]
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-t", unsignedImageRef, buildCtx).AssertOK()
+	base.Cmd("push", unsignedImageRef).AssertOK()
+	base.Cmd("image", "verify", "--cosign-key="+keyPair.publicKey, unsignedImageRef).AssertFail()
+	base.Cmd("pull", unsignedImageRef, "--verify=cosign", "--cosign-key="+keyPair.publicKey).AssertFail()
+
+	base.Cmd("tag", unsignedImageRef, signedImageRef).AssertOK()
+	base.Cmd("push", signedImageRef, "--sign=cosign", "--cosign-key="+keyPair.privateKey).AssertOK()
+	base.Cmd("image", "verify", "--cosign-key="+keyPair.publicKey, signedImageRef).AssertOutContains(signedImageRef + "@sha256:")
+}
@@ -36,8 +36,10 @@ import (
 	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/snapshots"
 	"github.com/containerd/nerdctl/pkg/formatter"
+	"github.com/containerd/nerdctl/pkg/idutil/imagewalker"
 	"github.com/containerd/nerdctl/pkg/imgutil"
 	"github.com/containerd/nerdctl/pkg/referenceutil"
+	timetypes "github.com/docker/docker/api/types/time"
 	"github.com/opencontainers/image-spec/identity"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
@@ -71,7 +73,7 @@ Properties:
 
 	imagesCommand.Flags().BoolP("quiet", "q", false, "Only show numeric IDs")
 	imagesCommand.Flags().Bool("no-trunc", false, "Don't truncate output")
-	// Alias "-f" is reserved for "--filter"
+	imagesCommand.Flags().StringArrayP("filter", "f", nil, "Filter output based on conditions provided (e.g., \"before=<image>\", \"since=<image>\", \"until=<timestamp>\")")
 	imagesCommand.Flags().String("format", "", "Format the output using the given Go template, e.g, '{{json .}}', 'wide'")
 	imagesCommand.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"json", "table", "wide"}, cobra.ShellCompDirectiveNoFileComp
@@ -79,6 +81,11 @@ Properties:
 	imagesCommand.Flags().Bool("digests", false, "Show digests (compatible with Docker, unlike ID)")
 	imagesCommand.Flags().Bool("names", false, "Show image names")
 	imagesCommand.Flags().BoolP("all", "a", true, "(unimplemented yet, always true)")
+	imagesCommand.Flags().String("sort", "", "Sort the images by the key in ascending order (\"created\"|\"size\"|\"name\")")
+	imagesCommand.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return imageSortKeys, cobra.ShellCompDirectiveNoFileComp
+	})
+	imagesCommand.Flags().Bool("reverse", false, "Reverse the order of --sort")
 
 	return imagesCommand
 }
@@ -104,28 +111,161 @@ func imagesAction(cmd *cobra.Command, args []string) error {
 		imageStore = client.ImageService()
 	)
 
-	// To-do: Add support for --filter.
 	imageList, err := imageStore.List(ctx, filters...)
 	if err != nil {
 		return err
 	}
 
+	filterFlags, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	if len(filterFlags) > 0 {
+		imgFilters, err := parseImageFilters(ctx, client, filterFlags)
+		if err != nil {
+			return err
+		}
+		imageList = filterImages(imageList, imgFilters)
+	}
+
 	return printImages(ctx, cmd, client, imageList)
 }
 
+// imageFilters is the parsed value of `nerdctl images --filter`.
+// The time-relative filters are compared with the creation time of the image records, as shown in "CREATED".
+type imageFilters struct {
+	before []time.Time // "before=<image>"
+	since  []time.Time // "since=<image>"
+	until  []time.Time // "until=<timestamp>"
+}
+
+func parseImageFilters(ctx context.Context, client *containerd.Client, filters []string) (*imageFilters, error) {
+	f := &imageFilters{}
+	for _, filter := range filters {
+		kv := strings.SplitN(filter, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("bad format of filter %q (expected name=value)", filter)
+		}
+		k, v := kv[0], kv[1]
+		switch k {
+		case "before", "since":
+			createdAt, err := imageCreatedAt(ctx, client, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+			}
+			if k == "before" {
+				f.before = append(f.before, createdAt)
+			} else {
+				f.since = append(f.since, createdAt)
+			}
+		case "until":
+			until, err := parseTimestampFilter(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+			}
+			f.until = append(f.until, until)
+		default:
+			return nil, fmt.Errorf("invalid filter %q", filter)
+		}
+	}
+	return f, nil
+}
+
+// parseTimestampFilter parses the value of "until=" filter, which is either a timestamp or a duration relative to now.
+func parseTimestampFilter(v string) (time.Time, error) {
+	// using GetTimestamp from moby to keep time format consistency
+	ts, err := timetypes.GetTimestamp(v, time.Now())
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, nsec, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// imageCreatedAt returns the creation time of the image specified by the name or the (short) ID.
+func imageCreatedAt(ctx context.Context, client *containerd.Client, req string) (time.Time, error) {
+	var createdAt time.Time
+	walker := &imagewalker.ImageWalker{
+		Client: client,
+		OnFound: func(ctx context.Context, found imagewalker.Found) error {
+			if found.MatchCount > 1 {
+				return fmt.Errorf("multiple IDs found with provided prefix: %s", found.Req)
+			}
+			createdAt = found.Image.CreatedAt
+			return nil
+		},
+	}
+	n, err := walker.Walk(ctx, req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if n == 0 {
+		return time.Time{}, fmt.Errorf("no such image: %s", req)
+	}
+	return createdAt, nil
+}
+
+func (f *imageFilters) match(img images.Image) bool {
+	for _, t := range f.before {
+		if !img.CreatedAt.Before(t) {
+			return false
+		}
+	}
+	for _, t := range f.since {
+		if !img.CreatedAt.After(t) {
+			return false
+		}
+	}
+	for _, t := range f.until {
+		if img.CreatedAt.After(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func filterImages(imageList []images.Image, f *imageFilters) []images.Image {
+	var filtered []images.Image
+	for _, img := range imageList {
+		if f.match(img) {
+			filtered = append(filtered, img)
+		}
+	}
+	return filtered
+}
+
 type imagePrintable struct {
 	// TODO: "Containers"
-	CreatedAt    string
-	CreatedSince string
-	Digest       string // "<none>" or image target digest (i.e., index digest or manifest digest)
-	ID           string // image target digest (not config digest, unlike Docker), or its short form
-	Repository   string
-	Tag          string // "<none>" or tag
-	Name         string // image name
-	Size         string // the size of the unpacked snapshots.
-	BlobSize     string // the size of the blobs in the content store (nerdctl extension)
+	CreatedAt     string
+	CreatedSince  string
+	Digest        string // "<none>" or image target digest (i.e., index digest or manifest digest)
+	ID            string // image target digest (not config digest, unlike Docker), or its short form
+	Repository    string
+	Tag           string // "<none>" or tag
+	Name          string // image name
+	Size          string // the size of the unpacked snapshots.
+	SizeBytes     int64  // Size in bytes (nerdctl extension)
+	BlobSize      string // the size of the blobs in the content store (nerdctl extension)
+	BlobSizeBytes int64  // BlobSize in bytes (nerdctl extension)
 	// TODO: "SharedSize", "UniqueSize", "VirtualSize"
 	Platform string // nerdctl extension
+
+	createdAt time.Time // for sorting
+}
+
+var imageSortKeys = []string{"created", "size", "name"}
+
+// sortImagePrintables sorts the images by the key ("created", "size", or "name") in ascending order,
+// or in descending order when reverse is true.
+func sortImagePrintables(ps []imagePrintable, key string, reverse bool) error {
+	return sortByKey(ps, key, reverse, map[string]func(i, j int) bool{
+		"created": func(i, j int) bool { return ps[i].createdAt.Before(ps[j].createdAt) },
+		"size":    func(i, j int) bool { return ps[i].SizeBytes < ps[j].SizeBytes },
+		"name":    func(i, j int) bool { return ps[i].Name < ps[j].Name },
+	})
 }
 
 func printImages(ctx context.Context, cmd *cobra.Command, client *containerd.Client, imageList []images.Image) error {
@@ -145,6 +285,18 @@ func printImages(ctx context.Context, cmd *cobra.Command, client *containerd.Cli
 	if err != nil {
 		return err
 	}
+	sortKey, err := cmd.Flags().GetString("sort")
+	if err != nil {
+		return err
+	}
+	reverse, err := cmd.Flags().GetBool("reverse")
+	if err != nil {
+		return err
+	}
+	// validate the sort key before printing the header
+	if err := sortImagePrintables(nil, sortKey, reverse); err != nil {
+		return err
+	}
 	var w io.Writer
 	w = os.Stdout
 	format, err := cmd.Flags().GetString("format")
@@ -201,8 +353,15 @@ func printImages(ctx context.Context, cmd *cobra.Command, client *containerd.Cli
 		snapshotter:  client.SnapshotService(snapshotter),
 	}
 
+	var printables []imagePrintable
 	for _, img := range imageList {
-		if err := printer.printImage(ctx, img); err != nil {
+		printables = append(printables, printer.imagePrintables(ctx, img)...)
+	}
+	if err := sortImagePrintables(printables, sortKey, reverse); err != nil {
+		return err
+	}
+	for _, p := range printables {
+		if err := printer.printImage(p); err != nil {
 			logrus.Warn(err)
 		}
 	}
@@ -221,21 +380,24 @@ type imagePrinter struct {
 	snapshotter                            snapshots.Snapshotter
 }
 
-func (x *imagePrinter) printImage(ctx context.Context, img images.Image) error {
+// imagePrintables returns the printables of the available platforms of the image.
+func (x *imagePrinter) imagePrintables(ctx context.Context, img images.Image) []imagePrintable {
 	ociPlatforms, err := images.Platforms(ctx, x.contentStore, img.Target)
 	if err != nil {
 		logrus.WithError(err).Warnf("failed to get the platform list of image %q", img.Name)
-		return x.printImageSinglePlatform(ctx, img, platforms.DefaultSpec())
+		ociPlatforms = []v1.Platform{platforms.DefaultSpec()}
 	}
+	var ps []imagePrintable
 	for _, ociPlatform := range ociPlatforms {
-		if err := x.printImageSinglePlatform(ctx, img, ociPlatform); err != nil {
-			logrus.WithError(err).Warnf("failed to get platform %q of image %q", platforms.Format(ociPlatform), img.Name)
+		if p := x.imagePrintableSinglePlatform(ctx, img, ociPlatform); p != nil {
+			ps = append(ps, *p)
 		}
 	}
-	return nil
+	return ps
 }
 
-func (x *imagePrinter) printImageSinglePlatform(ctx context.Context, img images.Image, ociPlatform v1.Platform) error {
+// imagePrintableSinglePlatform returns nil when the platform is not available.
+func (x *imagePrinter) imagePrintableSinglePlatform(ctx context.Context, img images.Image, ociPlatform v1.Platform) *imagePrintable {
 	platMC := platforms.OnlyStrict(ociPlatform)
 	if avail, _, _, _, availErr := images.Check(ctx, x.contentStore, img.Target, platMC); !avail {
 		logrus.WithError(availErr).Debugf("skipping printing image %q for platform %q", img.Name, platforms.Format(ociPlatform))
@@ -267,16 +429,19 @@ func (x *imagePrinter) printImageSinglePlatform(ctx context.Context, img images.
 	}
 
 	p := imagePrintable{
-		CreatedAt:    img.CreatedAt.Round(time.Second).Local().String(), // format like "2021-08-07 02:19:45 +0900 JST"
-		CreatedSince: formatter.TimeSinceInHuman(img.CreatedAt),
-		Digest:       img.Target.Digest.String(),
-		ID:           img.Target.Digest.String(),
-		Repository:   repository,
-		Tag:          tag,
-		Name:         img.Name,
-		Size:         progress.Bytes(size).String(),
-		BlobSize:     progress.Bytes(blobSize).String(),
-		Platform:     platforms.Format(ociPlatform),
+		CreatedAt:     img.CreatedAt.Round(time.Second).Local().String(), // format like "2021-08-07 02:19:45 +0900 JST"
+		CreatedSince:  formatter.TimeSinceInHuman(img.CreatedAt),
+		Digest:        img.Target.Digest.String(),
+		ID:            img.Target.Digest.String(),
+		Repository:    repository,
+		Tag:           tag,
+		Name:          img.Name,
+		Size:          progress.Bytes(size).String(),
+		SizeBytes:     size,
+		BlobSize:      progress.Bytes(blobSize).String(),
+		BlobSizeBytes: blobSize,
+		Platform:      platforms.Format(ociPlatform),
+		createdAt:     img.CreatedAt,
 	}
 	if p.Repository == "" {
 		p.Repository = "<none>"
@@ -288,12 +453,16 @@ func (x *imagePrinter) printImageSinglePlatform(ctx context.Context, img images.
 		// p.Digest does not need to be truncated
 		p.ID = strings.Split(p.ID, ":")[1][:12]
 	}
+	return &p
+}
+
+func (x *imagePrinter) printImage(p imagePrintable) error {
 	if x.tmpl != nil {
 		var b bytes.Buffer
 		if err := x.tmpl.Execute(&b, p); err != nil {
 			return err
 		}
-		if _, err = fmt.Fprintf(x.w, b.String()+"\n"); err != nil {
+		if _, err := fmt.Fprintf(x.w, b.String()+"\n"); err != nil {
 			return err
 		}
 	} else if x.quiet {
@@ -18,8 +18,10 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/containerd/nerdctl/pkg/tabutil"
 	"github.com/containerd/nerdctl/pkg/testutil"
@@ -74,3 +76,141 @@ func TestImages(t *testing.T) {
 		return nil
 	})
 }
+
+func TestImagesFilter(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker compares the creation time of the image config, which is shared by the tags
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	tags := []string{tID + "-a:latest", tID + "-b:latest", tID + "-c:latest"}
+
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	for _, tag := range tags {
+		defer base.Cmd("rmi", tag).Run()
+		base.Cmd("tag", testutil.CommonImage, tag).AssertOK()
+		// ensure the creation times to be distinct
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	names := func(args ...string) []string {
+		out := base.Cmd(append([]string{"images", "--format", "{{.Name}}"}, args...)...).Out()
+		var res []string
+		for _, name := range strings.Split(strings.TrimSpace(out), "\n") {
+			if strings.Contains(name, tID) {
+				res = append(res, strings.TrimPrefix(name, "docker.io/library/"))
+			}
+		}
+		return res
+	}
+	assert.DeepEqual(t, tags[1:], names("--filter", "since="+tags[0]))
+	assert.DeepEqual(t, tags[:2], names("--filter", "before="+tags[2]))
+	assert.DeepEqual(t, tags[1:2], names("--filter", "since="+tags[0], "--filter", "before="+tags[2]))
+	assert.Assert(t, len(names("--filter", "until=1h")) == 0)
+	base.Cmd("images", "--filter", "since=nonexistent-"+tID).AssertFail()
+	base.Cmd("images", "--filter", "unknown=foo").AssertFail()
+}
+
+func TestImagesSort(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	small, medium, large := tID+"-c-small:latest", tID+"-a-medium:latest", tID+"-b-large:latest"
+
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	defer base.Cmd("rmi", small).Run()
+	base.Cmd("tag", testutil.CommonImage, small).AssertOK()
+	for _, img := range []struct {
+		name string
+		kb   int
+	}{{medium, 1024}, {large, 4096}} {
+		defer base.Cmd("rmi", img.name).Run()
+		defer base.Cmd("rm", "-f", tID).Run()
+		base.Cmd("run", "--name", tID, testutil.CommonImage,
+			"dd", "if=/dev/urandom", "of=/blob", "bs=1024", fmt.Sprintf("count=%d", img.kb)).AssertOK()
+		base.Cmd("commit", tID, img.name).AssertOK()
+		base.Cmd("rm", "-f", tID).AssertOK()
+	}
+
+	names := func(args ...string) []string {
+		out := base.Cmd(append([]string{"images", "--format", "{{.Name}}"}, args...)...).Out()
+		var res []string
+		for _, name := range strings.Split(strings.TrimSpace(out), "\n") {
+			if strings.Contains(name, tID) {
+				res = append(res, strings.TrimPrefix(name, "docker.io/library/"))
+			}
+		}
+		return res
+	}
+	assert.DeepEqual(t, []string{large, medium, small}, names("--sort", "size", "--reverse"))
+	assert.DeepEqual(t, []string{small, medium, large}, names("--sort", "size"))
+	assert.DeepEqual(t, []string{medium, large, small}, names("--sort", "name"))
+	assert.DeepEqual(t, []string{small, medium, large}, names("--sort", "created"))
+	assert.DeepEqual(t, []string{large, medium, small}, names("--sort", "created", "--reverse"))
+	base.Cmd("images", "--sort", "unknown").AssertFail()
+}
+
+func TestImagesNoTrunc(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	header := "REPOSITORY\tTAG\tIMAGE ID\tCREATED\tPLATFORM\tSIZE\tBLOB SIZE"
+	if base.Target == testutil.Docker {
+		header = "REPOSITORY\tTAG\tIMAGE ID\tCREATED\tSIZE"
+	}
+	const (
+		truncatedIDLen = 12
+		fullIDLen      = len("sha256:") + 64
+	)
+
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	for _, tc := range []struct {
+		args  []string
+		idLen int
+	}{
+		{nil, truncatedIDLen},
+		{[]string{"--no-trunc"}, fullIDLen},
+	} {
+		quietArgs := append([]string{"images", "--quiet"}, tc.args...)
+		for _, id := range base.Cmd(append(quietArgs, testutil.CommonImage)...).OutLines() {
+			if id != "" {
+				assert.Equal(t, tc.idLen, len(id), id)
+			}
+		}
+
+		tableArgs := append([]string{"images"}, tc.args...)
+		base.Cmd(append(tableArgs, testutil.CommonImage)...).AssertOutWithFunc(func(out string) error {
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			if len(lines) < 2 {
+				return fmt.Errorf("expected at least 2 lines, got %d", len(lines))
+			}
+			tab := tabutil.NewReader(header)
+			if err := tab.ParseHeader(lines[0]); err != nil {
+				return fmt.Errorf("failed to parse header: %v", err)
+			}
+			id, _ := tab.ReadRow(lines[1], "IMAGE ID")
+			if len(id) != tc.idLen {
+				return fmt.Errorf("expected the length of the ID %q to be %d", id, tc.idLen)
+			}
+			return nil
+		})
+	}
+}
+
+func TestImagesFormatSizeBytes(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks .SizeBytes
+	t.Parallel()
+	base := testutil.NewBase(t)
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	base.Cmd("images", "--format", "{{.SizeBytes}},{{.BlobSizeBytes}}", testutil.CommonImage).AssertOutWithFunc(func(out string) error {
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			for _, f := range strings.Split(line, ",") {
+				n, err := strconv.ParseInt(f, 10, 64)
+				if err != nil {
+					return fmt.Errorf("expected an integer, got %q in %q: %w", f, line, err)
+				}
+				if n <= 0 {
+					return fmt.Errorf("expected a positive size, got %d in %q", n, line)
+				}
+			}
+		}
+		return nil
+	})
+}
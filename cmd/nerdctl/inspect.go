@@ -45,6 +45,8 @@ func newInspectCommand() *cobra.Command {
 var validInspectType = map[string]bool{
 	"container": true,
 	"image":     true,
+	"network":   true,
+	"volume":    true,
 }
 
 func addInspectFlags(cmd *cobra.Command) {
@@ -52,9 +54,10 @@ func addInspectFlags(cmd *cobra.Command) {
 	cmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"json"}, cobra.ShellCompDirectiveNoFileComp
 	})
+	cmd.Flags().Bool("pretty", false, "Indent the output of the `json` template function (same as `prettyjson`)")
 	cmd.Flags().String("type", "", "Return JSON for specified type")
 	cmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return []string{"image", "container", ""}, cobra.ShellCompDirectiveNoFileComp
+		return []string{"image", "container", "network", "volume", ""}, cobra.ShellCompDirectiveNoFileComp
 	})
 	cmd.Flags().String("mode", "dockercompat", `Inspect mode, "dockercompat" for Docker-compatible output, "native" for containerd-native output`)
 	cmd.RegisterFlagCompletionFunc("mode", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -72,6 +75,14 @@ func inspectAction(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%q is not a valid value for --type", inspectType)
 	}
 
+	// networks and volumes are not stored in containerd, so they are inspected only with --type
+	switch inspectType {
+	case "network":
+		return networkInspectAction(cmd, args)
+	case "volume":
+		return volumeInspectAction(cmd, args)
+	}
+
 	client, ctx, cancel, err := newClient(cmd)
 	if err != nil {
 		return err
@@ -136,6 +147,13 @@ func inspectAction(cmd *cobra.Command, args []string) error {
 }
 
 func inspectShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	inspectType, _ := cmd.Flags().GetString("type")
+	switch inspectType {
+	case "network":
+		return networkInspectShellComplete(cmd, args, toComplete)
+	case "volume":
+		return volumeInspectShellComplete(cmd, args, toComplete)
+	}
 	// show container names
 	containers, _ := shellCompleteContainerNames(cmd, nil)
 	// show image names
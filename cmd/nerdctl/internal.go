@@ -17,6 +17,8 @@
 package main
 
 import (
+	"os/exec"
+
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +33,24 @@ func newInternalCommand() *cobra.Command {
 
 	internalCommand.AddCommand(
 		newInternalOCIHookCommandCommand(),
+		newInternalHealthcheckCommand(),
+		newInternalRemoveOnExitCommand(),
 	)
 
 	return internalCommand
 }
+
+// startInternalProcess starts nerdctl with the global flags and the args in the background.
+// The process survives the exit of the current process.
+func startInternalProcess(cmd *cobra.Command, args ...string) error {
+	selfExe, f := globalFlags(cmd)
+	c := exec.Command(selfExe, append(f, args...)...)
+	// the process must not hold the stdio of the current process, as the caller (e.g., the OCI runtime
+	// that runs the hook) may wait for the stdio to be closed
+	c.Stdin, c.Stdout, c.Stderr = nil, nil, nil
+	c.SysProcAttr = detachedSysProcAttr()
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Process.Release()
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/containerd/nerdctl/pkg/healthcheck"
+	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
+	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newInternalHealthcheckCommand() *cobra.Command {
+	var internalHealthcheckCommand = &cobra.Command{
+		Use:           "healthcheck",
+		Short:         "Healthcheck monitor",
+		RunE:          internalHealthcheckAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	return internalHealthcheckCommand
+}
+
+// internalHealthcheckAction runs either of:
+//   - `hook`: the Poststart hook that reads the state from stdin, and spawns the monitor in the background
+//   - `monitor CONTAINER PID`: the monitor that runs the healthcheck until the task with the PID exits
+func internalHealthcheckAction(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return errors.New("event type needs to be passed")
+	}
+	switch args[0] {
+	case "hook":
+		return internalHealthcheckHook(cmd, os.Stdin)
+	case "monitor":
+		if len(args) != 3 {
+			return errors.New("container and pid need to be passed")
+		}
+		pid, err := strconv.ParseUint(args[2], 10, 32)
+		if err != nil {
+			return err
+		}
+		return internalHealthcheckMonitor(cmd, args[1], uint32(pid))
+	default:
+		return fmt.Errorf("unexpected event %q", args[0])
+	}
+}
+
+func internalHealthcheckHook(cmd *cobra.Command, stdin io.Reader) error {
+	var state specs.State
+	if err := json.NewDecoder(stdin).Decode(&state); err != nil {
+		return err
+	}
+	if err := startInternalProcess(cmd, "internal", "healthcheck", "monitor", state.ID, strconv.Itoa(state.Pid)); err != nil {
+		return fmt.Errorf("failed to start the healthcheck monitor: %w", err)
+	}
+	return nil
+}
+
+func internalHealthcheckMonitor(cmd *cobra.Command, id string, pid uint32) error {
+	client, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	container, err := client.LoadContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	l, err := container.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	stateDir := l[labels.StateDir]
+	if stateDir == "" {
+		return errors.New("state dir must be set")
+	}
+	logFile, err := os.Create(filepath.Join(stateDir, "healthcheck.log"))
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	logrus.SetOutput(logFile)
+
+	var hc dockercompat.HealthConfig
+	if err := json.Unmarshal([]byte(l[labels.Healthcheck]), &hc); err != nil {
+		return fmt.Errorf("failed to parse label %q: %w", labels.Healthcheck, err)
+	}
+	if err := healthcheck.Monitor(ctx, container, pid, stateDir, hc); err != nil {
+		logrus.WithError(err).Error("healthcheck monitor exited with an error")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newInternalRemoveOnExitCommand() *cobra.Command {
+	var internalRemoveOnExitCommand = &cobra.Command{
+		Use:           "remove-on-exit CONTAINER",
+		Short:         "Remove the container when the task exits (for `nerdctl run -d --rm`)",
+		Args:          cobra.ExactArgs(1),
+		RunE:          internalRemoveOnExitAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	internalRemoveOnExitCommand.Flags().String("cidfile", "", "The container ID file to remove with the container")
+	return internalRemoveOnExitCommand
+}
+
+// startRemoveOnExitMonitor spawns `nerdctl internal remove-on-exit` in the background.
+// The spawned process survives the exit of the current process.
+func startRemoveOnExitMonitor(cmd *cobra.Command, id, cidfile string) error {
+	monitorArgs := []string{"internal", "remove-on-exit"}
+	if cidfile != "" {
+		abs, err := filepath.Abs(cidfile)
+		if err != nil {
+			return err
+		}
+		monitorArgs = append(monitorArgs, "--cidfile="+abs)
+	}
+	return startInternalProcess(cmd, append(monitorArgs, id)...)
+}
+
+func internalRemoveOnExitAction(cmd *cobra.Command, args []string) error {
+	cidfile, err := cmd.Flags().GetString("cidfile")
+	if err != nil {
+		return err
+	}
+	client, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	container, err := client.LoadContainer(ctx, args[0])
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			// already removed, e.g., with `nerdctl rm -f`
+			return nil
+		}
+		return err
+	}
+	l, err := container.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	// the log is removed with the state dir, unless the container could not be removed
+	if stateDir := l[labels.StateDir]; stateDir != "" {
+		logFile, err := os.Create(filepath.Join(stateDir, "remove-on-exit.log"))
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+		logrus.SetOutput(logFile)
+	}
+
+	task, err := container.Task(ctx, nil)
+	if err == nil {
+		statusC, err := task.Wait(ctx)
+		if err != nil {
+			logrus.WithError(err).Errorf("failed to wait for container %s", container.ID())
+			return err
+		}
+		<-statusC
+	} else if !errdefs.IsNotFound(err) {
+		logrus.WithError(err).Errorf("failed to load the task of container %s", container.ID())
+		return err
+	}
+
+	if err := removeContainer(cmd, ctx, container, l[labels.Namespace], true, true); err != nil {
+		logrus.WithError(err).Errorf("failed to remove container %s", container.ID())
+		return err
+	}
+	if cidfile != "" {
+		if err := os.Remove(cidfile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove the container ID file %q: %w", cidfile, err)
+		}
+	}
+	return nil
+}
@@ -24,7 +24,6 @@ import (
 	"syscall"
 
 	"github.com/containerd/containerd"
-	"github.com/containerd/containerd/cio"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
 
@@ -93,21 +92,14 @@ func killAction(cmd *cobra.Command, args []string) error {
 }
 
 func killContainer(ctx context.Context, container containerd.Container, signal syscall.Signal) error {
-	task, err := container.Task(ctx, cio.Load)
+	task, status, err := ensureRunning(ctx, container)
 	if err != nil {
-		return err
-	}
-
-	status, err := task.Status(ctx)
-	if err != nil {
-		return err
+		return fmt.Errorf("cannot kill container: %w", err)
 	}
 
 	paused := false
 
 	switch status.Status {
-	case containerd.Created, containerd.Stopped:
-		return fmt.Errorf("cannot kill container: %s: Container %s is not running", container.ID(), container.ID())
 	case containerd.Paused, containerd.Pausing:
 		paused = true
 	default:
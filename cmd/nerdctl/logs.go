@@ -27,6 +27,8 @@ import (
 	"time"
 
 	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/runtime/restart"
 	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
 	"github.com/containerd/nerdctl/pkg/labels"
 	"github.com/containerd/nerdctl/pkg/logging"
@@ -48,6 +50,7 @@ func newLogsCommand() *cobra.Command {
 	}
 	logsCommand.Flags().BoolP("follow", "f", false, "Follow log output")
 	logsCommand.Flags().BoolP("timestamps", "t", false, "Show timestamps")
+	logsCommand.Flags().Bool("details", false, "Show extra details provided to logs")
 	logsCommand.Flags().StringP("tail", "n", "all", "Number of lines to show from the end of the logs")
 	logsCommand.Flags().String("since", "", "Show logs since timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)")
 	logsCommand.Flags().String("until", "", "Show logs before a timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)")
@@ -93,6 +96,10 @@ func logsAction(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return err
 			}
+			details, err := cmd.Flags().GetBool("details")
+			if err != nil {
+				return err
+			}
 			since, err := cmd.Flags().GetString("since")
 			if err != nil {
 				return err
@@ -121,59 +128,24 @@ func logsAction(cmd *cobra.Command, args []string) error {
 				if _, err := os.Stat(logJSONFilePath); err != nil {
 					return fmt.Errorf("failed to open %q, container is not created with `nerdctl run -d`?: %w", logJSONFilePath, err)
 				}
-				task, err := found.Container.Task(ctx, nil)
-				if err != nil {
-					return err
-				}
-				status, err := task.Status(ctx)
-				if err != nil {
-					return err
-				}
-				var reader io.Reader
-				var execCmd *exec.Cmd
-				//chan for non-follow tail to check the logsEOF
-				logsEOFChan := make(chan struct{})
-				if follow && status.Status == containerd.Running {
-					waitCh, err := task.Wait(ctx)
-					if err != nil {
-						return err
-					}
-					reader, execCmd, err = newTailReader(ctx, task, logJSONFilePath, follow, tail)
+				// The task is only needed for following the logs of a running (or restarting) container.
+				// The task may be missing (e.g., after restarting containerd), but the log file is still readable.
+				var stopCh <-chan struct{}
+				if follow {
+					stopCh, err = followContainer(ctx, found.Container)
 					if err != nil {
 						return err
 					}
-
-					go func() {
-						<-waitCh
-						execCmd.Process.Kill()
-					}()
-				} else {
-					if tail != "" {
-						reader, execCmd, err = newTailReader(ctx, task, logJSONFilePath, false, tail)
-						if err != nil {
-							return err
-						}
-						go func() {
-							<-logsEOFChan
-							execCmd.Process.Kill()
-						}()
-
-					} else {
-						f, err := os.Open(logJSONFilePath)
-						if err != nil {
-							return err
-						}
-						defer f.Close()
-						reader = f
-						go func() {
-							<-logsEOFChan
-						}()
-					}
 				}
-				return jsonfile.Decode(os.Stdout, os.Stderr, reader, timestamps, since, until, logsEOFChan)
+				return readJSONFileLogs(ctx, os.Stdout, os.Stderr, logJSONFilePath, stopCh, tail, timestamps, details, since, until)
 			case "journald":
+				// SYSLOG_IDENTIFIER is matched for the logs written without CONTAINER_ID_FULL by older versions of nerdctl.
+				// SYSLOG_IDENTIFIER cannot be used alone, as it is customizable with `--log-opt tag=...`.
 				shortID := found.Container.ID()[:12]
-				var journalctlArgs = []string{fmt.Sprintf("SYSLOG_IDENTIFIER=%s", shortID), "--output=cat"}
+				var journalctlArgs = []string{
+					fmt.Sprintf("CONTAINER_ID_FULL=%s", found.Container.ID()), "+", fmt.Sprintf("SYSLOG_IDENTIFIER=%s", shortID),
+					"--output=cat",
+				}
 				if follow {
 					journalctlArgs = append(journalctlArgs, "-f")
 				}
@@ -192,6 +164,9 @@ func logsAction(cmd *cobra.Command, args []string) error {
 				if timestamps {
 					logrus.Warnf("unsupported timestamps option for jounrald driver")
 				}
+				if details {
+					logrus.Warnf("unsupported details option for journald driver")
+				}
 				if until != "" {
 					// using GetTimestamp from moby to keep time format consistency
 					ts, err := timetypes.GetTimestamp(until, time.Now())
@@ -224,7 +199,152 @@ func logsShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]
 	return shellCompleteContainerNames(cmd, nil)
 }
 
-func newTailReader(ctx context.Context, task containerd.Task, filePath string, follow bool, tail string) (io.Reader, *exec.Cmd, error) {
+// followContainer returns a channel that is closed when the container exits and is not going to be
+// restarted by its restart policy, so that the logs can be followed across restarts.
+// The restarted task appends to the same log file.
+// followContainer returns a nil channel when the container is neither running nor restarting.
+func followContainer(ctx context.Context, container containerd.Container) (<-chan struct{}, error) {
+	exitCh, alive, err := waitContainerTask(ctx, container)
+	if err != nil || !alive {
+		return nil, err
+	}
+	stopCh := make(chan struct{})
+	go func() {
+		defer close(stopCh)
+		for {
+			if exitCh != nil {
+				select {
+				case <-exitCh:
+				case <-ctx.Done():
+					return
+				}
+			} else {
+				// the restart monitor of containerd has not started the new task yet
+				select {
+				case <-time.After(time.Second):
+				case <-ctx.Done():
+					return
+				}
+			}
+			exitCh, alive, err = waitContainerTask(ctx, container)
+			if err != nil {
+				// e.g., the container was removed
+				logrus.WithError(err).Debugf("stopped following container %s", container.ID())
+				return
+			}
+			if !alive {
+				return
+			}
+		}
+	}()
+	return stopCh, nil
+}
+
+// waitContainerTask returns the exit channel of the task when the task is running or paused.
+// Otherwise, it returns a nil channel, and alive indicates whether the task is going to be
+// (re)started by the restart policy of the container.
+func waitContainerTask(ctx context.Context, container containerd.Container) (exitCh <-chan containerd.ExitStatus, alive bool, err error) {
+	l, err := container.Labels(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	// the restart monitor keeps the task running when the desired status is "running"
+	desiredRunning := l[restart.StatusLabel] == string(containerd.Running)
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			// the restart monitor deletes the stopped task before creating the new one
+			return nil, desiredRunning, nil
+		}
+		return nil, false, err
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	switch status.Status {
+	case containerd.Running, containerd.Paused, containerd.Pausing:
+		exitCh, err = task.Wait(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		return exitCh, true, nil
+	case containerd.Stopped:
+		return nil, desiredRunning && restart.Reconcile(status, l), nil
+	default:
+		// "created" (the new task is not started yet), "unknown"
+		return nil, desiredRunning, nil
+	}
+}
+
+// readJSONFileLogs reads the logs purely from the json-file, independently of the task and its IO.
+// When stopCh is non-nil, readJSONFileLogs follows the file until stopCh is closed.
+func readJSONFileLogs(ctx context.Context, stdout, stderr io.Writer, logJSONFilePath string, stopCh <-chan struct{}, tail string, timestamps, details bool, since, until string) error {
+	var (
+		reader  io.Reader
+		execCmd *exec.Cmd
+		err     error
+	)
+	now := time.Now()
+	sinceTime, err := jsonfile.ParseTimestamp(since, now)
+	if err != nil {
+		return fmt.Errorf("invalid value for \"since\": %w", err)
+	}
+	untilTime, err := jsonfile.ParseTimestamp(until, now)
+	if err != nil {
+		return fmt.Errorf("invalid value for \"until\": %w", err)
+	}
+	if !untilTime.IsZero() && !untilTime.After(now) {
+		// like Docker, no need to follow the logs that end in the past
+		stopCh = nil
+	}
+	// chan for checking the logsEOF.
+	// Buffered, as nobody receives it when following the logs.
+	logsEOFChan := make(chan struct{}, 1)
+	if stopCh != nil {
+		reader, execCmd, err = newTailReader(ctx, logJSONFilePath, true, tail)
+		if err != nil {
+			return err
+		}
+		go func() {
+			var untilCh <-chan time.Time
+			if !untilTime.IsZero() {
+				timer := time.NewTimer(time.Until(untilTime))
+				defer timer.Stop()
+				untilCh = timer.C
+			}
+			select {
+			case <-stopCh:
+			case <-untilCh:
+			}
+			execCmd.Process.Kill()
+		}()
+	} else if tail != "" {
+		reader, execCmd, err = newTailReader(ctx, logJSONFilePath, false, tail)
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-logsEOFChan
+			execCmd.Process.Kill()
+		}()
+	} else {
+		f, err := os.Open(logJSONFilePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+	err = jsonfile.Decode(stdout, stderr, reader, timestamps, details, sinceTime, untilTime, logsEOFChan)
+	if execCmd != nil {
+		// Decode may return before reaching EOF, because of --until
+		execCmd.Process.Kill()
+	}
+	return err
+}
+
+func newTailReader(ctx context.Context, filePath string, follow bool, tail string) (io.Reader, *exec.Cmd, error) {
 
 	var args []string
 
@@ -0,0 +1,48 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+)
+
+// TestLogsWithoutTask tests that the logs are read from the persisted log file,
+// even when the task (and its IO) is gone, e.g., after restarting containerd.
+func TestLogsWithoutTask(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+	containerName := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", containerName).Run()
+	bindSource := filepath.Join(t.TempDir(), "bind")
+	assert.NilError(t, os.Mkdir(bindSource, 0755))
+	base.Cmd("run", "-d", "--name", containerName, "-v", bindSource+":/mnt", testutil.CommonImage, "sh", "-c", "echo foo; echo bar").AssertOK()
+	base.Cmd("wait", containerName).AssertOutExactly("0\n")
+
+	// `nerdctl start` deletes the old task, and fails to create the new task due to the missing bind source,
+	// so the container is left without any task
+	assert.NilError(t, os.Remove(bindSource))
+	base.Cmd("start", containerName).AssertFail()
+
+	base.Cmd("logs", containerName).AssertOutExactly("foo\nbar\n")
+	base.Cmd("logs", "-f", containerName).AssertOutExactly("foo\nbar\n")
+	base.Cmd("logs", "-n", "1", containerName).AssertOutExactly("bar\n")
+}
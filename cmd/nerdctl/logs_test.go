@@ -17,13 +17,22 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/containerd/nerdctl/pkg/logging/jsonfile"
 	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
 )
 
 func TestLogs(t *testing.T) {
@@ -66,6 +75,92 @@ bar`
 	base.Cmd("rm", "-f", containerName).AssertOK()
 }
 
+func TestLogsWithDetails(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("`nerdctl logs` is not implemented on Windows (why?)")
+	}
+	base := testutil.NewBase(t)
+	containerName := testutil.Identifier(t)
+
+	defer base.Cmd("rm", "-f", containerName).Run()
+	base.Cmd("run", "-d", "--name", containerName,
+		"--label", "foo=bar", "--log-opt", "labels=foo",
+		"-e", "BAZ=qux quux", "--log-opt", "env=BAZ",
+		testutil.CommonImage, "echo", "hello").AssertOK()
+
+	const expected = "BAZ=qux%20quux,foo=bar hello\n"
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if out := base.Cmd("logs", "--details", containerName).Out(); out != expected {
+			return poll.Continue("waiting for the logs of %q, got %q", containerName, out)
+		}
+		return poll.Success()
+	}, poll.WithDelay(100*time.Millisecond), poll.WithTimeout(30*time.Second))
+	base.Cmd("logs", containerName).AssertOutExactly("hello\n")
+}
+
+// TestReadJSONFileLogs reads the logs written by another process, without any task or IO of the container.
+func TestReadJSONFileLogs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("`nerdctl logs` is not implemented on Windows (why?)")
+	}
+	logJSONFilePath := filepath.Join(t.TempDir(), "foo-json.log")
+	f, err := os.Create(logJSONFilePath)
+	assert.NilError(t, err)
+	enc := json.NewEncoder(f)
+	// sub-second intervals, to test the precision of --since and --until
+	t0 := time.Now().UTC().Add(-time.Hour)
+	t1, t2 := t0.Add(500*time.Millisecond), t0.Add(time.Second)
+	for _, e := range []jsonfile.Entry{
+		{Log: "foo\n", Stream: "stdout", Time: t0},
+		{Log: "err\n", Stream: "stderr", Time: t1, Attrs: map[string]string{"foo": "a b", "bar": "c"}},
+		{Log: "bar\n", Stream: "stdout", Time: t2},
+	} {
+		assert.NilError(t, enc.Encode(e))
+	}
+	assert.NilError(t, f.Close())
+
+	testCases := []struct {
+		tail           string
+		details        bool
+		since          string
+		until          string
+		expectedStdout string
+		expectedStderr string
+	}{
+		{tail: "", expectedStdout: "foo\nbar\n", expectedStderr: "err\n"},
+		{tail: "all", expectedStdout: "foo\nbar\n", expectedStderr: "err\n"},
+		{tail: "1", expectedStdout: "bar\n", expectedStderr: ""},
+		{since: t1.Format(time.RFC3339Nano), expectedStdout: "bar\n", expectedStderr: "err\n"},
+		{until: t1.Format(time.RFC3339Nano), expectedStdout: "foo\n", expectedStderr: "err\n"},
+		{since: t1.Format(time.RFC3339Nano), until: t1.Format(time.RFC3339Nano), expectedStdout: "", expectedStderr: "err\n"},
+		{since: "2h", until: "30m", expectedStdout: "foo\nbar\n", expectedStderr: "err\n"},
+		{since: "30m", expectedStdout: "", expectedStderr: ""},
+		// --tail is applied before --since and --until, like Docker
+		{tail: "2", since: t0.Format(time.RFC3339Nano), expectedStdout: "bar\n", expectedStderr: "err\n"},
+		{tail: "1", until: t1.Format(time.RFC3339Nano), expectedStdout: "", expectedStderr: ""},
+		{details: true, expectedStdout: " foo\n bar\n", expectedStderr: "bar=c,foo=a%20b err\n"},
+	}
+	for _, tc := range testCases {
+		var stdout, stderr bytes.Buffer
+		err := readJSONFileLogs(context.Background(), &stdout, &stderr, logJSONFilePath, nil, tc.tail, false, tc.details, tc.since, tc.until)
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expectedStdout, stdout.String(), "tail=%q, details=%v, since=%q, until=%q", tc.tail, tc.details, tc.since, tc.until)
+		assert.Equal(t, tc.expectedStderr, stderr.String(), "tail=%q, details=%v, since=%q, until=%q", tc.tail, tc.details, tc.since, tc.until)
+	}
+
+	// following the logs returns immediately when --until is in the past
+	var stdout, stderr bytes.Buffer
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	err = readJSONFileLogs(context.Background(), &stdout, &stderr, logJSONFilePath, stopCh, "", false, false, "", t0.Format(time.RFC3339Nano))
+	assert.NilError(t, err)
+	assert.Equal(t, "foo\n", stdout.String())
+
+	err = readJSONFileLogs(context.Background(), &stdout, &stderr, logJSONFilePath, nil, "", false, false, "foo", "")
+	assert.ErrorContains(t, err, "since")
+}
+
 func TestLogsOfJournaldDriver(t *testing.T) {
 	t.Parallel()
 	if runtime.GOOS == "windows" {
@@ -96,6 +191,37 @@ func TestLogsOfJournaldDriver(t *testing.T) {
 	base.Cmd("rm", "-f", containerName).AssertOK()
 }
 
+func TestLogsOfJournaldDriverWithTag(t *testing.T) {
+	t.Parallel()
+	testutil.DockerIncompatible(t) // Docker does not support reading the journald logs of the containers with a custom tag
+	if runtime.GOOS == "windows" {
+		t.Skip("`nerdctl logs` is not implemented on Windows (why?)")
+	}
+	base := testutil.NewBase(t)
+	containerName := testutil.Identifier(t)
+
+	defer base.Cmd("rm", "-f", containerName).Run()
+	base.Cmd("run", "-d", "--network", "none", "--log-driver", "journald", "--log-opt", "tag={{.Name}}-{{.ID}}",
+		"--name", containerName, testutil.CommonImage, "echo", "foo").AssertOK()
+
+	time.Sleep(3 * time.Second)
+	// the logs are looked up by CONTAINER_ID_FULL, not by the customized SYSLOG_IDENTIFIER
+	base.Cmd("logs", containerName).AssertOutExactly("foo\n")
+
+	journalctl, err := exec.LookPath("journalctl")
+	assert.NilError(t, err)
+	id := base.InspectContainer(containerName).ID
+	out, err := exec.Command(journalctl, "CONTAINER_NAME="+containerName, "--output=json").Output()
+	assert.NilError(t, err)
+	var entry map[string]interface{}
+	assert.NilError(t, json.Unmarshal([]byte(strings.Split(strings.TrimSpace(string(out)), "\n")[0]), &entry))
+	assert.Equal(t, "foo", entry["MESSAGE"])
+	assert.Equal(t, id, entry["CONTAINER_ID_FULL"])
+	assert.Equal(t, id[:12], entry["CONTAINER_ID"])
+	assert.Equal(t, containerName+"-"+id[:12], entry["CONTAINER_TAG"])
+	assert.Equal(t, containerName+"-"+id[:12], entry["SYSLOG_IDENTIFIER"])
+}
+
 func TestLogsWithFailingContainer(t *testing.T) {
 	t.Parallel()
 	if runtime.GOOS == "windows" {
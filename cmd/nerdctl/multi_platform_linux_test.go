@@ -20,12 +20,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/testutil"
 	"github.com/containerd/nerdctl/pkg/testutil/nettestutil"
 	"github.com/containerd/nerdctl/pkg/testutil/testregistry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gotest.tools/v3/assert"
 )
 
@@ -106,6 +109,58 @@ CMD echo dummy
 	base.Cmd("push", "--platform=amd64,arm64,linux/arm/v7", imageName).AssertOK()
 }
 
+func TestMultiPlatformBuildWithIIDFile(t *testing.T) {
+	testutil.DockerIncompatible(t) // non-buildx version of `docker build` lacks multi-platform
+	testutil.RequiresBuild(t)
+	testutil.RequireExecPlatform(t, "linux/amd64", "linux/arm64")
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+RUN echo dummy
+	`, testutil.AlpineImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	iidFile := filepath.Join(t.TempDir(), "id.txt")
+
+	base.Cmd("build", "-t", imageName, "--platform=amd64,arm64", "--iidfile", iidFile, buildCtx).AssertOK()
+	b, err := os.ReadFile(iidFile)
+	assert.NilError(t, err)
+	dgst, err := digest.Parse(string(b))
+	assert.NilError(t, err)
+
+	// the iidfile holds the digest of the index, i.e., the target of the image
+	base.Cmd("image", "inspect", "--mode=native", "--format={{.Image.Target.MediaType}} {{.Image.Target.Digest}}", imageName).
+		AssertOutContains(ocispec.MediaTypeImageIndex + " " + dgst.String())
+}
+
+func TestMultiPlatformBuildErrors(t *testing.T) {
+	testutil.DockerIncompatible(t) // non-buildx version of `docker build` lacks multi-platform
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	// no RUN instruction, so that the build does not need to execute the binaries of the platforms
+	dockerfile := fmt.Sprintf(`FROM %s
+CMD ["echo", "dummy"]
+	`, testutil.AlpineImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-t", imageName, "--platform=amd64,mips64le", buildCtx).AssertErrContains("does not provide the requested platform")
+	base.Cmd("build", "-t", imageName, "--platform=foo/bar/baz/qux", buildCtx).AssertErrContains("invalid platform")
+	tarPath := filepath.Join(t.TempDir(), "image.tar")
+	base.Cmd("build", "--platform=amd64,arm64", "-o", "type=docker,dest="+tarPath, buildCtx).AssertErrContains("type=docker does not support multi-platform builds")
+}
+
 func TestMultiPlatformPullPushAllPlatforms(t *testing.T) {
 	testutil.DockerIncompatible(t)
 	base := testutil.NewBase(t)
@@ -174,3 +229,37 @@ RUN uname -m > /usr/share/nginx/html/index.html
 		assert.Assert(t, strings.Contains(string(respBody), expectedIndexHTML))
 	}
 }
+
+// TestMultiPlatformRunPullMissing tests that `--pull=missing` pulls the image when the local image
+// exists only for a different platform.
+func TestMultiPlatformRunPullMissing(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	testutil.RequiresBuild(t)
+	testutil.RequireExecPlatform(t, "linux/amd64", "linux/arm64")
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	tID := testutil.Identifier(t)
+	reg := testregistry.NewPlainHTTP(base, 5000)
+	defer reg.Cleanup()
+
+	imageName := fmt.Sprintf("localhost:%d/%s:latest", reg.ListenPort, tID)
+	defer base.Cmd("rmi", imageName).Run()
+
+	base.Cmd("pull", "--all-platforms", testutil.AlpineImage).AssertOK()
+	base.Cmd("tag", testutil.AlpineImage, imageName).AssertOK()
+	base.Cmd("push", "--all-platforms", imageName).AssertOK()
+	base.Cmd("rmi", imageName).AssertOK()
+
+	// replace the local image with an amd64-only image that is not an index
+	dockerfile := fmt.Sprintf(`FROM %s
+RUN echo dummy
+	`, testutil.AlpineImage)
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	base.Cmd("build", "-t", imageName, "--platform=amd64", buildCtx).AssertOK()
+
+	base.Cmd("run", "--rm", "--platform=amd64", "--pull=never", imageName, "uname", "-m").AssertOutExactly("x86_64\n")
+	base.Cmd("run", "--rm", "--platform=arm64", "--pull=never", imageName, "uname", "-m").AssertFail()
+	base.Cmd("run", "--rm", "--platform=arm64", "--pull=missing", imageName, "uname", "-m").AssertOutExactly("aarch64\n")
+}
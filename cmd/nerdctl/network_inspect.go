@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/containerd/nerdctl/pkg/dnsutil/hostsstore"
 	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
 	"github.com/containerd/nerdctl/pkg/inspecttypes/native"
 	"github.com/containerd/nerdctl/pkg/netutil"
@@ -64,6 +65,11 @@ func networkInspectAction(cmd *cobra.Command, args []string) error {
 
 	netMap := e.NetworkMap()
 
+	containers, err := networkContainers(cmd)
+	if err != nil {
+		return err
+	}
+
 	result := make([]interface{}, len(args))
 	for i, name := range args {
 		if name == "host" || name == "none" {
@@ -79,6 +85,7 @@ func networkInspectAction(cmd *cobra.Command, args []string) error {
 			NerdctlID:     l.NerdctlID,
 			NerdctlLabels: l.NerdctlLabels,
 			File:          l.File,
+			Containers:    containers[name],
 		}
 		mode, err := cmd.Flags().GetString("mode")
 		if err != nil {
@@ -101,6 +108,40 @@ func networkInspectAction(cmd *cobra.Command, args []string) error {
 	return formatSlice(cmd, result)
 }
 
+// networkContainers returns the running containers grouped by the names of the networks,
+// from the CNI results recorded in the hostsstore.
+func networkContainers(cmd *cobra.Command) (map[string]map[string]native.NetworkContainer, error) {
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return nil, err
+	}
+	dataStore, err := getDataStore(cmd)
+	if err != nil {
+		return nil, err
+	}
+	hs, err := hostsstore.NewStore(dataStore)
+	if err != nil {
+		return nil, err
+	}
+	metas, err := hs.List(ns)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]map[string]native.NetworkContainer)
+	for _, meta := range metas {
+		for nwName, cniRes := range meta.Networks {
+			if res[nwName] == nil {
+				res[nwName] = make(map[string]native.NetworkContainer)
+			}
+			res[nwName][meta.ID] = native.NetworkContainer{
+				Name:      meta.Name,
+				CNIResult: cniRes,
+			}
+		}
+	}
+	return res, nil
+}
+
 func networkInspectShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	// show network names, including "bridge"
 	exclude := []string{"host", "none"}
@@ -66,3 +66,46 @@ func TestNetworkInspect(t *testing.T) {
 	}
 	assert.DeepEqual(base.T, expectedIPAM, got.IPAM)
 }
+
+func TestNetworkInspectContainers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hostsstore is not used on Windows")
+	}
+
+	testNetwork := testutil.Identifier(t)
+	testContainer := testutil.Identifier(t) + "-container"
+	const testSubnet = "10.24.25.0/24"
+
+	base := testutil.NewBase(t)
+	defer base.Cmd("network", "rm", testNetwork).Run()
+	defer base.Cmd("rm", "-f", testContainer).Run()
+
+	base.Cmd("network", "create", "--subnet", testSubnet, testNetwork).AssertOK()
+	got := base.InspectNetwork(testNetwork)
+	assert.Equal(base.T, 0, len(got.Containers))
+
+	base.Cmd("run", "-d", "--name", testContainer, "--net", testNetwork, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	c := base.InspectContainer(testContainer)
+	got = base.InspectNetwork(testNetwork)
+	assert.Equal(base.T, 1, len(got.Containers))
+	ep, ok := got.Containers[c.ID]
+	assert.Assert(base.T, ok, "container %s not found in %v", c.ID, got.Containers)
+	assert.Equal(base.T, testContainer, ep.Name)
+	assert.Equal(base.T, c.NetworkSettings.IPAddress+"/24", ep.IPv4Address)
+	assert.Equal(base.T, c.NetworkSettings.MacAddress, ep.MacAddress)
+
+	base.Cmd("network", "inspect", "--format", "{{range $id, $c := .Containers}}{{$c.Name}}{{end}}", testNetwork).AssertOutExactly(testContainer + "\n")
+}
+
+func TestInspectTypeNetwork(t *testing.T) {
+	testNetwork := testutil.Identifier(t)
+
+	base := testutil.NewBase(t)
+	defer base.Cmd("network", "rm", testNetwork).Run()
+
+	base.Cmd("network", "create", "--label", "tag=testNetwork", testNetwork).AssertOK()
+	base.Cmd("inspect", "--type", "network", "--format", "{{.Name}} {{.Labels.tag}}", testNetwork).AssertOutExactly(testNetwork + " testNetwork\n")
+	base.Cmd("inspect", "--type", "network", testNetwork+"-nonexistent").AssertFail()
+	// networks are not looked up without --type
+	base.Cmd("inspect", testNetwork).AssertFail()
+}
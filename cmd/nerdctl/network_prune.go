@@ -0,0 +1,169 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/nerdctl/pkg/lockutil"
+	"github.com/containerd/nerdctl/pkg/netutil"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newNetworkPruneCommand() *cobra.Command {
+	networkPruneCommand := &cobra.Command{
+		Use:           "prune [flags]",
+		Short:         "Remove all unused networks",
+		Args:          cobra.NoArgs,
+		RunE:          networkPruneAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	networkPruneCommand.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
+	networkPruneCommand.Flags().StringArray("filter", nil, "Provide filter values (e.g. \"until=24h\", \"label=foo=bar\")")
+	addPruneDryRunFlag(networkPruneCommand)
+	return networkPruneCommand
+}
+
+func networkPruneAction(cmd *cobra.Command, _ []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	filterFlags, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	filters, err := parsePruneFilters(filterFlags)
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	if !force && !dryRun {
+		var confirm string
+		msg := "This will remove all custom networks not used by at least one container."
+		if len(filterFlags) > 0 {
+			msg += "\n  Networks to be pruned will be filtered with:"
+			for _, f := range filterFlags {
+				msg += "\n  - " + f
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "WARNING! %s\nAre you sure you want to continue? [y/N] ", msg)
+		fmt.Fscanf(cmd.InOrStdin(), "%s", &confirm)
+
+		if strings.ToLower(confirm) != "y" {
+			return nil
+		}
+	}
+
+	client, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return pruneNetworks(ctx, cmd, client, filters, nil, dryRun)
+}
+
+// pruneNetworks removes the networks that match the filters (can be nil) and are not used by any container,
+// including the stopped ones.
+// The default network and the networks not managed by nerdctl are never removed.
+// The containers in pruned are not counted as the users, so that the dry-run of `system prune` reports
+// the networks that would be freed by removing the containers.
+// With dryRun, pruneNetworks only prints the networks that would be removed.
+func pruneNetworks(ctx context.Context, cmd *cobra.Command, client *containerd.Client, filters *pruneFilters, pruned map[string]struct{}, dryRun bool) error {
+	cniPath, err := cmd.Flags().GetString("cni-path")
+	if err != nil {
+		return err
+	}
+	cniNetconfpath, err := cmd.Flags().GetString("cni-netconfpath")
+	if err != nil {
+		return err
+	}
+	e, err := netutil.NewCNIEnv(cniPath, cniNetconfpath)
+	if err != nil {
+		return err
+	}
+	users, err := networkUsers(ctx, client, true)
+	if err != nil {
+		return err
+	}
+	var removed []string
+	var errs []error
+	fn := func() error {
+		for _, l := range e.Networks {
+			if l.Name == netutil.DefaultNetworkName || l.NerdctlID == nil || l.File == "" {
+				continue
+			}
+			if len(withoutPrunedContainerIDs(users[l.Name], pruned)) > 0 {
+				continue
+			}
+			if filters != nil {
+				// the modification time of the config file is used as the creation time
+				st, err := os.Stat(l.File)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				var netLabels map[string]string
+				if l.NerdctlLabels != nil {
+					netLabels = *l.NerdctlLabels
+				}
+				if !filters.match(st.ModTime(), netLabels) {
+					continue
+				}
+			}
+			if dryRun {
+				removed = append(removed, l.Name)
+				continue
+			}
+			if err := os.RemoveAll(l.File); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if l.Plugins[0].Network.Type == "bridge" {
+				removeBridgeNetworkInterface(netutil.GetBridgeName(*l.NerdctlID))
+			}
+			removed = append(removed, l.Name)
+		}
+		return nil
+	}
+	if err := lockutil.WithDirLock(cniNetconfpath, fn); err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s Networks:\n", pruneVerb(dryRun))
+		for _, name := range removed {
+			fmt.Fprintln(cmd.OutOrStdout(), name)
+		}
+	}
+	if len(errs) > 0 {
+		for _, err := range errs {
+			logrus.Error(err)
+		}
+		return fmt.Errorf("failed to remove %d networks", len(errs))
+	}
+	return nil
+}
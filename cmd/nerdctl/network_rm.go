@@ -17,12 +17,17 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/nerdctl/pkg/labels"
 	"github.com/containerd/nerdctl/pkg/lockutil"
 	"github.com/containerd/nerdctl/pkg/netutil"
-
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +36,7 @@ func newNetworkRmCommand() *cobra.Command {
 		Use:               "rm [flags] NETWORK [NETWORK, ...]",
 		Aliases:           []string{"remove"},
 		Short:             "Remove one or more networks",
-		Long:              "NOTE: network in use is deleted without caution",
+		Long:              "NOTE: network in use by a running container cannot be removed",
 		Args:              cobra.MinimumNArgs(1),
 		RunE:              networkRmAction,
 		ValidArgsFunction: networkRmShellComplete,
@@ -54,10 +59,20 @@ func networkRmAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	client, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	users, err := networkUsers(ctx, client, false)
+	if err != nil {
+		return err
+	}
+	// Like Docker, the removal continues on errors, and the command fails if any of the networks could not be removed.
+	var errs []error
 	fn := func() error {
 		netMap := e.NetworkMap()
-
-		for _, name := range args {
+		removeNetwork := func(name string) error {
 			if name == "host" || name == "none" {
 				return fmt.Errorf("pseudo network %q cannot be removed", name)
 			}
@@ -71,6 +86,9 @@ func networkRmAction(cmd *cobra.Command, args []string) error {
 			if l.File == "" {
 				return fmt.Errorf("%s is a pre-defined network and cannot be removed", name)
 			}
+			if len(users[name]) > 0 {
+				return fmt.Errorf("network %q is in use by running container(s) %v and cannot be removed", name, users[name])
+			}
 			if err := os.RemoveAll(l.File); err != nil {
 				return err
 			}
@@ -79,11 +97,67 @@ func networkRmAction(cmd *cobra.Command, args []string) error {
 				netIf := netutil.GetBridgeName(*l.NerdctlID)
 				removeBridgeNetworkInterface(netIf)
 			}
+			return nil
+		}
+		for _, name := range args {
+			if err := removeNetwork(name); err != nil {
+				logrus.Error(err)
+				errs = append(errs, err)
+				continue
+			}
 			fmt.Fprintln(cmd.OutOrStdout(), name)
 		}
 		return nil
 	}
-	return lockutil.WithDirLock(cniNetconfpath, fn)
+	if err := lockutil.WithDirLock(cniNetconfpath, fn); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove %d of %d networks", len(errs), len(args))
+	}
+	return nil
+}
+
+// networkUsers returns the IDs of the running (or paused) containers grouped by the names of
+// the networks they are attached to, as recorded in the nerdctl/networks label.
+// When includeStopped is true, the stopped containers are included too.
+func networkUsers(ctx context.Context, client *containerd.Client, includeStopped bool) (map[string][]string, error) {
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make(map[string][]string)
+	for _, c := range containers {
+		l, err := c.Labels(ctx)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		networksJSON, ok := l[labels.Networks]
+		if !ok {
+			continue
+		}
+		var networks []string
+		if err := json.Unmarshal([]byte(networksJSON), &networks); err != nil {
+			return nil, err
+		}
+		if !includeStopped {
+			task, err := c.Task(ctx, nil)
+			if err != nil {
+				continue
+			}
+			st, err := task.Status(ctx)
+			if err != nil || (st.Status != containerd.Running && st.Status != containerd.Paused) {
+				continue
+			}
+		}
+		for _, n := range networks {
+			users[n] = append(users[n], c.ID())
+		}
+	}
+	return users, nil
 }
 
 func networkRmShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
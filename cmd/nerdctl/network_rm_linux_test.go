@@ -0,0 +1,83 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestNetworkRmInUse(t *testing.T) {
+	base := testutil.NewBase(t)
+	freeNetwork := testutil.Identifier(t) + "-free"
+	usedNetwork := testutil.Identifier(t) + "-used"
+	containerName := testutil.Identifier(t)
+	defer base.Cmd("network", "rm", freeNetwork, usedNetwork).Run()
+	defer base.Cmd("rm", "-f", containerName).Run()
+
+	base.Cmd("network", "create", freeNetwork).AssertOK()
+	base.Cmd("network", "create", usedNetwork).AssertOK()
+	base.Cmd("run", "-d", "--name", containerName, "--net", usedNetwork, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+
+	// the free network is removed, while the network in use is skipped
+	res := base.Cmd("network", "rm", freeNetwork, usedNetwork).Run()
+	assert.Assert(t, res.ExitCode != 0)
+	assert.Equal(t, freeNetwork+"\n", res.Stdout())
+	assert.Assert(t, strings.Contains(res.Stderr(), usedNetwork), res.Stderr())
+	base.Cmd("network", "inspect", freeNetwork).AssertFail()
+	base.Cmd("network", "inspect", usedNetwork).AssertOK()
+
+	// the network is no longer in use after the container is stopped
+	base.Cmd("stop", containerName).AssertOK()
+	base.Cmd("network", "rm", usedNetwork).AssertOutExactly(usedNetwork + "\n")
+	base.Cmd("network", "inspect", usedNetwork).AssertFail()
+}
+
+func TestNetworkPrune(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	unused, used, unlabeled := tID+"-unused", tID+"-used", tID+"-unlabeled"
+	defer base.Cmd("rm", "-f", tID).Run()
+	defer base.Cmd("network", "rm", unused, used, unlabeled).Run()
+
+	base.Cmd("network", "create", "--label", "nerdctl-test="+tID, unused).AssertOK()
+	base.Cmd("network", "create", "--label", "nerdctl-test="+tID, used).AssertOK()
+	base.Cmd("network", "create", unlabeled).AssertOK()
+	base.Cmd("run", "-d", "--name", tID, "--network", used, testutil.CommonImage, "sleep", "infinity").AssertOK()
+
+	// recent networks are retained
+	base.Cmd("network", "prune", "-f", "--filter", "label=nerdctl-test="+tID, "--filter", "until=1h").AssertOutNotContains(unused)
+	base.Cmd("network", "prune", "-f", "--filter", "label=nerdctl-test="+tID).AssertOutExactly("Deleted Networks:\n" + unused + "\n")
+	base.Cmd("network", "inspect", unused).AssertFail()
+	base.Cmd("network", "inspect", used).AssertOK()
+	base.Cmd("network", "inspect", unlabeled).AssertOK()
+	base.Cmd("network", "inspect", "bridge").AssertOK()
+}
+
+func TestNetworkPruneDryRun(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks `network prune --dry-run`
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	defer base.Cmd("network", "rm", tID).Run()
+
+	base.Cmd("network", "create", "--label", "nerdctl-test="+tID, tID).AssertOK()
+	base.Cmd("network", "prune", "--dry-run", "--filter", "label=nerdctl-test="+tID).AssertOutExactly("Would Delete Networks:\n" + tID + "\n")
+	base.Cmd("network", "inspect", tID).AssertOK()
+}
@@ -21,7 +21,6 @@ import (
 	"fmt"
 
 	"github.com/containerd/containerd"
-	"github.com/containerd/containerd/cio"
 	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
 
 	"github.com/spf13/cobra"
@@ -75,12 +74,7 @@ func pauseContainer(ctx context.Context, client *containerd.Client, id string) e
 		return err
 	}
 
-	task, err := container.Task(ctx, cio.Load)
-	if err != nil {
-		return err
-	}
-
-	status, err := task.Status(ctx)
+	task, status, err := ensureRunning(ctx, container)
 	if err != nil {
 		return err
 	}
@@ -88,8 +82,6 @@ func pauseContainer(ctx context.Context, client *containerd.Client, id string) e
 	switch status.Status {
 	case containerd.Paused:
 		return fmt.Errorf("container %s is already paused", id)
-	case containerd.Created, containerd.Stopped:
-		return fmt.Errorf("container %s is not running", id)
 	default:
 		return task.Pause(ctx)
 	}
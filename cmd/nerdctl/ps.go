@@ -24,6 +24,7 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"text/template"
@@ -33,9 +34,13 @@ import (
 	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/pkg/progress"
+	"github.com/containerd/containerd/runtime/restart"
+	"github.com/containerd/nerdctl/pkg/containerinspector"
 	"github.com/containerd/nerdctl/pkg/formatter"
+	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
 	"github.com/containerd/nerdctl/pkg/labels"
 	"github.com/containerd/nerdctl/pkg/labels/k8slabels"
+	"github.com/containerd/nerdctl/pkg/strutil"
 	"github.com/sirupsen/logrus"
 
 	"github.com/spf13/cobra"
@@ -57,11 +62,17 @@ func newPsCommand() *cobra.Command {
 	psCommand.Flags().BoolP("quiet", "q", false, "Only display container IDs")
 	psCommand.Flags().BoolP("size", "s", false, "Display total file sizes")
 
-	// Alias "-f" is reserved for "--filter"
+	// filter is defined as StringArray, not StringSlice, to allow label values containing commas
+	psCommand.Flags().StringArrayP("filter", "f", nil, "Filter output based on conditions provided (e.g., \"label=foo\", \"label!=foo\", \"exited=0\", \"status=running\", \"health=healthy\")")
 	psCommand.Flags().String("format", "", "Format the output using the given Go template, e.g, '{{json .}}', 'wide'")
 	psCommand.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"json", "table", "wide"}, cobra.ShellCompDirectiveNoFileComp
 	})
+	psCommand.Flags().String("sort", "", "Sort the containers by the key in ascending order (\"created\"|\"name\"|\"status\")")
+	psCommand.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return containerSortKeys, cobra.ShellCompDirectiveNoFileComp
+	})
+	psCommand.Flags().Bool("reverse", false, "Reverse the order of --sort")
 	return psCommand
 }
 
@@ -86,10 +97,39 @@ func psAction(cmd *cobra.Command, args []string) error {
 	if lastN == -1 && latest {
 		lastN = 1
 	}
+	sortKey, err := cmd.Flags().GetString("sort")
+	if err != nil {
+		return err
+	}
+	if sortKey != "" && !strutil.InStringSlice(containerSortKeys, sortKey) {
+		return fmt.Errorf("invalid sort key %q, expected one of %v", sortKey, containerSortKeys)
+	}
+	reverse, err := cmd.Flags().GetBool("reverse")
+	if err != nil {
+		return err
+	}
+	filters, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	cf, err := parseContainerFilters(filters)
+	if err != nil {
+		return err
+	}
 	containers, err := client.Containers(ctx)
 	if err != nil {
 		return err
 	}
+	if len(cf.exited) > 0 || len(cf.status) > 0 {
+		// like Docker, filtering by the state implies --all
+		all = true
+	}
+	if len(cf.labels) > 0 || len(cf.exited) > 0 || len(cf.status) > 0 || len(cf.health) > 0 {
+		containers, err = filterContainers(ctx, containers, cf)
+		if err != nil {
+			return err
+		}
+	}
 	if lastN > 0 {
 		all = true
 		sort.Slice(containers, func(i, j int) bool {
@@ -101,9 +141,346 @@ func psAction(cmd *cobra.Command, args []string) error {
 			containers = containers[:lastN]
 		}
 	}
+	if sortKey != "" {
+		containers, err = sortContainers(ctx, containers, sortKey, reverse)
+		if err != nil {
+			return err
+		}
+	}
 	return printContainers(ctx, client, cmd, containers, all)
 }
 
+var containerSortKeys = []string{"created", "name", "status"}
+
+// containerSortable holds the sort keys of a container.
+type containerSortable struct {
+	container containerd.Container
+	createdAt time.Time
+	name      string
+	status    string // Docker-compatible status string, e.g., "running", "exited"
+}
+
+// sortContainers sorts the containers by the key ("created", "name", or "status") in ascending order,
+// or in descending order when reverse is true.
+func sortContainers(ctx context.Context, containers []containerd.Container, key string, reverse bool) ([]containerd.Container, error) {
+	var sortables []containerSortable
+	for _, c := range containers {
+		info, err := c.Info(ctx, containerd.WithoutRefreshedMetadata)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				logrus.Warn(err)
+				continue
+			}
+			return nil, err
+		}
+		cs := containerSortable{
+			container: c,
+			createdAt: info.CreatedAt,
+			name:      getPrintableContainerName(info.Labels),
+		}
+		if key == "status" {
+			cs.status, _, err = containerState(ctx, c, info.Labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+		sortables = append(sortables, cs)
+	}
+	err := sortByKey(sortables, key, reverse, map[string]func(i, j int) bool{
+		"created": func(i, j int) bool { return sortables[i].createdAt.Before(sortables[j].createdAt) },
+		"name":    func(i, j int) bool { return sortables[i].name < sortables[j].name },
+		"status":  func(i, j int) bool { return sortables[i].status < sortables[j].status },
+	})
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]containerd.Container, len(sortables))
+	for i, cs := range sortables {
+		sorted[i] = cs.container
+	}
+	return sorted, nil
+}
+
+type labelFilter struct {
+	key      string
+	value    string
+	hasValue bool
+	negate   bool
+}
+
+// parseLabelFilter parses the value of "label=KEY[=VALUE]" filter.
+func parseLabelFilter(v string, negate bool) (labelFilter, error) {
+	lf := labelFilter{key: v, negate: negate}
+	if lkv := strings.SplitN(v, "=", 2); len(lkv) == 2 {
+		lf.key, lf.value, lf.hasValue = lkv[0], lkv[1], true
+	}
+	if lf.key == "" {
+		return lf, errors.New("label key must not be empty")
+	}
+	return lf, nil
+}
+
+// containerFilters is the parsed form of `nerdctl ps --filter`.
+// Label filters are ANDed, while exited, status and health filters are ORed within the same type.
+// Filters of different types are ANDed.
+type containerFilters struct {
+	labels []labelFilter
+	exited []int
+	status []string
+	health []string
+}
+
+// containerStates are the values accepted by `--filter status=`, compatible with Docker.
+var containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+
+// healthStates are the values accepted by `--filter health=`, compatible with Docker.
+var healthStates = []string{dockercompat.Starting, dockercompat.Healthy, dockercompat.Unhealthy, dockercompat.NoHealthcheck}
+
+func parseContainerFilters(filters []string) (*containerFilters, error) {
+	cf := &containerFilters{}
+	for _, f := range filters {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("bad format of filter %q (expected name=value)", f)
+		}
+		k, v := kv[0], kv[1]
+		negate := strings.HasSuffix(k, "!")
+		k = strings.TrimSuffix(k, "!")
+		switch k {
+		case "label":
+			lf, err := parseLabelFilter(v, negate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", f, err)
+			}
+			cf.labels = append(cf.labels, lf)
+		case "exited":
+			if negate {
+				return nil, fmt.Errorf("invalid filter %q: negation is not supported for %q", f, k)
+			}
+			code, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: exit code must be an integer: %w", f, err)
+			}
+			cf.exited = append(cf.exited, code)
+		case "status":
+			if negate {
+				return nil, fmt.Errorf("invalid filter %q: negation is not supported for %q", f, k)
+			}
+			if !strutil.InStringSlice(containerStates, v) {
+				return nil, fmt.Errorf("invalid filter %q: status must be one of %v", f, containerStates)
+			}
+			cf.status = append(cf.status, v)
+		case "health":
+			if negate {
+				return nil, fmt.Errorf("invalid filter %q: negation is not supported for %q", f, k)
+			}
+			if !strutil.InStringSlice(healthStates, v) {
+				return nil, fmt.Errorf("invalid filter %q: health must be one of %v", f, healthStates)
+			}
+			cf.health = append(cf.health, v)
+		default:
+			return nil, fmt.Errorf("invalid filter %q", f)
+		}
+	}
+	return cf, nil
+}
+
+// isUserLabel returns false for the labels that are set internally by nerdctl and containerd,
+// so that they do not match the label filters of `nerdctl ps` and `nerdctl * prune`.
+// The nerdctl labels that are meant to be set by users, such as the prune protection label, are user labels.
+func isUserLabel(key string) bool {
+	switch key {
+	case labels.Bypass4netns, labels.PruneProtect:
+		return true
+	}
+	for _, prefix := range []string{labels.Prefix, "containerd.io/", "io.containerd."} {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cf *containerFilters) matchLabels(containerLabels map[string]string) bool {
+	return matchLabelFilters(cf.labels, containerLabels)
+}
+
+// matchLabelFilters returns true if the labels match all the label filters.
+// The labels reserved by nerdctl and containerd never match.
+func matchLabelFilters(filters []labelFilter, labels map[string]string) bool {
+	for _, lf := range filters {
+		matched := false
+		if isUserLabel(lf.key) {
+			v, ok := labels[lf.key]
+			matched = ok && (!lf.hasValue || v == lf.value)
+		}
+		if matched == lf.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// matchState matches the Docker-compatible status string and the exit code of a container.
+func (cf *containerFilters) matchState(status string, exitCode int) bool {
+	if len(cf.status) > 0 && !strutil.InStringSlice(cf.status, status) {
+		return false
+	}
+	if len(cf.exited) > 0 {
+		if status != "exited" {
+			return false
+		}
+		matched := false
+		for _, code := range cf.exited {
+			if code == exitCode {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchHealth matches the health status of a container, which is "none" when the healthcheck is not configured.
+func (cf *containerFilters) matchHealth(health string) bool {
+	return len(cf.health) == 0 || strutil.InStringSlice(cf.health, health)
+}
+
+// containerHealthStatus returns the health status of a container, or "none" when the healthcheck is not configured.
+func containerHealthStatus(containerLabels map[string]string, running bool) (string, error) {
+	health, err := dockercompat.HealthFromLabels(containerLabels, running)
+	if err != nil {
+		return "", err
+	}
+	if health == nil {
+		return dockercompat.NoHealthcheck, nil
+	}
+	return health.Status, nil
+}
+
+// containerState returns the Docker-compatible status string and the exit code of a container.
+func containerState(ctx context.Context, c containerd.Container, containerLabels map[string]string) (string, int, error) {
+	task, err := c.Task(ctx, nil)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			// the task may have been deleted after exiting, e.g., on restarting containerd
+			if code, err := strconv.Atoi(containerLabels[labels.ExitCode]); err == nil {
+				return "exited", code, nil
+			}
+			return "created", 0, nil
+		}
+		return "", 0, err
+	}
+	st, err := task.Status(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	switch st.Status {
+	case containerd.Stopped:
+		if containerLabels[restart.StatusLabel] == string(containerd.Running) && restart.Reconcile(st, containerLabels) {
+			return "restarting", int(st.ExitStatus), nil
+		}
+		return "exited", int(st.ExitStatus), nil
+	default:
+		// "created", "running", "paused", "pausing", "unknown"
+		return string(st.Status), 0, nil
+	}
+}
+
+// updateContainerExitCodeLabel records the exit code of the task, and whether the task was killed by the OOM killer,
+// so that they are still available to containerState and `nerdctl inspect` after the task is deleted.
+// task may be nil when the task has been already deleted.
+func updateContainerExitCodeLabel(ctx context.Context, container containerd.Container, task containerd.Task, status containerd.ExitStatus) error {
+	if status.Error() != nil {
+		return nil
+	}
+	m := map[string]string{
+		labels.ExitCode: strconv.FormatUint(uint64(status.ExitCode()), 10),
+	}
+	if task != nil {
+		// the cgroup is still readable until the task is deleted
+		if oomKilled, err := containerinspector.InspectOOMKilled(ctx, task); err != nil {
+			logrus.WithError(err).Debugf("failed to inspect OOMKilled of container %s", container.ID())
+		} else {
+			m[labels.OOMKilled] = strconv.FormatBool(oomKilled)
+		}
+	}
+	opt := containerd.WithAdditionalContainerLabels(m)
+	return container.Update(ctx, containerd.UpdateContainerOpts(opt))
+}
+
+// clearContainerExitCodeLabel removes the exit code and OOMKilled recorded for the previous task,
+// so that they are not reported for the new task.
+func clearContainerExitCodeLabel(ctx context.Context, container containerd.Container) error {
+	return container.Update(ctx, func(ctx context.Context, client *containerd.Client, c *containers.Container) error {
+		delete(c.Labels, labels.ExitCode)
+		delete(c.Labels, labels.OOMKilled)
+		return nil
+	})
+}
+
+func filterContainers(ctx context.Context, containers []containerd.Container, cf *containerFilters) ([]containerd.Container, error) {
+	var filtered []containerd.Container
+	for _, c := range containers {
+		containerLabels, err := c.Labels(ctx)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				logrus.Warn(err)
+				continue
+			}
+			return nil, err
+		}
+		if !cf.matchLabels(containerLabels) {
+			continue
+		}
+		if len(cf.exited) > 0 || len(cf.status) > 0 || len(cf.health) > 0 {
+			status, exitCode, err := containerState(ctx, c, containerLabels)
+			if err != nil {
+				if errdefs.IsNotFound(err) {
+					logrus.Warn(err)
+					continue
+				}
+				return nil, err
+			}
+			if !cf.matchState(status, exitCode) {
+				continue
+			}
+			if len(cf.health) > 0 {
+				health, err := containerHealthStatus(containerLabels, status == "running")
+				if err != nil {
+					return nil, err
+				}
+				if !cf.matchHealth(health) {
+					continue
+				}
+			}
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered, nil
+}
+
+// selectContainers returns the containers matching the filters of `nerdctl ps --filter`,
+// for `--all` and `--filter` of the commands like `nerdctl stop`.
+// Only the containers with defaultStatus (e.g., "running") are selected unless the state is filtered.
+func selectContainers(ctx context.Context, client *containerd.Client, filters []string, defaultStatus ...string) ([]containerd.Container, error) {
+	cf, err := parseContainerFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	if len(cf.exited) == 0 && len(cf.status) == 0 {
+		cf.status = defaultStatus
+	}
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return filterContainers(ctx, containers, cf)
+}
+
 type containerPrintable struct {
 	Command   string
 	CreatedAt string
@@ -205,6 +582,13 @@ func printContainers(ctx context.Context, client *containerd.Client, cmd *cobra.
 		if !strings.HasPrefix(cStatus, "Up") && !all {
 			continue
 		}
+		if strings.HasPrefix(cStatus, "Up") {
+			health, err := containerHealthStatus(info.Labels, true)
+			if err != nil {
+				logrus.Warn(err)
+			}
+			cStatus = formatHealthStatus(cStatus, health)
+		}
 
 		p := containerPrintable{
 			Command:   formatter.InspectContainerCommand(spec, trunc),
@@ -270,6 +654,19 @@ func printContainers(ctx context.Context, client *containerd.Client, cmd *cobra.
 	return nil
 }
 
+// formatHealthStatus appends the health status to the status of a running container, like Docker.
+// e.g., "Up (healthy)", "Up (health: starting)"
+func formatHealthStatus(status, health string) string {
+	switch health {
+	case "", dockercompat.NoHealthcheck:
+		return status
+	case dockercompat.Starting:
+		return fmt.Sprintf("%s (health: %s)", status, health)
+	default:
+		return fmt.Sprintf("%s (%s)", status, health)
+	}
+}
+
 func getPrintableContainerName(containerLabels map[string]string) string {
 	if name, ok := containerLabels[labels.Name]; ok {
 		return name
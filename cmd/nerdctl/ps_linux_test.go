@@ -133,3 +133,193 @@ func TestContainerListWideMode(t *testing.T) {
 		return nil
 	})
 }
+
+func TestContainerListFilterLabel(t *testing.T) {
+	base := testutil.NewBase(t)
+	testContainerName := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", testContainerName).Run()
+	base.Cmd("run", "-d", "--name", testContainerName, "--label", "foo=bar", "--label", "baz", testutil.CommonImage, "sleep", "infinity").AssertOK()
+
+	base.Cmd("ps", "--filter", "label=foo").AssertOutContains(testContainerName)
+	base.Cmd("ps", "--filter", "label=foo=bar").AssertOutContains(testContainerName)
+	base.Cmd("ps", "--filter", "label=foo", "--filter", "label=baz").AssertOutContains(testContainerName)
+	base.Cmd("ps", "--filter", "label=foo=baz").AssertOutNotContains(testContainerName)
+	base.Cmd("ps", "--filter", "label=foo", "--filter", "label=qux").AssertOutNotContains(testContainerName)
+	base.Cmd("ps", "--filter", "label!=foo").AssertOutNotContains(testContainerName)
+	base.Cmd("ps", "--filter", "label!=foo=baz").AssertOutContains(testContainerName)
+	base.Cmd("ps", "--filter", "label!=qux").AssertOutContains(testContainerName)
+}
+
+func TestContainerListFilterExited(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	testContainer0 := tID + "-0"
+	testContainer1 := tID + "-1"
+	testContainerRunning := tID + "-running"
+	defer base.Cmd("rm", "-f", testContainer0, testContainer1, testContainerRunning).Run()
+	base.Cmd("run", "--name", testContainer0, testutil.CommonImage, "sh", "-c", "exit 0").AssertOK()
+	base.Cmd("run", "--name", testContainer1, testutil.CommonImage, "sh", "-c", "exit 1").AssertExitCode(1)
+	base.Cmd("run", "-d", "--name", testContainerRunning, testutil.CommonImage, "sleep", "infinity").AssertOK()
+
+	base.Cmd("ps", "-a", "--filter", "exited=0").AssertOutWithFunc(func(stdout string) error {
+		if !strings.Contains(stdout, testContainer0) {
+			return fmt.Errorf("expected %q to be listed", testContainer0)
+		}
+		if strings.Contains(stdout, testContainer1) || strings.Contains(stdout, testContainerRunning) {
+			return fmt.Errorf("expected only %q to be listed, got %q", testContainer0, stdout)
+		}
+		return nil
+	})
+	base.Cmd("ps", "-a", "--filter", "exited=1", "--filter", "status=exited").AssertOutWithFunc(func(stdout string) error {
+		if !strings.Contains(stdout, testContainer1) {
+			return fmt.Errorf("expected %q to be listed", testContainer1)
+		}
+		if strings.Contains(stdout, testContainer0) || strings.Contains(stdout, testContainerRunning) {
+			return fmt.Errorf("expected only %q to be listed, got %q", testContainer1, stdout)
+		}
+		return nil
+	})
+	base.Cmd("ps", "-a", "--filter", "exited=0", "--filter", "exited=1").AssertOutWithFunc(func(stdout string) error {
+		if !strings.Contains(stdout, testContainer0) || !strings.Contains(stdout, testContainer1) {
+			return fmt.Errorf("expected both %q and %q to be listed, got %q", testContainer0, testContainer1, stdout)
+		}
+		return nil
+	})
+	base.Cmd("ps", "-a", "--filter", "exited=1", "--filter", "status=running").AssertOutNotContains(testContainer1)
+	base.Cmd("ps", "--filter", "status=running").AssertOutContains(testContainerRunning)
+
+	if base.Target == testutil.Nerdctl {
+		// the exit code is recorded, so that the filter works after the task is deleted
+		base.Cmd("inspect", "--format", "{{index .Config.Labels \"nerdctl/exit-code\"}}", testContainer1).AssertOutExactly("1\n")
+	}
+}
+
+func TestContainerListFilterExitedAfterRestart(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+	testContainerName := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", testContainerName).Run()
+	// exits with 1 for the first time, and keeps running after being started again
+	base.Cmd("run", "--name", testContainerName, testutil.CommonImage,
+		"sh", "-c", "if [ -e /started ]; then sleep infinity; fi; touch /started; exit 1").AssertExitCode(1)
+	base.Cmd("ps", "-a", "--filter", "exited=1").AssertOutContains(testContainerName)
+
+	base.Cmd("start", testContainerName).AssertOK()
+	base.Cmd("inspect", "--format", "{{index .Config.Labels \"nerdctl/exit-code\"}}", testContainerName).AssertOutExactly("\n")
+	base.Cmd("ps", "-a", "--filter", "exited=1").AssertOutNotContains(testContainerName)
+	base.Cmd("ps", "--filter", "status=running").AssertOutContains(testContainerName)
+}
+
+func TestContainerListSort(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	running, exited, created := tID+"-b", tID+"-c", tID+"-a"
+	defer base.Cmd("rm", "-f", running, exited, created).Run()
+	label := "group=" + tID
+	// the containers are created one after another, so their creation times (in nanoseconds) are ordered
+	base.Cmd("run", "-d", "--name", running, "--label", label, testutil.CommonImage, "sleep", "infinity").AssertOK()
+	base.Cmd("run", "--name", exited, "--label", label, testutil.CommonImage, "true").AssertOK()
+	base.Cmd("create", "--name", created, "--label", label, testutil.CommonImage, "true").AssertOK()
+
+	names := func(args ...string) string {
+		args = append([]string{"ps", "--filter", "label=" + label, "--format", "{{.Names}}"}, args...)
+		return strings.Join(strings.Fields(base.Cmd(args...).Out()), ",")
+	}
+	assert.Equal(t, strings.Join([]string{created, exited, running}, ","), names("-a", "--sort", "created", "--reverse"))
+	assert.Equal(t, strings.Join([]string{running, exited, created}, ","), names("-a", "--sort", "created"))
+	assert.Equal(t, strings.Join([]string{created, running, exited}, ","), names("-a", "--sort", "name"))
+	assert.Equal(t, strings.Join([]string{created, exited, running}, ","), names("-a", "--sort", "status"))
+	assert.Equal(t, strings.Join([]string{exited, created}, ","), names("-n", "2", "--sort", "name", "--reverse"))
+	assert.Equal(t, running, names("--sort", "created", "--reverse"))
+	base.Cmd("ps", "--sort", "unknown").AssertFail()
+}
+
+func TestParseContainerFilters(t *testing.T) {
+	cf, err := parseContainerFilters([]string{"label=foo", "label=foo=bar=baz", "label!=qux"})
+	assert.NilError(t, err)
+	expected := []labelFilter{
+		{key: "foo"},
+		{key: "foo", value: "bar=baz", hasValue: true},
+		{key: "qux", negate: true},
+	}
+	assert.Equal(t, len(expected), len(cf.labels))
+	for i := range expected {
+		assert.Equal(t, expected[i], cf.labels[i])
+	}
+
+	cf, err = parseContainerFilters([]string{"exited=0", "exited=137", "status=exited"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []int{0, 137}, cf.exited)
+	assert.DeepEqual(t, []string{"exited"}, cf.status)
+
+	cf, err = parseContainerFilters([]string{"health=healthy", "health=none"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"healthy", "none"}, cf.health)
+
+	for _, f := range []string{"label", "label=", "unknown=foo", "exited=foo", "exited!=0", "status=foo", "health=foo", "health!=healthy"} {
+		_, err := parseContainerFilters([]string{f})
+		assert.Assert(t, err != nil, "expected an error for %q", f)
+	}
+}
+
+func TestContainerFiltersMatchState(t *testing.T) {
+	testCases := []struct {
+		filters  []string
+		status   string
+		exitCode int
+		expected bool
+	}{
+		{[]string{"exited=0"}, "exited", 0, true},
+		{[]string{"exited=0"}, "exited", 1, false},
+		{[]string{"exited=0"}, "running", 0, false},
+		{[]string{"exited=0", "exited=1"}, "exited", 1, true},
+		{[]string{"exited=1", "status=exited"}, "exited", 1, true},
+		{[]string{"exited=1", "status=running"}, "exited", 1, false},
+		{[]string{"status=running", "status=paused"}, "paused", 0, true},
+		{[]string{"status=created"}, "running", 0, false},
+	}
+	for _, tc := range testCases {
+		cf, err := parseContainerFilters(tc.filters)
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, cf.matchState(tc.status, tc.exitCode), "filters: %v, status: %q, exit code: %d", tc.filters, tc.status, tc.exitCode)
+	}
+}
+
+func TestContainerFiltersMatchLabels(t *testing.T) {
+	containerLabels := map[string]string{
+		"foo":                          "bar",
+		"nerdctl/name":                 "internal",
+		"containerd.io/restart.status": "running",
+		"nerdctl/prune":                "false",
+	}
+	testCases := []struct {
+		filters  []string
+		expected bool
+	}{
+		{[]string{"label=foo"}, true},
+		{[]string{"label=foo=bar"}, true},
+		{[]string{"label=foo=baz"}, false},
+		{[]string{"label!=foo"}, false},
+		{[]string{"label!=foo=baz"}, true},
+		// internal labels are not matched
+		{[]string{"label=nerdctl/name"}, false},
+		{[]string{"label!=nerdctl/name"}, true},
+		{[]string{"label=containerd.io/restart.status=running"}, false},
+		// but the labels set by users are
+		{[]string{"label=nerdctl/prune=false"}, true},
+		{[]string{"label!=nerdctl/prune"}, false},
+	}
+	for _, tc := range testCases {
+		cf, err := parseContainerFilters(tc.filters)
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, cf.matchLabels(containerLabels), "filters: %v", tc.filters)
+	}
+}
+
+func TestFormatHealthStatus(t *testing.T) {
+	assert.Equal(t, "Up", formatHealthStatus("Up", "none"))
+	assert.Equal(t, "Up", formatHealthStatus("Up", ""))
+	assert.Equal(t, "Up (health: starting)", formatHealthStatus("Up", "starting"))
+	assert.Equal(t, "Up (healthy)", formatHealthStatus("Up", "healthy"))
+	assert.Equal(t, "Up (unhealthy)", formatHealthStatus("Up", "unhealthy"))
+}
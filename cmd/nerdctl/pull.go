@@ -26,6 +26,9 @@ import (
 	"strings"
 
 	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	refdocker "github.com/containerd/containerd/reference/docker"
+	"github.com/containerd/imgcrypt"
 	"github.com/containerd/nerdctl/pkg/imgutil"
 	"github.com/containerd/nerdctl/pkg/ipfs"
 	"github.com/containerd/nerdctl/pkg/platformutil"
@@ -105,7 +108,7 @@ func pullAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	_, err = ensureImage(cmd, ctx, client, rawRef, ocispecPlatforms, "always", unpack, quiet)
+	_, err = ensureImage(cmd, ctx, client, rawRef, ocispecPlatforms, "always", unpack, quiet, nil)
 	if err != nil {
 		return err
 	}
@@ -114,7 +117,7 @@ func pullAction(cmd *cobra.Command, args []string) error {
 }
 
 func ensureImage(cmd *cobra.Command, ctx context.Context, client *containerd.Client, rawRef string, ocispecPlatforms []v1.Platform,
-	pull string, unpack *bool, quiet bool) (*imgutil.EnsuredImage, error) {
+	pull string, unpack *bool, quiet bool, decryptPayload *imgcrypt.Payload) (*imgutil.EnsuredImage, error) {
 
 	var ensured *imgutil.EnsuredImage
 	snapshotter, err := cmd.Flags().GetString("snapshotter")
@@ -159,7 +162,7 @@ func ensureImage(cmd *cobra.Command, ctx context.Context, client *containerd.Cli
 			return nil, err
 		}
 
-		ref, err = verifyCosign(ctx, rawRef, keyRef, hostsDirs)
+		ref, err = verifyCosign(ctx, rawRef, keyRef, insecureRegistry, hostsDirs)
 		if err != nil {
 			return nil, err
 		}
@@ -170,15 +173,45 @@ func ensureImage(cmd *cobra.Command, ctx context.Context, client *containerd.Cli
 	}
 
 	ensured, err = imgutil.EnsureImage(ctx, client, cmd.OutOrStdout(), cmd.ErrOrStderr(), snapshotter, ref,
-		pull, insecureRegistry, hostsDirs, ocispecPlatforms, unpack, quiet)
+		pull, insecureRegistry, hostsDirs, ocispecPlatforms, unpack, quiet, decryptPayload)
 	if err != nil {
 		return nil, err
 	}
+	if ref != rawRef {
+		// The image was pulled by the verified digest, so the tag has to be updated separately
+		if err := tagPulledImage(ctx, client, rawRef, ensured.Image); err != nil {
+			return nil, err
+		}
+	}
 	return ensured, err
 }
 
-func verifyCosign(ctx context.Context, rawRef string, keyRef string, hostsDirs []string) (string, error) {
-	digest, err := imgutil.ResolveDigest(ctx, rawRef, false, hostsDirs)
+// tagPulledImage points the tag of rawRef (e.g., "alpine:latest") to the pulled image.
+// Digested references are left as-is.
+func tagPulledImage(ctx context.Context, client *containerd.Client, rawRef string, pulled containerd.Image) error {
+	named, err := referenceutil.ParseDockerRef(rawRef)
+	if err != nil {
+		return err
+	}
+	if _, ok := named.(refdocker.Digested); ok {
+		return nil
+	}
+	img := pulled.Metadata()
+	img.Name = named.String()
+	imageService := client.ImageService()
+	if _, err := imageService.Create(ctx, img); err != nil {
+		if !errdefs.IsAlreadyExists(err) {
+			return err
+		}
+		if _, err := imageService.Update(ctx, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifyCosign(ctx context.Context, rawRef string, keyRef string, insecure bool, hostsDirs []string) (string, error) {
+	digest, err := imgutil.ResolveDigest(ctx, rawRef, insecure, hostsDirs)
 	if err != nil {
 		logrus.WithError(err).Errorf("unable to resolve digest for an image %s: %v", rawRef, err)
 		return rawRef, err
@@ -205,6 +238,9 @@ func verifyCosign(ctx context.Context, rawRef string, keyRef string, hostsDirs [
 	} else {
 		cosignCmd.Env = append(cosignCmd.Env, "COSIGN_EXPERIMENTAL=true")
 	}
+	if insecure {
+		cosignCmd.Args = append(cosignCmd.Args, "--allow-insecure-registry")
+	}
 
 	cosignCmd.Args = append(cosignCmd.Args, ref)
 
@@ -113,6 +113,39 @@ CMD ["echo", "nerdctl-build-test-string"]
 	base.Cmd("--insecure-registry", "pull", testImageRef).AssertOK()
 }
 
+func TestImageVerifyWithCosignInsecureRegistry(t *testing.T) {
+	if _, err := exec.LookPath("cosign"); err != nil {
+		t.Skip()
+	}
+	testutil.DockerIncompatible(t)
+	testutil.RequiresBuild(t)
+	t.Setenv("COSIGN_PASSWORD", "1")
+	keyPair := newCosignKeyPair(t, "cosign-key-pair")
+	defer keyPair.cleanup()
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	tID := testutil.Identifier(t)
+	reg := testregistry.NewPlainHTTP(base, 5000)
+	defer reg.Cleanup()
+	// not localhost, so that plain HTTP is used only with --insecure-registry
+	testImageRef := fmt.Sprintf("%s:%d/%s", reg.IP.String(), reg.ListenPort, tID)
+	t.Logf("testImageRef=%q", testImageRef)
+
+	dockerfile := fmt.Sprintf(`FROM %s
+CMD ["echo", "nerdctl-build-test-string"]
+	`, testutil.CommonImage)
+
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+
+	base.Cmd("build", "-t", testImageRef, buildCtx).AssertOK()
+	base.Cmd("push", testImageRef, "--sign=cosign", "--cosign-key="+keyPair.privateKey).AssertFail()
+	base.Cmd("--insecure-registry", "push", testImageRef, "--sign=cosign", "--cosign-key="+keyPair.privateKey).AssertOK()
+	base.Cmd("pull", testImageRef, "--verify=cosign", "--cosign-key="+keyPair.publicKey).AssertFail()
+	base.Cmd("--insecure-registry", "pull", testImageRef, "--verify=cosign", "--cosign-key="+keyPair.publicKey).AssertOK()
+}
+
 func TestImageVerifyWithCosignShouldFailWhenKeyIsNotCorrect(t *testing.T) {
 	if _, err := exec.LookPath("cosign"); err != nil {
 		t.Skip()
@@ -149,3 +182,34 @@ CMD ["echo", "nerdctl-build-test-string"]
 	newKeyPair := newCosignKeyPair(t, "cosign-key-pair-test")
 	base.Cmd("pull", testImageRef, "--verify=cosign", "--cosign-key="+newKeyPair.publicKey).AssertFail()
 }
+
+func TestPullUnpackFalse(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	reg := testregistry.NewPlainHTTP(base, 5000)
+	defer reg.Cleanup()
+
+	// the image has a unique layer, so that the snapshot is not shared with the other images
+	imageName := fmt.Sprintf("localhost:%d/%s:latest", reg.ListenPort, tID)
+	defer base.Cmd("rmi", imageName).Run()
+	base.Cmd("run", "--name", tID, testutil.CommonImage, "sh", "-c", "echo "+tID+" > /unique").AssertOK()
+	defer base.Cmd("rm", "-f", tID).Run()
+	base.Cmd("commit", tID, imageName).AssertOK()
+	base.Cmd("push", imageName).AssertOK()
+	base.Cmd("rm", "-f", tID).AssertOK()
+	base.Cmd("rmi", imageName).AssertOK()
+
+	base.Cmd("pull", "--unpack=false", imageName).AssertOK()
+
+	// the content is present, but no snapshot is created, so the unpacked size is zero
+	base.Cmd("image", "inspect", "--mode=native", imageName).AssertOK()
+	unpackedSize := func() string {
+		return base.Cmd("images", "--format", "{{.SizeBytes}}", imageName).Out()
+	}
+	assert.Equal(t, "0\n", unpackedSize(), "no snapshot must be created")
+
+	// `nerdctl run` unpacks the image on demand
+	base.Cmd("run", "--rm", "--pull=never", imageName, "cat", "/unique").AssertOutExactly(tID + "\n")
+	assert.Assert(t, unpackedSize() != "0\n")
+}
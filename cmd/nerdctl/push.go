@@ -245,7 +245,7 @@ func pushAction(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		err = signCosign(rawRef, keyRef)
+		err = signCosign(rawRef, keyRef, insecure)
 		if err != nil {
 			return err
 		}
@@ -305,7 +305,7 @@ func isReusableESGZ(ctx context.Context, cs content.Store, desc ocispec.Descript
 	return true
 }
 
-func signCosign(rawRef string, keyRef string) error {
+func signCosign(rawRef string, keyRef string, insecure bool) error {
 	cosignExecutable, err := exec.LookPath("cosign")
 	if err != nil {
 		logrus.WithError(err).Error("cosign executable not found in path $PATH")
@@ -321,6 +321,9 @@ func signCosign(rawRef string, keyRef string) error {
 	} else {
 		cosignCmd.Env = append(cosignCmd.Env, "COSIGN_EXPERIMENTAL=true")
 	}
+	if insecure {
+		cosignCmd.Args = append(cosignCmd.Args, "--allow-insecure-registry")
+	}
 
 	cosignCmd.Args = append(cosignCmd.Args, rawRef)
 
@@ -18,13 +18,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/nerdctl/pkg/dnsutil/hostsstore"
 	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
 	"github.com/containerd/nerdctl/pkg/labels"
 	"github.com/containerd/nerdctl/pkg/namestore"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -88,17 +91,41 @@ func renameContainer(ctx context.Context, container containerd.Container, newNam
 	if err != nil {
 		return err
 	}
+	id := container.ID()
 	name := l[labels.Name]
-	if err := namst.Rename(name, container.ID(), newName); err != nil {
+	if name == "" {
+		// the container was created with `--name=""`
+		err = namst.Acquire(newName, id)
+	} else {
+		err = namst.Rename(name, id, newName)
+	}
+	if err != nil {
 		return err
 	}
-	if err := hostst.Update(ns, container.ID(), newName); err != nil {
+	rollback := func() {
+		var err error
+		if name == "" {
+			err = namst.Release(newName, id)
+		} else {
+			err = namst.Rename(newName, id, name)
+		}
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to restore the name of container %q", id)
+		}
+	}
+	// the hosts meta does not exist for the containers without CNI networks, or never started
+	if err := hostst.Update(ns, id, newName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		rollback()
 		return err
 	}
 	labels := map[string]string{
 		labels.Name: newName,
 	}
 	if _, err = container.SetLabels(ctx, labels); err != nil {
+		if err := hostst.Update(ns, id, name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logrus.WithError(err).Warnf("failed to restore the hosts of container %q", id)
+		}
+		rollback()
 		return err
 	}
 	return nil
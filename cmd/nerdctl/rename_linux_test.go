@@ -17,9 +17,11 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
 )
 
 func TestRename(t *testing.T) {
@@ -37,6 +39,35 @@ func TestRename(t *testing.T) {
 	base.Cmd("rename", testContainerName+"_new", testContainerName+"_new").AssertFail()
 }
 
+func TestRenameNotStarted(t *testing.T) {
+	t.Parallel()
+	testContainerName := testutil.Identifier(t)
+	base := testutil.NewBase(t)
+
+	defer base.Cmd("rm", "-f", testContainerName, testContainerName+"_new").Run()
+	base.Cmd("create", "--name", testContainerName, testutil.CommonImage, "sleep", "infinity").AssertOK()
+	base.Cmd("rename", testContainerName, testContainerName+"_new").AssertOK()
+	base.Cmd("ps", "-a").AssertOutContains(testContainerName + "_new")
+	base.Cmd("start", testContainerName+"_new").AssertOK()
+	base.Cmd("exec", testContainerName+"_new", "cat", "/etc/hosts").AssertOutContains(testContainerName + "_new")
+}
+
+func TestRenameByID(t *testing.T) {
+	t.Parallel()
+	testContainerName := testutil.Identifier(t)
+	base := testutil.NewBase(t)
+
+	defer base.Cmd("rm", "-f", testContainerName, testContainerName+"_new", testContainerName+"_other").Run()
+	base.Cmd("run", "-d", "--name", testContainerName, "--network", "host", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	base.Cmd("run", "-d", "--name", testContainerName+"_other", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	id := base.InspectContainer(testContainerName).ID
+	// the new name is already taken
+	base.Cmd("rename", id, testContainerName+"_other").AssertFail()
+	base.Cmd("rename", id[:12], testContainerName+"_new").AssertOK()
+	// Docker prefixes the name with "/"
+	assert.Equal(t, testContainerName+"_new", strings.TrimPrefix(base.InspectContainer(id).Name, "/"))
+}
+
 func TestRenameUpdateHosts(t *testing.T) {
 	t.Parallel()
 	testutil.DockerIncompatible(t)
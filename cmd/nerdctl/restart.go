@@ -17,31 +17,110 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 func newRestartCommand() *cobra.Command {
 	var restartCommand = &cobra.Command{
 		Use:               "restart [flags] CONTAINER [CONTAINER, ...]",
-		Args:              cobra.MinimumNArgs(1),
 		Short:             "Restart one or more running containers",
 		RunE:              restartAction,
 		ValidArgsFunction: startShellComplete,
 		SilenceUsage:      true,
 		SilenceErrors:     true,
 	}
-	restartCommand.Flags().StringP("time", "t", "10", "Seconds to wait for stop before killing it")
+	restartCommand.Flags().IntP("time", "t", 10, "Seconds to wait for stop before killing it")
+	restartCommand.Flags().BoolP("all", "a", false, "Restart all running containers (or all the containers matching --filter)")
+	// filter is defined as StringArray, not StringSlice, to allow label values containing commas
+	restartCommand.Flags().StringArrayP("filter", "f", nil, "Restart the containers matching the conditions, in the same way as `nerdctl ps --filter` (e.g., \"label=foo\", \"status=exited\")")
 	return restartCommand
 }
 
 func restartAction(cmd *cobra.Command, args []string) error {
-	err := stopAction(cmd, args)
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+	filters, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	if all || len(filters) > 0 {
+		if len(args) > 0 {
+			return errors.New("no argument can be specified with --all or --filter")
+		}
+	} else if len(args) == 0 {
+		return errors.New("requires at least 1 argument (or --all, --filter)")
+	}
+	timeout, err := getStopTimeout(cmd)
 	if err != nil {
 		return err
 	}
-	err = startAction(cmd, args)
+
+	client, ctx, cancel, err := newClient(cmd)
 	if err != nil {
 		return err
 	}
+	defer cancel()
+
+	restart := func(ctx context.Context, c containerd.Container) error {
+		// the stop timeout of the container is used unless --time is specified
+		// created containers have no task to stop
+		if err := stopContainer(ctx, cmd, c, timeout); err != nil && !errdefs.IsNotFound(err) {
+			return fmt.Errorf("failed to stop container %s: %w", c.ID(), err)
+		}
+		if err := startContainer(ctx, cmd, client, c, false); err != nil {
+			return fmt.Errorf("failed to start container %s: %w", c.ID(), err)
+		}
+		return nil
+	}
+
+	if all || len(filters) > 0 {
+		containers, err := selectContainers(ctx, client, filters, string(containerd.Running))
+		if err != nil {
+			return err
+		}
+		// Like `nerdctl stop`, restarting continues on errors, and the command fails if any of the containers could not be restarted.
+		var errs []error
+		for _, c := range containers {
+			if err := restart(ctx, c); err != nil {
+				logrus.WithError(err).Error("failed to restart container")
+				errs = append(errs, err)
+				continue
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), c.ID())
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to restart %d of %d containers", len(errs), len(containers))
+		}
+		return nil
+	}
+
+	walker := &containerwalker.ContainerWalker{
+		Client: client,
+		OnFound: func(ctx context.Context, found containerwalker.Found) error {
+			if err := restart(ctx, found.Container); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(cmd.OutOrStdout(), "%s\n", found.Req)
+			return err
+		},
+	}
+	for _, req := range args {
+		n, err := walker.Walk(ctx, req)
+		if err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("no such container %s", req)
+		}
+	}
 	return nil
 }
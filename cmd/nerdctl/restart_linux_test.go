@@ -0,0 +1,64 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestRestartAll(t *testing.T) {
+	testutil.DockerIncompatible(t) // `docker restart` lacks --all and --filter
+	base := testutil.NewBase(t)
+	// use a dedicated namespace, so as not to restart the containers of other tests
+	ns := testutil.Identifier(t)
+	base.Args = []string{"--namespace=" + ns}
+	labeled, unlabeled, created := ns+"-labeled", ns+"-unlabeled", ns+"-created"
+	defer base.Cmd("namespace", "remove", ns).Run()
+	defer base.Cmd("rmi", testutil.AlpineImage).Run()
+	defer base.Cmd("rm", "-f", labeled, unlabeled, created).Run()
+
+	base.Cmd("run", "-d", "--name", labeled, "--label", "foo=bar", testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	base.Cmd("run", "-d", "--name", unlabeled, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	base.Cmd("create", "--name", created, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+
+	pid := func(name string) int {
+		return base.InspectContainer(name).State.Pid
+	}
+	labeledPid, unlabeledPid := pid(labeled), pid(unlabeled)
+
+	// --filter restarts only the matching containers
+	base.Cmd("restart", "--filter", "label=foo=bar", "--time", "1").AssertOutExactly(base.InspectContainer(labeled).ID + "\n")
+	assert.Assert(t, pid(labeled) != labeledPid)
+	assert.Equal(t, unlabeledPid, pid(unlabeled))
+	labeledPid = pid(labeled)
+
+	// --all restarts all the running containers, but does not start the created container
+	base.Cmd("restart", "--all", "--time", "1").AssertOK()
+	assert.Assert(t, pid(labeled) != labeledPid)
+	assert.Assert(t, pid(unlabeled) != unlabeledPid)
+	assert.Equal(t, "created", base.InspectContainer(created).State.Status)
+
+	// restart by name
+	labeledPid = pid(labeled)
+	base.Cmd("restart", "--time", "1", labeled).AssertOutExactly(labeled + "\n")
+	assert.Assert(t, pid(labeled) != labeledPid)
+
+	base.Cmd("restart", "--all", labeled).AssertFail()
+}
@@ -135,6 +135,9 @@ func removeContainer(cmd *cobra.Command, ctx context.Context, container containe
 		if err := hostsstore.DeallocHostsFile(dataStore, ns, id); err != nil {
 			logrus.WithError(retErr).Warnf("failed to remove hosts file for container %q", id)
 		}
+		if err := unmountPluginVolumes(cmd, id, l[labels.Mounts]); err != nil {
+			logrus.WithError(err).Warnf("failed to unmount volumes of container %q", id)
+		}
 	}()
 	if anonVolumesJSON, ok := l[labels.AnonymousVolumes]; ok && removeAnonVolumes {
 		var anonVolumes []string
@@ -146,7 +149,7 @@ func removeContainer(cmd *cobra.Command, ctx context.Context, container containe
 			return err
 		}
 		defer func() {
-			if _, err := volStore.Remove(anonVolumes); err != nil {
+			if _, err := volStore.Remove(anonVolumes, false); err != nil {
 				logrus.WithError(err).Warnf("failed to remove anonymous volumes %v", anonVolumes)
 			}
 		}()
@@ -20,9 +20,12 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/nerdctl/pkg/idutil/imagewalker"
+	"github.com/containerd/nerdctl/pkg/strutil"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -37,7 +40,7 @@ func newRmiCommand() *cobra.Command {
 		SilenceUsage:      true,
 		SilenceErrors:     true,
 	}
-	rmiCommand.Flags().BoolP("force", "f", false, "Ignore removal errors")
+	rmiCommand.Flags().BoolP("force", "f", false, "Force removal of the images used by stopped containers")
 	// Alias `-a` is reserved for `--all`. Should be compatible with `podman rmi --all`.
 	rmiCommand.Flags().Bool("async", false, "Asynchronous mode")
 	return rmiCommand
@@ -62,41 +65,113 @@ func rmiAction(cmd *cobra.Command, args []string) error {
 	}
 	defer cancel()
 
-	cs := client.ContentStore()
-	is := client.ImageService()
+	users, err := imageUsers(ctx, client)
+	if err != nil {
+		return err
+	}
 
+	// The images are resolved before removing any of them, so that an image specified twice
+	// (e.g., `nerdctl rmi -f $(nerdctl images -q)` with multiple tags) is removed only once.
+	var (
+		req      string
+		toRemove []images.Image
+		// reqsOf maps an image name to the requests that resolved to it
+		reqsOf = make(map[string][]string)
+	)
 	walker := &imagewalker.ImageWalker{
 		Client: client,
 		OnFound: func(ctx context.Context, found imagewalker.Found) error {
-			// digests is used only for emulating human-readable output of `docker rmi`
-			digests, err := found.Image.RootFS(ctx, cs, platforms.DefaultStrict())
-			if err != nil {
-				logrus.WithError(err).Warning("failed to enumerate rootfs")
-			}
-
-			if err := is.Delete(ctx, found.Image.Name, delOpts...); err != nil {
-				return err
-			}
-			fmt.Fprintf(cmd.OutOrStdout(), "Untagged: %s@%s\n", found.Image.Name, found.Image.Target.Digest)
-			for _, digest := range digests {
-				fmt.Fprintf(cmd.OutOrStdout(), "Deleted: %s\n", digest)
+			if _, ok := reqsOf[found.Image.Name]; !ok {
+				toRemove = append(toRemove, found.Image)
 			}
+			reqsOf[found.Image.Name] = append(reqsOf[found.Image.Name], req)
 			return nil
 		},
 	}
-	for _, req := range args {
+	// Like Docker, the removal continues on errors, and the command fails if any of the images could not be removed.
+	// The errors are counted per request, i.e., per image specified in the arguments.
+	failed := make(map[string]struct{})
+	reqs := strutil.DedupeStrSlice(args)
+	for _, req = range reqs {
 		n, err := walker.Walk(ctx, req)
 		if err == nil && n == 0 {
 			err = fmt.Errorf("no such image %s", req)
 		}
 		if err != nil {
-			if force {
-				logrus.Error(err)
-			} else {
-				return err
+			logrus.Error(err)
+			failed[req] = struct{}{}
+		}
+	}
+	for _, img := range toRemove {
+		if err := removeImage(ctx, cmd, client, img, users[img.Name], force, delOpts...); err != nil {
+			logrus.Error(err)
+			for _, r := range reqsOf[img.Name] {
+				failed[r] = struct{}{}
 			}
 		}
 	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to remove %d of %d images", len(failed), len(reqs))
+	}
+	return nil
+}
+
+// removeImage removes the image unless it is used by containers, and prints the removed digests.
+func removeImage(ctx context.Context, cmd *cobra.Command, client *containerd.Client, img images.Image, users []containerd.Container, force bool, delOpts ...images.DeleteOpt) error {
+	if err := checkImageUsers(ctx, img.Name, users, force); err != nil {
+		return err
+	}
+	// digests is used only for emulating human-readable output of `docker rmi`
+	digests, err := img.RootFS(ctx, client.ContentStore(), platforms.DefaultStrict())
+	if err != nil {
+		logrus.WithError(err).Warning("failed to enumerate rootfs")
+	}
+
+	if err := client.ImageService().Delete(ctx, img.Name, delOpts...); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Untagged: %s@%s\n", img.Name, img.Target.Digest)
+	for _, digest := range digests {
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted: %s\n", digest)
+	}
+	return nil
+}
+
+// imageUsers returns the containers grouped by their image names.
+func imageUsers(ctx context.Context, client *containerd.Client) (map[string][]containerd.Container, error) {
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make(map[string][]containerd.Container)
+	for _, c := range containers {
+		info, err := c.Info(ctx, containerd.WithoutRefreshedMetadata)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		users[info.Image] = append(users[info.Image], c)
+	}
+	return users, nil
+}
+
+// checkImageUsers returns an error if the image cannot be removed because of the containers using it.
+// Images used by stopped containers can be removed with force, but images used by running containers cannot.
+func checkImageUsers(ctx context.Context, name string, containers []containerd.Container, force bool) error {
+	for _, c := range containers {
+		task, err := c.Task(ctx, nil)
+		if err == nil {
+			st, err := task.Status(ctx)
+			if err == nil && (st.Status == containerd.Running || st.Status == containerd.Paused) {
+				return fmt.Errorf("conflict: unable to delete %s (cannot be forced) - image is being used by running container %s", name, c.ID())
+			}
+		}
+		if !force {
+			return fmt.Errorf("conflict: unable to remove repository reference %q (must force) - container %s is using its referenced image", name, c.ID())
+		}
+	}
 	return nil
 }
 
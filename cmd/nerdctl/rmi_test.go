@@ -0,0 +1,62 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/icmd"
+)
+
+func TestRmiPartialFailure(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	removable, inUse, stopped := tID+"-removable", tID+"-in-use", tID+"-stopped"
+	for _, img := range []string{removable, inUse, stopped} {
+		defer base.Cmd("rmi", "-f", img).Run()
+	}
+	defer base.Cmd("rm", "-f", tID+"-running", tID+"-stopped").Run()
+
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	for _, img := range []string{removable, inUse, stopped} {
+		base.Cmd("tag", testutil.CommonImage, img).AssertOK()
+	}
+	base.Cmd("run", "-d", "--name", tID+"-running", inUse, "sleep", "infinity").AssertOK()
+	base.Cmd("create", "--name", tID+"-stopped", stopped, "sleep", "infinity").AssertOK()
+
+	// images used by stopped containers need --force
+	base.Cmd("rmi", stopped).AssertErrContains("must force")
+
+	// images used by running containers cannot be removed even with --force,
+	// but the other images are removed
+	base.Cmd("rmi", "-f", removable, inUse, stopped).Assert(icmd.Expected{
+		ExitCode: 1,
+		Out:      "Untagged: docker.io/library/" + removable,
+		Err:      "image is being used by running container",
+	})
+	base.Cmd("images", "-q", removable).AssertOutExactly("")
+	base.Cmd("images", "-q", stopped).AssertOutExactly("")
+	base.Cmd("image", "inspect", inUse).AssertOK()
+
+	// the errors are counted per image specified in the arguments
+	base.Cmd("rmi", "-f", inUse, tID+"-nonexistent").AssertErrContains("failed to remove 2 of 2 images")
+
+	// an image specified twice is removed only once
+	base.Cmd("tag", testutil.CommonImage, removable).AssertOK()
+	base.Cmd("rmi", "-f", removable, removable).AssertOK()
+}
@@ -27,6 +27,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -40,6 +41,7 @@ import (
 	"github.com/containerd/containerd/oci"
 	gocni "github.com/containerd/go-cni"
 	"github.com/containerd/nerdctl/pkg/defaults"
+	"github.com/containerd/nerdctl/pkg/errutil"
 	"github.com/containerd/nerdctl/pkg/idgen"
 	"github.com/containerd/nerdctl/pkg/imgutil"
 	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
@@ -53,6 +55,7 @@ import (
 	"github.com/containerd/nerdctl/pkg/strutil"
 	"github.com/containerd/nerdctl/pkg/taskutil"
 	dopts "github.com/docker/cli/opts"
+	"github.com/moby/sys/signal"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -88,6 +91,10 @@ func newRunCommand() *cobra.Command {
 	setCreateFlags(runCommand)
 
 	runCommand.Flags().BoolP("detach", "d", false, "Run container in background and print container ID")
+	runCommand.Flags().StringSliceP("attach", "a", nil, "Attach STDIN, STDOUT, or STDERR (default all)")
+	runCommand.RegisterFlagCompletionFunc("attach", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"STDIN", "STDOUT", "STDERR"}, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	return runCommand
 }
@@ -99,7 +106,7 @@ func setCreateFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolP("tty", "t", false, "(Currently -t needs to correspond to -i)")
 	cmd.Flags().BoolP("interactive", "i", false, "Keep STDIN open even if not attached")
-	cmd.Flags().String("restart", "no", `Restart policy to apply when a container exits (implemented values: "no"|"always")`)
+	cmd.Flags().String("restart", "no", `Restart policy to apply when a container exits (implemented values: "no"|"always"|"on-failure[:max-retries]"|"unless-stopped")`)
 	cmd.RegisterFlagCompletionFunc("restart", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"no", "always", "on-failure", "unless-stopped"}, cobra.ShellCompDirectiveNoFileComp
 	})
@@ -108,9 +115,18 @@ func setCreateFlags(cmd *cobra.Command) {
 	cmd.RegisterFlagCompletionFunc("pull", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"always", "missing", "never"}, cobra.ShellCompDirectiveNoFileComp
 	})
-	cmd.Flags().String("stop-signal", "SIGTERM", "Signal to stop a container")
+	cmd.Flags().String("stop-signal", "", "Signal to stop a container, as a name or a number (default: the StopSignal of the image, or \"SIGTERM\")")
 	cmd.Flags().Int("stop-timeout", 0, "Timeout (in seconds) to stop a container")
 
+	// #region healthcheck flags
+	cmd.Flags().String("health-cmd", "", `Command to run to check health ("NONE" to disable any container-specified HEALTHCHECK)`)
+	cmd.Flags().Duration("health-interval", 0, "Time between running the check (ms|s|m|h) (default 30s)")
+	cmd.Flags().Duration("health-timeout", 0, "Maximum time to allow one check to run (ms|s|m|h) (default 30s)")
+	cmd.Flags().Duration("health-start-period", 0, "Start period for the container to initialize before starting health-retries countdown (ms|s|m|h) (default 0s)")
+	cmd.Flags().Int("health-retries", 0, "Consecutive failures needed to report unhealthy (default 3)")
+	cmd.Flags().Bool("no-healthcheck", false, "Disable any container-specified HEALTHCHECK")
+	// #endregion
+
 	// #region for init process
 	cmd.Flags().Bool("init", false, "Run an init process inside the container, Default to use tini")
 	cmd.Flags().String("init-binary", tiniInitBinary, "The custom binary to use as the init process")
@@ -123,14 +139,17 @@ func setCreateFlags(cmd *cobra.Command) {
 
 	// #region network flags
 	// network (net) is defined as StringSlice, not StringArray, to allow specifying "--network=cni1,cni2"
-	cmd.Flags().StringSlice("network", []string{netutil.DefaultNetworkName}, `Connect a container to a network ("bridge"|"host"|"none"|<CNI>)`)
+	cmd.Flags().StringSlice("network", []string{netutil.DefaultNetworkName}, `Connect a container to a network ("bridge"|"host"|"none"|"container:<container>"|<CNI>)`)
 	cmd.RegisterFlagCompletionFunc("network", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return shellCompleteNetworkNames(cmd, []string{})
 	})
-	cmd.Flags().StringSlice("net", []string{netutil.DefaultNetworkName}, `Connect a container to a network ("bridge"|"host"|"none"|<CNI>)`)
+	cmd.Flags().StringSlice("net", []string{netutil.DefaultNetworkName}, `Connect a container to a network ("bridge"|"host"|"none"|"container:<container>"|<CNI>)`)
 	cmd.RegisterFlagCompletionFunc("net", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return shellCompleteNetworkNames(cmd, []string{})
 	})
+	// We allow for both "--network-alias" and "--net-alias", like Docker.
+	cmd.Flags().StringSlice("network-alias", nil, "Add network-scoped alias for the container (only for user-defined networks)")
+	cmd.Flags().StringSlice("net-alias", nil, "Add network-scoped alias for the container (only for user-defined networks)")
 	// dns is defined as StringSlice, not StringArray, to allow specifying "--dns=1.1.1.1,8.8.8.8" (compatible with Podman)
 	cmd.Flags().StringSlice("dns", nil, "Set custom DNS servers")
 	cmd.Flags().StringSlice("dns-search", nil, "Set custom DNS search domains")
@@ -154,7 +173,9 @@ func setCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().String("memory-reservation", "", "Memory soft limit")
 	cmd.Flags().String("memory-swap", "", "Swap limit equal to memory plus swap: '-1' to enable unlimited swap")
 	cmd.Flags().Int64("memory-swappiness", -1, "Tune container memory swappiness (0 to 100) (default -1)")
+	cmd.Flags().String("kernel-memory", "", "Kernel memory limit (deprecated, cgroup v1 only)")
 	cmd.Flags().Bool("oom-kill-disable", false, "Disable OOM Killer")
+	cmd.Flags().Int("oom-score-adj", 0, "Tune container's OOM preferences (-1000 to 1000, rootless: the value of the rootless containerd to 1000)")
 	cmd.Flags().String("pid", "", "PID namespace to use")
 	cmd.RegisterFlagCompletionFunc("pid", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"host"}, cobra.ShellCompDirectiveNoFileComp
@@ -162,6 +183,10 @@ func setCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().Int64("pids-limit", -1, "Tune container pids limit (set -1 for unlimited)")
 	cmd.Flags().StringSlice("cgroup-conf", nil, "Configure cgroup v2 (key=value)")
 	cmd.Flags().Uint16("blkio-weight", 0, "Block IO (relative weight), between 10 and 1000, or 0 to disable (default 0)")
+	cmd.Flags().StringArray("device-read-bps", nil, "Limit read rate (bytes per second) from a device (format: <device-path>:<number>[<unit>], e.g., /dev/sda:1mb)")
+	cmd.Flags().StringArray("device-write-bps", nil, "Limit write rate (bytes per second) to a device (format: <device-path>:<number>[<unit>], e.g., /dev/sda:1mb)")
+	cmd.Flags().StringArray("device-read-iops", nil, "Limit read rate (IO per second) from a device (format: <device-path>:<number>)")
+	cmd.Flags().StringArray("device-write-iops", nil, "Limit write rate (IO per second) to a device (format: <device-path>:<number>)")
 	cmd.Flags().String("cgroupns", defaults.CgroupnsMode(), `Cgroup namespace to use, the default depends on the cgroup version ("host"|"private")`)
 	cmd.RegisterFlagCompletionFunc("cgroupns", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"host", "private"}, cobra.ShellCompDirectiveNoFileComp
@@ -171,6 +196,8 @@ func setCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().Uint64("cpu-shares", 0, "CPU shares (relative weight)")
 	cmd.Flags().Int64("cpu-quota", -1, "Limit CPU CFS (Completely Fair Scheduler) quota")
 	cmd.Flags().Uint64("cpu-period", 0, "Limit CPU CFS (Completely Fair Scheduler) period")
+	cmd.Flags().Int64("cpu-rt-runtime", 0, "Limit CPU real-time runtime in microseconds (cgroup v1 only)")
+	cmd.Flags().Uint64("cpu-rt-period", 0, "Limit CPU real-time period in microseconds (cgroup v1 only)")
 	// device is defined as StringSlice, not StringArray, to allow specifying "--device=DEV1,DEV2" (compatible with Podman)
 	cmd.Flags().StringSlice("device", nil, "Add a host device to the container")
 	// ulimit is defined as StringSlice, not StringArray, to allow specifying "--ulimit=ULIMIT1,ULIMIT2" (compatible with Podman)
@@ -194,10 +221,15 @@ func setCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSlice("cap-drop", []string{}, "Drop Linux capabilities")
 	cmd.RegisterFlagCompletionFunc("cap-drop", capShellComplete)
 	cmd.Flags().Bool("privileged", false, "Give extended privileges to this container")
+	cmd.Flags().String("userns-remap", "", `Remap the container root to the subordinate ids of "USER[:GROUP]" in /etc/subuid and /etc/subgid (rootful only)`)
 	// #endregion
 
 	// #region runtime flags
 	cmd.Flags().String("runtime", defaults.Runtime, "Runtime to use for this container, e.g. \"crun\", or \"io.containerd.runsc.v1\"")
+	cmd.Flags().String("isolation", "default", `Container isolation technology ("default"|"process"|"hyperv"), only "default" is supported on Linux`)
+	cmd.RegisterFlagCompletionFunc("isolation", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"default", "process", "hyperv"}, cobra.ShellCompDirectiveNoFileComp
+	})
 	// sysctl needs to be StringArray, not StringSlice, to prevent "foo=foo1,foo2" from being split to {"foo=foo1", "foo2"}
 	cmd.Flags().StringArray("sysctl", nil, "Sysctl options")
 	// gpus needs to be StringArray, not StringSlice, to prevent "capabilities=utility,device=DEV" from being split to {"capabilities=utility", "device=DEV"}
@@ -213,6 +245,7 @@ func setCreateFlags(cmd *cobra.Command) {
 	// tmpfs needs to be StringArray, not StringSlice, to prevent "/foo:size=64m,exec" from being split to {"/foo:size=64m", "exec"}
 	cmd.Flags().StringArray("tmpfs", nil, "Mount a tmpfs directory")
 	cmd.Flags().StringArray("mount", nil, "Attach a filesystem mount to the container")
+	cmd.Flags().Bool("expand-mount-env", false, "Expand the environment variables like ${VAR} and the leading ~ in the paths of --volume and --mount")
 	// #endregion
 
 	// rootfs flags
@@ -225,7 +258,9 @@ func setCreateFlags(cmd *cobra.Command) {
 	// entrypoint StringArray is an internal implementation to support `nerdctl compose` entrypoint yaml filed with multiple strings
 	// users are not expected to specify multiple --entrypoint flags manually.
 	cmd.Flags().StringArray("entrypoint", nil, "Overwrite the default ENTRYPOINT of the image")
-	cmd.Flags().StringP("workdir", "w", "", "Working directory inside the container")
+	workdir := new(string)
+	cmd.Flags().StringVarP(workdir, "workdir", "w", "", "Working directory inside the container. A relative path is resolved against the WORKDIR of the image")
+	cmd.Flags().StringVar(workdir, "chdir", "", "Alias of --workdir")
 	// env needs to be StringArray, not StringSlice, to prevent "FOO=foo1,foo2" from being split to {"FOO=foo1", "foo2"}
 	cmd.Flags().StringArrayP("env", "e", nil, "Set environment variables")
 	// add-host is defined as StringSlice, not StringArray, to allow specifying "--add-host=HOST1:IP1,HOST2:IP2" (compatible with Podman)
@@ -243,6 +278,9 @@ func setCreateFlags(cmd *cobra.Command) {
 
 	// label-file is defined as StringSlice, not StringArray, to allow specifying "--env-file=FILE1,FILE2" (compatible with Podman)
 	cmd.Flags().StringSlice("label-file", nil, "Set metadata on container from file")
+	// annotation needs to be StringArray, not StringSlice, to prevent "foo=foo1,foo2" from being split to {"foo=foo1", "foo2"}
+	cmd.Flags().StringArray("annotation", nil, "Add an OCI annotation to the container (e.g., \"io.katacontainers.config.hypervisor.default_memory=4096\")")
+	cmd.Flags().StringSlice("annotation-file", nil, "Read in a line delimited file of OCI annotations")
 	cmd.Flags().String("cidfile", "", "Write the container ID to the file")
 	// #endregion
 
@@ -266,6 +304,11 @@ func setCreateFlags(cmd *cobra.Command) {
 	})
 	cmd.Flags().String("cosign-key", "", "Path to the public key file, KMS, URI or Kubernetes Secret for --verify=cosign")
 	// #endregion
+
+	// #region decryption flags
+	// decryption-key is defined as StringSlice, not StringArray, to allow specifying "--decryption-key=KEY1,KEY2" (compatible with `nerdctl image decrypt --key`)
+	cmd.Flags().StringSlice("decryption-key", nil, "A private key's filename and an optional password separated by colon (<KEY>[:<PWDDESC>]), for running encrypted images")
+	// #endregion
 }
 
 // runAction is heavily based on ctr implementation:
@@ -277,6 +320,9 @@ func runAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := platformutil.CheckBinfmt(platform); err != nil {
+		return err
+	}
 	client, ctx, cancel, err := newClientWithPlatform(cmd, platform)
 	if err != nil {
 		return err
@@ -287,6 +333,13 @@ func runAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	attach, err := cmd.Flags().GetStringSlice("attach")
+	if err != nil {
+		return err
+	}
+	if flagD && len(attach) > 0 {
+		return errors.New("conflicting options: cannot specify both --attach and --detach")
+	}
 	flagI, err := cmd.Flags().GetBool("interactive")
 	if err != nil {
 		return err
@@ -295,6 +348,29 @@ func runAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	attachStreams, err := parseAttach(attach, flagI)
+	if err != nil {
+		return err
+	}
+	rm, err := cmd.Flags().GetBool("rm")
+	if err != nil {
+		return err
+	}
+	if rm {
+		restartValue, err := cmd.Flags().GetString("restart")
+		if err != nil {
+			return err
+		}
+		if restartValue != "" && restartValue != "no" {
+			return errors.New("flag --restart and --rm cannot be specified together")
+		}
+	}
+	if flagD && flagI {
+		// nerdctl does not support attaching to a running container yet, so nothing can write to the stdin.
+		// Like `docker run -dit`, a TTY without any input keeps shells (e.g., `sh`) running in the background.
+		logrus.Warn("ignoring -i, as attaching to a container started with -d is not supported yet")
+		flagI = false
+	}
 	container, err := createContainer(cmd, ctx, client, args, platform, flagI, flagT, flagD)
 	if err != nil {
 		return err
@@ -307,24 +383,28 @@ func runAction(cmd *cobra.Command, args []string) error {
 	logURI := lab[labels.LogURI]
 
 	id := container.ID()
-	rm, err := cmd.Flags().GetBool("rm")
+	cidfile, err := cmd.Flags().GetString("cidfile")
 	if err != nil {
 		return err
 	}
-	if rm {
-		if flagD {
-			return errors.New("flag -d and --rm cannot be specified together")
-		}
+	if rm && !flagD {
 		defer func() {
 			ns := lab[labels.Namespace]
 			if err := removeContainer(cmd, ctx, container, ns, true, true); err != nil {
 				logrus.WithError(err).Warnf("failed to remove container %s", id)
+				return
+			}
+			// the cidfile is kept when the container could not be removed
+			if cidfile != "" {
+				if err := os.Remove(cidfile); err != nil && !errors.Is(err, os.ErrNotExist) {
+					logrus.WithError(err).Warnf("failed to remove the container ID file %q", cidfile)
+				}
 			}
 		}()
 	}
 
 	var con console.Console
-	if flagT {
+	if flagT && !flagD {
 		con = console.Current()
 		defer con.Reset()
 		if err := con.SetRaw(); err != nil {
@@ -332,7 +412,7 @@ func runAction(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	task, err := taskutil.NewTask(ctx, client, container, flagI, flagT, flagD, con, logURI)
+	task, err := taskutil.NewTask(ctx, client, container, flagI, flagT, flagD, attachStreams, con, logURI)
 	if err != nil {
 		return err
 	}
@@ -351,23 +431,38 @@ func runAction(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// The pty of the task is created with the task, so the initial size of the client console
+	// is set before starting the task, to avoid the process seeing the default size.
+	if flagT && !flagD {
+		if err := tasks.HandleConsoleResize(ctx, task, con); err != nil {
+			logrus.WithError(err).Error("console resize")
+		}
+	}
+
 	if err := task.Start(ctx); err != nil {
 		return err
 	}
 
 	if flagD {
+		if rm {
+			// the container is removed by the background process, as this process exits now
+			if err := startRemoveOnExitMonitor(cmd, id, cidfile); err != nil {
+				return fmt.Errorf("failed to start the process to remove container %s on exit: %w", id, err)
+			}
+		}
 		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", id)
 		return nil
 	}
-	if flagT {
-		if err := tasks.HandleConsoleResize(ctx, task, con); err != nil {
-			logrus.WithError(err).Error("console resize")
-		}
-	} else {
+	if !flagT {
 		sigc := commands.ForwardAllSignals(ctx, task)
 		defer commands.StopCatch(sigc)
 	}
 	status := <-statusC
+	if !rm {
+		if err := updateContainerExitCodeLabel(ctx, container, task, status); err != nil {
+			logrus.WithError(err).Warnf("failed to record the exit code of container %s", id)
+		}
+	}
 	code, _, err := status.Result()
 	if err != nil {
 		return err
@@ -380,7 +475,29 @@ func runAction(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd.Client, args []string, platform string, flagI, flagT, flagD bool) (containerd.Container, error) {
+// parseAttach parses the values of `--attach` ("STDIN", "STDOUT", "STDERR", case-insensitive).
+// parseAttach returns nil, i.e., all the streams, when no value is specified.
+// STDIN requires -i.
+func parseAttach(values []string, flagI bool) ([]string, error) {
+	var streams []string
+	for _, v := range values {
+		stream := strings.ToUpper(v)
+		switch stream {
+		case "STDIN", "STDOUT", "STDERR":
+			if !strutil.InStringSlice(streams, stream) {
+				streams = append(streams, stream)
+			}
+		default:
+			return nil, fmt.Errorf("invalid argument %q for \"-a, --attach\" flag: valid streams are STDIN, STDOUT and STDERR", v)
+		}
+	}
+	if !flagI && strutil.InStringSlice(streams, "STDIN") {
+		return nil, errors.New("attaching STDIN requires -i (--interactive)")
+	}
+	return streams, nil
+}
+
+func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd.Client, args []string, platform string, flagI, flagT, flagD bool) (_ containerd.Container, retErr error) {
 	// simulate the behavior of double dash
 	newArg := []string{}
 	if len(args) >= 2 && args[1] == "--" {
@@ -389,6 +506,10 @@ func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd
 		args = newArg
 	}
 
+	if err := validateIsolation(cmd); err != nil {
+		return nil, err
+	}
+
 	ns, err := cmd.Flags().GetString("namespace")
 	if err != nil {
 		return nil, err
@@ -408,6 +529,14 @@ func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd
 		if err := writeCIDFile(cidfile, id); err != nil {
 			return nil, err
 		}
+		defer func() {
+			// do not leave the cidfile of the container that failed to be created
+			if retErr != nil {
+				if err := os.Remove(cidfile); err != nil && !errors.Is(err, os.ErrNotExist) {
+					logrus.WithError(err).Warnf("failed to remove the container ID file %q", cidfile)
+				}
+			}
+		}()
 	}
 
 	dataStore, err := getDataStore(cmd)
@@ -444,7 +573,17 @@ func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd
 		return nil, err
 	}
 	if wd != "" {
-		opts = append(opts, oci.WithProcessCwd(wd))
+		var imageWorkdir string
+		if ensuredImage != nil {
+			imageWorkdir = ensuredImage.ImageConfig.WorkingDir
+		}
+		opts = append(opts, oci.WithProcessCwd(resolveWorkdir(imageWorkdir, wd)))
+	}
+
+	// envs is used for resolving `--log-opt env=...`
+	var envs []string
+	if ensuredImage != nil {
+		envs = append(envs, ensuredImage.ImageConfig.Env...)
 	}
 
 	envFile, err := cmd.Flags().GetStringSlice("env-file")
@@ -457,6 +596,7 @@ func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd
 			return nil, err
 		}
 		opts = append(opts, oci.WithEnv(env))
+		envs = append(envs, env...)
 	}
 
 	env, err := cmd.Flags().GetStringArray("env")
@@ -465,49 +605,66 @@ func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd
 	}
 	if env := strutil.DedupeStrSlice(env); len(env) > 0 {
 		opts = append(opts, oci.WithEnv(env))
-	}
-
-	if flagI {
-		if flagD {
-			return nil, errors.New("currently flag -i and -d cannot be specified together (FIXME)")
-		}
+		envs = append(envs, env...)
 	}
 
 	if flagT {
-		if flagD {
-			return nil, errors.New("currently flag -t and -d cannot be specified together (FIXME)")
-		}
 		opts = append(opts, oci.WithTTY)
 	}
 
-	mountOpts, anonVolumes, mountPoints, err := generateMountOpts(cmd, ctx, client, ensuredImage)
+	mountOpts, anonVolumes, mountPoints, err := generateMountOpts(cmd, ctx, client, ensuredImage, id)
 	if err != nil {
 		return nil, err
 	} else {
 		opts = append(opts, mountOpts...)
 	}
+	if err := remapUsernsMountPoints(cmd, mountPoints); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// the volumes mounted by plugins are usually unmounted by `nerdctl rm`,
+		// which is never called for the container that failed to be created
+		if retErr != nil {
+			volStore, err := getVolumeStore(cmd)
+			if err != nil {
+				logrus.WithError(err).Warn("failed to unmount the volumes of the container that failed to be created")
+				return
+			}
+			unmountPluginMountPoints(volStore, id, mountPoints)
+		}
+	}()
 
+	// json-file is the built-in and default log driver for nerdctl
+	logDriver, err := cmd.Flags().GetString("log-driver")
+	if err != nil {
+		return nil, err
+	}
+	logOptMap, err := parseKVStringsMapFromLogOpt(cmd, logDriver)
+	if err != nil {
+		return nil, err
+	}
+	// the driver is created (and the opts are validated) even when the logs are not forwarded to it
+	logDriverInst, err := logging.GetDriver(logDriver, logOptMap)
+	if err != nil {
+		return nil, err
+	}
 	var logURI string
 	if flagD {
-		// json-file is the built-in and default log driver for nerdctl
-		logDriver, err := cmd.Flags().GetString("log-driver")
-		if err != nil {
+		if err := logDriverInst.Init(dataStore, ns, id); err != nil {
 			return nil, err
 		}
-		logOptMap, err := parseKVStringsMapFromLogOpt(cmd, logDriver)
+		labelMap, err := readKVStringsMapfFromLabel(cmd)
 		if err != nil {
 			return nil, err
 		}
-		logDriverInst, err := logging.GetDriver(logDriver, logOptMap)
+		logAttrs, err := logging.ExtraAttributes(logOptMap, labelMap, envs)
 		if err != nil {
 			return nil, err
 		}
-		if err := logDriverInst.Init(dataStore, ns, id); err != nil {
-			return nil, err
-		}
 		logConfig := &logging.LogConfig{
 			Driver: logDriver,
 			Opts:   logOptMap,
+			Attrs:  logAttrs,
 		}
 		logConfigB, err := json.Marshal(logConfig)
 		if err != nil {
@@ -544,11 +701,22 @@ func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd
 	}
 	cOpts = append(cOpts, withStop(stopSignal, stopTimeout, ensuredImage))
 
-	netOpts, netSlice, ipAddress, ports, err := generateNetOpts(cmd, dataStore, stateDir, ns, id)
+	healthcheckSpecOpts, healthcheckOpts, err := generateHealthcheckOpts(cmd)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, healthcheckSpecOpts...)
+	cOpts = append(cOpts, healthcheckOpts...)
+
+	netOpts, netSlice, ipAddress, endpoints, ports, err := generateNetOpts(ctx, cmd, client, dataStore, stateDir, ns, id)
 	if err != nil {
 		return nil, err
 	}
 	opts = append(opts, netOpts...)
+	networkAliases, err := getNetworkAliases(cmd, netSlice)
+	if err != nil {
+		return nil, err
+	}
 
 	hostname := id[0:12]
 	customHostname, err := cmd.Flags().GetString("hostname")
@@ -645,13 +813,29 @@ func createContainer(cmd *cobra.Command, ctx context.Context, client *containerd
 			return nil, err
 		}
 	}
-	ilOpt, err := withInternalLabels(ns, name, hostname, stateDir, extraHosts, netSlice, ipAddress, ports, logURI, anonVolumes, pidFile, platform, mountPoints)
+	ilOpt, err := withInternalLabels(ns, name, hostname, stateDir, extraHosts, netSlice, networkAliases, ipAddress, endpoints, ports, logURI, anonVolumes, pidFile, platform, mountPoints)
 	if err != nil {
 		return nil, err
 	}
 	cOpts = append(cOpts, ilOpt)
+	rm, err := cmd.Flags().GetBool("rm")
+	if err != nil {
+		return nil, err
+	}
+	if rm {
+		// recorded so that `nerdctl update --restart` can refuse the container, as in Docker
+		cOpts = append(cOpts, containerd.WithAdditionalContainerLabels(map[string]string{labels.AutoRemove: "true"}))
+	}
 
 	opts = append(opts, propagateContainerdLabelsToOCIAnnotations())
+	// explicit annotations take precedence over the labels
+	annotations, err := readAnnotations(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(annotations) > 0 {
+		opts = append(opts, oci.WithAnnotations(annotations))
+	}
 
 	var s specs.Spec
 	spec := containerd.WithSpec(&s, opts...)
@@ -687,21 +871,35 @@ func generateRootfsOpts(ctx context.Context, client *containerd.Client, platform
 			return nil, nil, nil, err
 		}
 		rawRef := args[0]
-		ensured, err = ensureImage(cmd, ctx, client, rawRef, ocispecPlatforms, pull, nil, false)
+		decryptPayload, err := generateDecryptPayload(cmd)
 		if err != nil {
 			return nil, nil, nil, err
 		}
+		ensured, err = ensureImage(cmd, ctx, client, rawRef, ocispecPlatforms, pull, nil, false, decryptPayload)
+		if err != nil {
+			if decryptPayload != nil && errutil.IsErrDecryptionFailed(err) {
+				return nil, nil, nil, fmt.Errorf("failed to decrypt image %q with the keys specified by --decryption-key: %w", rawRef, err)
+			}
+			return nil, nil, nil, err
+		}
 	}
 	var (
 		opts  []oci.SpecOpts
 		cOpts []containerd.NewContainerOpts
 	)
 	if !imageless {
+		usernsOpts, snapshotOpt, err := generateUsernsRemapOpts(cmd, id, ensured.Image)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if snapshotOpt == nil {
+			snapshotOpt = containerd.WithNewSnapshot(id, ensured.Image)
+		}
+		opts = append(opts, usernsOpts...)
 		cOpts = append(cOpts,
 			containerd.WithImage(ensured.Image),
 			containerd.WithSnapshotter(ensured.Snapshotter),
-			containerd.WithNewSnapshot(id, ensured.Image),
-			containerd.WithImageStopSignal(ensured.Image, "SIGTERM"),
+			snapshotOpt,
 		)
 
 		if len(ensured.ImageConfig.Env) == 0 {
@@ -717,6 +915,11 @@ func generateRootfsOpts(ctx context.Context, client *containerd.Client, platform
 			}
 		}
 	} else {
+		if usernsRemap, err := cmd.Flags().GetString("userns-remap"); err != nil {
+			return nil, nil, nil, err
+		} else if usernsRemap != "" {
+			return nil, nil, nil, errors.New("--userns-remap cannot be used with --rootfs")
+		}
 		absRootfs, err := filepath.Abs(args[0])
 		if err != nil {
 			return nil, nil, nil, err
@@ -736,10 +939,7 @@ func generateRootfsOpts(ctx context.Context, client *containerd.Client, platform
 		if !imageless {
 			opts = append(opts, oci.WithImageConfig(ensured.Image))
 		}
-		var processArgs []string
-		if len(entrypoint) != 0 {
-			processArgs = append(processArgs, entrypoint...)
-		}
+		processArgs := parseEntrypoint(entrypoint)
 		if len(args) > 1 {
 			processArgs = append(processArgs, args[1:]...)
 		}
@@ -908,6 +1108,9 @@ func withContainerLabels(cmd *cobra.Command) ([]containerd.NewContainerOpts, err
 	if err != nil {
 		return nil, err
 	}
+	// the exit code is recorded by nerdctl, and must not be set for a new container
+	delete(labelMap, labels.ExitCode)
+	delete(labelMap, labels.OOMKilled)
 	o := containerd.WithAdditionalContainerLabels(labelMap)
 	return []containerd.NewContainerOpts{o}, nil
 }
@@ -931,6 +1134,41 @@ func readKVStringsMapfFromLabel(cmd *cobra.Command) (map[string]string, error) {
 	return strutil.ConvertKVStringsToMap(labels), nil
 }
 
+// readAnnotations reads `--annotation` and `--annotation-file`.
+// The annotations specified with `--annotation` take precedence over the ones read from the files.
+func readAnnotations(cmd *cobra.Command) (map[string]string, error) {
+	annotations, err := cmd.Flags().GetStringArray("annotation")
+	if err != nil {
+		return nil, err
+	}
+	annotationFiles, err := cmd.Flags().GetStringSlice("annotation-file")
+	if err != nil {
+		return nil, err
+	}
+	kvs, err := dopts.ReadKVStrings(strutil.DedupeStrSlice(annotationFiles), annotations)
+	if err != nil {
+		return nil, err
+	}
+	m := strutil.ConvertKVStringsToMap(kvs)
+	for k := range m {
+		if err := validateAnnotationKey(k); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+var annotationKeyRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]*(\.[a-zA-Z0-9_-]+)+(/[a-zA-Z0-9_.-]+)?$`)
+
+// validateAnnotationKey validates that the annotation key is in the reverse domain notation,
+// as recommended by the OCI runtime spec, e.g., "io.katacontainers.config.hypervisor.default_memory".
+func validateAnnotationKey(k string) error {
+	if !annotationKeyRegexp.MatchString(k) {
+		return fmt.Errorf("invalid annotation key %q, expected the reverse domain notation (e.g., \"com.example.foo\")", k)
+	}
+	return nil
+}
+
 // parseKVStringsMapFromLogOpt parse log options KV entries and convert to Map
 func parseKVStringsMapFromLogOpt(cmd *cobra.Command, logDriver string) (map[string]string, error) {
 	logOptArray, err := cmd.Flags().GetStringArray("log-opt")
@@ -952,12 +1190,13 @@ func withStop(stopSignal string, stopTimeout int, ensuredImage *imgutil.EnsuredI
 		if c.Labels == nil {
 			c.Labels = make(map[string]string)
 		}
-		var err error
+		var image containerd.Image
 		if ensuredImage != nil {
-			stopSignal, err = containerd.GetOCIStopSignal(ctx, ensuredImage.Image, stopSignal)
-			if err != nil {
-				return err
-			}
+			image = ensuredImage.Image
+		}
+		stopSignal, err := resolveStopSignal(ctx, stopSignal, image)
+		if err != nil {
+			return err
 		}
 		c.Labels[containerd.StopSignalLabel] = stopSignal
 		if stopTimeout != 0 {
@@ -967,7 +1206,27 @@ func withStop(stopSignal string, stopTimeout int, ensuredImage *imgutil.EnsuredI
 	}
 }
 
-func withInternalLabels(ns, name, hostname, containerStateDir string, extraHosts, networks []string, ipAddress string, ports []gocni.PortMapping, logURI string, anonVolumes []string, pidFile, platform string, mountPoints []*mountutil.Processed) (containerd.NewContainerOpts, error) {
+// resolveStopSignal returns the signal to stop the container.
+// When stopSignal is empty, it falls back to the StopSignal of the image (if any), and then to SIGTERM.
+// The signal can be either a name ("SIGTERM", "TERM") or a number ("15").
+func resolveStopSignal(ctx context.Context, stopSignal string, image containerd.Image) (string, error) {
+	if stopSignal == "" && image != nil {
+		var err error
+		stopSignal, err = containerd.GetOCIStopSignal(ctx, image, "")
+		if err != nil {
+			return "", err
+		}
+	}
+	if stopSignal == "" {
+		stopSignal = defaultStopSignal
+	}
+	if _, err := signal.ParseSignal(stopSignal); err != nil {
+		return "", fmt.Errorf("invalid stop signal %q: %w", stopSignal, err)
+	}
+	return stopSignal, nil
+}
+
+func withInternalLabels(ns, name, hostname, containerStateDir string, extraHosts, networks, networkAliases []string, ipAddress string, endpoints map[string]netutil.EndpointOpts, ports []gocni.PortMapping, logURI string, anonVolumes []string, pidFile, platform string, mountPoints []*mountutil.Processed) (containerd.NewContainerOpts, error) {
 	m := make(map[string]string)
 	m[labels.Namespace] = ns
 	if name != "" {
@@ -985,6 +1244,13 @@ func withInternalLabels(ns, name, hostname, containerStateDir string, extraHosts
 		return nil, err
 	}
 	m[labels.Networks] = string(networksJSON)
+	if len(networkAliases) > 0 {
+		networkAliasesJSON, err := json.Marshal(networkAliases)
+		if err != nil {
+			return nil, err
+		}
+		m[labels.NetworkAliases] = string(networkAliasesJSON)
+	}
 	if len(ports) > 0 {
 		portsJSON, err := json.Marshal(ports)
 		if err != nil {
@@ -1011,6 +1277,14 @@ func withInternalLabels(ns, name, hostname, containerStateDir string, extraHosts
 		m[labels.IPAddress] = ipAddress
 	}
 
+	if len(endpoints) > 0 {
+		endpointsJSON, err := json.Marshal(endpoints)
+		if err != nil {
+			return nil, err
+		}
+		m[labels.NetworkEndpoints] = string(endpointsJSON)
+	}
+
 	m[labels.Platform], err = platformutil.NormalizeString(platform)
 	if err != nil {
 		return nil, err
@@ -1046,9 +1320,18 @@ func dockercompatMounts(mountPoints []*mountutil.Processed) []dockercompat.Mount
 			reuslt[i].Name = mp.AnonymousVolume
 		}
 
-		// volume only support local driver
 		if mp.Type == "volume" {
-			reuslt[i].Driver = "local"
+			reuslt[i].Driver = mp.Driver
+		}
+
+		// the propagation of bind mounts is set to the default ("rprivate") even when not specified in Mode
+		if mp.Type == "bind" {
+			for _, opt := range mp.Mount.Options {
+				switch opt {
+				case "private", "rprivate", "shared", "rshared", "slave", "rslave":
+					reuslt[i].Propagation = opt
+				}
+			}
 		}
 	}
 	return reuslt
@@ -1060,25 +1343,26 @@ func propagateContainerdLabelsToOCIAnnotations() oci.SpecOpts {
 	}
 }
 
+// writeCIDFile writes the container ID to the file.
+// The file is created exclusively, so that concurrent runs with the same cidfile do not overwrite it.
+// A partially written file is removed on errors.
 func writeCIDFile(path, id string) error {
-	if _, err := os.Stat(path); err == nil {
-		return fmt.Errorf("container ID file found, make sure the other container isn't running or delete %s", path)
-	} else if errors.Is(err, os.ErrNotExist) {
-		f, err := os.Create(path)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		if err != nil {
-			return fmt.Errorf("failed to create the container ID file: %s", err)
-		}
-		if _, err := f.WriteString(id); err != nil {
-			return err
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("container ID file found, make sure the other container isn't running or delete %s", path)
 		}
-		return nil
-	} else {
-		return err
+		return fmt.Errorf("failed to create the container ID file: %w", err)
 	}
+	_, err = f.WriteString(id)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write the container ID file: %w", err)
+	}
+	return nil
 }
 
 func parseEnvVars(paths []string) ([]string, error) {
@@ -1105,3 +1389,57 @@ func parseEnvVars(paths []string) ([]string, error) {
 	}
 	return vars, nil
 }
+
+// parseEntrypoint parses the values of `--entrypoint` into the process args.
+// A value in the JSON array form (e.g., `["/bin/sh","-c"]`) is expanded to multiple args,
+// otherwise the value is treated as a single executable.
+// An empty value resets the ENTRYPOINT of the image.
+func parseEntrypoint(values []string) []string {
+	var args []string
+	for _, v := range values {
+		if strings.HasPrefix(strings.TrimSpace(v), "[") {
+			var arr []string
+			if err := json.Unmarshal([]byte(v), &arr); err == nil {
+				args = append(args, arr...)
+				continue
+			}
+		}
+		if v != "" {
+			args = append(args, v)
+		}
+	}
+	return args
+}
+
+// resolveWorkdir resolves a relative --workdir against the WORKDIR of the image.
+func resolveWorkdir(imageWorkdir, wd string) string {
+	if filepath.IsAbs(wd) {
+		return wd
+	}
+	if imageWorkdir == "" {
+		imageWorkdir = string(filepath.Separator)
+	}
+	return filepath.Join(imageWorkdir, wd)
+}
+
+// validateIsolation validates --isolation.
+// The isolation technologies are specific to Windows, so "default" is accepted as a no-op on other platforms.
+func validateIsolation(cmd *cobra.Command) error {
+	isolation, err := cmd.Flags().GetString("isolation")
+	if err != nil {
+		return err
+	}
+	switch isolation {
+	case "", "default":
+		return nil
+	case "process":
+		// process isolation is the default on Windows
+		if runtime.GOOS == "windows" {
+			return nil
+		}
+	case "hyperv":
+	default:
+		return fmt.Errorf("invalid isolation %q (valid values: \"default\", \"process\", \"hyperv\")", isolation)
+	}
+	return fmt.Errorf("isolation %q is unsupported on this platform (%s)", isolation, runtime.GOOS)
+}
@@ -23,21 +23,26 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/oci"
 	"github.com/containerd/nerdctl/pkg/infoutil"
 	"github.com/containerd/nerdctl/pkg/rootlessutil"
+	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/go-units"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
 )
 
 type customMemoryOptions struct {
 	MemoryReservation *int64
 	MemorySwappiness  *uint64
+	KernelMemory      *int64
 	disableOOMKiller  *bool
 }
 
@@ -69,6 +74,11 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 		return nil, err
 	}
 
+	kernelMem, err := cmd.Flags().GetString("kernel-memory")
+	if err != nil {
+		return nil, err
+	}
+
 	okd, err := cmd.Flags().GetBool("oom-kill-disable")
 	if err != nil {
 		return nil, err
@@ -86,7 +96,7 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 			return nil, errors.New("cgroup-manager \"none\" is only supported for rootless")
 		}
 
-		if cpus > 0.0 || memStr != "" || memSwap != "" || pidsLimit > 0 {
+		if cpus > 0.0 || memStr != "" || memSwap != "" || kernelMem != "" || pidsLimit > 0 {
 			logrus.Warn("cgroup manager is set to \"none\", discarding resource limit requests. " +
 				"(Hint: enable cgroup v2 with systemd: https://rootlesscontaine.rs/getting-started/common/cgroup2/)")
 		}
@@ -127,6 +137,11 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 		return nil, err
 	}
 	if cpuset != "" {
+		if online, err := os.ReadFile(onlineCPUsPath); err != nil {
+			logrus.WithError(err).Warnf("failed to read the online CPUs, skipping the validation of cpuset-cpus %q", cpuset)
+		} else if err := validateCPUSet(cpuset, string(online)); err != nil {
+			return nil, err
+		}
 		opts = append(opts, oci.WithCPUs(cpuset))
 	}
 	cpuQuota, err := cmd.Flags().GetInt64("cpu-quota")
@@ -143,6 +158,27 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 		}
 		opts = append(opts, oci.WithCPUCFS(cpuQuota, cpuPeriod))
 	}
+	cpuRtRuntime, err := cmd.Flags().GetInt64("cpu-rt-runtime")
+	if err != nil {
+		return nil, err
+	}
+	cpuRtPeriod, err := cmd.Flags().GetUint64("cpu-rt-period")
+	if err != nil {
+		return nil, err
+	}
+	if cpuRtRuntime != 0 || cpuRtPeriod != 0 {
+		if infoutil.CgroupsVersion() != "1" {
+			return nil, errors.New("cpu-rt-runtime and cpu-rt-period are only supported with cgroup v1")
+		}
+		hostRtRuntime, hostRtPeriod, err := readCPURealtime(cpuCgroupV1Root)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateCPURealtime(cpuRtRuntime, cpuRtPeriod, hostRtRuntime, hostRtPeriod); err != nil {
+			return nil, err
+		}
+		opts = append(opts, withCPURealtime(cpuRtRuntime, cpuRtPeriod))
+	}
 	cpusetMems, err := cmd.Flags().GetString("cpuset-mems")
 	if err != nil {
 		return nil, err
@@ -152,18 +188,17 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 	}
 	var mem64 int64
 	if memStr != "" {
-		mem64, err = units.RAMInBytes(memStr)
+		mem64, err = parseMemoryBytes("memory", memStr, linuxMinMemory)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse memory bytes %q: %w", memStr, err)
+			return nil, err
 		}
 		opts = append(opts, oci.WithMemoryLimit(uint64(mem64)))
-
 	}
 	var memReserve64 int64
 	if memReserve != "" {
-		memReserve64, err = units.RAMInBytes(memReserve)
+		memReserve64, err = parseMemoryBytes("memory-reservation", memReserve, linuxMinMemory)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse memory bytes %q: %w", memReserve, err)
+			return nil, err
 		}
 	}
 	var memSwap64 int64
@@ -171,9 +206,9 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 		if memSwap == "-1" {
 			memSwap64 = -1
 		} else {
-			memSwap64, err = units.RAMInBytes(memSwap)
+			memSwap64, err = parseMemoryBytes("memory-swap", memSwap, 0)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse memory-swap bytes %q: %w", memSwap, err)
+				return nil, err
 			}
 			if mem64 > 0 && memSwap64 > 0 && memSwap64 < mem64 {
 				return nil, fmt.Errorf("minimum memoryswap limit should be larger than memory limit, see usage")
@@ -207,6 +242,19 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 	if okd {
 		customMemRes.disableOOMKiller = &okd
 	}
+	if kernelMem != "" {
+		kernelMem64, err := parseMemoryBytes("kernel-memory", kernelMem, linuxMinKernelMemory)
+		if err != nil {
+			return nil, err
+		}
+		// Like Docker, --kernel-memory is not an error on cgroup v2, to keep the existing scripts working.
+		if infoutil.CgroupsVersion() == "1" {
+			logrus.Warn("--kernel-memory is deprecated, as the kernel memory limit is deprecated in the kernel")
+			customMemRes.KernelMemory = &kernelMem64
+		} else {
+			logrus.Warn("--kernel-memory is ignored, as the kernel memory limit is not supported on cgroup v2")
+		}
+	}
 	opts = append(opts, withCustomMemoryResources(customMemRes))
 
 	if pidsLimit > 0 {
@@ -252,6 +300,12 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 	}
 	opts = append(opts, withBlkioWeight(blkioWeight))
 
+	throttle, err := generateBlkioThrottle(cmd)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, withBlkioThrottle(throttle))
+
 	cgroupns, err := cmd.Flags().GetString("cgroupns")
 	if err != nil {
 		return nil, err
@@ -272,16 +326,43 @@ func generateCgroupOpts(cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 	if err != nil {
 		return nil, err
 	}
+	var devPaths []string
 	for _, f := range device {
 		devPath, mode, err := parseDevice(f)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse device %q: %w", f, err)
 		}
+		devPaths = append(devPaths, devPath)
 		opts = append(opts, oci.WithLinuxDevice(devPath, mode))
 	}
+	if err := validateDevicesExist(devPaths); err != nil {
+		return nil, err
+	}
 	return opts, nil
 }
 
+// validateDevicesExist checks that every requested host device exists,
+// so that a missing device is reported before creating the container.
+func validateDevicesExist(devPaths []string) error {
+	var missing []string
+	for _, devPath := range devPaths {
+		if _, err := os.Stat(devPath); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to stat device %q: %w", devPath, err)
+			}
+			missing = append(missing, devPath)
+		}
+	}
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("device %q does not exist on the host (Hint: the host may lack the device, or the kernel module for it may not be loaded)", missing[0])
+	default:
+		return fmt.Errorf("devices %q do not exist on the host (Hint: the host may lack the devices, or the kernel modules for them may not be loaded)", missing)
+	}
+}
+
 func parseDevice(s string) (hostDevPath string, mode string, err error) {
 	mode = "rwm"
 	split := strings.Split(s, ":")
@@ -344,12 +425,225 @@ func withUnified(unified map[string]string) oci.SpecOpts {
 	}
 }
 
+// cpuCgroupV1Root is the cgroup v1 cpu controller of the host, which holds the RT bandwidth of the whole system.
+const cpuCgroupV1Root = "/sys/fs/cgroup/cpu"
+
+// readCPURealtime reads cpu.rt_runtime_us and cpu.rt_period_us from the cgroup v1 cpu controller dir.
+// hostRuntime is -1 when the RT bandwidth is not limited.
+func readCPURealtime(dir string) (hostRuntime int64, hostPeriod uint64, err error) {
+	runtimeB, err := os.ReadFile(filepath.Join(dir, "cpu.rt_runtime_us"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, 0, errors.New("the kernel does not support the CPU real-time scheduler for cgroups (CONFIG_RT_GROUP_SCHED)")
+		}
+		return 0, 0, err
+	}
+	periodB, err := os.ReadFile(filepath.Join(dir, "cpu.rt_period_us"))
+	if err != nil {
+		return 0, 0, err
+	}
+	hostRuntime, err = strconv.ParseInt(strings.TrimSpace(string(runtimeB)), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse cpu.rt_runtime_us: %w", err)
+	}
+	hostPeriod, err = strconv.ParseUint(strings.TrimSpace(string(periodB)), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse cpu.rt_period_us: %w", err)
+	}
+	return hostRuntime, hostPeriod, nil
+}
+
+// validateCPURealtime checks that the requested RT bandwidth (runtime per period) fits in the bandwidth of the host.
+// A zero period means the period of the host.
+func validateCPURealtime(runtime int64, period uint64, hostRuntime int64, hostPeriod uint64) error {
+	if runtime < 0 {
+		return fmt.Errorf("invalid cpu-rt-runtime %d, must not be negative", runtime)
+	}
+	if period == 0 {
+		period = hostPeriod
+	}
+	if uint64(runtime) > period {
+		return fmt.Errorf("cpu-rt-runtime (%d) cannot be higher than cpu-rt-period (%d)", runtime, period)
+	}
+	if hostRuntime < 0 || hostPeriod == 0 {
+		return nil
+	}
+	// runtime/period > hostRuntime/hostPeriod, without floating-point arithmetic
+	if uint64(runtime)*hostPeriod > uint64(hostRuntime)*period {
+		return fmt.Errorf("cpu-rt-runtime %dus per cpu-rt-period %dus exceeds the real-time bandwidth of the host (%dus per %dus)",
+			runtime, period, hostRuntime, hostPeriod)
+	}
+	return nil
+}
+
+// onlineCPUsPath lists the online CPUs of the host, in the same list format as `--cpuset-cpus` (e.g., "0-3,8").
+const onlineCPUsPath = "/sys/devices/system/cpu/online"
+
+// validateCPUSet validates that cpuset is well-formed and that all the CPUs in cpuset are listed in online.
+func validateCPUSet(cpuset, online string) error {
+	cpus, err := parsers.ParseUintList(cpuset)
+	if err != nil {
+		return fmt.Errorf("invalid cpuset-cpus %q: %w", cpuset, err)
+	}
+	onlineCPUs, err := parsers.ParseUintList(strings.TrimSpace(online))
+	if err != nil {
+		return fmt.Errorf("failed to parse the online CPUs %q: %w", online, err)
+	}
+	var offline []int
+	for cpu := range cpus {
+		if !onlineCPUs[cpu] {
+			offline = append(offline, cpu)
+		}
+	}
+	if len(offline) == 0 {
+		return nil
+	}
+	sort.Ints(offline)
+	offlineStr := make([]string, len(offline))
+	for i, cpu := range offline {
+		offlineStr[i] = strconv.Itoa(cpu)
+	}
+	return fmt.Errorf("invalid cpuset-cpus %q: CPU %s not online (online CPUs: %s)", cpuset, strings.Join(offlineStr, ","), strings.TrimSpace(online))
+}
+
+func withCPURealtime(runtime int64, period uint64) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if s.Linux.Resources == nil {
+			s.Linux.Resources = &specs.LinuxResources{}
+		}
+		if s.Linux.Resources.CPU == nil {
+			s.Linux.Resources.CPU = &specs.LinuxCPU{}
+		}
+		if runtime != 0 {
+			s.Linux.Resources.CPU.RealtimeRuntime = &runtime
+		}
+		if period != 0 {
+			s.Linux.Resources.CPU.RealtimePeriod = &period
+		}
+		return nil
+	}
+}
+
 func withBlkioWeight(blkioWeight uint16) oci.SpecOpts {
 	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
 		if blkioWeight == 0 {
 			return nil
 		}
-		s.Linux.Resources.BlockIO = &specs.LinuxBlockIO{Weight: &blkioWeight}
+		if s.Linux.Resources.BlockIO == nil {
+			s.Linux.Resources.BlockIO = &specs.LinuxBlockIO{}
+		}
+		s.Linux.Resources.BlockIO.Weight = &blkioWeight
+		return nil
+	}
+}
+
+// blkioThrottle is the parsed values of `--device-{read,write}-{bps,iops}`.
+type blkioThrottle struct {
+	ReadBps   []specs.LinuxThrottleDevice
+	WriteBps  []specs.LinuxThrottleDevice
+	ReadIOPS  []specs.LinuxThrottleDevice
+	WriteIOPS []specs.LinuxThrottleDevice
+}
+
+func generateBlkioThrottle(cmd *cobra.Command) (*blkioThrottle, error) {
+	var throttle blkioThrottle
+	for _, f := range []struct {
+		name    string
+		isBytes bool
+		dst     *[]specs.LinuxThrottleDevice
+	}{
+		{"device-read-bps", true, &throttle.ReadBps},
+		{"device-write-bps", true, &throttle.WriteBps},
+		{"device-read-iops", false, &throttle.ReadIOPS},
+		{"device-write-iops", false, &throttle.WriteIOPS},
+	} {
+		values, err := cmd.Flags().GetStringArray(f.name)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst, err = parseThrottleDevices(values, f.isBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", f.name, err)
+		}
+	}
+	return &throttle, nil
+}
+
+// parseThrottleDevices parses values like "/dev/sda:1mb" (isBytes) or "/dev/sda:1000" into the throttle entries.
+// The device is identified by major:minor, so the later value wins when the same device is specified twice.
+func parseThrottleDevices(values []string, isBytes bool) ([]specs.LinuxThrottleDevice, error) {
+	var res []specs.LinuxThrottleDevice
+	index := make(map[[2]int64]int)
+	for _, v := range values {
+		i := strings.LastIndex(v, ":")
+		if i <= 0 || i == len(v)-1 {
+			return nil, fmt.Errorf("invalid value %q, expected <device-path>:<number>", v)
+		}
+		devPath, rateStr := v[:i], v[i+1:]
+		if !filepath.IsAbs(devPath) {
+			return nil, fmt.Errorf("invalid value %q: %q is not an absolute path", v, devPath)
+		}
+		var (
+			rate uint64
+			err  error
+		)
+		if isBytes {
+			var b int64
+			b, err = units.RAMInBytes(rateStr)
+			rate = uint64(b)
+		} else {
+			rate, err = strconv.ParseUint(rateStr, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate %q of %q: %w", rateStr, devPath, err)
+		}
+		major, minor, err := blockDeviceNumber(devPath)
+		if err != nil {
+			return nil, err
+		}
+		d := specs.LinuxThrottleDevice{Rate: rate}
+		d.Major, d.Minor = major, minor
+		key := [2]int64{major, minor}
+		if j, ok := index[key]; ok {
+			res[j] = d
+			continue
+		}
+		index[key] = len(res)
+		res = append(res, d)
+	}
+	return res, nil
+}
+
+// blockDeviceNumber returns the major and the minor numbers of the block device.
+func blockDeviceNumber(devPath string) (int64, int64, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(devPath, &st); err != nil {
+		return 0, 0, fmt.Errorf("failed to stat device %q: %w", devPath, err)
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFBLK {
+		return 0, 0, fmt.Errorf("%q is not a block device", devPath)
+	}
+	return int64(unix.Major(uint64(st.Rdev))), int64(unix.Minor(uint64(st.Rdev))), nil // nolint: unconvert
+}
+
+// withBlkioThrottle sets the throttle entries of the blkio resources.
+// The OCI runtime writes them to blkio.throttle.* on cgroup v1, and to io.max on cgroup v2.
+func withBlkioThrottle(throttle *blkioThrottle) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if len(throttle.ReadBps)+len(throttle.WriteBps)+len(throttle.ReadIOPS)+len(throttle.WriteIOPS) == 0 {
+			return nil
+		}
+		if s.Linux.Resources == nil {
+			s.Linux.Resources = &specs.LinuxResources{}
+		}
+		if s.Linux.Resources.BlockIO == nil {
+			s.Linux.Resources.BlockIO = &specs.LinuxBlockIO{}
+		}
+		blkio := s.Linux.Resources.BlockIO
+		blkio.ThrottleReadBpsDevice = throttle.ReadBps
+		blkio.ThrottleWriteBpsDevice = throttle.WriteBps
+		blkio.ThrottleReadIOPSDevice = throttle.ReadIOPS
+		blkio.ThrottleWriteIOPSDevice = throttle.WriteIOPS
 		return nil
 	}
 }
@@ -372,6 +666,9 @@ func withCustomMemoryResources(memoryOptions customMemoryOptions) oci.SpecOpts {
 			if memoryOptions.MemoryReservation != nil {
 				s.Linux.Resources.Memory.Reservation = memoryOptions.MemoryReservation
 			}
+			if memoryOptions.KernelMemory != nil {
+				s.Linux.Resources.Memory.Kernel = memoryOptions.KernelMemory
+			}
 		}
 		return nil
 	}
@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/containerd/cgroups"
@@ -120,6 +122,14 @@ func TestRunCgroupV2(t *testing.T) {
 		"cat", "cpu.max", "memory.max", "memory.swap.max", "memory.low",
 		"pids.max", "cpu.weight", "cpuset.cpus", "cpuset.mems").AssertOutExactly(expected2)
 
+	// --cpus is converted to the CFS quota, and the update persists across restarts
+	base.Cmd("run", "--name", testutil.Identifier(t)+"-testUpdate3", "-w", "/sys/fs/cgroup", "-d",
+		testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	defer base.Cmd("rm", "-f", testutil.Identifier(t)+"-testUpdate3").Run()
+	base.Cmd("update", "--cpus", "0.42", "--pids-limit", "42", testutil.Identifier(t)+"-testUpdate3").AssertOK()
+	base.Cmd("exec", testutil.Identifier(t)+"-testUpdate3", "cat", "cpu.max", "pids.max").AssertOutExactly("42000 100000\n42\n")
+	base.Cmd("restart", testutil.Identifier(t)+"-testUpdate3").AssertOK()
+	base.Cmd("exec", testutil.Identifier(t)+"-testUpdate3", "cat", "cpu.max", "pids.max").AssertOutExactly("42000 100000\n42\n")
 }
 
 func TestRunCgroupV1(t *testing.T) {
@@ -205,6 +215,24 @@ func TestRunDevice(t *testing.T) {
 	assert.Equal(t, string(bytes.Trim(lo1Read, "\x00")), "overwritten-lo1-content")
 }
 
+func TestRunDeviceNotExist(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+	base.Cmd("run", "--rm",
+		"--device", "/dev/nerdctl-test-nonexistent0",
+		"--device", "/dev/null",
+		"--device", "/dev/nerdctl-test-nonexistent1:r",
+		testutil.AlpineImage, "true").AssertErrContains(`devices ["/dev/nerdctl-test-nonexistent0" "/dev/nerdctl-test-nonexistent1"] do not exist on the host`)
+}
+
+func TestValidateDevicesExist(t *testing.T) {
+	t.Parallel()
+	assert.NilError(t, validateDevicesExist(nil))
+	assert.NilError(t, validateDevicesExist([]string{"/dev/null"}))
+	assert.ErrorContains(t, validateDevicesExist([]string{"/dev/null", "/dev/nerdctl-test-nonexistent"}),
+		`device "/dev/nerdctl-test-nonexistent" does not exist on the host`)
+}
+
 func TestParseDevice(t *testing.T) {
 	t.Parallel()
 	type testCase struct {
@@ -299,3 +327,220 @@ func TestRunBlkioWeightCgroupV2(t *testing.T) {
 	base.Cmd("run", "--rm", "--blkio-weight", "300", "-w", "/sys/fs/cgroup", testutil.AlpineImage,
 		"cat", "io.bfq.weight").AssertOutExactly("default 300\n")
 }
+
+func TestRunOOMKilled(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	info := base.Info()
+	switch info.CgroupDriver {
+	case "none", "":
+		t.Skip("test requires cgroup driver")
+	}
+	if !info.MemoryLimit {
+		t.Skip("test requires MemoryLimit")
+	}
+	tID := testutil.Identifier(t)
+	testContainerOOM := tID + "-oom"
+	testContainerOK := tID + "-ok"
+	defer base.Cmd("rm", "-f", testContainerOOM, testContainerOK).Run()
+
+	// `tail /dev/zero` keeps buffering the (endless) last line
+	base.Cmd("run", "--name", testContainerOOM, "--memory", "32m", "--memory-swap", "32m", testutil.AlpineImage,
+		"tail", "/dev/zero").AssertExitCode(137)
+	inspectOOM := base.InspectContainer(testContainerOOM)
+	assert.Equal(t, "exited", inspectOOM.State.Status)
+	assert.Equal(t, true, inspectOOM.State.OOMKilled)
+	if base.Target == testutil.Nerdctl {
+		// recorded for `nerdctl inspect` after the task is deleted
+		base.Cmd("inspect", "--format", "{{index .Config.Labels \"nerdctl/oom-killed\"}}", testContainerOOM).AssertOutExactly("true\n")
+	}
+
+	base.Cmd("run", "--name", testContainerOK, "--memory", "32m", testutil.AlpineImage, "true").AssertOK()
+	inspectOK := base.InspectContainer(testContainerOK)
+	assert.Equal(t, false, inspectOK.State.OOMKilled)
+}
+
+func TestRunMemoryBelowMinimum(t *testing.T) {
+	base := testutil.NewBase(t)
+	base.Cmd("run", "--rm", "--memory", "4m", testutil.AlpineImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--memory-reservation", "1k", testutil.AlpineImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--memory", "42x", testutil.AlpineImage, "true").AssertFail()
+}
+
+func TestRunCPURealtime(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	if cgroups.Mode() == cgroups.Unified {
+		base.Cmd("create", "--name", tID, "--cpu-rt-runtime", "1000", "--cpu-rt-period", "100000", testutil.AlpineImage, "true").
+			AssertErrContains("only supported with cgroup v1")
+		return
+	}
+	if _, err := os.Stat(filepath.Join(cpuCgroupV1Root, "cpu.rt_runtime_us")); err != nil {
+		t.Skip("test requires CONFIG_RT_GROUP_SCHED")
+	}
+	defer base.Cmd("rm", "-f", tID).Run()
+	// The container is only created, as running it requires RT bandwidth to be assigned to the parent cgroup.
+	base.Cmd("create", "--name", tID, "--cpu-rt-runtime", "1000", "--cpu-rt-period", "100000", testutil.AlpineImage, "true").AssertOK()
+	base.Cmd("container", "inspect", "--mode=native", "--format={{json .Spec.Linux.Resources.CPU}}", tID).
+		AssertOutWithFunc(func(stdout string) error {
+			if !strings.Contains(stdout, `"realtimeRuntime":1000`) || !strings.Contains(stdout, `"realtimePeriod":100000`) {
+				return fmt.Errorf("expected the RT fields in the spec, got %q", stdout)
+			}
+			return nil
+		})
+	base.Cmd("create", "--cpu-rt-runtime", "200000", "--cpu-rt-period", "100000", testutil.AlpineImage, "true").
+		AssertErrContains("cannot be higher than cpu-rt-period")
+}
+
+func TestRunKernelMemory(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker fails with cgroup v2, and the native inspection is needed for the spec
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", tID).Run()
+	res := base.Cmd("create", "--name", tID, "--kernel-memory", "42m", testutil.AlpineImage, "true").Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	kernelMemory := base.Cmd("container", "inspect", "--mode=native", "--format={{json .Spec.Linux.Resources.Memory.Kernel}}", tID).Out()
+	if cgroups.Mode() == cgroups.Unified {
+		assert.Assert(t, strings.Contains(res.Stderr(), "--kernel-memory is ignored"), res.Stderr())
+		assert.Equal(t, "null\n", kernelMemory)
+	} else {
+		assert.Assert(t, strings.Contains(res.Stderr(), "--kernel-memory is deprecated"), res.Stderr())
+		assert.Equal(t, "44040192\n", kernelMemory)
+	}
+	base.Cmd("create", "--kernel-memory", "1m", testutil.AlpineImage, "true").AssertErrContains("minimum allowed is")
+}
+
+func TestValidateCPURealtime(t *testing.T) {
+	testCases := []struct {
+		runtime     int64
+		period      uint64
+		hostRuntime int64
+		hostPeriod  uint64
+		errMsg      string
+	}{
+		{runtime: 950000, period: 1000000, hostRuntime: 950000, hostPeriod: 1000000},
+		{runtime: 1000, period: 100000, hostRuntime: 950000, hostPeriod: 1000000},
+		{runtime: 1000, period: 0, hostRuntime: 950000, hostPeriod: 1000000},
+		{runtime: 1000000, period: 1000000, hostRuntime: -1, hostPeriod: 1000000},
+		{runtime: 96000, period: 100000, hostRuntime: 950000, hostPeriod: 1000000, errMsg: "exceeds the real-time bandwidth of the host"},
+		{runtime: 2000, period: 1000, hostRuntime: -1, hostPeriod: 1000000, errMsg: "cannot be higher than cpu-rt-period"},
+		{runtime: -1, period: 1000, hostRuntime: 950000, hostPeriod: 1000000, errMsg: "must not be negative"},
+	}
+	for _, tc := range testCases {
+		err := validateCPURealtime(tc.runtime, tc.period, tc.hostRuntime, tc.hostPeriod)
+		if tc.errMsg == "" {
+			assert.NilError(t, err, "%+v", tc)
+		} else {
+			assert.ErrorContains(t, err, tc.errMsg, "%+v", tc)
+		}
+	}
+}
+
+func TestReadCPURealtime(t *testing.T) {
+	dir := t.TempDir()
+	_, _, err := readCPURealtime(dir)
+	assert.ErrorContains(t, err, "CONFIG_RT_GROUP_SCHED")
+
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "cpu.rt_runtime_us"), []byte("950000\n"), 0644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "cpu.rt_period_us"), []byte("1000000\n"), 0644))
+	runtime, period, err := readCPURealtime(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(950000), runtime)
+	assert.Equal(t, uint64(1000000), period)
+}
+
+func TestValidateCPUSet(t *testing.T) {
+	testCases := []struct {
+		cpuset string
+		online string
+		errMsg string
+	}{
+		{cpuset: "0", online: "0-3\n"},
+		{cpuset: "0-3", online: "0-3\n"},
+		{cpuset: "0-3,8", online: "0-3,8-11\n"},
+		{cpuset: "1,1", online: "0-3\n"},
+		{cpuset: "0-3,8", online: "0-3\n", errMsg: "CPU 8 not online"},
+		{cpuset: "2-5", online: "0-3\n", errMsg: "CPU 4,5 not online"},
+		{cpuset: "1", online: "0,2-3\n", errMsg: "CPU 1 not online (online CPUs: 0,2-3)"},
+		{cpuset: "3-1", online: "0-3\n", errMsg: "invalid cpuset-cpus"},
+		{cpuset: "foo", online: "0-3\n", errMsg: "invalid cpuset-cpus"},
+	}
+	for _, tc := range testCases {
+		err := validateCPUSet(tc.cpuset, tc.online)
+		if tc.errMsg == "" {
+			assert.NilError(t, err, "%+v", tc)
+		} else {
+			assert.ErrorContains(t, err, tc.errMsg, "%+v", tc)
+		}
+	}
+}
+
+func TestRunCPUSetValidation(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	info := base.Info()
+	switch info.CgroupDriver {
+	case "none", "":
+		t.Skip("test requires cgroup driver")
+	}
+	if !info.CPUSet {
+		t.Skip("test requires CPUSet")
+	}
+	base.Cmd("run", "--rm", "--cpuset-cpus", "0", testutil.AlpineImage, "true").AssertOK()
+	base.Cmd("run", "--rm", "--cpuset-cpus", "0,4096", testutil.AlpineImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--cpuset-cpus", "foo", testutil.AlpineImage, "true").AssertFail()
+}
+
+func TestRunBlkioThrottle(t *testing.T) {
+	t.Parallel()
+	if os.Geteuid() != 0 || sys.RunningInUserNS() {
+		t.Skip("test requires the root in the initial user namespace")
+	}
+	base := testutil.NewBase(t)
+	info := base.Info()
+	switch info.CgroupDriver {
+	case "none", "":
+		t.Skip("test requires cgroup driver")
+	}
+	lo, err := loopback.New(4096)
+	assert.NilError(t, err)
+	defer lo.Close()
+	major, minor, err := blockDeviceNumber(lo.Device)
+	assert.NilError(t, err)
+
+	args := []string{"run", "--rm",
+		"--device-read-bps", lo.Device + ":1mb",
+		"--device-write-iops", lo.Device + ":2000",
+		"-w", "/sys/fs/cgroup", testutil.AlpineImage}
+	if cgroups.Mode() == cgroups.Unified {
+		// the limits of a device are combined into a single io.max entry
+		expected := fmt.Sprintf("%d:%d rbps=1048576 wbps=max riops=max wiops=2000\n", major, minor)
+		base.Cmd(append(args, "cat", "io.max")...).AssertOutExactly(expected)
+	} else {
+		expected := fmt.Sprintf("%d:%d 1048576\n%d:%d 2000\n", major, minor, major, minor)
+		base.Cmd(append(args, "cat", "blkio/blkio.throttle.read_bps_device", "blkio/blkio.throttle.write_iops_device")...).AssertOutExactly(expected)
+	}
+}
+
+func TestParseThrottleDevices(t *testing.T) {
+	testCases := []struct {
+		values  []string
+		isBytes bool
+		errMsg  string
+	}{
+		{values: []string{"/dev/null"}, isBytes: true, errMsg: "expected <device-path>:<number>"},
+		{values: []string{"/dev/null:"}, isBytes: true, errMsg: "expected <device-path>:<number>"},
+		{values: []string{"dev/null:1mb"}, isBytes: true, errMsg: "not an absolute path"},
+		{values: []string{"/dev/null:1xb"}, isBytes: true, errMsg: "invalid rate"},
+		{values: []string{"/dev/null:1mb"}, isBytes: false, errMsg: "invalid rate"},
+		{values: []string{"/dev/null:1mb"}, isBytes: true, errMsg: "not a block device"},
+		{values: []string{"/nonexistent:1000"}, isBytes: false, errMsg: "failed to stat device"},
+	}
+	for _, tc := range testCases {
+		_, err := parseThrottleDevices(tc.values, tc.isBytes)
+		assert.ErrorContains(t, err, tc.errMsg, "%v", tc.values)
+	}
+	res, err := parseThrottleDevices(nil, true)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(res))
+}
@@ -18,9 +18,12 @@ package main
 
 import (
 	"context"
+	"errors"
 
+	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/oci"
+	"github.com/containerd/nerdctl/pkg/mountutil"
 	"github.com/spf13/cobra"
 )
 
@@ -41,3 +44,18 @@ func runShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]s
 func setPlatformOptions(opts []oci.SpecOpts, cmd *cobra.Command, id string) ([]oci.SpecOpts, error) {
 	return opts, nil
 }
+
+func generateUsernsRemapOpts(cmd *cobra.Command, id string, image containerd.Image) ([]oci.SpecOpts, containerd.NewContainerOpts, error) {
+	usernsRemap, err := cmd.Flags().GetString("userns-remap")
+	if err != nil {
+		return nil, nil, err
+	}
+	if usernsRemap != "" {
+		return nil, nil, errors.New("--userns-remap is not supported on freebsd")
+	}
+	return nil, nil, nil
+}
+
+func remapUsernsMountPoints(cmd *cobra.Command, mountPoints []*mountutil.Processed) error {
+	return nil
+}
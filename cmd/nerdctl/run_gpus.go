@@ -34,62 +34,88 @@ type gpuReq struct {
 	capabilities []string
 }
 
-func parseGPUOpts(value []string) (res []oci.SpecOpts, _ error) {
+// parseGPUOpts parses the values of `--gpus`.
+// The requests are merged into a single hook, as nvidia-container-cli cannot be invoked twice for a container.
+func parseGPUOpts(value []string) ([]oci.SpecOpts, error) {
+	var reqs []*gpuReq
 	for _, gpu := range value {
-		gpuOpt, err := parseGPUOpt(gpu)
+		req, err := parseGPUOptCSV(gpu)
 		if err != nil {
 			return nil, err
 		}
-		res = append(res, gpuOpt)
+		reqs = append(reqs, req)
 	}
-	return res, nil
-}
-
-func parseGPUOpt(value string) (oci.SpecOpts, error) {
-	req, err := parseGPUOptCSV(value)
-	if err != nil {
-		return nil, err
+	devices, caps := mergeGPUReqs(reqs)
+	if len(devices) == 0 {
+		return nil, nil
 	}
 
-	var gpuOpts []nvidia.Opts
-
-	if len(req.deviceIDs) > 0 {
-		gpuOpts = append(gpuOpts, nvidia.WithDeviceUUIDs(req.deviceIDs...))
-	} else if req.count > 0 {
-		var devices []int
-		for i := 0; i < req.count; i++ {
-			devices = append(devices, i)
-		}
-		gpuOpts = append(gpuOpts, nvidia.WithDevices(devices...))
-	} else if req.count < 0 {
+	gpuOpts := []nvidia.Opts{nvidia.WithCapabilities(caps...)}
+	if len(devices) == 1 && devices[0] == "all" {
 		gpuOpts = append(gpuOpts, nvidia.WithAllDevices)
+	} else {
+		// nvidia-container-cli accepts both the indexes and the UUIDs
+		gpuOpts = append(gpuOpts, nvidia.WithDeviceUUIDs(devices...))
+	}
+
+	if rootlessutil.IsRootless() {
+		// "--no-cgroups" option is needed to nvidia-container-cli in rootless environment
+		// Please see also: https://github.com/moby/moby/issues/38729#issuecomment-463493866
+		gpuOpts = append(gpuOpts, nvidia.WithNoCgroups)
 	}
 
+	return []oci.SpecOpts{nvidia.WithGPUs(gpuOpts...)}, nil
+}
+
+// mergeGPUReqs returns the devices and the capabilities for the requests.
+// devices is empty when no GPU is requested (e.g. `--gpus 0`), and is {"all"} when all the GPUs are requested.
+func mergeGPUReqs(reqs []*gpuReq) (devices []string, caps []nvidia.Capability) {
 	str2cap := make(map[string]nvidia.Capability)
 	for _, c := range nvidia.AllCaps() {
 		str2cap[string(c)] = c
 	}
-	var nvidiaCaps []nvidia.Capability
-	for _, c := range req.capabilities {
-		if cap, isNvidiaCap := str2cap[c]; isNvidiaCap {
-			nvidiaCaps = append(nvidiaCaps, cap)
+	var (
+		all         bool
+		seenDevices = make(map[string]struct{})
+		seenCaps    = make(map[nvidia.Capability]struct{})
+	)
+	addDevice := func(d string) {
+		if _, ok := seenDevices[d]; !ok {
+			seenDevices[d] = struct{}{}
+			devices = append(devices, d)
+		}
+	}
+	for _, req := range reqs {
+		if len(req.deviceIDs) > 0 {
+			for _, d := range req.deviceIDs {
+				addDevice(d)
+			}
+		} else if req.count > 0 {
+			for i := 0; i < req.count; i++ {
+				addDevice(strconv.Itoa(i))
+			}
+		} else if req.count < 0 {
+			all = true
+		}
+		for _, c := range req.capabilities {
+			// non-NVIDIA capabilities like "gpu" are ignored
+			if cap, isNvidiaCap := str2cap[c]; isNvidiaCap {
+				if _, ok := seenCaps[cap]; !ok {
+					seenCaps[cap] = struct{}{}
+					caps = append(caps, cap)
+				}
+			}
 		}
 	}
-	if len(nvidiaCaps) != 0 {
-		gpuOpts = append(gpuOpts, nvidia.WithCapabilities(nvidiaCaps...))
-	} else {
+	if all {
+		devices = []string{"all"}
+	}
+	if len(caps) == 0 {
 		// Add "utility", "compute" capability if unset.
 		// Please see also: https://docs.nvidia.com/datacenter/cloud-native/container-toolkit/user-guide.html#driver-capabilities
-		gpuOpts = append(gpuOpts, nvidia.WithCapabilities(nvidia.Utility, nvidia.Compute))
-	}
-
-	if rootlessutil.IsRootless() {
-		// "--no-cgroups" option is needed to nvidia-container-cli in rootless environment
-		// Please see also: https://github.com/moby/moby/issues/38729#issuecomment-463493866
-		gpuOpts = append(gpuOpts, nvidia.WithNoCgroups)
+		caps = []nvidia.Capability{nvidia.Utility, nvidia.Compute}
 	}
-
-	return nvidia.WithGPUs(gpuOpts...), nil
+	return devices, caps
 }
 
 func parseGPUOptCSV(value string) (*gpuReq, error) {
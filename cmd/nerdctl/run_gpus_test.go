@@ -19,6 +19,7 @@ package main
 import (
 	"testing"
 
+	"github.com/containerd/containerd/contrib/nvidia"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 )
@@ -55,3 +56,67 @@ func TestParseGpusOpts(t *testing.T) {
 		assert.Check(t, is.DeepEqual(req.capabilities, []string{"compute", "utility"}))
 	}
 }
+
+func TestParseGpusOptDevices(t *testing.T) {
+	t.Parallel()
+	for _, testcase := range []string{
+		"\"device=0,1\"",
+		"driver=nvidia,\"device=0,1\"",
+	} {
+		req, err := parseGPUOptCSV(testcase)
+		assert.NilError(t, err)
+		assert.Equal(t, req.count, 0)
+		assert.Check(t, is.DeepEqual(req.deviceIDs, []string{"0", "1"}))
+	}
+
+	// like Docker, the device list has to be quoted, otherwise "1" is parsed as the count
+	_, err := parseGPUOptCSV("device=0,1")
+	assert.ErrorContains(t, err, "cannot set both Count and DeviceIDs")
+	_, err = parseGPUOptCSV("count=2,\"device=0,1\"")
+	assert.ErrorContains(t, err, "cannot set both Count and DeviceIDs")
+}
+
+func TestMergeGPUReqs(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		values  []string
+		devices []string
+		caps    []nvidia.Capability
+	}{
+		{
+			values: []string{"0"},
+			caps:   []nvidia.Capability{nvidia.Utility, nvidia.Compute},
+		},
+		{
+			values:  []string{"2"},
+			devices: []string{"0", "1"},
+			caps:    []nvidia.Capability{nvidia.Utility, nvidia.Compute},
+		},
+		{
+			values:  []string{"all", "device=1"},
+			devices: []string{"all"},
+			caps:    []nvidia.Capability{nvidia.Utility, nvidia.Compute},
+		},
+		{
+			values:  []string{"\"device=1,GPU-3a23c669\"", "2,capabilities=video"},
+			devices: []string{"1", "GPU-3a23c669", "0"},
+			caps:    []nvidia.Capability{nvidia.Video},
+		},
+		{
+			values:  []string{"1,\"capabilities=gpu,utility\""},
+			devices: []string{"0"},
+			caps:    []nvidia.Capability{nvidia.Utility},
+		},
+	}
+	for _, tc := range testCases {
+		var reqs []*gpuReq
+		for _, v := range tc.values {
+			req, err := parseGPUOptCSV(v)
+			assert.NilError(t, err)
+			reqs = append(reqs, req)
+		}
+		devices, caps := mergeGPUReqs(reqs)
+		assert.Check(t, is.DeepEqual(devices, tc.devices), "%v", tc.values)
+		assert.Check(t, is.DeepEqual(caps, tc.caps), "%v", tc.values)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+	"github.com/containerd/nerdctl/pkg/healthcheck"
+	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
+	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/spf13/cobra"
+)
+
+// healthcheckNone is the `--health-cmd` value that disables the healthcheck of the image.
+const healthcheckNone = "NONE"
+
+// generateHealthcheckOpts returns the options for `--health-*` and `--no-healthcheck`.
+//
+// The healthcheck is recorded in the labels as dockercompat.HealthConfig, so that `nerdctl inspect` shows it.
+// The disabled healthcheck is recorded as {"Test":["NONE"]}, so that the image healthcheck will never be applied to the container.
+//
+// The healthcheck is run by the process spawned by the Poststart hook. See pkg/healthcheck.
+func generateHealthcheckOpts(cmd *cobra.Command) ([]oci.SpecOpts, []containerd.NewContainerOpts, error) {
+	healthCmd, err := cmd.Flags().GetString("health-cmd")
+	if err != nil {
+		return nil, nil, err
+	}
+	noHealthcheck, err := cmd.Flags().GetBool("no-healthcheck")
+	if err != nil {
+		return nil, nil, err
+	}
+	hc := &dockercompat.HealthConfig{}
+	if hc.Interval, err = getHealthcheckDuration(cmd, "health-interval"); err != nil {
+		return nil, nil, err
+	}
+	if hc.Timeout, err = getHealthcheckDuration(cmd, "health-timeout"); err != nil {
+		return nil, nil, err
+	}
+	if hc.StartPeriod, err = getHealthcheckDuration(cmd, "health-start-period"); err != nil {
+		return nil, nil, err
+	}
+	if hc.Retries, err = cmd.Flags().GetInt("health-retries"); err != nil {
+		return nil, nil, err
+	}
+	if hc.Retries < 0 {
+		return nil, nil, errors.New("--health-retries cannot be negative")
+	}
+	hasOptions := hc.Interval != 0 || hc.Timeout != 0 || hc.StartPeriod != 0 || hc.Retries != 0
+
+	switch {
+	case noHealthcheck && healthCmd != "" && healthCmd != healthcheckNone:
+		return nil, nil, errors.New("--no-healthcheck conflicts with --health-cmd")
+	case noHealthcheck && hasOptions:
+		return nil, nil, errors.New("--no-healthcheck conflicts with --health-* options")
+	case noHealthcheck, healthCmd == healthcheckNone:
+		return nil, []containerd.NewContainerOpts{withHealthcheck(&dockercompat.HealthConfig{Test: []string{healthcheckNone}})}, nil
+	case healthCmd != "":
+		if runtime.GOOS == "windows" {
+			return nil, nil, errors.New("--health-cmd is not supported on Windows")
+		}
+		if hc.Test, err = parseHealthCmd(healthCmd); err != nil {
+			return nil, nil, err
+		}
+		return []oci.SpecOpts{withHealthcheckHook(cmd)}, []containerd.NewContainerOpts{withHealthcheck(hc)}, nil
+	case hasOptions:
+		// nerdctl does not support the healthcheck of the image
+		return nil, nil, errors.New("--health-* options require --health-cmd")
+	}
+	return nil, nil, nil
+}
+
+// parseHealthCmd parses `--health-cmd` into the Test of dockercompat.HealthConfig.
+//
+// A JSON array like `["curl", "-f", "http://localhost"]` is the exec form, and recorded as {"CMD", args...}.
+// The array may also begin with "CMD" or "CMD-SHELL", as in the healthcheck of Docker Compose.
+// Any other string, including the one that begins with "[" but is not a JSON array (e.g., `[ -f /tmp/ready ]`),
+// is the shell form, and recorded as {"CMD-SHELL", command}, like Docker.
+func parseHealthCmd(s string) ([]string, error) {
+	var args []string
+	if !strings.HasPrefix(strings.TrimSpace(s), "[") || json.Unmarshal([]byte(s), &args) != nil {
+		// not a JSON array, e.g., `[ -f /tmp/ready ]`
+		return []string{"CMD-SHELL", s}, nil
+	}
+	var test []string
+	if len(args) > 0 && (args[0] == "CMD" || args[0] == "CMD-SHELL") {
+		test = args
+	} else {
+		test = append([]string{"CMD"}, args...)
+	}
+	if _, err := healthcheck.ProbeArgs(test); err != nil {
+		return nil, fmt.Errorf("invalid --health-cmd %q: %w", s, err)
+	}
+	return test, nil
+}
+
+// getHealthcheckDuration returns the value of the duration flag, which cannot be less than 1ms unless it is zero, as in Docker.
+func getHealthcheckDuration(cmd *cobra.Command, name string) (time.Duration, error) {
+	d, err := cmd.Flags().GetDuration(name)
+	if err != nil {
+		return 0, err
+	}
+	if d != 0 && d < time.Millisecond {
+		return 0, fmt.Errorf("--%s cannot be less than 1ms", name)
+	}
+	return d, nil
+}
+
+// withHealthcheckHook adds the Poststart hook that spawns the healthcheck monitor.
+func withHealthcheckHook(cmd *cobra.Command) oci.SpecOpts {
+	selfExe, f := globalFlags(cmd)
+	args := append([]string{selfExe}, append(f, "internal", "healthcheck", "hook")...)
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *specs.Spec) error {
+		if s.Hooks == nil {
+			s.Hooks = &specs.Hooks{}
+		}
+		s.Hooks.Poststart = append(s.Hooks.Poststart, specs.Hook{
+			Path: selfExe,
+			Args: args,
+			Env:  healthcheckHookEnv(os.Environ()),
+		})
+		return nil
+	}
+}
+
+// healthcheckHookEnvKeys are the environment variables passed to the healthcheck hook.
+// The hook is recorded in the OCI spec, so the other variables (e.g., credentials) must not be copied.
+// The global flags set on the command line are already passed as the args.
+var healthcheckHookEnvKeys = []string{
+	"PATH",
+	"HOME",
+	"XDG_RUNTIME_DIR",
+	"XDG_CONFIG_HOME",
+	"XDG_DATA_HOME",
+	"ROOTLESSKIT_STATE_DIR",
+	"ROOTLESSKIT_PARENT_EUID",
+	"ROOTLESSKIT_PARENT_EGID",
+	"CONTAINERD_ADDRESS",
+	"CONTAINERD_NAMESPACE",
+	"CONTAINERD_SNAPSHOTTER",
+	"NERDCTL_TOML",
+}
+
+// healthcheckHookEnv returns the subset of env needed by the healthcheck hook.
+func healthcheckHookEnv(env []string) []string {
+	var res []string
+	for _, kv := range env {
+		k := strings.SplitN(kv, "=", 2)[0]
+		for _, key := range healthcheckHookEnvKeys {
+			if k == key {
+				res = append(res, kv)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func withHealthcheck(hc *dockercompat.HealthConfig) containerd.NewContainerOpts {
+	return func(ctx context.Context, client *containerd.Client, c *containers.Container) error {
+		b, err := json.Marshal(hc)
+		if err != nil {
+			return err
+		}
+		if c.Labels == nil {
+			c.Labels = make(map[string]string)
+		}
+		c.Labels[labels.Healthcheck] = string(b)
+		return nil
+	}
+}
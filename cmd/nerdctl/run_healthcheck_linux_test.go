@@ -0,0 +1,151 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestRunNoHealthcheck(t *testing.T) {
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	imageName := tID + "-image"
+	defer base.Cmd("builder", "prune").Run()
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+HEALTHCHECK --interval=5s CMD ["false"]
+CMD ["sleep", "infinity"]
+	`, testutil.CommonImage)
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	base.Cmd("build", "-t", imageName, buildCtx).AssertOK()
+
+	defer base.Cmd("rm", "-f", tID).Run()
+	for _, flag := range []string{"--no-healthcheck", "--health-cmd=NONE"} {
+		base.Cmd("run", "-d", "--name", tID, flag, imageName).AssertOK()
+		base.Cmd("inspect", "--format={{json .Config.Healthcheck}}", tID).AssertOutExactly(`{"Test":["NONE"]}` + "\n")
+		base.Cmd("rm", "-f", tID).AssertOK()
+	}
+
+	base.Cmd("run", "--rm", "--no-healthcheck", "--health-cmd=true", imageName).AssertFail()
+}
+
+func TestRunHealthcheck(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	healthy, unhealthy := tID+"-healthy", tID+"-unhealthy"
+	healthyExec, unhealthyExec := tID+"-healthy-exec", tID+"-unhealthy-exec"
+	defer base.Cmd("rm", "-f", healthy, unhealthy, healthyExec, unhealthyExec).Run()
+
+	base.Cmd("run", "-d", "--name", healthy, "--health-cmd", "test -e /etc/hostname",
+		"--health-interval", "1s", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	base.Cmd("run", "-d", "--name", unhealthy, "--health-cmd", "exit 1",
+		"--health-interval", "1s", "--health-retries", "2", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	// the exec form does not need the shell
+	base.Cmd("run", "-d", "--name", healthyExec, "--health-cmd", `["test", "-e", "/etc/hostname"]`,
+		"--health-interval", "1s", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	base.Cmd("run", "-d", "--name", unhealthyExec, "--health-cmd", `["false"]`,
+		"--health-interval", "1s", "--health-retries", "2", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	base.Cmd("inspect", "--format={{json .Config.Healthcheck.Test}}", healthy).AssertOutExactly(`["CMD-SHELL","test -e /etc/hostname"]` + "\n")
+
+	waitHealthStatus := func(name, expected string) {
+		var status string
+		for i := 0; i < 30; i++ {
+			status = strings.TrimSpace(base.Cmd("inspect", "--format={{.State.Health.Status}}", name).Run().Stdout())
+			if status == expected {
+				return
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+		t.Fatalf("expected the health status of %s to be %q, got %q", name, expected, status)
+	}
+	waitHealthStatus(healthy, "healthy")
+	waitHealthStatus(unhealthy, "unhealthy")
+	base.Cmd("inspect", "--format={{json .Config.Healthcheck.Test}}", healthyExec).AssertOutExactly(`["CMD","test","-e","/etc/hostname"]` + "\n")
+	base.Cmd("inspect", "--format={{json .Config.Healthcheck.Test}}", unhealthyExec).AssertOutExactly(`["CMD","false"]` + "\n")
+	waitHealthStatus(healthyExec, "healthy")
+	waitHealthStatus(unhealthyExec, "unhealthy")
+	base.Cmd("inspect", "--format={{(index .State.Health.Log 0).ExitCode}}", unhealthy).AssertOutExactly("1\n")
+
+	base.Cmd("ps", "--format", "{{.Names}} {{.Status}}").AssertOutContains(healthy + " Up (healthy)")
+	base.Cmd("ps", "--filter", "health=healthy", "--format", "{{.Names}}").AssertOutWithFunc(func(stdout string) error {
+		if !strings.Contains(stdout, healthy) || strings.Contains(stdout, unhealthy) {
+			return fmt.Errorf("expected only %q to be listed, got %q", healthy, stdout)
+		}
+		return nil
+	})
+	base.Cmd("ps", "--filter", "health=unhealthy", "--format", "{{.Names}}").AssertOutWithFunc(func(stdout string) error {
+		if !strings.Contains(stdout, unhealthy) || strings.Contains(stdout, healthy) {
+			return fmt.Errorf("expected only %q to be listed, got %q", unhealthy, stdout)
+		}
+		return nil
+	})
+}
+
+func TestRunHealthcheckFlags(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker applies the options to the HEALTHCHECK of the image
+	base := testutil.NewBase(t)
+
+	base.Cmd("run", "--rm", "--health-interval", "1s", testutil.CommonImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--no-healthcheck", "--health-retries", "1", testutil.CommonImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--health-cmd", "true", "--health-retries", "-1", testutil.CommonImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--health-cmd", "true", "--health-timeout", "1us", testutil.CommonImage, "true").AssertFail()
+}
+
+func TestParseHealthCmd(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+		err      string
+	}{
+		{input: "exit 1", expected: []string{"CMD-SHELL", "exit 1"}},
+		{input: `curl -f http://localhost || exit 1`, expected: []string{"CMD-SHELL", "curl -f http://localhost || exit 1"}},
+		{input: `["curl", "-f", "http://localhost"]`, expected: []string{"CMD", "curl", "-f", "http://localhost"}},
+		{input: ` ["true"]`, expected: []string{"CMD", "true"}},
+		{input: `["CMD", "true"]`, expected: []string{"CMD", "true"}},
+		{input: `["CMD-SHELL", "exit 0"]`, expected: []string{"CMD-SHELL", "exit 0"}},
+		{input: `[]`, err: "no command"},
+		{input: `["CMD"]`, err: "no command"},
+		{input: `["CMD-SHELL", "a", "b"]`, err: "invalid healthcheck test"},
+		{input: `["true"`, expected: []string{"CMD-SHELL", `["true"`}},
+		{input: `[ -f /tmp/ready ]`, expected: []string{"CMD-SHELL", "[ -f /tmp/ready ]"}},
+	}
+	for _, tc := range testCases {
+		test, err := parseHealthCmd(tc.input)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err, tc.input)
+			continue
+		}
+		assert.NilError(t, err, tc.input)
+		assert.DeepEqual(t, tc.expected, test)
+	}
+}
+
+func TestHealthcheckHookEnv(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "CONTAINERD_ADDRESS=/run/foo.sock", "REGISTRY_TOKEN=secret", "XDG_RUNTIME_DIR=/run/user/1000", "PATHX=foo"}
+	assert.DeepEqual(t, []string{"PATH=/usr/bin", "CONTAINERD_ADDRESS=/run/foo.sock", "XDG_RUNTIME_DIR=/run/user/1000"}, healthcheckHookEnv(env))
+}
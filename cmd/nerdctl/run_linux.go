@@ -18,15 +18,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/containerd/nerdctl/pkg/bypass4netnsutil"
+	"github.com/containerd/nerdctl/pkg/mountutil"
 	"github.com/containerd/nerdctl/pkg/rootlessutil"
 	"github.com/containerd/nerdctl/pkg/strutil"
+	"github.com/containerd/nerdctl/pkg/usernsutil"
 	"github.com/docker/go-units"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
 
+	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/oci"
 	"github.com/containerd/containerd/pkg/cap"
@@ -72,6 +79,26 @@ func setPlatformOptions(opts []oci.SpecOpts, cmd *cobra.Command, id string) ([]o
 		opts = append(opts, cgOpts...)
 	}
 
+	if cmd.Flags().Changed("oom-score-adj") {
+		score, err := cmd.Flags().GetInt("oom-score-adj")
+		if err != nil {
+			return nil, err
+		}
+		minScore := -1000
+		if rootlessutil.IsRootless() {
+			// Unprivileged processes cannot decrease the score below the value inherited from the rootless containerd
+			minScore, err = rootlessOOMScoreAdj()
+			if err != nil {
+				return nil, err
+			}
+		}
+		score, err = validateOOMScoreAdj(score, minScore)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, withOOMScoreAdj(score))
+	}
+
 	labelsMap, err := readKVStringsMapfFromLabel(cmd)
 	if err != nil {
 		return nil, err
@@ -189,3 +216,116 @@ func setPlatformOptions(opts []oci.SpecOpts, cmd *cobra.Command, id string) ([]o
 
 	return opts, nil
 }
+
+// generateUsernsRemapOpts returns the options for `--userns-remap`.
+// The returned NewContainerOpts creates the snapshot with the ownership shifted to the remapped host IDs.
+// It is nil when `--userns-remap` is not specified.
+func generateUsernsRemapOpts(cmd *cobra.Command, id string, image containerd.Image) ([]oci.SpecOpts, containerd.NewContainerOpts, error) {
+	usernsRemap, err := cmd.Flags().GetString("userns-remap")
+	if err != nil {
+		return nil, nil, err
+	}
+	if usernsRemap == "" {
+		return nil, nil, nil
+	}
+	if rootlessutil.IsRootless() {
+		return nil, nil, errors.New("--userns-remap is not supported in rootless mode")
+	}
+	privileged, err := cmd.Flags().GetBool("privileged")
+	if err != nil {
+		return nil, nil, err
+	}
+	if privileged {
+		return nil, nil, errors.New("--privileged cannot be used with --userns-remap")
+	}
+	remap, err := usernsutil.Parse(usernsRemap)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := []oci.SpecOpts{oci.WithUserNamespace(remap.UIDMaps, remap.GIDMaps)}
+	return opts, containerd.WithRemappedSnapshot(id, image, remap.HostUID(), remap.HostGID()), nil
+}
+
+// remapUsernsMountPoints shifts the ownership of the anonymous volumes to the remapped host IDs of `--userns-remap`.
+// Bind mounts and named volumes are rejected, as they may be shared with the host and the other containers,
+// and their ownership cannot be shifted for a single container.
+func remapUsernsMountPoints(cmd *cobra.Command, mountPoints []*mountutil.Processed) error {
+	usernsRemap, err := cmd.Flags().GetString("userns-remap")
+	if err != nil {
+		return err
+	}
+	if usernsRemap == "" {
+		return nil
+	}
+	remap, err := usernsutil.Parse(usernsRemap)
+	if err != nil {
+		return err
+	}
+	for _, mp := range mountPoints {
+		switch mp.Type {
+		case mountutil.Bind:
+			return fmt.Errorf("bind mount %q cannot be used with --userns-remap, as its ownership is not remapped", mp.Mount.Source)
+		case mountutil.Volume:
+			if mp.AnonymousVolume == "" {
+				return fmt.Errorf("named volume %q cannot be used with --userns-remap, as its ownership is not remapped", mp.Name)
+			}
+			if mp.Driver != "" {
+				return fmt.Errorf("anonymous volume with volume driver %q cannot be used with --userns-remap", mp.Driver)
+			}
+			if err := remap.ShiftOwnership(mp.Mount.Source); err != nil {
+				return fmt.Errorf("failed to remap the ownership of anonymous volume %q: %w", mp.AnonymousVolume, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateOOMScoreAdj validates the value of `--oom-score-adj`.
+// A value in the valid range but lower than minScore is clamped to minScore with a warning.
+func validateOOMScoreAdj(score, minScore int) (int, error) {
+	if score < -1000 || score > 1000 {
+		return 0, fmt.Errorf("invalid value %d for --oom-score-adj, must be between -1000 and 1000", score)
+	}
+	if score < minScore {
+		logrus.Warnf("--oom-score-adj=%d is lower than the current value %d and cannot be set without privileges, using %d", score, minScore, minScore)
+		return minScore, nil
+	}
+	return score, nil
+}
+
+// rootlessOOMScoreAdj returns the OOM score adjustment of the RootlessKit child process that runs the rootless containerd.
+// The container processes are forked from containerd (via the shim and the OCI runtime), not from nerdctl,
+// so their score cannot be set lower than this value without privileges.
+// When the child process cannot be inspected (e.g., RootlessKit is running with --pidns), the value of nerdctl itself is used.
+func rootlessOOMScoreAdj() (int, error) {
+	b, err := readRootlessKitChildOOMScoreAdj()
+	if err != nil {
+		logrus.WithError(err).Debug("failed to read the OOM score adjustment of the RootlessKit child, using the value of nerdctl")
+		if b, err = os.ReadFile("/proc/self/oom_score_adj"); err != nil {
+			return 0, err
+		}
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+func readRootlessKitChildOOMScoreAdj() ([]byte, error) {
+	stateDir, err := rootlessutil.RootlessKitStateDir()
+	if err != nil {
+		return nil, err
+	}
+	childPid, err := rootlessutil.RootlessKitChildPid(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(fmt.Sprintf("/proc/%d/oom_score_adj", childPid))
+}
+
+func withOOMScoreAdj(score int) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if s.Process == nil {
+			s.Process = &specs.Process{}
+		}
+		s.Process.OOMScoreAdj = &score
+		return nil
+	}
+}
@@ -29,10 +29,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/containerd/nerdctl/pkg/rootlessutil"
 	"github.com/containerd/nerdctl/pkg/strutil"
 	"github.com/containerd/nerdctl/pkg/testutil"
 
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
 )
 
 func TestRunCustomRootfs(t *testing.T) {
@@ -137,6 +139,53 @@ func TestRunUlimit(t *testing.T) {
 	base.Cmd("run", "--rm", "--ulimit", ulimit2, testutil.AlpineImage, "sh", "-c", "ulimit -Hn").AssertOutExactly("722\n")
 }
 
+func TestRunOOMScoreAdj(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+
+	base.Cmd("run", "--rm", "--oom-score-adj", "500", testutil.AlpineImage, "cat", "/proc/self/oom_score_adj").AssertOutExactly("500\n")
+	base.Cmd("run", "--rm", "--oom-score-adj", "1001", testutil.AlpineImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--oom-score-adj", "-1001", testutil.AlpineImage, "true").AssertFail()
+
+	if rootlessutil.IsRootless() && testutil.GetTarget() == testutil.Nerdctl {
+		// negative values are clamped to the value of the rootless containerd
+		minScore, err := rootlessOOMScoreAdj()
+		assert.NilError(t, err)
+		res := base.Cmd("run", "--rm", "--oom-score-adj", "-1000", testutil.AlpineImage, "cat", "/proc/self/oom_score_adj").Run()
+		assert.Equal(t, 0, res.ExitCode, res.Combined())
+		assert.Equal(t, fmt.Sprintf("%d\n", minScore), res.Stdout())
+		assert.Assert(t, strings.Contains(res.Stderr(), "cannot be set without privileges"))
+	}
+}
+
+func TestValidateOOMScoreAdj(t *testing.T) {
+	testCases := []struct {
+		score    int
+		minScore int
+		expected int
+		err      string
+	}{
+		{score: 0, minScore: -1000, expected: 0},
+		{score: -1000, minScore: -1000, expected: -1000},
+		{score: 1000, minScore: -1000, expected: 1000},
+		{score: 1001, minScore: -1000, err: "must be between -1000 and 1000"},
+		{score: -1001, minScore: -1000, err: "must be between -1000 and 1000"},
+		{score: -500, minScore: 0, expected: 0},
+		{score: 50, minScore: 100, expected: 100},
+		{score: 200, minScore: 100, expected: 200},
+		{score: -1001, minScore: 100, err: "must be between -1000 and 1000"},
+	}
+	for _, tc := range testCases {
+		score, err := validateOOMScoreAdj(tc.score, tc.minScore)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, score)
+	}
+}
+
 func TestRunWithInitAndInitPath(t *testing.T) {
 	testutil.RequiresBuild(t)
 	testutil.DockerIncompatible(t)
@@ -268,6 +317,20 @@ func TestRunTTY(t *testing.T) {
 	base.Cmd("run", "--rm", testutil.CommonImage, "stty").AssertFail()
 }
 
+func TestRunTTYInitialSize(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	if testutil.GetTarget() == testutil.Nerdctl {
+		testutil.RequireDaemonVersion(base, ">= 1.6.0-0")
+	}
+
+	// the helper sets the size of the tty emulated by unbuffer(1) before running nerdctl ("$0" "$@")
+	helper := []string{"unbuffer", "sh", "-c", `stty rows 37 cols 123; exec "$0" "$@"`}
+	// stty runs immediately, so the size must be set before the process starts
+	base.CmdWithHelper(helper, "run", "--rm", "-it", testutil.CommonImage, "stty", "size").AssertOutContains("37 123")
+	base.CmdWithHelper(helper, "run", "--rm", "-t", testutil.CommonImage, "stty", "size").AssertOutContains("37 123")
+}
+
 func TestRunWithFluentdLogDriver(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("fluentd log driver is not yet implemented on Windows")
@@ -339,11 +402,9 @@ RUN rm -rf /fluentd/etc/test.conf && echo  -e '\
 	assert.Equal(t, true, strings.Contains(logData, inspectedContainer.ID))
 }
 
-func TestRunWithFluentdLogDriverWithLogOpt(t *testing.T) {
-	if runtime.GOOS == "windows" {
-		t.Skip("fluentd log driver is not yet implemented on Windows")
-	}
-	base := testutil.NewBase(t)
+// runFluentdServer runs a fluentd server that writes the received records to a *.log file
+// in the returned directory, and listens on the port of the host.
+func runFluentdServer(t *testing.T, base *testutil.Base, port int) string {
 	imageName := "test-fluentd-image"
 	dockerfile := fmt.Sprintf(`
 FROM %s
@@ -366,29 +427,39 @@ RUN rm -rf /fluentd/etc/test.conf && echo  -e '\
 	assert.NilError(t, err)
 	defer os.RemoveAll(buildCtx)
 	base.Cmd("build", "-t", imageName, buildCtx).AssertOK()
-	base.Cmd("build", buildCtx, "-t", imageName).AssertOK()
 	tempDirectory, err := os.MkdirTemp(t.TempDir(), "rw")
 	assert.NilError(t, err)
-	defer os.RemoveAll(tempDirectory)
 	err = os.Chmod(tempDirectory, 0777)
 	assert.NilError(t, err)
-	containerName := imageName + "container"
-	base.Cmd([]string{
-		"run",
-		"-d",
-		"--name",
-		containerName,
-		"-p",
-		"24225:24224",
-		"-v",
-		fmt.Sprintf("%s:/data", tempDirectory),
-		"-e",
-		"FLUENTD_CONF=test.conf",
-		imageName,
-	}...).AssertOK()
-	defer base.Cmd("rm", "-f", containerName).AssertOK()
-	defer base.Cmd("kill", containerName).AssertOK()
+	containerName := fmt.Sprintf("%s-%d", imageName, port)
+	base.Cmd("run", "-d", "--name", containerName, "-p", fmt.Sprintf("%d:24224", port),
+		"-v", fmt.Sprintf("%s:/data", tempDirectory), "-e", "FLUENTD_CONF=test.conf", imageName).AssertOK()
+	t.Cleanup(func() {
+		base.Cmd("rm", "-f", containerName).AssertOK()
+	})
 	time.Sleep(3 * time.Second)
+	return tempDirectory
+}
+
+// readFluentdLog returns the content of the *.log file written by runFluentdServer, or "" if not written yet.
+func readFluentdLog(t *testing.T, dir string) string {
+	matches, err := filepath.Glob(filepath.Join(dir, "*log"))
+	assert.NilError(t, err)
+	if len(matches) == 0 {
+		return ""
+	}
+	assert.Equal(t, 1, len(matches))
+	data, err := os.ReadFile(matches[0])
+	assert.NilError(t, err)
+	return string(data)
+}
+
+func TestRunWithFluentdLogDriverWithLogOpt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fluentd log driver is not yet implemented on Windows")
+	}
+	base := testutil.NewBase(t)
+	logDir := runFluentdServer(t, base, 24225)
 	testContainerName := "test-container"
 	base.Cmd([]string{
 		"run",
@@ -397,6 +468,12 @@ RUN rm -rf /fluentd/etc/test.conf && echo  -e '\
 		"fluentd",
 		"--log-opt",
 		"fluentd-address=127.0.0.1:24225",
+		"--log-opt",
+		"tag={{.Name}}",
+		"--log-opt",
+		"labels=foo",
+		"--label",
+		"foo=bar",
 		"--name",
 		testContainerName,
 		testutil.CommonImage,
@@ -404,18 +481,75 @@ RUN rm -rf /fluentd/etc/test.conf && echo  -e '\
 	}...).AssertOK()
 	defer base.Cmd("rm", "-f", testContainerName).AssertOK()
 	inspectedContainer := base.InspectContainer(testContainerName)
-	matches, err := filepath.Glob(tempDirectory + "/" + "*log")
-	assert.NilError(t, err)
-	assert.Equal(t, 1, len(matches))
-	logFile := matches[0]
-	defer os.Remove(logFile)
-	file, err := os.Open(logFile)
-	assert.NilError(t, err)
-	defer file.Close()
-	reader := bufio.NewReader(file)
-	data, _, err := reader.ReadLine()
-	assert.NilError(t, err)
-	logData := string(data)
+	logData := readFluentdLog(t, logDir)
 	assert.Equal(t, true, strings.Contains(logData, "test2"))
 	assert.Equal(t, true, strings.Contains(logData, inspectedContainer.ID))
+	// the records are formatted as "<TIME>\t<TAG>\t<JSON>"
+	assert.Equal(t, true, strings.Contains(logData, "\t"+testContainerName+"\t"), logData)
+	assert.Equal(t, true, strings.Contains(logData, `"container_name":"`+testContainerName+`"`), logData)
+	assert.Equal(t, true, strings.Contains(logData, `"foo":"bar"`), logData)
+
+	// the opts are validated even without -d
+	base.Cmd("run", "--rm", "--log-driver", "fluentd", "--log-opt", "fluentd-async=foo", testutil.CommonImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--log-driver", "fluentd", "--log-opt", "unknown=foo", testutil.CommonImage, "true").AssertFail()
+	base.Cmd("create", "--log-driver", "fluentd", "--log-opt", "unknown=foo", testutil.CommonImage, "true").AssertFail()
+}
+
+func TestRunWithFluentdLogDriverAsync(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fluentd log driver is not yet implemented on Windows")
+	}
+	base := testutil.NewBase(t)
+	logDir := runFluentdServer(t, base, 24226)
+	testContainerName := testutil.Identifier(t)
+	base.Cmd("run", "-d", "--log-driver", "fluentd", "--log-opt", "fluentd-address=127.0.0.1:24226",
+		"--log-opt", "fluentd-async=true", "--name", testContainerName, testutil.CommonImage,
+		"sh", "-c", "echo test-async").AssertOK()
+	defer base.Cmd("rm", "-f", testContainerName).AssertOK()
+	// the records buffered in the async mode are flushed when the container exits
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if strings.Contains(readFluentdLog(t, logDir), "test-async") {
+			return poll.Success()
+		}
+		return poll.Continue("the record is not yet received by fluentd")
+	}, poll.WithDelay(100*time.Millisecond), poll.WithTimeout(30*time.Second))
+}
+
+func TestRunDetachRm(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", tID).Run()
+
+	base.Cmd("run", "-d", "--rm", "--name", tID, "-v", "/anon", testutil.CommonImage, "sleep", "3").AssertOK()
+	anonVolume := ""
+	for _, m := range base.InspectContainer(tID).Mounts {
+		if m.Destination == "/anon" {
+			anonVolume = m.Name
+		}
+	}
+	assert.Assert(t, anonVolume != "")
+	defer base.Cmd("volume", "rm", "-f", anonVolume).Run()
+
+	removed := false
+	for i := 0; i < 30; i++ {
+		if base.Cmd("inspect", tID).Run().ExitCode != 0 {
+			removed = true
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	assert.Assert(t, removed, "container %s must be removed after exit", tID)
+	base.Cmd("volume", "inspect", anonVolume).AssertFail()
+	// the name is released
+	base.Cmd("run", "--rm", "--name", tID, testutil.CommonImage, "true").AssertOK()
+
+	base.Cmd("run", "-d", "--rm", "--restart=always", testutil.CommonImage, "true").AssertFail()
+}
+
+func TestRunEntrypointJSON(t *testing.T) {
+	t.Parallel()
+	testutil.DockerIncompatible(t) // Docker treats the JSON array as a single executable
+	base := testutil.NewBase(t)
+	base.Cmd("run", "--rm", "--entrypoint", `["/bin/sh", "-c"]`, testutil.CommonImage, "echo foo bar").AssertOutExactly("foo bar\n")
+	base.Cmd("run", "--rm", "--entrypoint", "/bin/echo", testutil.CommonImage, "foo").AssertOutExactly("foo\n")
 }
@@ -0,0 +1,47 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/docker/go-units"
+)
+
+// linuxMinMemory is the minimum value of `--memory` and `--memory-reservation` (6MB), as in Docker.
+// The memory cgroup rejects or misbehaves with smaller limits.
+const linuxMinMemory int64 = 6 * 1024 * 1024
+
+// linuxMinKernelMemory is the minimum value of `--kernel-memory` (4MB), as in Docker.
+const linuxMinKernelMemory int64 = 4 * 1024 * 1024
+
+// parseMemoryBytes parses the value of a memory flag such as "512m" and "1g".
+// The suffix is one of b, k, m, g, t, p (case-insensitive, optionally followed by "b" or "ib"),
+// and is interpreted in the base of 1024.
+//
+// When minimum is positive, non-zero values below minimum are rejected.
+// Zero is allowed, as it means "unlimited".
+func parseMemoryBytes(flagName, s string, minimum int64) (int64, error) {
+	b, err := units.RAMInBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q for --%s, expected bytes with an optional unit suffix (b, k, m, g): %w", s, flagName, err)
+	}
+	if minimum > 0 && b != 0 && b < minimum {
+		return 0, fmt.Errorf("invalid value %q for --%s: minimum allowed is %s (%d bytes)", s, flagName, units.BytesSize(float64(minimum)), minimum)
+	}
+	return b, nil
+}
@@ -0,0 +1,63 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseMemoryBytes(t *testing.T) {
+	testCases := []struct {
+		in       string
+		minimum  int64
+		expected int64
+		errMsg   string
+	}{
+		{in: "10485760", expected: 10 * 1024 * 1024},
+		{in: "10485760b", expected: 10 * 1024 * 1024},
+		{in: "10240k", expected: 10 * 1024 * 1024},
+		{in: "10240K", expected: 10 * 1024 * 1024},
+		{in: "10240kb", expected: 10 * 1024 * 1024},
+		{in: "10m", expected: 10 * 1024 * 1024},
+		{in: "10M", expected: 10 * 1024 * 1024},
+		{in: "10MiB", expected: 10 * 1024 * 1024},
+		{in: "1g", expected: 1024 * 1024 * 1024},
+		{in: "1G", expected: 1024 * 1024 * 1024},
+		{in: "1.5g", expected: 1536 * 1024 * 1024},
+		{in: "6m", minimum: linuxMinMemory, expected: linuxMinMemory},
+		{in: "0", minimum: linuxMinMemory, expected: 0},
+		{in: "4m", minimum: 0, expected: 4 * 1024 * 1024},
+		{in: "4m", minimum: linuxMinMemory, errMsg: "minimum allowed is 6MiB"},
+		{in: "6291455b", minimum: linuxMinMemory, errMsg: "minimum allowed is 6MiB"},
+		{in: "", errMsg: "invalid value"},
+		{in: "10x", errMsg: "invalid value"},
+		{in: "-1", errMsg: "invalid value"},
+		{in: "m", errMsg: "invalid value"},
+	}
+	for _, tc := range testCases {
+		got, err := parseMemoryBytes("memory", tc.in, tc.minimum)
+		if tc.errMsg != "" {
+			assert.ErrorContains(t, err, tc.errMsg, "input %q", tc.in)
+			assert.ErrorContains(t, err, "--memory", "input %q", tc.in)
+			continue
+		}
+		assert.NilError(t, err, "input %q", tc.in)
+		assert.Equal(t, tc.expected, got, "input %q", tc.in)
+	}
+}
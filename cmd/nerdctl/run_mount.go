@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -32,9 +33,11 @@ import (
 	"github.com/containerd/continuity/fs"
 	"github.com/containerd/nerdctl/pkg/idgen"
 	"github.com/containerd/nerdctl/pkg/imgutil"
+	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
 	"github.com/containerd/nerdctl/pkg/mountutil"
 	"github.com/containerd/nerdctl/pkg/mountutil/volumestore"
 	"github.com/containerd/nerdctl/pkg/strutil"
+	"github.com/containerd/nerdctl/pkg/volumeplugin"
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/opencontainers/image-spec/identity"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -76,13 +79,22 @@ func withMounts(mounts []specs.Mount) oci.SpecOpts {
 }
 
 // parseMountFlags parses --volume, --mount and --tmpfs.
-func parseMountFlags(cmd *cobra.Command, volStore volumestore.VolumeStore) ([]*mountutil.Processed, error) {
+func parseMountFlags(cmd *cobra.Command, volStore volumestore.VolumeStore, id string) (_ []*mountutil.Processed, retErr error) {
 	var parsed []*mountutil.Processed
+	defer func() {
+		if retErr != nil {
+			unmountPluginMountPoints(volStore, id, parsed)
+		}
+	}()
+	expandEnv, err := cmd.Flags().GetBool("expand-mount-env")
+	if err != nil {
+		return nil, err
+	}
 	if flagVSlice, err := cmd.Flags().GetStringArray("volume"); err != nil {
 		return nil, err
 	} else {
 		for _, v := range strutil.DedupeStrSlice(flagVSlice) {
-			x, err := mountutil.ProcessFlagV(v, volStore)
+			x, err := mountutil.ProcessFlagV(v, volStore, id, expandEnv)
 			if err != nil {
 				return nil, err
 			}
@@ -107,7 +119,7 @@ func parseMountFlags(cmd *cobra.Command, volStore volumestore.VolumeStore) ([]*m
 		return nil, err
 	} else {
 		for _, v := range strutil.DedupeStrSlice(mountsSlice) {
-			x, err := mountutil.ProcessFlagMount(v, volStore)
+			x, err := mountutil.ProcessFlagMount(v, volStore, id, expandEnv)
 			if err != nil {
 				return nil, err
 			}
@@ -118,9 +130,22 @@ func parseMountFlags(cmd *cobra.Command, volStore volumestore.VolumeStore) ([]*m
 	return parsed, nil
 }
 
+// unmountPluginMountPoints unmounts the volumes mounted by volume driver plugins for the container
+// that failed to be created. The errors are just logged.
+func unmountPluginMountPoints(volStore volumestore.VolumeStore, id string, mountPoints []*mountutil.Processed) {
+	for _, mp := range mountPoints {
+		if mp.Type != mountutil.Volume || mp.Name == "" || mp.Driver == "" || mp.Driver == volumeplugin.LocalDriver {
+			continue
+		}
+		if err := volStore.Unmount(mp.Name, id); err != nil {
+			logrus.WithError(err).Warnf("failed to unmount volume %q", mp.Name)
+		}
+	}
+}
+
 // generateMountOpts generates volume-related mount opts.
 // Other mounts such as procfs mount are not handled here.
-func generateMountOpts(cmd *cobra.Command, ctx context.Context, client *containerd.Client, ensuredImage *imgutil.EnsuredImage) ([]oci.SpecOpts, []string, []*mountutil.Processed, error) {
+func generateMountOpts(cmd *cobra.Command, ctx context.Context, client *containerd.Client, ensuredImage *imgutil.EnsuredImage, id string) (_ []oci.SpecOpts, _ []string, _ []*mountutil.Processed, retErr error) {
 	volStore, err := getVolumeStore(cmd)
 	if err != nil {
 		return nil, nil, nil, err
@@ -205,9 +230,14 @@ func generateMountOpts(cmd *cobra.Command, ctx context.Context, client *containe
 		}
 	}
 
-	if parsed, err := parseMountFlags(cmd, volStore); err != nil {
+	if parsed, err := parseMountFlags(cmd, volStore, id); err != nil {
 		return nil, nil, nil, err
 	} else if len(parsed) > 0 {
+		defer func() {
+			if retErr != nil {
+				unmountPluginMountPoints(volStore, id, parsed)
+			}
+		}()
 		ociMounts := make([]specs.Mount, len(parsed))
 		for i, x := range parsed {
 			ociMounts[i] = x.Mount
@@ -276,6 +306,7 @@ func generateMountOpts(cmd *cobra.Command, ctx context.Context, client *containe
 		mountPoint := &mountutil.Processed{
 			Type:            "volume",
 			AnonymousVolume: anonVolName,
+			Driver:          anonVol.Driver,
 			Mount:           m,
 		}
 		mountPoints = append(mountPoints, mountPoint)
@@ -301,3 +332,81 @@ func copyExistingContents(source, destination string) error {
 	}
 	return fs.CopyDir(destination, source)
 }
+
+// mountPluginVolumes mounts the volumes of the container that are managed by volume driver plugins,
+// as they are unmounted when the container is stopped, and after reboot.
+// mountsJSON is the value of the labels.Mounts label.
+func mountPluginVolumes(ctx context.Context, cmd *cobra.Command, container containerd.Container, mountsJSON string) error {
+	if mountsJSON == "" {
+		return nil
+	}
+	var mounts []dockercompat.MountPoint
+	if err := json.Unmarshal([]byte(mountsJSON), &mounts); err != nil {
+		return err
+	}
+	var (
+		volStore volumestore.VolumeStore
+		spec     *oci.Spec
+		updated  bool
+	)
+	for _, m := range mounts {
+		if m.Type != mountutil.Volume || m.Driver == "" || m.Driver == volumeplugin.LocalDriver {
+			continue
+		}
+		if volStore == nil {
+			var err error
+			volStore, err = getVolumeStore(cmd)
+			if err != nil {
+				return err
+			}
+			spec, err = container.Spec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		src, err := volStore.Mount(m.Name, container.ID())
+		if err != nil {
+			return fmt.Errorf("failed to mount volume %q: %w", m.Name, err)
+		}
+		// the plugin may return a different mountpoint than the one in the spec
+		for i := range spec.Mounts {
+			if spec.Mounts[i].Destination == m.Destination && spec.Mounts[i].Source != src {
+				spec.Mounts[i].Source = src
+				updated = true
+			}
+		}
+	}
+	if updated {
+		return updateContainerSpec(ctx, container, spec)
+	}
+	return nil
+}
+
+// unmountPluginVolumes unmounts the volumes that were mounted by volume driver plugins for the container.
+// mountsJSON is the value of the labels.Mounts label.
+func unmountPluginVolumes(cmd *cobra.Command, id, mountsJSON string) error {
+	if mountsJSON == "" {
+		return nil
+	}
+	var mounts []dockercompat.MountPoint
+	if err := json.Unmarshal([]byte(mountsJSON), &mounts); err != nil {
+		return err
+	}
+	var volStore volumestore.VolumeStore
+	for _, m := range mounts {
+		if m.Type != mountutil.Volume || m.Driver == "" || m.Driver == volumeplugin.LocalDriver {
+			continue
+		}
+		if volStore == nil {
+			var err error
+			volStore, err = getVolumeStore(cmd)
+			if err != nil {
+				return err
+			}
+		}
+		if err := volStore.Unmount(m.Name, id); err != nil {
+			return fmt.Errorf("failed to unmount volume %q: %w", m.Name, err)
+		}
+	}
+	return nil
+}
@@ -615,3 +615,40 @@ func isRootfsShareableMount() bool {
 
 	return false
 }
+
+func TestRunBindMountSourceCreation(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	tempDir := t.TempDir()
+
+	// -v creates the missing source
+	volumeSrc := filepath.Join(tempDir, "volume-src")
+	base.Cmd("run", "--rm", "-v", volumeSrc+":/mnt", testutil.AlpineImage, "touch", "/mnt/file").AssertOK()
+	_, err := os.Stat(filepath.Join(volumeSrc, "file"))
+	assert.NilError(t, err)
+
+	// --mount type=bind does not create the missing source unless create-source=true is specified
+	mountSrc := filepath.Join(tempDir, "mount-src")
+	base.Cmd("run", "--rm", "--mount", fmt.Sprintf("type=bind,src=%s,target=/mnt", mountSrc), testutil.AlpineImage, "true").AssertFail()
+	_, err = os.Stat(mountSrc)
+	assert.Assert(t, os.IsNotExist(err))
+	if base.Target == testutil.Docker {
+		// create-source is specific to nerdctl
+		return
+	}
+	base.Cmd("run", "--rm", "--mount", fmt.Sprintf("type=bind,src=%s,target=/mnt,create-source=true", mountSrc), testutil.AlpineImage, "touch", "/mnt/file").AssertOK()
+	_, err = os.Stat(filepath.Join(mountSrc, "file"))
+	assert.NilError(t, err)
+}
+
+func TestRunBindMountExpandEnv(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	t.Parallel()
+	base := testutil.NewBase(t)
+	tempDir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(tempDir, "file"), []byte("expanded"), 0644))
+	base.Env = append(os.Environ(), "NERDCTL_TEST_MOUNT_SRC="+tempDir, "NERDCTL_TEST_MOUNT_DST=/mnt")
+
+	base.Cmd("run", "--rm", "--expand-mount-env", "-v", "${NERDCTL_TEST_MOUNT_SRC}:${NERDCTL_TEST_MOUNT_DST}", testutil.AlpineImage, "cat", "/mnt/file").AssertOutExactly("expanded")
+	base.Cmd("run", "--rm", "--expand-mount-env", "--mount", "type=bind,src=$NERDCTL_TEST_MOUNT_SRC,dst=$NERDCTL_TEST_MOUNT_DST", testutil.AlpineImage, "cat", "/mnt/file").AssertOutExactly("expanded")
+}
@@ -18,17 +18,24 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
+	"net"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
+	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/oci"
 	gocni "github.com/containerd/go-cni"
 	"github.com/containerd/nerdctl/pkg/dnsutil"
 	"github.com/containerd/nerdctl/pkg/dnsutil/hostsstore"
+	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
+	"github.com/containerd/nerdctl/pkg/labels"
 	"github.com/containerd/nerdctl/pkg/mountutil"
 	"github.com/containerd/nerdctl/pkg/netutil"
 	"github.com/containerd/nerdctl/pkg/netutil/nettype"
@@ -71,6 +78,90 @@ func getNetworkSlice(cmd *cobra.Command) ([]string, error) {
 	return netSlice, nil
 }
 
+// parseNetworkSlice parses the values of --network, which are either a network name or
+// `name=<NETWORK>,ip=<IP>,alias=<ALIAS>` (the values are already split on commas by the flag parser).
+// The network names and the per-network options are returned.
+func parseNetworkSlice(values []string) ([]string, map[string]netutil.EndpointOpts, error) {
+	var (
+		netSlice  []string
+		endpoints = make(map[string]netutil.EndpointOpts)
+		current   string
+	)
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			netSlice = append(netSlice, v)
+			current = ""
+			continue
+		}
+		k, val := kv[0], kv[1]
+		if k == "name" {
+			if val == "" {
+				return nil, nil, fmt.Errorf("invalid network %q: empty name", v)
+			}
+			netSlice = append(netSlice, val)
+			current = val
+			continue
+		}
+		if current == "" {
+			return nil, nil, fmt.Errorf("invalid network option %q: must follow name=<NETWORK>", v)
+		}
+		ep := endpoints[current]
+		switch k {
+		case "ip":
+			if net.ParseIP(val) == nil {
+				return nil, nil, fmt.Errorf("invalid IP address %q for network %q", val, current)
+			}
+			if ep.IP != "" {
+				return nil, nil, fmt.Errorf("multiple IP addresses for network %q", current)
+			}
+			ep.IP = val
+		case "alias":
+			if val == "" {
+				return nil, nil, fmt.Errorf("empty alias for network %q", current)
+			}
+			ep.Aliases = strutil.DedupeStrSlice(append(ep.Aliases, val))
+		default:
+			return nil, nil, fmt.Errorf("unknown network option %q", k)
+		}
+		endpoints[current] = ep
+	}
+	if len(endpoints) == 0 {
+		endpoints = nil
+	}
+	return netSlice, endpoints, nil
+}
+
+// getNetworkAliases returns the values of --network-alias and --net-alias.
+// The aliases are resolvable only in the user-defined networks, like Docker.
+func getNetworkAliases(cmd *cobra.Command, netSlice []string) ([]string, error) {
+	var aliases []string
+	for _, flagName := range []string{"network-alias", "net-alias"} {
+		a, err := cmd.Flags().GetStringSlice(flagName)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a...)
+	}
+	if len(aliases) == 0 {
+		return nil, nil
+	}
+	netType, err := nettype.Detect(netSlice)
+	if err != nil {
+		return nil, err
+	}
+	userDefined := false
+	for _, n := range netSlice {
+		if n != netutil.DefaultNetworkName {
+			userDefined = true
+		}
+	}
+	if netType != nettype.CNI || !userDefined {
+		return nil, errors.New("network-scoped aliases are supported only for containers in user-defined networks")
+	}
+	return strutil.DedupeStrSlice(aliases), nil
+}
+
 func withCustomResolvConf(src string) func(context.Context, oci.Client, *containers.Container, *oci.Spec) error {
 	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
 		s.Mounts = append(s.Mounts, specs.Mount{
@@ -107,19 +198,25 @@ func withCustomHosts(src string) func(context.Context, oci.Client, *containers.C
 	}
 }
 
-func generateNetOpts(cmd *cobra.Command, dataStore, stateDir, ns, id string) ([]oci.SpecOpts, []string, string, []gocni.PortMapping, error) {
+// generateNetOpts returns the spec opts, the network names, the IP address (--ip), the per-network options
+// (`--network name=<NETWORK>,ip=<IP>,alias=<ALIAS>`), and the port mappings.
+func generateNetOpts(ctx context.Context, cmd *cobra.Command, client *containerd.Client, dataStore, stateDir, ns, id string) ([]oci.SpecOpts, []string, string, map[string]netutil.EndpointOpts, []gocni.PortMapping, error) {
 	opts := []oci.SpecOpts{}
 	portSlice, err := cmd.Flags().GetStringSlice("publish")
 	if err != nil {
-		return nil, nil, "", nil, err
+		return nil, nil, "", nil, nil, err
 	}
 	ipAddress, err := cmd.Flags().GetString("ip")
 	if err != nil {
-		return nil, nil, "", nil, err
+		return nil, nil, "", nil, nil, err
+	}
+	netValues, err := getNetworkSlice(cmd)
+	if err != nil {
+		return nil, nil, "", nil, nil, err
 	}
-	netSlice, err := getNetworkSlice(cmd)
+	netSlice, endpoints, err := parseNetworkSlice(netValues)
 	if err != nil {
-		return nil, nil, "", nil, err
+		return nil, nil, "", nil, nil, err
 	}
 
 	if (len(netSlice) == 0) && (ipAddress != "") {
@@ -129,7 +226,11 @@ func generateNetOpts(cmd *cobra.Command, dataStore, stateDir, ns, id string) ([]
 	ports := make([]gocni.PortMapping, 0)
 	netType, err := nettype.Detect(netSlice)
 	if err != nil {
-		return nil, nil, "", nil, err
+		return nil, nil, "", nil, nil, err
+	}
+
+	if len(endpoints) > 0 && netType != nettype.CNI {
+		return nil, nil, "", nil, nil, errors.New("per-network options are supported only for CNI networks")
 	}
 
 	switch netType {
@@ -141,33 +242,167 @@ func generateNetOpts(cmd *cobra.Command, dataStore, stateDir, ns, id string) ([]
 		// We only verify flags and generate resolv.conf here.
 		// The actual network is configured in the oci hook.
 		if err := verifyCNINetwork(cmd, netSlice); err != nil {
-			return nil, nil, "", nil, err
+			return nil, nil, "", nil, nil, err
+		}
+		if ipAddress != "" && len(netSlice) > 1 {
+			// the OCI hook would request the same address in all the networks
+			return nil, nil, "", nil, nil, errors.New("conflicting options: --ip and multiple networks (Hint: use --network name=<NETWORK>,ip=<IP>)")
+		}
+		for n, ep := range endpoints {
+			if ep.IP != "" && ipAddress != "" {
+				return nil, nil, "", nil, nil, fmt.Errorf("conflicting options: --ip and the IP address of network %q", n)
+			}
+			if len(ep.Aliases) > 0 && n == netutil.DefaultNetworkName {
+				return nil, nil, "", nil, nil, errors.New("network-scoped aliases are supported only for containers in user-defined networks")
+			}
 		}
 
 		if runtime.GOOS == "linux" {
 			resolvConfPath := filepath.Join(stateDir, "resolv.conf")
 			if err := buildResolvConf(cmd, resolvConfPath); err != nil {
-				return nil, nil, "", nil, err
+				return nil, nil, "", nil, nil, err
 			}
 
 			// the content of /etc/hosts is created in OCI Hook
 			etcHostsPath, err := hostsstore.AllocHostsFile(dataStore, ns, id)
 			if err != nil {
-				return nil, nil, "", nil, err
+				return nil, nil, "", nil, nil, err
 			}
 			opts = append(opts, withCustomResolvConf(resolvConfPath), withCustomHosts(etcHostsPath))
 			for _, p := range portSlice {
 				pm, err := portutil.ParseFlagP(p)
 				if err != nil {
-					return nil, nil, "", pm, err
+					return nil, nil, "", nil, pm, err
 				}
 				ports = append(ports, pm...)
 			}
 		}
+	case nettype.Container:
+		if runtime.GOOS != "linux" {
+			return nil, nil, "", nil, nil, fmt.Errorf("container network mode is not supported on %s", runtime.GOOS)
+		}
+		if len(portSlice) > 0 {
+			return nil, nil, "", nil, nil, errors.New("conflicting options: port publishing and the container type network mode")
+		}
+		if ipAddress != "" {
+			return nil, nil, "", nil, nil, errors.New("conflicting options: --ip and the container type network mode")
+		}
+		containerOpts, targetID, err := generateContainerNetOpts(ctx, client, dataStore, ns, strings.TrimPrefix(netSlice[0], nettype.ContainerPrefix))
+		if err != nil {
+			return nil, nil, "", nil, nil, err
+		}
+		opts = append(opts, containerOpts...)
+		// the ID is recorded in the label, so that the network namespace is resolved again on start
+		netSlice = []string{nettype.ContainerPrefix + targetID}
 	default:
-		return nil, nil, "", nil, fmt.Errorf("unexpected network type %v", netType)
+		return nil, nil, "", nil, nil, fmt.Errorf("unexpected network type %v", netType)
 	}
-	return opts, netSlice, ipAddress, ports, nil
+	return opts, netSlice, ipAddress, endpoints, ports, nil
+}
+
+// generateContainerNetOpts generates the spec opts to join the network namespace of the running container,
+// and to share its /etc/hosts and /etc/resolv.conf.
+// No CNI network is configured for the joining container.
+// The ID of the target container is returned as well.
+func generateContainerNetOpts(ctx context.Context, client *containerd.Client, dataStore, ns, req string) ([]oci.SpecOpts, string, error) {
+	var target containerd.Container
+	walker := &containerwalker.ContainerWalker{
+		Client: client,
+		OnFound: func(ctx context.Context, found containerwalker.Found) error {
+			if found.MatchCount > 1 {
+				return fmt.Errorf("ambiguous ID %q", found.Req)
+			}
+			target = found.Container
+			return nil
+		},
+	}
+	n, err := walker.Walk(ctx, req)
+	if err != nil {
+		return nil, "", err
+	} else if n == 0 {
+		return nil, "", fmt.Errorf("no such container: %s", req)
+	}
+	netNSPath, err := containerNetNSPath(ctx, target)
+	if err != nil {
+		return nil, "", err
+	}
+	opts := []oci.SpecOpts{
+		oci.WithLinuxNamespace(specs.LinuxNamespace{
+			Type: specs.NetworkNamespace,
+			Path: netNSPath,
+		}),
+	}
+	targetLabels, err := target.Labels(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	// the files do not exist when the target container is not on a CNI network
+	if stateDir := targetLabels[labels.StateDir]; stateDir != "" {
+		resolvConfPath := filepath.Join(stateDir, "resolv.conf")
+		if _, err := os.Stat(resolvConfPath); err == nil {
+			opts = append(opts, withCustomResolvConf(resolvConfPath))
+		}
+	}
+	etcHostsPath := hostsstore.HostsPath(dataStore, ns, target.ID())
+	if _, err := os.Stat(etcHostsPath); err == nil {
+		opts = append(opts, withCustomHosts(etcHostsPath))
+	}
+	return opts, target.ID(), nil
+}
+
+// containerNetNSPath returns the path of the network namespace of the running container.
+func containerNetNSPath(ctx context.Context, container containerd.Container) (string, error) {
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("container %s is not running: %w", container.ID(), err)
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	if status.Status != containerd.Running {
+		return "", fmt.Errorf("container %s is not running (status: %s)", container.ID(), status.Status)
+	}
+	return fmt.Sprintf("/proc/%d/ns/net", task.Pid()), nil
+}
+
+// updateContainerNetNSPath resolves the network namespace of the container created with
+// `--network=container:<container>` again, as the PID of the target container changes when it is restarted.
+func updateContainerNetNSPath(ctx context.Context, client *containerd.Client, container containerd.Container, lab map[string]string) error {
+	var networks []string
+	if err := json.Unmarshal([]byte(lab[labels.Networks]), &networks); err != nil {
+		return nil
+	}
+	if len(networks) != 1 || !strings.HasPrefix(networks[0], nettype.ContainerPrefix) {
+		return nil
+	}
+	targetID := strings.TrimPrefix(networks[0], nettype.ContainerPrefix)
+	target, err := client.LoadContainer(ctx, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to load container %s to join its network namespace: %w", targetID, err)
+	}
+	netNSPath, err := containerNetNSPath(ctx, target)
+	if err != nil {
+		return err
+	}
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return err
+	}
+	if spec.Linux == nil {
+		return nil
+	}
+	for i, n := range spec.Linux.Namespaces {
+		if n.Type != specs.NetworkNamespace {
+			continue
+		}
+		if n.Path == netNSPath {
+			return nil
+		}
+		spec.Linux.Namespaces[i].Path = netNSPath
+		return updateContainerSpec(ctx, container, spec)
+	}
+	return nil
 }
 
 func verifyCNINetwork(cmd *cobra.Command, netSlice []string) error {
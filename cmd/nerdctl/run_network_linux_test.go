@@ -485,3 +485,98 @@ func TestRunDNS(t *testing.T) {
 	cmd.AssertOutContains("search test\n")
 	cmd.AssertOutContains("options attempts:10\n")
 }
+
+func TestRunContainerNetwork(t *testing.T) {
+	base := testutil.NewBase(t)
+	targetName := testutil.Identifier(t) + "-target"
+	defer base.Cmd("rm", "-f", targetName).Run()
+
+	// the target container must be running
+	base.Cmd("create", "--name", targetName, testutil.NginxAlpineImage).AssertOK()
+	base.Cmd("run", "--rm", "--net", "container:"+targetName, testutil.AlpineImage, "true").AssertFail()
+	base.Cmd("start", targetName).AssertOK()
+
+	// the service listening on the loopback of the target is reachable
+	base.Cmd("run", "--rm", "--net", "container:"+targetName, testutil.AlpineImage,
+		"wget", "-qO-", "http://127.0.0.1:80").AssertOutContains(testutil.NginxAlpineIndexHTMLSnippet)
+
+	// the IP address is shared
+	targetIP := base.InspectContainer(targetName).NetworkSettings.IPAddress
+	base.Cmd("run", "--rm", "--net", "container:"+targetName, testutil.AlpineImage, "ip", "addr", "show", "eth0").AssertOutContains(targetIP)
+
+	// the network namespace is resolved again on start after the target is restarted
+	joinerName := testutil.Identifier(t) + "-joiner"
+	defer base.Cmd("rm", "-f", joinerName).Run()
+	base.Cmd("create", "--name", joinerName, "--net", "container:"+targetName, testutil.AlpineImage, "ip", "addr", "show", "eth0").AssertOK()
+	base.Cmd("restart", targetName).AssertOK()
+	base.Cmd("start", "-a", joinerName).AssertOutContains(base.InspectContainer(targetName).NetworkSettings.IPAddress)
+
+	base.Cmd("run", "--rm", "--net", "container:"+targetName, "-p", "8080:80", testutil.AlpineImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--net", "container:"+targetName, "--ip", "10.4.0.100", testutil.AlpineImage, "true").AssertFail()
+	base.Cmd("run", "--rm", "--net", "container:"+targetName, "--net", "bridge", testutil.AlpineImage, "true").AssertFail()
+}
+
+func TestRunMultipleNetworksWithAlias(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	net0, net1 := tID+"-n0", tID+"-n1"
+	serverName, clientName := tID+"-server", tID+"-client"
+	defer base.Cmd("network", "rm", net0, net1).Run()
+	defer base.Cmd("rm", "-f", serverName, clientName).Run()
+
+	base.Cmd("network", "create", net0).AssertOK()
+	base.Cmd("network", "create", net1).AssertOK()
+	base.Cmd("run", "-d", "--name", serverName, "--net", net0, "--net", net1,
+		"--network-alias", "web", testutil.NginxAlpineImage).AssertOK()
+
+	// the container has an interface on each network
+	base.Cmd("exec", serverName, "ip", "addr", "show", "eth0").AssertOK()
+	base.Cmd("exec", serverName, "ip", "addr", "show", "eth1").AssertOK()
+
+	// the alias is resolvable from the containers on the other network
+	base.Cmd("run", "-d", "--name", clientName, "--net", net1, testutil.NginxAlpineImage).AssertOK()
+	base.Cmd("exec", clientName, "wget", "-qO-", "http://web").AssertOutContains(testutil.NginxAlpineIndexHTMLSnippet)
+	base.Cmd("exec", clientName, "wget", "-qO-", "http://web."+net1).AssertOutContains(testutil.NginxAlpineIndexHTMLSnippet)
+
+	// aliases are not supported in the default network
+	base.Cmd("run", "--rm", "--network-alias", "web", testutil.AlpineImage, "true").AssertFail()
+
+	// --ip cannot be combined with multiple networks
+	base.Cmd("run", "--rm", "--net", net0, "--net", net1, "--ip", "10.4.0.100", testutil.AlpineImage, "true").AssertFail()
+}
+
+func TestRunMultipleNetworksWithEndpointOpts(t *testing.T) {
+	if rootlessutil.IsRootless() {
+		t.Skip("Static IP assignment is not supported rootless mode yet.")
+	}
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	net0, net1 := tID+"-n0", tID+"-n1"
+	serverName, client0Name, client1Name := tID+"-server", tID+"-client0", tID+"-client1"
+	defer base.Cmd("network", "rm", net0, net1).Run()
+	defer base.Cmd("rm", "-f", serverName, client0Name, client1Name).Run()
+
+	base.Cmd("network", "create", net0, "--subnet", "10.5.10.0/24").AssertOK()
+	base.Cmd("network", "create", net1, "--subnet", "10.5.11.0/24").AssertOK()
+	base.Cmd("run", "-d", "--name", serverName,
+		"--net", "name="+net0+",ip=10.5.10.100,alias=web0",
+		"--net", "name="+net1+",ip=10.5.11.100,alias=web1",
+		testutil.NginxAlpineImage).AssertOK()
+
+	// each network has its own IP address
+	base.Cmd("exec", serverName, "ip", "addr", "show", "eth0").AssertOutContains("10.5.10.100/")
+	base.Cmd("exec", serverName, "ip", "addr", "show", "eth1").AssertOutContains("10.5.11.100/")
+
+	// each alias is resolvable only in its own network
+	base.Cmd("run", "-d", "--name", client0Name, "--net", net0, testutil.NginxAlpineImage).AssertOK()
+	base.Cmd("run", "-d", "--name", client1Name, "--net", net1, testutil.NginxAlpineImage).AssertOK()
+	base.Cmd("exec", client0Name, "wget", "-qO-", "http://web0").AssertOutContains(testutil.NginxAlpineIndexHTMLSnippet)
+	base.Cmd("exec", client1Name, "wget", "-qO-", "http://web1."+net1).AssertOutContains(testutil.NginxAlpineIndexHTMLSnippet)
+	base.Cmd("exec", client0Name, "wget", "-qO-", "http://web1").AssertFail()
+	base.Cmd("exec", client1Name, "wget", "-qO-", "http://web0").AssertFail()
+
+	// --ip conflicts with the per-network IP address
+	base.Cmd("run", "--rm", "--net", "name="+net0+",ip=10.5.10.101", "--ip", "10.5.10.101", testutil.AlpineImage, "true").AssertFail()
+	// aliases are not supported in the default network
+	base.Cmd("run", "--rm", "--net", "name=bridge,alias=web", testutil.AlpineImage, "true").AssertFail()
+}
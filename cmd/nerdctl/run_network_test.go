@@ -0,0 +1,86 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/netutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseNetworkSlice(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		values    []string
+		netSlice  []string
+		endpoints map[string]netutil.EndpointOpts
+		err       string
+	}{
+		{
+			values:   []string{"bridge"},
+			netSlice: []string{"bridge"},
+		},
+		{
+			values:   []string{"foo", "bar"},
+			netSlice: []string{"foo", "bar"},
+		},
+		{
+			// --net name=foo,ip=10.4.1.10,alias=a1,alias=a2 --net bar --net name=baz,alias=b1
+			values:   []string{"name=foo", "ip=10.4.1.10", "alias=a1", "alias=a2", "bar", "name=baz", "alias=b1"},
+			netSlice: []string{"foo", "bar", "baz"},
+			endpoints: map[string]netutil.EndpointOpts{
+				"foo": {IP: "10.4.1.10", Aliases: []string{"a1", "a2"}},
+				"baz": {Aliases: []string{"b1"}},
+			},
+		},
+		{
+			values:   []string{"name=foo"},
+			netSlice: []string{"foo"},
+		},
+		{
+			values: []string{"foo", "ip=10.4.1.10"},
+			err:    "must follow name=<NETWORK>",
+		},
+		{
+			values: []string{"name=foo", "ip=10.4.1"},
+			err:    "invalid IP address",
+		},
+		{
+			values: []string{"name=foo", "ip=10.4.1.10", "ip=10.4.1.11"},
+			err:    "multiple IP addresses",
+		},
+		{
+			values: []string{"name=foo", "mac=02:42:ac:11:00:02"},
+			err:    "unknown network option",
+		},
+		{
+			values: []string{"name="},
+			err:    "empty name",
+		},
+	}
+	for _, tc := range testCases {
+		netSlice, endpoints, err := parseNetworkSlice(tc.values)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, tc.netSlice, netSlice)
+		assert.DeepEqual(t, tc.endpoints, endpoints)
+	}
+}
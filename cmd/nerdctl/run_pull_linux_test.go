@@ -0,0 +1,53 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"github.com/containerd/nerdctl/pkg/testutil/testregistry"
+)
+
+func TestRunPullAlways(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	reg := testregistry.NewPlainHTTP(base, 5000)
+	defer reg.Cleanup()
+	testImageRef := fmt.Sprintf("127.0.0.1:%d/%s:latest", reg.ListenPort, tID)
+	t.Logf("testImageRef=%q", testImageRef)
+	oldImage := tID + "-old"
+	defer base.Cmd("rmi", testImageRef, oldImage).Run()
+
+	commitVersion := func(version, imageRef string) {
+		container := tID + "-" + version
+		defer base.Cmd("rm", "-f", container).Run()
+		base.Cmd("run", "--name", container, testutil.CommonImage, "sh", "-c", "echo "+version+" > /version").AssertOK()
+		base.Cmd("commit", container, imageRef).AssertOK()
+	}
+	commitVersion("v1", oldImage)
+	commitVersion("v2", testImageRef)
+	base.Cmd("push", testImageRef).AssertOK()
+
+	// make the local tag stale, while the registry has v2
+	base.Cmd("tag", oldImage, testImageRef).AssertOK()
+	base.Cmd("run", "--rm", testImageRef, "cat", "/version").AssertOutExactly("v1\n")
+	base.Cmd("run", "--rm", "--pull=always", testImageRef, "cat", "/version").AssertOutExactly("v2\n")
+	// the local tag was updated
+	base.Cmd("run", "--rm", "--pull=never", testImageRef, "cat", "/version").AssertOutExactly("v2\n")
+}
@@ -52,6 +52,9 @@ func generateRestartOpts(ctx context.Context, client *containerd.Client, restart
 	if err != nil {
 		return nil, err
 	}
+	if policy.MaximumRetryCount() < 0 {
+		return nil, fmt.Errorf("invalid restart policy %q: maximum retry count cannot be negative", restartFlag)
+	}
 	opts := []containerd.NewContainerOpts{restart.WithPolicy(policy), restart.WithStatus(containerd.Running)}
 	if logURI != "" {
 		opts = append(opts, restart.WithLogURIString(logURI))
@@ -65,3 +68,12 @@ func updateContainerStoppedLabel(ctx context.Context, container containerd.Conta
 	})
 	return container.Update(ctx, containerd.UpdateContainerOpts(opt))
 }
+
+// resetContainerRestartCount resets the restart count of the container, so that a container started by the user
+// is restarted again up to the maximum retry count of `--restart=on-failure:N`, like Docker.
+func resetContainerRestartCount(ctx context.Context, container containerd.Container) error {
+	opt := containerd.WithAdditionalContainerLabels(map[string]string{
+		restart.CountLabel: "0",
+	})
+	return container.Update(ctx, containerd.UpdateContainerOpts(opt))
+}
@@ -98,16 +98,73 @@ func TestRunRestartWithOnFailure(t *testing.T) {
 	defer base.Cmd("rm", "-f", tID).Run()
 	base.Cmd("run", "-d", "--restart=on-failure:2", "--name", tID, testutil.AlpineImage, "sh", "-c", "exit 1").AssertOK()
 
+	// the container stays stopped after the maximum retry count, so the restart count never exceeds it
 	check := func(log poll.LogT) poll.Result {
 		inspect := base.InspectContainer(tID)
-		if inspect.State != nil && inspect.State.Status == "exited" {
+		if inspect.RestartCount > 2 {
+			return poll.Error(fmt.Errorf("container was restarted %d times, expected at most 2", inspect.RestartCount))
+		}
+		if inspect.State != nil && inspect.State.Status == "exited" && inspect.RestartCount == 2 {
 			return poll.Success()
 		}
-		return poll.Continue("container is not yet exited")
+		return poll.Continue("container is not yet exited after restarts")
 	}
-	poll.WaitOn(t, check, poll.WithDelay(100*time.Microsecond), poll.WithTimeout(60*time.Second))
-	inspect := base.InspectContainer(tID)
-	assert.Equal(t, inspect.RestartCount, 2)
+	poll.WaitOn(t, check, poll.WithDelay(100*time.Millisecond), poll.WithTimeout(60*time.Second))
+
+	// starting the container manually resets the restart count
+	base.Cmd("start", tID).AssertOK()
+	assert.Equal(t, 0, base.InspectContainer(tID).RestartCount)
+	poll.WaitOn(t, check, poll.WithDelay(100*time.Millisecond), poll.WithTimeout(60*time.Second))
+
+	base.Cmd("create", "--restart=on-failure:-1", testutil.AlpineImage, "true").AssertFail()
+	base.Cmd("create", "--restart=always:2", testutil.AlpineImage, "true").AssertFail()
+}
+
+func TestUpdateRestartPolicy(t *testing.T) {
+	base := testutil.NewBase(t)
+	if testutil.GetTarget() == testutil.Nerdctl {
+		testutil.RequireContainerdPlugin(base, "io.containerd.internal.v1", "restart", []string{"on-failure"})
+	}
+	tID := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", tID).Run()
+	base.Cmd("run", "-d", "--name", tID, testutil.AlpineImage, "sh", "-c", "sleep 3; exit 1").AssertOK()
+	base.Cmd("update", "--restart=on-failure:2", tID).AssertOK()
+
+	check := func(log poll.LogT) poll.Result {
+		inspect := base.InspectContainer(tID)
+		if inspect.State != nil && inspect.State.Status == "exited" && inspect.RestartCount == 2 {
+			return poll.Success()
+		}
+		return poll.Continue("container is not yet exited after restarts")
+	}
+	poll.WaitOn(t, check, poll.WithDelay(100*time.Millisecond), poll.WithTimeout(60*time.Second))
+}
+
+func TestUpdateRestartPolicyDoesNotStart(t *testing.T) {
+	base := testutil.NewBase(t)
+	if testutil.GetTarget() == testutil.Nerdctl {
+		testutil.RequireContainerdPlugin(base, "io.containerd.internal.v1", "restart", []string{"always"})
+	}
+	tID := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", tID).Run()
+	base.Cmd("create", "--name", tID, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	base.Cmd("update", "--restart=always", tID).AssertOK()
+	// the restart monitor runs every 10 seconds by default, so the container must stay created for longer than that
+	deadline := time.Now().Add(12 * time.Second)
+	poll.WaitOn(t, func(log poll.LogT) poll.Result {
+		if status := base.InspectContainer(tID).State.Status; status != "created" {
+			return poll.Error(fmt.Errorf("expected the container to stay created, got %q", status))
+		}
+		if time.Now().Before(deadline) {
+			return poll.Continue("watching the container until the restart monitor has run")
+		}
+		return poll.Success()
+	}, poll.WithDelay(500*time.Millisecond), poll.WithTimeout(30*time.Second))
+
+	rmID := tID + "-rm"
+	defer base.Cmd("rm", "-f", rmID).Run()
+	base.Cmd("run", "-d", "--rm", "--name", rmID, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	base.Cmd("update", "--restart=always", rmID).AssertFail()
 }
 
 func TestRunRestartWithUnlessStopped(t *testing.T) {
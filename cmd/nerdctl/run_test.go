@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -86,6 +87,25 @@ CMD ["echo", "bar"]
 	})
 }
 
+func TestParseEntrypoint(t *testing.T) {
+	testCases := []struct {
+		values   []string
+		expected []string
+	}{
+		{nil, nil},
+		{[]string{""}, nil},
+		{[]string{"/bin/sh"}, []string{"/bin/sh"}},
+		{[]string{`["/bin/sh", "-c"]`}, []string{"/bin/sh", "-c"}},
+		{[]string{` ["/bin/sh"]`}, []string{"/bin/sh"}},
+		{[]string{"[not json"}, []string{"[not json"}},
+		// multiple values are passed by `nerdctl compose`
+		{[]string{"/bin/sh", "-c"}, []string{"/bin/sh", "-c"}},
+	}
+	for _, tc := range testCases {
+		assert.DeepEqual(t, tc.expected, parseEntrypoint(tc.values))
+	}
+}
+
 func TestRunWorkdir(t *testing.T) {
 	t.Parallel()
 	base := testutil.NewBase(t)
@@ -97,6 +117,129 @@ func TestRunWorkdir(t *testing.T) {
 	cmd.AssertOutContains("/foo")
 }
 
+func TestRunWorkdirRelative(t *testing.T) {
+	t.Parallel()
+	testutil.DockerIncompatible(t) // Docker requires an absolute path
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+WORKDIR /foo
+	`, testutil.CommonImage)
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	base.Cmd("build", "-t", imageName, buildCtx).AssertOK()
+
+	base.Cmd("run", "--rm", imageName, "pwd").AssertOutExactly("/foo\n")
+	base.Cmd("run", "--rm", "--workdir=bar", imageName, "pwd").AssertOutExactly("/foo/bar\n")
+	base.Cmd("run", "--rm", "--workdir=/bar", imageName, "pwd").AssertOutExactly("/bar\n")
+	base.Cmd("run", "--rm", "--chdir=bar/baz", imageName, "pwd").AssertOutExactly("/foo/bar/baz\n")
+}
+
+func TestResolveWorkdir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test assumes unix paths")
+	}
+	testCases := []struct {
+		imageWorkdir string
+		wd           string
+		expected     string
+	}{
+		{"", "/foo", "/foo"},
+		{"", "foo", "/foo"},
+		{"/foo", "/bar", "/bar"},
+		{"/foo", "bar", "/foo/bar"},
+		{"/foo", "bar/../baz", "/foo/baz"},
+		{"/foo/", "./bar", "/foo/bar"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, resolveWorkdir(tc.imageWorkdir, tc.wd))
+	}
+}
+
+func TestResolveStopSignal(t *testing.T) {
+	testCases := []struct {
+		stopSignal string
+		expected   string
+		err        string
+	}{
+		{"", "SIGTERM", ""},
+		{"SIGQUIT", "SIGQUIT", ""},
+		{"KILL", "KILL", ""},
+		{"15", "15", ""},
+		{"SIGFOO", "", "invalid stop signal"},
+		{"0", "", "invalid stop signal"},
+	}
+	for _, tc := range testCases {
+		sig, err := resolveStopSignal(context.Background(), tc.stopSignal, nil)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, sig)
+	}
+}
+
+func TestValidateAnnotationKey(t *testing.T) {
+	for _, k := range []string{
+		"com.example.foo",
+		"io.katacontainers.config.hypervisor.default_memory",
+		"io.kubernetes.cri.container-type",
+		"com.example/foo.bar",
+	} {
+		assert.NilError(t, validateAnnotationKey(k), k)
+	}
+	for _, k := range []string{"", "foo", ".com.example", "com.example.", "com..example", "com.example foo", "/foo"} {
+		assert.ErrorContains(t, validateAnnotationKey(k), "invalid annotation key", k)
+	}
+}
+
+func TestRunAnnotation(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks --annotation-file
+	t.Parallel()
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", tID).Run()
+
+	annotationFile := filepath.Join(t.TempDir(), "annotations")
+	assert.NilError(t, os.WriteFile(annotationFile, []byte(`# comment
+com.example.foo=from-file
+com.example.bar=from-file
+`), 0644))
+
+	base.Cmd("create", "--name", tID,
+		"--annotation-file", annotationFile,
+		"--annotation", "com.example.bar=from-flag",
+		"--annotation", "com.example.baz=baz1,baz2",
+		testutil.CommonImage).AssertOK()
+	for k, v := range map[string]string{
+		"com.example.foo": "from-file",
+		"com.example.bar": "from-flag",
+		"com.example.baz": "baz1,baz2",
+	} {
+		base.Cmd("container", "inspect", "--mode=native", fmt.Sprintf("--format={{index .Spec.Annotations %q}}", k), tID).AssertOutExactly(v + "\n")
+	}
+
+	base.Cmd("create", "--annotation", "foo=bar", testutil.CommonImage).AssertFail()
+}
+
+func TestRunIsolation(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	base.Cmd("run", "--rm", "--isolation=default", testutil.CommonImage, "true").AssertOK()
+	cmd := base.Cmd("run", "--rm", "--isolation=hyperv", testutil.CommonImage, "true")
+	if base.Target == testutil.Nerdctl {
+		cmd.AssertErrContains("isolation \"hyperv\" is unsupported on this platform")
+	} else {
+		cmd.AssertFail()
+	}
+}
+
 func TestRunWithDoubleDash(t *testing.T) {
 	t.Parallel()
 	testutil.DockerIncompatible(t)
@@ -133,18 +276,116 @@ func TestRunExitCode(t *testing.T) {
 	assert.Equal(base.T, 123, inspect123.State.ExitCode)
 }
 
+func TestRunDetach(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	testContainerFail := tID + "-fail"
+	testContainerTTY := tID + "-tty"
+	defer base.Cmd("rm", "-f", testContainerFail, testContainerTTY).Run()
+
+	// -d returns immediately with the full container ID, even if the process exits with non-zero later
+	start := time.Now()
+	id := strings.TrimSpace(base.Cmd("run", "-d", "--name", testContainerFail, testutil.CommonImage, "sh", "-c", "sleep 3; exit 42").Out())
+	assert.Assert(t, time.Since(start) < 3*time.Second, "run -d should not wait for the process")
+	assert.Equal(t, 64, len(id), "expected the full container ID, got %q", id)
+	base.Cmd("inspect", "--format", "{{.ID}}", testContainerFail).AssertOutExactly(id + "\n")
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if inspect := base.InspectContainer(testContainerFail); inspect.State.Status == "exited" {
+			assert.Equal(t, 42, inspect.State.ExitCode)
+			return poll.Success()
+		}
+		return poll.Continue("waiting for %q to exit", testContainerFail)
+	}, poll.WithTimeout(30*time.Second), poll.WithDelay(time.Second))
+
+	// -dit keeps a shell running in the background
+	dit := base.Cmd("run", "-dit", "--name", testContainerTTY, testutil.CommonImage, "sh")
+	if base.Target == testutil.Nerdctl {
+		// -i is ignored with a warning
+		dit.Assert(icmd.Expected{ExitCode: 0, Err: "ignoring -i"})
+	} else {
+		dit.AssertOK()
+	}
+	time.Sleep(time.Second)
+	base.Cmd("exec", testContainerTTY, "echo", "foo").AssertOutExactly("foo\n")
+	base.Cmd("inspect", "--format", "{{.State.Status}}", testContainerTTY).AssertOutExactly("running\n")
+}
+
+func TestRunAttach(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+
+	base.Cmd("run", "--rm", "-d", "-a", "stdout", testutil.CommonImage, "true").AssertErrContains("cannot specify both --attach and --detach")
+	base.Cmd("run", "--rm", "-a", "foo", testutil.CommonImage, "true").AssertFail()
+
+	script := "echo attached-stdout; echo attached-stderr >&2"
+	res := base.Cmd("run", "--rm", "-a", "stdout", testutil.CommonImage, "sh", "-c", script).Run()
+	assert.Equal(t, 0, res.ExitCode)
+	assert.Equal(t, "attached-stdout\n", res.Stdout())
+	assert.Assert(t, !strings.Contains(res.Stderr(), "attached-stderr"), res.Stderr())
+
+	res = base.Cmd("run", "--rm", "-a", "STDERR", testutil.CommonImage, "sh", "-c", script).Run()
+	assert.Equal(t, 0, res.ExitCode)
+	assert.Equal(t, "", res.Stdout())
+	assert.Assert(t, strings.Contains(res.Stderr(), "attached-stderr"), res.Stderr())
+
+	// the stdin is kept open but not attached with `-i -a stdout`, so nothing is read
+	base.Cmd("run", "--rm", "-i", "-a", "stdout", testutil.CommonImage, "sh", "-c", "timeout 3 cat; echo done").
+		CmdOption(testutil.WithStdin(strings.NewReader("unattached-stdin"))).AssertOutExactly("done\n")
+	base.Cmd("run", "--rm", "-i", "-a", "stdin", "-a", "stdout", testutil.CommonImage, "cat").
+		CmdOption(testutil.WithStdin(strings.NewReader("attached-stdin"))).AssertOutExactly("attached-stdin")
+}
+
+func TestParseAttach(t *testing.T) {
+	streams, err := parseAttach(nil, false)
+	assert.NilError(t, err)
+	assert.Assert(t, streams == nil)
+	streams, err = parseAttach(nil, true)
+	assert.NilError(t, err)
+	assert.Assert(t, streams == nil)
+	streams, err = parseAttach([]string{"stdout", "STDERR", "Stdout"}, false)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"STDOUT", "STDERR"}, streams)
+	_, err = parseAttach([]string{"stdout", "foo"}, false)
+	assert.ErrorContains(t, err, "valid streams are STDIN, STDOUT and STDERR")
+	streams, err = parseAttach([]string{"stdin", "stdout"}, true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"STDIN", "STDOUT"}, streams)
+	_, err = parseAttach([]string{"stdin"}, false)
+	assert.ErrorContains(t, err, "requires -i")
+}
+
 func TestRunCIDFile(t *testing.T) {
 	t.Parallel()
 	base := testutil.NewBase(t)
 	fileName := filepath.Join(t.TempDir(), "cid.file")
 
-	base.Cmd("run", "--rm", "--cidfile", fileName, testutil.CommonImage).AssertOK()
-	defer os.Remove(fileName)
+	containerName := testutil.Identifier(t)
+	defer base.Cmd("rm", "-f", containerName).Run()
 
-	_, err := os.Stat(fileName)
+	// success: the cidfile contains the ID, and another container cannot use the same cidfile
+	base.Cmd("create", "--name", containerName, "--cidfile", fileName, testutil.CommonImage).AssertOK()
+	defer os.Remove(fileName)
+	b, err := os.ReadFile(fileName)
 	assert.NilError(base.T, err)
-
+	assert.Equal(base.T, base.InspectContainer(containerName).ID, string(b))
 	base.Cmd("run", "--rm", "--cidfile", fileName, testutil.CommonImage).AssertFail()
+	b, err = os.ReadFile(fileName)
+	assert.NilError(base.T, err)
+	assert.Equal(base.T, base.InspectContainer(containerName).ID, string(b))
+	base.Cmd("rm", "-f", containerName).AssertOK()
+	assert.NilError(base.T, os.Remove(fileName))
+
+	// creation failure: no cidfile is left
+	base.Cmd("run", "--rm", "--cidfile", fileName, "--pull=never", "nerdctl-test-nonexistent-image").AssertFail()
+	_, err = os.Stat(fileName)
+	assert.Assert(base.T, errors.Is(err, os.ErrNotExist), "cidfile must not be left, got %v", err)
+
+	// --rm: the cidfile is removed with the container
+	testutil.DockerIncompatible(t) // Docker keeps the cidfile
+	base.Cmd("run", "--rm", "--cidfile", fileName, testutil.CommonImage).AssertOK()
+	_, err = os.Stat(fileName)
+	assert.Assert(base.T, errors.Is(err, os.ErrNotExist), "cidfile must be removed with the container, got %v", err)
 }
 
 func TestRunEnvFile(t *testing.T) {
@@ -0,0 +1,69 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/rootlessutil"
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"github.com/containerd/nerdctl/pkg/usernsutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestRunUsernsRemap(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker has `--userns-remap` as a daemon flag
+	if rootlessutil.IsRootless() {
+		t.Skip("--userns-remap is not supported in rootless mode")
+	}
+	const remapUser = "root"
+	remap, err := usernsutil.Parse(remapUser)
+	if err != nil {
+		t.Skipf("test requires the subordinate id ranges of %q: %v", remapUser, err)
+	}
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+
+	base.Cmd("run", "-d", "--userns-remap="+remapUser, "--name", tID, "-v", "/mnt", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	defer base.Cmd("rm", "-f", "-v", tID).Run()
+
+	// the container root is still root inside the container
+	base.Cmd("exec", tID, "stat", "-c", "%u:%g", "/etc/passwd").AssertOutExactly("0:0\n")
+	base.Cmd("exec", tID, "cat", "/proc/self/uid_map").AssertOutContains(fmt.Sprintf("%d", remap.HostUID()))
+
+	// but the files are owned by the remapped uid on the host
+	pid := base.InspectContainer(tID).State.Pid
+	st, err := os.Stat(fmt.Sprintf("/proc/%d/root/etc/passwd", pid))
+	assert.NilError(t, err)
+	stat := st.Sys().(*syscall.Stat_t)
+	assert.Equal(t, remap.HostUID(), stat.Uid)
+	assert.Equal(t, remap.HostGID(), stat.Gid)
+
+	// the ownership of the anonymous volume is remapped too
+	base.Cmd("exec", tID, "stat", "-c", "%u:%g", "/mnt").AssertOutExactly("0:0\n")
+	base.Cmd("exec", tID, "touch", "/mnt/foo").AssertOK()
+
+	// bind mounts and named volumes are rejected
+	volName := tID + "-vol"
+	defer base.Cmd("volume", "rm", volName).Run()
+	base.Cmd("volume", "create", volName).AssertOK()
+	base.Cmd("run", "--rm", "--userns-remap="+remapUser, "-v", volName+":/mnt", testutil.CommonImage, "true").AssertErrContains("cannot be used with --userns-remap")
+	base.Cmd("run", "--rm", "--userns-remap="+remapUser, "-v", t.TempDir()+":/mnt", testutil.CommonImage, "true").AssertErrContains("cannot be used with --userns-remap")
+}
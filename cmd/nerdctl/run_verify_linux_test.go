@@ -57,6 +57,9 @@ CMD ["echo", "nerdctl-build-test-string"]
 
 	base.Cmd("build", "-t", testImageRef, buildCtx).AssertOK()
 	base.Cmd("push", testImageRef, "--sign=cosign", "--cosign-key="+keyPair.privateKey).AssertOK()
+	// the image is pulled by the verified digest, and the tag is updated too
+	base.Cmd("rmi", testImageRef).AssertOK()
 	base.Cmd("run", "--rm", "--verify=cosign", "--cosign-key="+keyPair.publicKey, testImageRef).AssertOK()
+	base.Cmd("image", "inspect", testImageRef).AssertOK()
 	base.Cmd("run", "--rm", "--verify=cosign", "--cosign-key=dummy", testImageRef).AssertFail()
 }
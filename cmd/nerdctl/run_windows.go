@@ -18,11 +18,12 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
 
+	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/oci"
-	"github.com/docker/go-units"
+	"github.com/containerd/nerdctl/pkg/mountutil"
 	"github.com/spf13/cobra"
 )
 
@@ -54,9 +55,9 @@ func setPlatformOptions(opts []oci.SpecOpts, cmd *cobra.Command, id string) ([]o
 		return nil, err
 	}
 	if memStr != "" {
-		mem64, err := units.RAMInBytes(memStr)
+		mem64, err := parseMemoryBytes("memory", memStr, 0)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse memory bytes %q: %w", memStr, err)
+			return nil, err
 		}
 		opts = append(opts, oci.WithMemoryLimit(uint64(mem64)))
 	}
@@ -67,3 +68,18 @@ func setPlatformOptions(opts []oci.SpecOpts, cmd *cobra.Command, id string) ([]o
 
 	return opts, nil
 }
+
+func generateUsernsRemapOpts(cmd *cobra.Command, id string, image containerd.Image) ([]oci.SpecOpts, containerd.NewContainerOpts, error) {
+	usernsRemap, err := cmd.Flags().GetString("userns-remap")
+	if err != nil {
+		return nil, nil, err
+	}
+	if usernsRemap != "" {
+		return nil, nil, errors.New("--userns-remap is not supported on windows")
+	}
+	return nil, nil, nil
+}
+
+func remapUsernsMountPoints(cmd *cobra.Command, mountPoints []*mountutil.Processed) error {
+	return nil
+}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -28,6 +29,7 @@ import (
 	"github.com/containerd/nerdctl/pkg/formatter"
 	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
 	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/containerd/nerdctl/pkg/taskutil"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -36,7 +38,6 @@ import (
 func newStartCommand() *cobra.Command {
 	var startCommand = &cobra.Command{
 		Use:               "start [flags] CONTAINER [CONTAINER, ...]",
-		Args:              cobra.MinimumNArgs(1),
 		Short:             "Start one or more running containers",
 		RunE:              startAction,
 		ValidArgsFunction: startShellComplete,
@@ -46,6 +47,10 @@ func newStartCommand() *cobra.Command {
 
 	startCommand.Flags().SetInterspersed(false)
 	startCommand.Flags().BoolP("attach", "a", false, "Attach STDOUT/STDERR and forward signals")
+	// no "-a" shorthand, as "-a" is for "--attach"
+	startCommand.Flags().Bool("all", false, "Start all stopped containers (or all the containers matching --filter)")
+	// filter is defined as StringArray, not StringSlice, to allow label values containing commas
+	startCommand.Flags().StringArrayP("filter", "f", nil, "Start the containers matching the conditions, in the same way as `nerdctl ps --filter` (e.g., \"label=foo\")")
 
 	return startCommand
 }
@@ -62,6 +67,44 @@ func startAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+	filters, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	if all || len(filters) > 0 {
+		if len(args) > 0 {
+			return errors.New("no argument can be specified with --all or --filter")
+		}
+		if flagA {
+			return errors.New("you cannot start and attach multiple containers at once")
+		}
+		containers, err := selectContainers(ctx, client, filters, "created", "exited")
+		if err != nil {
+			return err
+		}
+		// Like `nerdctl rmi`, starting continues on errors, and the command fails if any of the containers could not be started.
+		var errs []error
+		for _, c := range containers {
+			if err := startContainer(ctx, cmd, client, c, false); err != nil {
+				logrus.WithError(err).Errorf("failed to start container %s", c.ID())
+				errs = append(errs, err)
+				continue
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), c.ID())
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to start %d of %d containers", len(errs), len(containers))
+		}
+		return nil
+	}
+	if len(args) == 0 {
+		return errors.New("requires at least 1 argument (or --all, --filter)")
+	}
+
 	if flagA && len(args) > 1 {
 		return fmt.Errorf("you cannot start and attach multiple containers at once")
 	}
@@ -69,7 +112,7 @@ func startAction(cmd *cobra.Command, args []string) error {
 	walker := &containerwalker.ContainerWalker{
 		Client: client,
 		OnFound: func(ctx context.Context, found containerwalker.Found) error {
-			if err := startContainer(ctx, found.Container, flagA); err != nil {
+			if err := startContainer(ctx, cmd, client, found.Container, flagA); err != nil {
 				return err
 			}
 			if !flagA {
@@ -92,7 +135,7 @@ func startAction(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func startContainer(ctx context.Context, container containerd.Container, flagA bool) error {
+func startContainer(ctx context.Context, cmd *cobra.Command, client *containerd.Client, container containerd.Container, flagA bool) error {
 	lab, err := container.Labels(ctx)
 	if err != nil {
 		return err
@@ -110,6 +153,12 @@ func startContainer(ctx context.Context, container containerd.Container, flagA b
 		}
 		if flagA {
 			logrus.Debug("attaching output instead of using the log-uri")
+		} else if spec, err := container.Spec(ctx); err == nil && spec.Process != nil && spec.Process.Terminal {
+			// created with `nerdctl run -dt`
+			taskCIO, err = taskutil.TerminalLogURI(logURI)
+			if err != nil {
+				return err
+			}
 		} else {
 			taskCIO = cio.LogURI(logURI)
 		}
@@ -123,11 +172,23 @@ func startContainer(ctx context.Context, container containerd.Container, flagA b
 	if err := updateContainerStoppedLabel(ctx, container, false); err != nil {
 		return err
 	}
+	if err := resetContainerRestartCount(ctx, container); err != nil {
+		return err
+	}
+	if err := updateContainerNetNSPath(ctx, client, container, lab); err != nil {
+		return err
+	}
+	if err := mountPluginVolumes(ctx, cmd, container, lab[labels.Mounts]); err != nil {
+		return err
+	}
 	if oldTask, err := container.Task(ctx, nil); err == nil {
 		if _, err := oldTask.Delete(ctx); err != nil {
 			logrus.WithError(err).Debug("failed to delete old task")
 		}
 	}
+	if err := clearContainerExitCodeLabel(ctx, container); err != nil {
+		return err
+	}
 	task, err := container.NewTask(ctx, taskCIO)
 	if err != nil {
 		return err
@@ -152,6 +213,9 @@ func startContainer(ctx context.Context, container containerd.Container, flagA b
 	sigc := commands.ForwardAllSignals(ctx, task)
 	defer commands.StopCatch(sigc)
 	status := <-statusC
+	if err := updateContainerExitCodeLabel(ctx, container, task, status); err != nil {
+		logrus.WithError(err).Warnf("failed to record the exit code of container %s", container.ID())
+	}
 	code, _, err := status.Result()
 	if err != nil {
 		return err
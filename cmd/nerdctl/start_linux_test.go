@@ -0,0 +1,55 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestStartAll(t *testing.T) {
+	testutil.DockerIncompatible(t) // `docker start` lacks --all and --filter
+	base := testutil.NewBase(t)
+	// use a dedicated namespace, so as not to start the containers of other tests
+	ns := testutil.Identifier(t)
+	base.Args = []string{"--namespace=" + ns}
+	created, exited, labeled := ns+"-created", ns+"-exited", ns+"-labeled"
+	defer base.Cmd("namespace", "remove", ns).Run()
+	defer base.Cmd("rmi", testutil.AlpineImage).Run()
+	defer base.Cmd("rm", "-f", created, exited, labeled).Run()
+
+	base.Cmd("create", "--name", created, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	base.Cmd("run", "-d", "--name", exited, testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	base.Cmd("stop", "--time", "1", exited).AssertOK()
+	base.Cmd("create", "--name", labeled, "--label", "foo=bar", testutil.AlpineImage, "sleep", "infinity").AssertOK()
+
+	// --filter starts only the matching containers
+	base.Cmd("start", "--filter", "label=foo=bar").AssertOutExactly(base.InspectContainer(labeled).ID + "\n")
+	assert.Equal(t, "running", base.InspectContainer(labeled).State.Status)
+	assert.Equal(t, "created", base.InspectContainer(created).State.Status)
+
+	// --all starts all the created and exited containers
+	base.Cmd("start", "--all").AssertOK()
+	for _, name := range []string{created, exited, labeled} {
+		assert.Equal(t, "running", base.InspectContainer(name).State.Status)
+	}
+
+	base.Cmd("start", "--all", created).AssertFail()
+	base.Cmd("start", "--all", "--attach").AssertFail()
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/containerd/containerd"
 	eventstypes "github.com/containerd/containerd/api/events"
 	"github.com/containerd/containerd/events"
+	"github.com/containerd/nerdctl/pkg/bypass4netnsutil"
 	"github.com/containerd/nerdctl/pkg/containerinspector"
 	"github.com/containerd/nerdctl/pkg/eventutil"
 	"github.com/containerd/nerdctl/pkg/formatter"
@@ -60,7 +61,7 @@ func newStatsCommand() *cobra.Command {
 
 func addStatsFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolP("all", "a", false, "Show all containers (default shows just running)")
-	cmd.Flags().String("format", "", "Pretty-print images using a Go template, e.g, '{{json .}}'")
+	cmd.Flags().String("format", "", "Pretty-print container stats using a Go template, e.g, '{{json .}}'")
 	cmd.Flags().Bool("no-stream", false, "Disable streaming stats and only pull the first result")
 	cmd.Flags().Bool("no-trunc", false, "Do not truncate output")
 }
@@ -325,10 +326,11 @@ func statsAction(cmd *cobra.Command, args []string) error {
 			if tmpl != nil {
 				var b bytes.Buffer
 				if err := tmpl.Execute(&b, rc); err != nil {
-					break
+					return err
 				}
-				if _, err = fmt.Fprintf(cmd.OutOrStdout(), b.String()+"\n"); err != nil {
-					break
+				// the rendered string may contain "%" (e.g., CPUPerc), so it must not be used as a format string
+				if _, err := fmt.Fprintln(cmd.OutOrStdout(), b.String()); err != nil {
+					return err
 				}
 			} else {
 				if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
@@ -341,7 +343,7 @@ func statsAction(cmd *cobra.Command, args []string) error {
 					rc.BlockIO,
 					rc.PIDs,
 				); err != nil {
-					break
+					return err
 				}
 			}
 		}
@@ -401,6 +403,10 @@ func collect(cmd *cobra.Command, s *statsutil.Stats, waitFirst *sync.WaitGroup,
 	go func() {
 
 		previousStats := make(map[string]uint64)
+		// the first reading only fills previousStats, as the CPU percentage is computed from
+		// the delta between two readings. Otherwise `--no-stream` would print the CPU time
+		// accumulated since the start of the container as the percentage.
+		primed := false
 
 		for {
 			//task is in the for loop to avoid nil task just after Container creation
@@ -437,11 +443,31 @@ func collect(cmd *cobra.Command, s *statsutil.Stats, waitFirst *sync.WaitGroup,
 				continue
 			}
 
-			statsEntry, err := renderStatsEntry(previousStats, anydata, int(task.Pid()), netNS.Interfaces)
+			procs, err := task.Pids(ctx)
+			if err != nil {
+				u <- err
+				continue
+			}
+			pids := make([]int, len(procs))
+			for i, p := range procs {
+				pids[i] = int(p.Pid)
+			}
+
+			bypass4netns, err := bypass4netnsutil.IsBypass4netnsEnabled(clabels)
+			if err != nil {
+				u <- err
+				continue
+			}
+
+			statsEntry, err := renderStatsEntry(previousStats, anydata, int(task.Pid()), pids, netNS.Interfaces, bypass4netns)
 			if err != nil {
 				u <- err
 				continue
 			}
+			if !primed {
+				primed = true
+				continue
+			}
 			statsEntry.Name = clabels[labels.Name]
 			statsEntry.ID = container.ID()
 
@@ -21,16 +21,25 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	v1 "github.com/containerd/cgroups/stats/v1"
 	v2 "github.com/containerd/cgroups/v2/stats"
 	"github.com/containerd/nerdctl/pkg/inspecttypes/native"
+	"github.com/containerd/nerdctl/pkg/rootlessutil"
 	"github.com/containerd/nerdctl/pkg/statsutil"
 	"github.com/vishvananda/netlink"
 	"github.com/vishvananda/netns"
 )
 
-func renderStatsEntry(previousStats map[string]uint64, anydata interface{}, pid int, interfaces []native.NetInterface) (statsutil.StatsEntry, error) {
+// bypassedNetIOInterval is the interval of reading the traffic bypassed with bypass4netns,
+// as all the sockets of the container processes are walked.
+const bypassedNetIOInterval = 5 * time.Second
+
+// renderStatsEntry renders the stats entry of the container.
+// pid is the init process of the container and pids are all the processes of the container.
+// bypass4netns is true if the container is created with bypass4netns.
+func renderStatsEntry(previousStats map[string]uint64, anydata interface{}, pid int, pids []int, interfaces []native.NetInterface, bypass4netns bool) (statsutil.StatsEntry, error) {
 
 	var (
 		data       *v1.Metrics
@@ -95,6 +104,22 @@ func renderStatsEntry(previousStats map[string]uint64, anydata interface{}, pid
 		if err != nil {
 			return statsutil.StatsEntry{}, err
 		}
+		if rootlessutil.IsRootless() && (data2.Io == nil || len(data2.Io.Usage) == 0) {
+			// the io controller is not delegated to the rootless user
+			blkRead, blkWrite := statsutil.ProcBlockIO(pids)
+			statsEntry.BlockRead, statsEntry.BlockWrite = float64(blkRead), float64(blkWrite)
+		}
+	}
+
+	if rootlessutil.IsRootless() && bypass4netns {
+		// the traffic bypassed with bypass4netns does not go through the interfaces of the container
+		now := uint64(time.Now().UnixNano())
+		if now-previousStats["BypassedTime"] >= uint64(bypassedNetIOInterval) {
+			previousStats["BypassedRx"], previousStats["BypassedTx"] = statsutil.BypassedNetIO(pid, pids)
+			previousStats["BypassedTime"] = now
+		}
+		statsEntry.NetworkRx += float64(previousStats["BypassedRx"])
+		statsEntry.NetworkTx += float64(previousStats["BypassedTx"])
 	}
 
 	return statsEntry, nil
@@ -17,6 +17,8 @@
 package main
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/containerd/nerdctl/pkg/infoutil"
@@ -38,5 +40,13 @@ func TestStats(t *testing.T) {
 
 	base.Cmd("run", "-d", "--name", testContainerName, testutil.AlpineImage, "sleep", "5").AssertOK()
 	base.Cmd("stats", "--no-stream", testContainerName).AssertOK()
+	base.Cmd("stats", "--no-stream", "--format", "{{.Name}} {{.CPUPerc}} {{.PIDs}}", testContainerName).AssertOutWithFunc(func(stdout string) error {
+		fields := strings.Fields(stdout)
+		if len(fields) != 3 || fields[0] != testContainerName || !strings.HasSuffix(fields[1], "%") || strings.Contains(stdout, "%!") {
+			return fmt.Errorf("unexpected output %q", stdout)
+		}
+		return nil
+	})
+	base.Cmd("stats", "--no-stream", "--format", "json", testContainerName).AssertOutContains(`"MemUsage":`)
 
 }
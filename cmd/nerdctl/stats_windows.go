@@ -21,7 +21,7 @@ import (
 	"github.com/containerd/nerdctl/pkg/statsutil"
 )
 
-func renderStatsEntry(previousStats map[string]uint64, anydata interface{}, pid int, interfaces []native.NetInterface) (statsutil.StatsEntry, error) {
+func renderStatsEntry(previousStats map[string]uint64, anydata interface{}, pid int, pids []int, interfaces []native.NetInterface, bypass4netns bool) (statsutil.StatsEntry, error) {
 
 	return statsutil.StatsEntry{}, nil
 
@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -33,10 +34,12 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// defaultStopSignal is used when neither `--stop-signal` nor the StopSignal of the image is specified.
+const defaultStopSignal = "SIGTERM"
+
 func newStopCommand() *cobra.Command {
 	var stopCommand = &cobra.Command{
 		Use:               "stop [flags] CONTAINER [CONTAINER, ...]",
-		Args:              cobra.MinimumNArgs(1),
 		Short:             "Stop one or more running containers",
 		RunE:              stopAction,
 		ValidArgsFunction: stopShellComplete,
@@ -44,19 +47,46 @@ func newStopCommand() *cobra.Command {
 		SilenceErrors:     true,
 	}
 	stopCommand.Flags().IntP("time", "t", 10, "Seconds to wait for stop before killing it")
+	stopCommand.Flags().BoolP("all", "a", false, "Stop all running containers (or all the containers matching --filter)")
+	// filter is defined as StringArray, not StringSlice, to allow label values containing commas
+	stopCommand.Flags().StringArrayP("filter", "f", nil, "Stop the containers matching the conditions, in the same way as `nerdctl ps --filter` (e.g., \"label=foo\")")
 	return stopCommand
 }
 
-func stopAction(cmd *cobra.Command, args []string) error {
+// getStopTimeout returns the value of --time, or nil when --time is not specified,
+// so that the stop timeout of each container is used.
+func getStopTimeout(cmd *cobra.Command) (*time.Duration, error) {
 	// Time to wait after sending a SIGTERM and before sending a SIGKILL.
-	var timeout *time.Duration
-	if cmd.Flags().Changed("time") {
-		timeValue, err := cmd.Flags().GetInt("time")
-		if err != nil {
-			return err
+	if !cmd.Flags().Changed("time") {
+		return nil, nil
+	}
+	timeValue, err := cmd.Flags().GetInt("time")
+	if err != nil {
+		return nil, err
+	}
+	t := time.Duration(timeValue) * time.Second
+	return &t, nil
+}
+
+func stopAction(cmd *cobra.Command, args []string) error {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+	filters, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	if all || len(filters) > 0 {
+		if len(args) > 0 {
+			return errors.New("no argument can be specified with --all or --filter")
 		}
-		t := time.Duration(timeValue) * time.Second
-		timeout = &t
+	} else if len(args) == 0 {
+		return errors.New("requires at least 1 argument (or --all, --filter)")
+	}
+	timeout, err := getStopTimeout(cmd)
+	if err != nil {
+		return err
 	}
 
 	client, ctx, cancel, err := newClient(cmd)
@@ -65,10 +95,32 @@ func stopAction(cmd *cobra.Command, args []string) error {
 	}
 	defer cancel()
 
+	if all || len(filters) > 0 {
+		containers, err := selectContainers(ctx, client, filters, string(containerd.Running))
+		if err != nil {
+			return err
+		}
+		// Like `nerdctl rmi`, stopping continues on errors, and the command fails if any of the containers could not be stopped.
+		var errs []error
+		for _, c := range containers {
+			// the stop timeout and the stop signal of the container are used unless --time is specified
+			if err := stopContainer(ctx, cmd, c, timeout); err != nil && !errdefs.IsNotFound(err) {
+				logrus.WithError(err).Errorf("failed to stop container %s", c.ID())
+				errs = append(errs, err)
+				continue
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), c.ID())
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to stop %d of %d containers", len(errs), len(containers))
+		}
+		return nil
+	}
+
 	walker := &containerwalker.ContainerWalker{
 		Client: client,
 		OnFound: func(ctx context.Context, found containerwalker.Found) error {
-			if err := stopContainer(ctx, found.Container, timeout); err != nil {
+			if err := stopContainer(ctx, cmd, found.Container, timeout); err != nil {
 				if errdefs.IsNotFound(err) {
 					fmt.Fprintf(cmd.ErrOrStderr(), "No such container: %s\n", found.Req)
 					return nil
@@ -90,7 +142,7 @@ func stopAction(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func stopContainer(ctx context.Context, container containerd.Container, timeout *time.Duration) error {
+func stopContainer(ctx context.Context, cmd *cobra.Command, container containerd.Container, timeout *time.Duration) (retErr error) {
 	if err := updateContainerStoppedLabel(ctx, container, true); err != nil {
 		return err
 	}
@@ -100,6 +152,16 @@ func stopContainer(ctx context.Context, container containerd.Container, timeout
 		return err
 	}
 
+	// the plugin volumes are mounted again on start
+	defer func() {
+		if retErr != nil && !errdefs.IsNotFound(retErr) {
+			return
+		}
+		if err := unmountPluginVolumes(cmd, container.ID(), l[labels.Mounts]); err != nil {
+			logrus.WithError(err).Warnf("failed to unmount volumes of container %q", container.ID())
+		}
+	}()
+
 	if timeout == nil {
 		t, ok := l[labels.StopTimout]
 		if !ok {
@@ -140,16 +202,14 @@ func stopContainer(ctx context.Context, container containerd.Container, timeout
 	}
 
 	if *timeout > 0 {
-		sig, err := signal.ParseSignal("SIGTERM")
+		stopSignal := defaultStopSignal
+		if v, ok := l[containerd.StopSignalLabel]; ok && v != "" {
+			stopSignal = v
+		}
+		sig, err := signal.ParseSignal(stopSignal)
 		if err != nil {
 			return err
 		}
-		if stopSignal, ok := l[containerd.StopSignalLabel]; ok {
-			sig, err = signal.ParseSignal(stopSignal)
-			if err != nil {
-				return err
-			}
-		}
 
 		if err := task.Kill(ctx, sig); err != nil {
 			return err
@@ -168,7 +228,7 @@ func stopContainer(ctx context.Context, container containerd.Container, timeout
 		sigtermCtx, sigtermCtxCancel := context.WithTimeout(ctx, *timeout)
 		defer sigtermCtxCancel()
 
-		err = waitContainerStop(sigtermCtx, exitCh, container.ID())
+		err = waitContainerStop(sigtermCtx, exitCh, container, task)
 		if err == nil {
 			return nil
 		}
@@ -193,17 +253,20 @@ func stopContainer(ctx context.Context, container containerd.Container, timeout
 			logrus.Warnf("Cannot unpause container %s: %s", container.ID(), err)
 		}
 	}
-	return waitContainerStop(ctx, exitCh, container.ID())
+	return waitContainerStop(ctx, exitCh, container, task)
 }
 
-func waitContainerStop(ctx context.Context, exitCh <-chan containerd.ExitStatus, id string) error {
+func waitContainerStop(ctx context.Context, exitCh <-chan containerd.ExitStatus, container containerd.Container, task containerd.Task) error {
 	select {
 	case <-ctx.Done():
 		if err := ctx.Err(); err != nil {
-			return fmt.Errorf("wait container %v: %w", id, err)
+			return fmt.Errorf("wait container %v: %w", container.ID(), err)
 		}
 		return nil
 	case status := <-exitCh:
+		if err := updateContainerExitCodeLabel(ctx, container, task, status); err != nil {
+			logrus.WithError(err).Warnf("failed to record the exit code of container %s", container.ID())
+		}
 		return status.Error()
 	}
 }
@@ -19,6 +19,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"testing"
 
@@ -86,3 +87,66 @@ echo "signal quit"`).AssertOK()
 	base.Cmd("stop", testContainerName).AssertOK()
 	base.Cmd("logs", "-f", testContainerName).AssertOutContains("signal quit")
 }
+
+func TestStopWithImageStopSignal(t *testing.T) {
+	testutil.RequiresBuild(t)
+	base := testutil.NewBase(t)
+	defer base.Cmd("builder", "prune").Run()
+	imageName := testutil.Identifier(t)
+	defer base.Cmd("rmi", imageName).Run()
+
+	dockerfile := fmt.Sprintf(`FROM %s
+STOPSIGNAL SIGQUIT
+CMD ["sh", "-c", "trap 'echo signal quit; exit 0' QUIT; trap 'echo signal term; exit 0' TERM; while true; do sleep 1; done"]
+	`, testutil.CommonImage)
+	buildCtx, err := createBuildContext(dockerfile)
+	assert.NilError(t, err)
+	defer os.RemoveAll(buildCtx)
+	base.Cmd("build", "-t", imageName, buildCtx).AssertOK()
+
+	// the StopSignal of the image is used when --stop-signal is not specified
+	name := testutil.Identifier(t) + "-image"
+	defer base.Cmd("rm", "-f", name).Run()
+	base.Cmd("run", "-d", "--name", name, imageName).AssertOK()
+	base.Cmd("stop", name).AssertOK()
+	base.Cmd("logs", name).AssertOutContains("signal quit")
+
+	// --stop-signal overrides the StopSignal of the image, and accepts a number
+	name = testutil.Identifier(t) + "-override"
+	defer base.Cmd("rm", "-f", name).Run()
+	base.Cmd("run", "-d", "--stop-signal", "15", "--name", name, imageName).AssertOK()
+	base.Cmd("stop", name).AssertOK()
+	base.Cmd("logs", name).AssertOutContains("signal term")
+
+	base.Cmd("run", "--rm", "--stop-signal", "SIGFOO", imageName).AssertFail()
+}
+
+func TestStopAll(t *testing.T) {
+	testutil.DockerIncompatible(t) // `docker stop` lacks --all and --filter
+	base := testutil.NewBase(t)
+	// use a dedicated namespace, so as not to stop the containers of other tests
+	ns := testutil.Identifier(t)
+	base.Args = []string{"--namespace=" + ns}
+	names := []string{ns + "-0", ns + "-1", ns + "-2"}
+	defer base.Cmd("namespace", "remove", ns).Run()
+	defer base.Cmd("rmi", testutil.AlpineImage).Run()
+	defer base.Cmd(append([]string{"rm", "-f"}, names...)...).Run()
+
+	for _, name := range names {
+		base.Cmd("run", "-d", "--name", name, "--label", "group="+strings.TrimPrefix(name, ns+"-"), testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	}
+
+	// --filter stops only the matching containers
+	base.Cmd("stop", "--time", "1", "--filter", "label=group=0").AssertOutExactly(base.InspectContainer(names[0]).ID + "\n")
+	assert.Equal(t, "exited", base.InspectContainer(names[0]).State.Status)
+	assert.Equal(t, "running", base.InspectContainer(names[1]).State.Status)
+
+	// --all stops all the running containers
+	base.Cmd("stop", "--time", "1", "--all").AssertOK()
+	for _, name := range names {
+		assert.Equal(t, "exited", base.InspectContainer(name).State.Status)
+	}
+	base.Cmd("ps", "-q").AssertOutExactly("")
+
+	base.Cmd("stop", "--all", names[0]).AssertFail()
+}
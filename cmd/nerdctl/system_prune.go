@@ -0,0 +1,252 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/pkg/progress"
+	"github.com/containerd/nerdctl/pkg/defaults"
+	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newSystemPruneCommand() *cobra.Command {
+	systemPruneCommand := &cobra.Command{
+		Use:           "prune [flags]",
+		Short:         "Remove unused data",
+		Args:          cobra.NoArgs,
+		RunE:          systemPruneAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	systemPruneCommand.Flags().BoolP("all", "a", false, "Remove all unused images, not just dangling ones")
+	systemPruneCommand.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
+	systemPruneCommand.Flags().Bool("volumes", false, "Prune volumes")
+	systemPruneCommand.Flags().StringArray("filter", nil, "Provide filter values (e.g. \"until=24h\", \"label=foo=bar\")")
+	addPruneDryRunFlag(systemPruneCommand)
+	AddStringFlag(systemPruneCommand, "buildkit-host", nil, defaults.BuildKitHost(), "BUILDKIT_HOST", "BuildKit address")
+	return systemPruneCommand
+}
+
+// pruneFilters is the parsed form of `nerdctl system prune --filter` and `nerdctl container prune --filter`.
+// All the filters are ANDed.
+type pruneFilters struct {
+	until  time.Time // zero when not specified
+	labels []labelFilter
+}
+
+func parsePruneFilters(filters []string) (*pruneFilters, error) {
+	f := &pruneFilters{}
+	for _, filter := range filters {
+		kv := strings.SplitN(filter, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("bad format of filter %q (expected name=value)", filter)
+		}
+		k, v := kv[0], kv[1]
+		negate := strings.HasSuffix(k, "!")
+		k = strings.TrimSuffix(k, "!")
+		switch k {
+		case "until":
+			if negate {
+				return nil, fmt.Errorf("invalid filter %q: negation is not supported for %q", filter, k)
+			}
+			if !f.until.IsZero() {
+				return nil, fmt.Errorf("invalid filter %q: %q can be specified only once", filter, k)
+			}
+			until, err := parseTimestampFilter(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+			}
+			f.until = until
+		case "label":
+			lf, err := parseLabelFilter(v, negate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+			}
+			f.labels = append(f.labels, lf)
+		default:
+			return nil, fmt.Errorf("invalid filter %q", filter)
+		}
+	}
+	return f, nil
+}
+
+// match returns true if the object created at createdAt with the labels matches the filters.
+// A nil pruneFilters matches everything.
+func (f *pruneFilters) match(createdAt time.Time, labels map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	if !f.until.IsZero() && !createdAt.Before(f.until) {
+		return false
+	}
+	return matchLabelFilters(f.labels, labels)
+}
+
+// withoutPrunedContainers returns the containers except the ones in pruned.
+func withoutPrunedContainers(containers []containerd.Container, pruned map[string]struct{}) []containerd.Container {
+	var res []containerd.Container
+	for _, c := range containers {
+		if _, ok := pruned[c.ID()]; !ok {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// withoutPrunedContainerIDs returns the container IDs except the ones in pruned.
+func withoutPrunedContainerIDs(ids []string, pruned map[string]struct{}) []string {
+	var res []string
+	for _, id := range ids {
+		if _, ok := pruned[id]; !ok {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// addPruneDryRunFlag adds the --dry-run flag shared by the prune commands.
+// With --dry-run, the objects are selected in the same way, but only reported instead of being removed.
+func addPruneDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Only show what would be removed and the reclaimable space")
+}
+
+// pruneVerb returns the verb for the headers like "Deleted Containers:".
+func pruneVerb(dryRun bool) string {
+	if dryRun {
+		return "Would Delete"
+	}
+	return "Deleted"
+}
+
+// printPruneTotal prints the reclaimed space, or the reclaimable space on dry-run.
+func printPruneTotal(w io.Writer, reclaimed int64, dryRun bool) {
+	if dryRun {
+		fmt.Fprintf(w, "Total reclaimable space: %s\n", progress.Bytes(reclaimed))
+		return
+	}
+	fmt.Fprintf(w, "Total reclaimed space: %s\n", progress.Bytes(reclaimed))
+}
+
+func systemPruneAction(cmd *cobra.Command, _ []string) error {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	volumes, err := cmd.Flags().GetBool("volumes")
+	if err != nil {
+		return err
+	}
+	filterFlags, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	filters, err := parsePruneFilters(filterFlags)
+	if err != nil {
+		return err
+	}
+	// volumes do not have the creation time
+	if volumes && !filters.until.IsZero() {
+		return errors.New("filter \"until\" cannot be used with --volumes")
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	if !force && !dryRun {
+		var confirm string
+		msg := "This will remove:\n  - all stopped containers\n"
+		msg += "  - all networks not used by at least one container\n"
+		if volumes {
+			msg += "  - all volumes not used by at least one container\n"
+		}
+		if all {
+			msg += "  - all images without at least one container associated to them\n"
+		} else {
+			msg += "  - all dangling images\n"
+		}
+		// BuildKit does not support label filters
+		if len(filters.labels) == 0 {
+			msg += "  - all build cache\n"
+		}
+		if len(filterFlags) > 0 {
+			msg += "\n  Items to be pruned will be filtered with:\n"
+			for _, f := range filterFlags {
+				msg += "  - " + f + "\n"
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "WARNING! %s\nAre you sure you want to continue? [y/N] ", msg)
+		fmt.Fscanf(cmd.InOrStdin(), "%s", &confirm)
+
+		if strings.ToLower(confirm) != "y" {
+			return nil
+		}
+	}
+
+	client, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	// on dry-run, the containers are not removed, so the later passes need to ignore them
+	// to report what the real run would remove
+	pruned, reclaimed, err := pruneContainers(ctx, cmd, client, false, filters, labels.PruneProtect, dryRun)
+	if err != nil {
+		return err
+	}
+	if err := pruneNetworks(ctx, cmd, client, filters, pruned, dryRun); err != nil {
+		return err
+	}
+	if volumes {
+		volumesReclaimed, err := pruneVolumes(ctx, cmd, client, filters, pruned, dryRun)
+		if err != nil {
+			return err
+		}
+		reclaimed += volumesReclaimed
+	}
+	imagesReclaimed, err := pruneImages(ctx, cmd, client, filters, pruned, all, dryRun)
+	if err != nil {
+		return err
+	}
+	reclaimed += imagesReclaimed
+	if len(filters.labels) == 0 {
+		var pruneArgs []string
+		if !filters.until.IsZero() {
+			pruneArgs = append(pruneArgs, "--keep-duration", time.Since(filters.until).Round(time.Second).String())
+		}
+		buildCacheReclaimed, err := pruneBuildCache(cmd, dryRun, pruneArgs...)
+		if err != nil {
+			logrus.WithError(err).Warn("failed to prune the build cache")
+		}
+		reclaimed += buildCacheReclaimed
+	}
+	printPruneTotal(cmd.OutOrStdout(), reclaimed, dryRun)
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestSystemPruneWithFilters(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	labeled, unlabeled := tID+"-labeled", tID+"-unlabeled"
+	defer base.Cmd("rm", "-f", labeled, unlabeled).Run()
+
+	base.Cmd("create", "--name", labeled, "--label", "nerdctl-test="+tID, testutil.CommonImage, "true").AssertOK()
+	base.Cmd("create", "--name", unlabeled, testutil.CommonImage, "true").AssertOK()
+
+	// recent containers are retained
+	base.Cmd("system", "prune", "-f", "--filter", "until=1h").AssertOutNotContains("Deleted Containers")
+	base.Cmd("inspect", labeled).AssertOK()
+	base.Cmd("inspect", unlabeled).AssertOK()
+
+	// only the labeled container is removed
+	base.Cmd("system", "prune", "-f", "--filter", "label=nerdctl-test="+tID).AssertOutContains("Deleted Containers")
+	base.Cmd("inspect", labeled).AssertFail()
+	base.Cmd("inspect", unlabeled).AssertOK()
+}
+
+func TestSystemPruneNetworksAndVolumes(t *testing.T) {
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	label := "nerdctl-test=" + tID
+	unusedNet, usedNet := tID+"-unused", tID+"-used"
+	defer base.Cmd("rm", "-f", tID).Run()
+	defer base.Cmd("network", "rm", unusedNet, usedNet).Run()
+	defer base.Cmd("volume", "rm", "-f", tID).Run()
+
+	base.Cmd("network", "create", "--label", label, unusedNet).AssertOK()
+	base.Cmd("network", "create", "--label", label, usedNet).AssertOK()
+	base.Cmd("volume", "create", "--label", label, tID).AssertOK()
+	// a stopped container still uses the network
+	base.Cmd("create", "--name", tID, "--network", usedNet, testutil.CommonImage, "true").AssertOK()
+
+	base.Cmd("system", "prune", "-f", "--volumes", "--filter", "until=1h").AssertFail()
+
+	// volumes are pruned only with --volumes
+	base.Cmd("system", "prune", "-f", "--filter", "label="+label).AssertOutContains(unusedNet)
+	base.Cmd("network", "inspect", unusedNet).AssertFail()
+	base.Cmd("network", "inspect", usedNet).AssertOK()
+	base.Cmd("volume", "inspect", tID).AssertOK()
+
+	base.Cmd("system", "prune", "-f", "--volumes", "--filter", "label="+label).AssertOutContains("Deleted Volumes")
+	base.Cmd("volume", "inspect", tID).AssertFail()
+	base.Cmd("network", "inspect", usedNet).AssertOK()
+}
+
+func TestSystemPruneDryRun(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks `system prune --dry-run`
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	label := "nerdctl-test=" + tID
+	used := tID + "-used"
+	defer base.Cmd("rm", "-f", tID).Run()
+	defer base.Cmd("network", "rm", tID, used).Run()
+	defer base.Cmd("volume", "rm", "-f", tID, used).Run()
+
+	base.Cmd("network", "create", "--label", label, tID).AssertOK()
+	base.Cmd("network", "create", "--label", label, used).AssertOK()
+	base.Cmd("volume", "create", "--label", label, tID).AssertOK()
+	base.Cmd("volume", "create", "--label", label, used).AssertOK()
+	// the network and the volume of the stopped container are freed by pruning the container
+	base.Cmd("create", "--name", tID, "--label", label, "--network", used, "-v", used+":/mnt", testutil.CommonImage, "true").AssertOK()
+
+	res := base.Cmd("system", "prune", "--dry-run", "--volumes", "--filter", "label="+label).Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	for _, s := range []string{
+		"Would Delete Containers:",
+		"Would Delete Networks:",
+		"\n" + tID + "\n",
+		"\n" + used + "\n",
+		"Would Delete Volumes:",
+		"Total reclaimable space",
+	} {
+		assert.Assert(t, strings.Contains(res.Stdout(), s), res.Combined())
+	}
+	base.Cmd("inspect", tID).AssertOK()
+	base.Cmd("network", "inspect", used).AssertOK()
+	base.Cmd("volume", "inspect", used).AssertOK()
+
+	// the same items are removed by the real run
+	res = base.Cmd("system", "prune", "-f", "--volumes", "--filter", "label="+label).Run()
+	assert.Equal(t, 0, res.ExitCode, res.Combined())
+	base.Cmd("inspect", tID).AssertFail()
+	base.Cmd("network", "inspect", used).AssertFail()
+	base.Cmd("volume", "inspect", used).AssertFail()
+}
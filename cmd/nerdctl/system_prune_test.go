@@ -0,0 +1,46 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPruneFilters(t *testing.T) {
+	f, err := parsePruneFilters([]string{"until=1h", "label=foo=bar", "label!=baz"})
+	assert.NilError(t, err)
+
+	old := time.Now().Add(-2 * time.Hour)
+	recent := time.Now()
+	assert.Assert(t, f.match(old, map[string]string{"foo": "bar"}))
+	assert.Assert(t, !f.match(recent, map[string]string{"foo": "bar"}))
+	assert.Assert(t, !f.match(old, map[string]string{"foo": "qux"}))
+	assert.Assert(t, !f.match(old, map[string]string{"foo": "bar", "baz": ""}))
+
+	var nilFilters *pruneFilters
+	assert.Assert(t, nilFilters.match(recent, nil))
+
+	for _, invalid := range []string{"until", "until=foo", "until!=1h", "label=", "dangling=true"} {
+		_, err := parsePruneFilters([]string{invalid})
+		assert.Assert(t, err != nil, invalid)
+	}
+	_, err = parsePruneFilters([]string{"until=1h", "until=2h"})
+	assert.ErrorContains(t, err, "only once")
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/containerd/containerd/errdefs"
+	refdocker "github.com/containerd/containerd/reference/docker"
 	"github.com/containerd/nerdctl/pkg/idutil/imagewalker"
 	"github.com/containerd/nerdctl/pkg/referenceutil"
 
@@ -37,10 +38,33 @@ func newTagCommand() *cobra.Command {
 		SilenceUsage:      true,
 		SilenceErrors:     true,
 	}
+	tagCommand.Flags().BoolP("quiet", "q", true, "Suppress the output. Set --quiet=false to print the new reference and the digest")
 	return tagCommand
 }
 
+// parseTagTarget parses the target reference of `nerdctl tag`.
+// Digest references are rejected, as in Docker.
+func parseTagTarget(rawRef string) (refdocker.Named, error) {
+	target, err := referenceutil.ParseDockerRef(rawRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target reference %q: %w", rawRef, err)
+	}
+	if _, ok := target.(refdocker.Digested); ok {
+		return nil, fmt.Errorf("invalid target reference %q: refusing to create a tag with a digest reference", rawRef)
+	}
+	return target, nil
+}
+
 func tagAction(cmd *cobra.Command, args []string) error {
+	quiet, err := cmd.Flags().GetBool("quiet")
+	if err != nil {
+		return err
+	}
+	target, err := parseTagTarget(args[1])
+	if err != nil {
+		return err
+	}
+
 	client, ctx, cancel, err := newClient(cmd)
 	if err != nil {
 		return err
@@ -66,11 +90,6 @@ func tagAction(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%s: not found", args[0])
 	}
 
-	target, err := referenceutil.ParseDockerRef(args[1])
-	if err != nil {
-		return err
-	}
-
 	ctx, done, err := client.WithLease(ctx)
 	if err != nil {
 		return err
@@ -94,6 +113,9 @@ func tagAction(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	}
+	if !quiet {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", image.Name, image.Target.Digest)
+	}
 	return nil
 }
 
@@ -0,0 +1,72 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseTagTarget(t *testing.T) {
+	valid := map[string]string{
+		"foo":                           "docker.io/library/foo:latest",
+		"foo:bar":                       "docker.io/library/foo:bar",
+		"example.com/foo/bar:baz":       "example.com/foo/bar:baz",
+		"127.0.0.1:5000/foo:latest":     "127.0.0.1:5000/foo:latest",
+		"ghcr.io/containerd/nerdctl:v1": "ghcr.io/containerd/nerdctl:v1",
+	}
+	for s, expected := range valid {
+		target, err := parseTagTarget(s)
+		assert.NilError(t, err, s)
+		assert.Equal(t, expected, target.String())
+	}
+	invalid := []string{
+		"",
+		"Foo",
+		"foo:",
+		"foo:bar:baz",
+		"-foo",
+		"foo@sha256:e28fe5f1e19ef1bbd6a8a9b2d6d7bdd1b8d2a6b4e5c4cb9c1a3f2d1c0b9a8f7e",
+	}
+	for _, s := range invalid {
+		_, err := parseTagTarget(s)
+		assert.ErrorContains(t, err, "invalid target reference", s)
+	}
+}
+
+func TestTag(t *testing.T) {
+	testutil.DockerIncompatible(t) // --quiet is a nerdctl extension
+	base := testutil.NewBase(t)
+	tID := testutil.Identifier(t)
+	target := "docker.io/library/" + tID + ":latest"
+	defer base.Cmd("rmi", target).Run()
+
+	base.Cmd("pull", testutil.CommonImage).AssertOK()
+	base.Cmd("tag", testutil.CommonImage, tID).AssertOutExactly("")
+	base.Cmd("tag", "--quiet=false", testutil.CommonImage, tID).AssertOutWithFunc(func(out string) error {
+		fields := strings.Fields(out)
+		assert.Equal(t, 2, len(fields))
+		assert.Equal(t, target, fields[0])
+		assert.Assert(t, strings.HasPrefix(fields[1], "sha256:"))
+		return nil
+	})
+	base.Cmd("tag", testutil.CommonImage, "Invalid:"+tID).AssertErrContains("invalid target reference")
+	base.Cmd("tag", testutil.CommonImage, tID+"@sha256:e28fe5f1e19ef1bbd6a8a9b2d6d7bdd1b8d2a6b4e5c4cb9c1a3f2d1c0b9a8f7e").AssertErrContains("digest reference")
+}
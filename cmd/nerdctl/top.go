@@ -98,7 +98,10 @@ func topAction(cmd *cobra.Command, args []string) error {
 	walker := &containerwalker.ContainerWalker{
 		Client: client,
 		OnFound: func(ctx context.Context, found containerwalker.Found) error {
-			if err := containerTop(ctx, cmd, client, found.Container.ID(), strings.Join(args[1:], " ")); err != nil {
+			if found.MatchCount > 1 {
+				return fmt.Errorf("ambiguous ID %q", found.Req)
+			}
+			if err := containerTop(ctx, cmd, client, found.Container.ID(), args[1:]); err != nil {
 				return err
 			}
 			return nil
@@ -243,12 +246,16 @@ func parsePSOutput(output []byte, procs []uint32) (*ContainerTopOKBody, error) {
 // "-ef" if no args are given.  An error is returned if the container
 // is not found, or is not running, or if there are any problems
 // running ps, or parsing the output.
-func containerTop(ctx context.Context, cmd *cobra.Command, client *containerd.Client, id string, psArgs string) error {
-	if psArgs == "" {
-		psArgs = "-ef"
+//
+// The args are passed to ps as they are, so that an arg can contain spaces (e.g., `-o "pid,args"`).
+// The PIDs in the output are the PIDs on the host, as in Docker.
+func containerTop(ctx context.Context, cmd *cobra.Command, client *containerd.Client, id string, psArgs []string) error {
+	if len(psArgs) == 0 {
+		psArgs = []string{"-ef"}
 	}
 
-	if err := validatePSArgs(psArgs); err != nil {
+	// the leading space is needed for validatePSArgs to detect "pid=PID" in the first arg
+	if err := validatePSArgs(" " + strings.Join(psArgs, " ")); err != nil {
 		return err
 	}
 
@@ -257,20 +264,12 @@ func containerTop(ctx context.Context, cmd *cobra.Command, client *containerd.Cl
 		return err
 	}
 
-	task, err := container.Task(ctx, nil)
+	// like Docker, the processes of paused containers are listed as well
+	task, _, err := ensureRunning(ctx, container)
 	if err != nil {
 		return err
 	}
 
-	status, err := task.Status(ctx)
-	if err != nil {
-		return err
-	}
-
-	if status.Status != containerd.Running {
-		return nil
-	}
-
 	//TO DO handle restarting case: wait for container to restart and then launch top command
 
 	procs, err := task.Pids(ctx)
@@ -283,13 +282,12 @@ func containerTop(ctx context.Context, cmd *cobra.Command, client *containerd.Cl
 		psList = append(psList, ps.Pid)
 	}
 
-	args := strings.Split(psArgs, " ")
 	pids := psPidsArg(psList)
-	output, err := exec.Command("ps", append(args, pids)...).Output()
+	output, err := exec.Command("ps", append(psArgs, pids)...).Output()
 	if err != nil {
 		// some ps options (such as f) can't be used together with q,
 		// so retry without it
-		output, err = exec.Command("ps", args...).Output()
+		output, err = exec.Command("ps", psArgs...).Output()
 		if err != nil {
 			if ee, ok := err.(*exec.ExitError); ok {
 				// first line of stderr shows why ps failed
@@ -298,7 +296,7 @@ func containerTop(ctx context.Context, cmd *cobra.Command, client *containerd.Cl
 					return errors.New(string(line[0]))
 				}
 			}
-			return nil
+			return fmt.Errorf("failed to run ps %v: %w", psArgs, err)
 		}
 	}
 	procList, err := parsePSOutput(output, psList)
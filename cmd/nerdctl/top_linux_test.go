@@ -22,6 +22,7 @@ import (
 	"github.com/containerd/nerdctl/pkg/infoutil"
 	"github.com/containerd/nerdctl/pkg/rootlessutil"
 	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
 )
 
 func TestTop(t *testing.T) {
@@ -37,5 +38,34 @@ func TestTop(t *testing.T) {
 
 	base.Cmd("run", "-d", "--name", testContainerName, testutil.AlpineImage, "sleep", "5").AssertOK()
 	base.Cmd("top", testContainerName, "-o", "pid,user,cmd").AssertOK()
+	// the default is "-ef"
+	base.Cmd("top", testContainerName).AssertOutContains("sleep 5")
+	// an arg with spaces is passed to ps as it is
+	base.Cmd("top", testContainerName, "-o", "pid args").AssertOutContains("sleep 5")
+	base.Cmd("top", testContainerName, "-o", "ppid=PID").AssertFail()
 
 }
+
+func TestParsePSOutput(t *testing.T) {
+	output := []byte(`UID        PID  PPID  C STIME TTY          TIME CMD
+root         1     0  0 10:00 ?        00:00:01 /sbin/init
+root       100     1  0 10:01 ?        00:00:00 sleep infinity
+root       101   100  0 10:01 ?        00:00:00 sh -c echo foo
+`)
+	procList, err := parsePSOutput(output, []uint32{100, 101})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"}, procList.Titles)
+	assert.DeepEqual(t, [][]string{
+		{"root", "100", "1", "0", "10:01", "?", "00:00:00", "sleep infinity"},
+		{"root", "101", "100", "0", "10:01", "?", "00:00:00", "sh -c echo foo"},
+	}, procList.Processes)
+
+	_, err = parsePSOutput([]byte("UID CMD\nroot sleep\n"), []uint32{100})
+	assert.ErrorContains(t, err, "couldn't find PID field")
+}
+
+func TestValidatePSArgs(t *testing.T) {
+	assert.NilError(t, validatePSArgs(" -ef"))
+	assert.NilError(t, validatePSArgs(" -o pid=PID"))
+	assert.ErrorContains(t, validatePSArgs(" -o ppid=PID"), "is not allowed")
+}
@@ -21,16 +21,18 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"strconv"
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/log"
 	"github.com/containerd/containerd/pkg/cri/util"
+	"github.com/containerd/containerd/runtime/restart"
 	"github.com/containerd/nerdctl/pkg/formatter"
 	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
+	"github.com/containerd/nerdctl/pkg/labels"
 	"github.com/containerd/typeurl"
-	"github.com/docker/go-units"
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/spf13/cobra"
 )
@@ -73,6 +75,10 @@ func setUpdateFlags(cmd *cobra.Command) {
 	cmd.Flags().String("cpuset-cpus", "", "CPUs in which to allow execution (0-3, 0,1)")
 	cmd.Flags().String("cpuset-mems", "", "MEMs in which to allow execution (0-3, 0,1)")
 	cmd.Flags().Int64("pids-limit", -1, "Tune container pids limit (set -1 for unlimited)")
+	cmd.Flags().String("restart", "no", `Restart policy to apply when a container exits (implemented values: "no"|"always"|"on-failure[:max-retries]"|"unless-stopped")`)
+	cmd.RegisterFlagCompletionFunc("restart", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"no", "always", "on-failure", "unless-stopped"}, cobra.ShellCompDirectiveNoFileComp
+	})
 }
 
 func updateAction(cmd *cobra.Command, args []string) error {
@@ -142,9 +148,9 @@ func getUpdateOption(cmd *cobra.Command) (updateResourceOptions, error) {
 	}
 	var mem64 int64
 	if memStr != "" {
-		mem64, err = units.RAMInBytes(memStr)
+		mem64, err = parseMemoryBytes("memory", memStr, linuxMinMemory)
 		if err != nil {
-			return options, fmt.Errorf("failed to parse memory bytes %q: %w", memStr, err)
+			return options, err
 		}
 	}
 	var memSwap64 int64
@@ -152,9 +158,9 @@ func getUpdateOption(cmd *cobra.Command) (updateResourceOptions, error) {
 		if memSwap == "-1" {
 			memSwap64 = -1
 		} else {
-			memSwap64, err = units.RAMInBytes(memSwap)
+			memSwap64, err = parseMemoryBytes("memory-swap", memSwap, 0)
 			if err != nil {
-				return options, fmt.Errorf("failed to parse memory-swap bytes %q: %w", memSwap, err)
+				return options, err
 			}
 			if mem64 > 0 && memSwap64 > 0 && memSwap64 < mem64 {
 				return options, fmt.Errorf("minimum memoryswap limit should be larger than memory limit, see usage")
@@ -172,9 +178,9 @@ func getUpdateOption(cmd *cobra.Command) (updateResourceOptions, error) {
 	}
 	var memReserve64 int64
 	if memReserve != "" {
-		memReserve64, err = units.RAMInBytes(memReserve)
+		memReserve64, err = parseMemoryBytes("memory-reservation", memReserve, linuxMinMemory)
 		if err != nil {
-			return options, fmt.Errorf("failed to parse memory bytes %q: %w", memReserve, err)
+			return options, err
 		}
 	}
 	if mem64 > 0 && memReserve64 > 0 && mem64 < memReserve64 {
@@ -215,7 +221,7 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 		return err
 	}
 	cStatus := formatter.ContainerStatus(ctx, container)
-	if cStatus == "pausing" {
+	if cStatus == "Pausing" {
 		return fmt.Errorf("container %q is in pausing state", id)
 	}
 	spec, err := container.Spec(ctx)
@@ -253,9 +259,8 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 			}
 		}
 		if cmd.Flags().Changed("cpus") {
-			if spec.Linux.Resources.CPU.Cpus != opts.CpusetCpus {
-				spec.Linux.Resources.CPU.Cpus = opts.CpusetCpus
-			}
+			spec.Linux.Resources.CPU.Quota = &opts.CPUQuota
+			spec.Linux.Resources.CPU.Period = &opts.CPUPeriod
 		}
 		if cmd.Flags().Changed("cpuset-mems") {
 			if spec.Linux.Resources.CPU.Mems != opts.CpusetMems {
@@ -275,6 +280,8 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 			if spec.Linux.Resources.Memory.Limit != &opts.MemoryLimitInBytes {
 				spec.Linux.Resources.Memory.Limit = &opts.MemoryLimitInBytes
 			}
+		}
+		if cmd.Flags().Changed("memory") || cmd.Flags().Changed("memory-swap") {
 			if spec.Linux.Resources.Memory.Swap != &opts.MemorySwapInBytes {
 				spec.Linux.Resources.Memory.Swap = &opts.MemorySwapInBytes
 			}
@@ -295,7 +302,7 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 	}
 
 	if err := updateContainerSpec(ctx, container, spec); err != nil {
-		log.G(ctx).WithError(err).Errorf("Failed to update spec %+v for container %q", spec, id)
+		return err
 	}
 	defer func() {
 		if retErr != nil {
@@ -306,6 +313,16 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 		}
 	}()
 
+	if cmd.Flags().Changed("restart") {
+		restartFlag, err := cmd.Flags().GetString("restart")
+		if err != nil {
+			return err
+		}
+		if err := updateContainerRestartPolicy(ctx, client, container, restartFlag); err != nil {
+			return err
+		}
+	}
+
 	// If container is not running, only update spec is enough, new resource
 	// limit will be applied when container start.
 	if cStatus != "Up" {
@@ -325,6 +342,57 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 	return nil
 }
 
+// updateContainerRestartPolicy replaces the restart policy of the container, in the same way as `nerdctl run --restart`.
+func updateContainerRestartPolicy(ctx context.Context, client *containerd.Client, container containerd.Container, restartFlag string) error {
+	l, err := container.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	if autoRemove, _ := strconv.ParseBool(l[labels.AutoRemove]); autoRemove && restartFlag != "" && restartFlag != "no" {
+		return errors.New("restart policy cannot be updated because AutoRemove is enabled for the container")
+	}
+	restartOpts, err := generateRestartOpts(ctx, client, restartFlag, l[labels.LogURI])
+	if err != nil {
+		return err
+	}
+	updateOpts := []containerd.UpdateContainerOpts{containerd.UpdateContainerOpts(restart.WithNoRestarts)}
+	for _, opt := range restartOpts {
+		updateOpts = append(updateOpts, containerd.UpdateContainerOpts(opt))
+	}
+	if len(restartOpts) > 0 {
+		// generateRestartOpts sets the desired status to Running, which makes the restart monitor
+		// start a stopped container. Unlike `run`, `update` must never start the container.
+		status, err := restartStatus(ctx, container)
+		if err != nil {
+			return err
+		}
+		updateOpts = append(updateOpts, containerd.UpdateContainerOpts(restart.WithStatus(status)))
+	}
+	return container.Update(ctx, updateOpts...)
+}
+
+// restartStatus returns the desired status for the restart monitor that keeps the current state of the container.
+func restartStatus(ctx context.Context, container containerd.Container) (containerd.ProcessStatus, error) {
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return containerd.Stopped, nil
+		}
+		return "", err
+	}
+	st, err := task.Status(ctx)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return containerd.Stopped, nil
+		}
+		return "", err
+	}
+	if st.Status == containerd.Running || st.Status == containerd.Paused {
+		return containerd.Running, nil
+	}
+	return containerd.Stopped, nil
+}
+
 func updateContainerSpec(ctx context.Context, container containerd.Container, spec *runtimespec.Spec) error {
 	if err := container.Update(ctx, func(ctx context.Context, client *containerd.Client, c *containers.Container) error {
 		any, err := typeurl.MarshalAny(spec)
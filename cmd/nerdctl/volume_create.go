@@ -21,6 +21,7 @@ import (
 
 	"github.com/containerd/containerd/identifiers"
 	"github.com/containerd/nerdctl/pkg/strutil"
+	"github.com/containerd/nerdctl/pkg/volumeplugin"
 
 	"github.com/spf13/cobra"
 )
@@ -35,6 +36,8 @@ func newVolumeCreateCommand() *cobra.Command {
 		SilenceErrors: true,
 	}
 	volumeCreateCommand.Flags().StringArray("label", nil, "Set a label on the volume")
+	volumeCreateCommand.Flags().StringP("driver", "d", volumeplugin.LocalDriver, "Specify volume driver name (a Docker volume plugin, or \"local\")")
+	volumeCreateCommand.Flags().StringArrayP("opt", "o", nil, "Set driver specific options")
 	return volumeCreateCommand
 }
 
@@ -53,7 +56,19 @@ func volumeCreateAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	labels = strutil.DedupeStrSlice(labels)
-	if _, err := volStore.Create(name, labels); err != nil {
+	driver, err := cmd.Flags().GetString("driver")
+	if err != nil {
+		return err
+	}
+	opts, err := cmd.Flags().GetStringArray("opt")
+	if err != nil {
+		return err
+	}
+	var driverOpts map[string]string
+	if len(opts) > 0 {
+		driverOpts = strutil.ConvertKVStringsToMap(strutil.DedupeStrSlice(opts))
+	}
+	if _, err := volStore.CreateWithDriver(name, driver, driverOpts, labels); err != nil {
 		return err
 	}
 	fmt.Fprintf(cmd.OutOrStdout(), "%s\n", name)
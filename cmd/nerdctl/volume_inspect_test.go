@@ -37,3 +37,17 @@ func TestVolumeInspectContainsLabels(t *testing.T) {
 	expected["tag"] = "testVolume"
 	assert.DeepEqual(base.T, expected, inspectNerdctlLabels)
 }
+
+func TestInspectTypeVolume(t *testing.T) {
+	t.Parallel()
+	testVolume := testutil.Identifier(t)
+
+	base := testutil.NewBase(t)
+	defer base.Cmd("volume", "rm", "-f", testVolume).Run()
+
+	base.Cmd("volume", "create", "--label", "tag=testVolume", testVolume).AssertOK()
+	base.Cmd("inspect", "--type", "volume", "--format", "{{.Name}} {{.Labels.tag}}", testVolume).AssertOutExactly(testVolume + " testVolume\n")
+	base.Cmd("inspect", "--type", "volume", testVolume+"-nonexistent").AssertFail()
+	// volumes are not looked up without --type
+	base.Cmd("inspect", testVolume).AssertFail()
+}
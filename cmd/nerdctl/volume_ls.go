@@ -93,7 +93,7 @@ func volumeLsAction(cmd *cobra.Command, args []string) error {
 
 	for _, v := range vols {
 		p := volumePrintable{
-			Driver:     "local",
+			Driver:     v.Driver,
 			Labels:     "",
 			Mountpoint: v.Mountpoint,
 			Name:       v.Name,
@@ -0,0 +1,191 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/continuity/fs"
+	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
+	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/containerd/nerdctl/pkg/mountutil"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newVolumePruneCommand() *cobra.Command {
+	volumePruneCommand := &cobra.Command{
+		Use:           "prune [flags]",
+		Short:         "Remove all unused volumes",
+		Args:          cobra.NoArgs,
+		RunE:          volumePruneAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	volumePruneCommand.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
+	volumePruneCommand.Flags().StringArray("filter", nil, "Provide filter values (e.g. 'label=<key>=<value>')")
+	addPruneDryRunFlag(volumePruneCommand)
+	return volumePruneCommand
+}
+
+func volumePruneAction(cmd *cobra.Command, _ []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	filterFlags, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return err
+	}
+	filters, err := parsePruneFilters(filterFlags)
+	if err != nil {
+		return err
+	}
+	// volumes do not have the creation time
+	if !filters.until.IsZero() {
+		return errors.New("filter \"until\" is not supported for volumes")
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	if !force && !dryRun {
+		var confirm string
+		msg := "This will remove all local volumes not used by at least one container."
+		if len(filterFlags) > 0 {
+			msg += fmt.Sprintf("\nOnly the volumes that match the filters (%s) will be removed.", strings.Join(filterFlags, ", "))
+		}
+		msg += "\nAre you sure you want to continue? [y/N] "
+		fmt.Fprintf(cmd.OutOrStdout(), "WARNING! %s", msg)
+		fmt.Fscanf(cmd.InOrStdin(), "%s", &confirm)
+
+		if strings.ToLower(confirm) != "y" {
+			return nil
+		}
+	}
+
+	client, ctx, cancel, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	reclaimed, err := pruneVolumes(ctx, cmd, client, filters, nil, dryRun)
+	if err != nil {
+		return err
+	}
+	printPruneTotal(cmd.OutOrStdout(), reclaimed, dryRun)
+	return nil
+}
+
+// pruneVolumes removes the volumes that match the label filters and are not used by any container.
+// pruneVolumes returns the size of the local volumes that were removed.
+// With dryRun, pruneVolumes only prints the volumes that would be removed, and returns their size.
+func pruneVolumes(ctx context.Context, cmd *cobra.Command, client *containerd.Client, filters *pruneFilters, pruned map[string]struct{}, dryRun bool) (int64, error) {
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	containers = withoutPrunedContainers(containers, pruned)
+	usedVolumes, err := usedVolumeNames(ctx, containers)
+	if err != nil {
+		return 0, err
+	}
+	volStore, err := getVolumeStore(cmd)
+	if err != nil {
+		return 0, err
+	}
+	vols, err := volStore.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var removeNames []string
+	for _, vol := range vols {
+		if _, ok := usedVolumes[vol.Name]; ok {
+			continue
+		}
+		var volLabels map[string]string
+		if vol.Labels != nil {
+			volLabels = *vol.Labels
+		}
+		if !matchLabelFilters(filters.labels, volLabels) {
+			continue
+		}
+		// the mount sources are checked as well, for the containers without the mounts label
+		if err := checkVolumeUsers(ctx, vol.Name, vol.Mountpoint, containers, false); err != nil {
+			logrus.Debug(err)
+			continue
+		}
+		removeNames = append(removeNames, vol.Name)
+	}
+	if len(removeNames) == 0 {
+		return 0, nil
+	}
+	sizes := make(map[string]int64, len(removeNames))
+	for _, name := range removeNames {
+		// the volumes of driver plugins do not have the local mountpoint
+		if mp := vols[name].Mountpoint; mp != "" {
+			if usage, err := fs.DiskUsage(ctx, mp); err == nil {
+				sizes[name] = usage.Size
+			}
+		}
+	}
+	removed := removeNames
+	if !dryRun {
+		removed, err = volStore.Remove(removeNames, false)
+	}
+	var reclaimed int64
+	if len(removed) > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s Volumes:\n", pruneVerb(dryRun))
+		for _, name := range removed {
+			fmt.Fprintln(cmd.OutOrStdout(), name)
+			reclaimed += sizes[name]
+		}
+	}
+	return reclaimed, err
+}
+
+// usedVolumeNames returns the names of the volumes recorded in the mounts label of the containers.
+func usedVolumeNames(ctx context.Context, containers []containerd.Container) (map[string]struct{}, error) {
+	used := make(map[string]struct{})
+	for _, c := range containers {
+		l, err := c.Labels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		mountsJSON := l[labels.Mounts]
+		if mountsJSON == "" {
+			continue
+		}
+		var mounts []dockercompat.MountPoint
+		if err := json.Unmarshal([]byte(mountsJSON), &mounts); err != nil {
+			return nil, err
+		}
+		for _, m := range mounts {
+			if m.Type == mountutil.Volume {
+				used[m.Name] = struct{}{}
+			}
+		}
+	}
+	return used, nil
+}
@@ -0,0 +1,63 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/containerd/containerd/pkg/progress"
+	"github.com/containerd/continuity/fs"
+	"github.com/containerd/nerdctl/pkg/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestVolumePruneWithFilter(t *testing.T) {
+	base := testutil.NewBase(t)
+	id := testutil.Identifier(t)
+	matched, unmatched, used := id+"-matched", id+"-unmatched", id+"-used"
+	defer base.Cmd("volume", "rm", "-f", matched, unmatched, used).Run()
+	defer base.Cmd("rm", "-f", id).Run()
+
+	base.Cmd("volume", "create", "--label", "project="+id, matched).AssertOK()
+	base.Cmd("volume", "create", "--label", "project="+id+"-other", unmatched).AssertOK()
+	base.Cmd("volume", "create", "--label", "project="+id, used).AssertOK()
+	base.Cmd("create", "--name", id, "-v", used+":/mnt", testutil.AlpineImage).AssertOK()
+
+	base.Cmd("volume", "prune", "--force", "--filter", "project="+id).AssertFail()
+	base.Cmd("volume", "prune", "--force", "--filter", "until=1h").AssertFail()
+	usage, err := fs.DiskUsage(context.Background(), base.InspectVolume(matched).Mountpoint)
+	assert.NilError(t, err)
+	base.Cmd("volume", "prune", "--force", "--filter", "label=project="+id).AssertOutExactly(
+		fmt.Sprintf("Deleted Volumes:\n%s\nTotal reclaimed space: %s\n", matched, progress.Bytes(usage.Size)))
+	base.Cmd("volume", "inspect", matched).AssertFail()
+	base.Cmd("volume", "inspect", unmatched).AssertOK()
+	// volumes in use by a stopped container are not removed either
+	base.Cmd("volume", "inspect", used).AssertOK()
+}
+
+func TestVolumePruneDryRun(t *testing.T) {
+	testutil.DockerIncompatible(t) // Docker lacks `volume prune --dry-run`
+	base := testutil.NewBase(t)
+	id := testutil.Identifier(t)
+	defer base.Cmd("volume", "rm", "-f", id).Run()
+
+	base.Cmd("volume", "create", "--label", "project="+id, id).AssertOK()
+	base.Cmd("volume", "prune", "--dry-run", "--filter", "label=project="+id).AssertOutContains("Would Delete Volumes:\n" + id + "\n")
+	base.Cmd("volume", "inspect", id).AssertOK()
+}
@@ -17,10 +17,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
-	"github.com/containerd/nerdctl/pkg/containerinspector"
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
+	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/containerd/nerdctl/pkg/mountutil"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -31,18 +36,22 @@ func newVolumeRmCommand() *cobra.Command {
 		Use:               "rm [flags] VOLUME [VOLUME...]",
 		Aliases:           []string{"remove"},
 		Short:             "Remove one or more volumes",
-		Long:              "NOTE: You cannot remove a volume that is in use by a container.",
+		Long:              "NOTE: You cannot remove a volume that is in use by a running container. A volume in use by a stopped container can be removed with --force.",
 		Args:              cobra.MinimumNArgs(1),
 		RunE:              volumeRmAction,
 		ValidArgsFunction: volumeRmShellComplete,
 		SilenceUsage:      true,
 		SilenceErrors:     true,
 	}
-	volumeRmCommand.Flags().BoolP("force", "f", false, "(unimplemented yet)")
+	volumeRmCommand.Flags().BoolP("force", "f", false, "Force the removal of volumes in use by stopped containers or without available driver plugins, and ignore nonexistent volumes")
 	return volumeRmCommand
 }
 
 func volumeRmAction(cmd *cobra.Command, args []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
 	client, ctx, cancel, err := newClient(cmd)
 	if err != nil {
 		return err
@@ -52,45 +61,71 @@ func volumeRmAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	var mount *specs.Spec
-	var volumenames []string
 	volStore, err := getVolumeStore(cmd)
 	if err != nil {
 		return err
 	}
-	names := args
 
-	for _, name := range names {
-		var found bool
-		for _, container := range containers {
-			n, err := containerinspector.Inspect(ctx, container)
-			if err != nil {
-				return err
-			}
-			err = json.Unmarshal(n.Container.Spec.GetValue(), &mount)
-			if err != nil {
-				return err
-			}
-			volume, _ := volStore.Get(name)
-			if found = checkVolume(&mount.Mounts, volume.Mountpoint); found {
-				found = true
-				logrus.WithError(fmt.Errorf("volume %q is in use", name)).Error(fmt.Errorf("remove Volume: %q failed", name))
-				break
+	var volumenames []string
+	errs := 0
+	for _, name := range args {
+		volume, err := volStore.Get(name)
+		if err != nil {
+			if force && errdefs.IsNotFound(err) {
+				continue
 			}
+			logrus.Error(err)
+			errs++
+			continue
 		}
-		if !found {
-			// volumes that are to be deleted
-			volumenames = append(volumenames, name)
+		if err := checkVolumeUsers(ctx, name, volume.Mountpoint, containers, force); err != nil {
+			logrus.Error(err)
+			errs++
+			continue
 		}
+		// volumes that are to be deleted
+		volumenames = append(volumenames, name)
 	}
 	if volumenames != nil {
-		volnames, err := volStore.Remove(volumenames)
+		volnames, err := volStore.Remove(volumenames, force)
+		for _, name := range volnames {
+			fmt.Fprintln(cmd.OutOrStdout(), name)
+		}
+		if err != nil {
+			logrus.Error(err)
+			errs += len(volumenames) - len(volnames)
+		}
+	}
+	if errs > 0 {
+		return fmt.Errorf("failed to remove %d of %d volumes", errs, len(args))
+	}
+	return nil
+}
+
+// checkVolumeUsers returns an error when the volume is mounted by a container.
+// The volume mounted by stopped containers can be removed with force, but the volume
+// mounted by running (or paused) containers cannot be removed even with force.
+func checkVolumeUsers(ctx context.Context, name, volmountpoint string, containers []containerd.Container, force bool) error {
+	for _, c := range containers {
+		used, err := containerUsesVolume(ctx, c, name, volmountpoint)
 		if err != nil {
 			return err
 		}
-		fmt.Fprintln(cmd.OutOrStdout(), volnames)
+		if !used {
+			continue
+		}
+		task, err := c.Task(ctx, nil)
+		if err == nil {
+			st, err := task.Status(ctx)
+			if err == nil && (st.Status == containerd.Running || st.Status == containerd.Paused) {
+				return fmt.Errorf("conflict: unable to remove volume %q (cannot be forced) - volume is in use by running container %s", name, c.ID())
+			}
+		}
+		if !force {
+			return fmt.Errorf("conflict: unable to remove volume %q (must force) - volume is in use by stopped container %s", name, c.ID())
+		}
 	}
-	return err
+	return nil
 }
 
 func volumeRmShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -98,6 +133,38 @@ func volumeRmShellComplete(cmd *cobra.Command, args []string, toComplete string)
 	return shellCompleteVolumeNames(cmd)
 }
 
+// containerUsesVolume checks whether the container mounts the volume.
+//
+// The volume is matched by the name recorded in the labels.Mounts label, as the host path of
+// a volume created with a driver plugin is not known until it is mounted.
+// The host path in the spec is checked for the containers created without the label.
+func containerUsesVolume(ctx context.Context, c containerd.Container, name, volmountpoint string) (bool, error) {
+	l, err := c.Labels(ctx)
+	if err != nil {
+		return false, err
+	}
+	if mountsJSON, ok := l[labels.Mounts]; ok {
+		var mounts []dockercompat.MountPoint
+		if err := json.Unmarshal([]byte(mountsJSON), &mounts); err != nil {
+			return false, err
+		}
+		for _, m := range mounts {
+			if m.Type == mountutil.Volume && m.Name == name {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if volmountpoint == "" {
+		return false, nil
+	}
+	spec, err := c.Spec(ctx)
+	if err != nil {
+		return false, err
+	}
+	return checkVolume(&spec.Mounts, volmountpoint), nil
+}
+
 // checkVolume checks whether the container mount path and the given volume mount point are same or not.
 func checkVolume(mounts *[]specs.Mount, volmountpoint string) bool {
 	for _, mount := range *mounts {
@@ -0,0 +1,64 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/testutil"
+)
+
+func TestVolumeRm(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	volName := testutil.Identifier(t)
+	defer base.Cmd("volume", "rm", "-f", volName).Run()
+
+	base.Cmd("volume", "create", volName).AssertOK()
+	base.Cmd("volume", "rm", volName).AssertOutExactly(volName + "\n")
+	base.Cmd("volume", "inspect", volName).AssertFail()
+
+	// nonexistent volumes are ignored only with --force
+	base.Cmd("volume", "rm", volName).AssertFail()
+	base.Cmd("volume", "rm", "-f", volName).AssertOK()
+}
+
+func TestVolumeRmInUse(t *testing.T) {
+	t.Parallel()
+	base := testutil.NewBase(t)
+	volName := testutil.Identifier(t)
+	stoppedName := volName + "-stopped"
+	runningName := volName + "-running"
+	defer base.Cmd("volume", "rm", "-f", volName).Run()
+	defer base.Cmd("rm", "-f", stoppedName, runningName).Run()
+
+	base.Cmd("volume", "create", volName).AssertOK()
+	base.Cmd("run", "-d", "--name", runningName, "-v", volName+":/mnt", testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	base.Cmd("create", "--name", stoppedName, "-v", volName+":/mnt", testutil.AlpineImage).AssertOK()
+
+	// a volume in use by a running container cannot be removed, even with --force
+	base.Cmd("volume", "rm", volName).AssertErrContains("in use")
+	base.Cmd("volume", "rm", "-f", volName).AssertErrContains("cannot be forced")
+
+	base.Cmd("rm", "-f", runningName).AssertOK()
+
+	// a volume in use by a stopped container can be removed only with --force
+	base.Cmd("volume", "rm", volName).AssertErrContains("must force")
+	base.Cmd("volume", "inspect", volName).AssertOK()
+	base.Cmd("volume", "rm", "-f", volName).AssertOutExactly(volName + "\n")
+	base.Cmd("volume", "inspect", volName).AssertFail()
+}
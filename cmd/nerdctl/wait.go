@@ -24,6 +24,7 @@ import (
 	"github.com/containerd/containerd"
 	"github.com/containerd/nerdctl/pkg/idutil/containerwalker"
 	"github.com/hashicorp/go-multierror"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -89,6 +90,9 @@ func waitContainer(ctx context.Context, w io.Writer, container containerd.Contai
 	}
 
 	status := <-statusC
+	if err := updateContainerExitCodeLabel(ctx, container, task, status); err != nil {
+		logrus.WithError(err).Warnf("failed to record the exit code of container %s", container.ID())
+	}
 	code, _, err := status.Result()
 	if err != nil {
 		return err
@@ -21,6 +21,7 @@ import (
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/nerdctl/pkg/inspecttypes/native"
+	"github.com/containerd/nerdctl/pkg/labels"
 	"github.com/containerd/typeurl"
 	"github.com/sirupsen/logrus"
 )
@@ -54,6 +55,15 @@ func Inspect(ctx context.Context, container containerd.Container) (*native.Conta
 		return n, nil
 	}
 	n.Process.Status = st
+	if st.Status == containerd.Stopped {
+		oomKilled, err := InspectOOMKilled(ctx, task)
+		if err != nil {
+			logrus.WithError(err).WithField("id", id).Debugf("failed to inspect OOMKilled")
+			// the cgroup may be already gone, so the value recorded on exit is used
+			oomKilled = info.Labels[labels.OOMKilled] == "true"
+		}
+		n.Process.OOMKilled = oomKilled
+	}
 	netNS, err := InspectNetNS(ctx, n.Process.Pid)
 	if err != nil {
 		logrus.WithError(err).WithField("id", id).Warnf("failed to inspect NetNS")
@@ -22,7 +22,11 @@ import (
 	"net"
 	"strings"
 
+	v1 "github.com/containerd/cgroups/stats/v1"
+	v2 "github.com/containerd/cgroups/v2/stats"
+	"github.com/containerd/containerd"
 	"github.com/containerd/nerdctl/pkg/inspecttypes/native"
+	"github.com/containerd/typeurl"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 )
@@ -73,3 +77,25 @@ func determinePrimaryInterface(interfaces []native.NetInterface) int {
 	}
 	return 0
 }
+
+// InspectOOMKilled returns whether a process in the task was killed by the OOM killer,
+// by reading the memory events of the cgroup.
+// The cgroup is still readable after the task exited, until the task is deleted.
+func InspectOOMKilled(ctx context.Context, task containerd.Task) (bool, error) {
+	metric, err := task.Metrics(ctx)
+	if err != nil {
+		return false, err
+	}
+	anydata, err := typeurl.UnmarshalAny(metric.Data)
+	if err != nil {
+		return false, err
+	}
+	switch v := anydata.(type) {
+	case *v1.Metrics:
+		return v.MemoryOomControl != nil && v.MemoryOomControl.OomKill > 0, nil
+	case *v2.Metrics:
+		return v.MemoryEvents != nil && v.MemoryEvents.OomKill > 0, nil
+	default:
+		return false, fmt.Errorf("unexpected metric type %T", anydata)
+	}
+}
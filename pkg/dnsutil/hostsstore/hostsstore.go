@@ -104,12 +104,19 @@ type Meta struct {
 	Hostname   string
 	ExtraHosts map[string]string // host:ip
 	Name       string
+	// Aliases is the network-scoped aliases (`nerdctl run --network-alias`), resolvable in the user-defined networks
+	Aliases []string `json:",omitempty"`
+	// NetworkAliases is the aliases of each network (`nerdctl run --network name=<NETWORK>,alias=<ALIAS>`)
+	NetworkAliases map[string][]string `json:",omitempty"`
 }
 
 type Store interface {
 	Acquire(Meta) error
 	Release(ns, id string) error
 	Update(ns, id, newName string) error
+	// List returns the metadata of the containers in the namespace.
+	// Only the containers with the network set up (i.e., running containers) are listed.
+	List(ns string) ([]Meta, error)
 }
 
 type store struct {
@@ -179,3 +186,33 @@ func (x *store) Update(ns, id, newName string) error {
 	}
 	return lockutil.WithDirLock(x.hostsD, fn)
 }
+
+func (x *store) List(ns string) ([]Meta, error) {
+	var res []Meta
+	fn := func() error {
+		dirs, err := os.ReadDir(filepath.Join(x.hostsD, ns))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		for _, d := range dirs {
+			metaB, err := os.ReadFile(filepath.Join(x.hostsD, ns, d.Name(), metaJSON))
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+			var meta Meta
+			if err := json.Unmarshal(metaB, &meta); err != nil {
+				return err
+			}
+			res = append(res, meta)
+		}
+		return nil
+	}
+	err := lockutil.WithDirLock(x.hostsD, fn)
+	return res, err
+}
@@ -0,0 +1,62 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hostsstore
+
+import (
+	"net"
+	"testing"
+
+	types100 "github.com/containernetworking/cni/pkg/types/100"
+	"gotest.tools/v3/assert"
+)
+
+func TestStoreList(t *testing.T) {
+	hs, err := NewStore(t.TempDir())
+	assert.NilError(t, err)
+
+	metas, err := hs.List("default")
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(metas))
+
+	meta := Meta{
+		Namespace: "default",
+		ID:        "foo",
+		Name:      "foo-name",
+		Networks: map[string]*types100.Result{
+			"n1": {
+				IPs: []*types100.IPConfig{
+					{Address: net.IPNet{IP: net.ParseIP("10.4.2.2").To4(), Mask: net.CIDRMask(24, 32)}},
+				},
+			},
+		},
+	}
+	assert.NilError(t, hs.Acquire(meta))
+	assert.NilError(t, hs.Acquire(Meta{Namespace: "other", ID: "bar"}))
+
+	metas, err = hs.List("default")
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(metas))
+	assert.Equal(t, "foo", metas[0].ID)
+	assert.Equal(t, "foo-name", metas[0].Name)
+	assert.Equal(t, "10.4.2.2/24", metas[0].Networks["n1"].IPs[0].Address.String())
+
+	// released containers are not listed
+	assert.NilError(t, hs.Release("default", "foo"))
+	metas, err = hs.List("default")
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(metas))
+}
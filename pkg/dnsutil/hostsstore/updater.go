@@ -173,8 +173,8 @@ func (u *updater) phase2() error {
 }
 
 // createLine returns a line string slice.
-// line is like "foo foo.nw0 bar bar.nw0\n"
-// for `nerdctl --name=foo --hostname=bar --network=n0`.
+// line is like "foo foo.nw0 bar bar.nw0 baz baz.nw0\n"
+// for `nerdctl --name=foo --hostname=bar --network-alias=baz --network=n0`.
 //
 // May return an empty string slice
 func createLine(thatNetwork string, meta *Meta, myNetworks map[string]struct{}) []string {
@@ -187,6 +187,11 @@ func createLine(thatNetwork string, meta *Meta, myNetworks map[string]struct{})
 	if meta.Name != "" {
 		baseHostnames = append(baseHostnames, meta.Name)
 	}
+	if thatNetwork != netutil.DefaultNetworkName {
+		// Like Docker, aliases are not resolvable in the default network
+		baseHostnames = append(baseHostnames, meta.Aliases...)
+		baseHostnames = append(baseHostnames, meta.NetworkAliases[thatNetwork]...)
+	}
 
 	for _, baseHostname := range baseHostnames {
 		line = append(line, baseHostname)
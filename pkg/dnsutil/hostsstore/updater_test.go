@@ -27,12 +27,14 @@ import (
 
 func TestCreateLine(t *testing.T) {
 	type testCase struct {
-		thatIP       string
-		thatNetwork  string
-		thatHostname string // nerdctl run --hostname
-		thatName     string // nerdctl run --name
-		myNetwork    string
-		expected     string
+		thatIP             string
+		thatNetwork        string
+		thatHostname       string              // nerdctl run --hostname
+		thatName           string              // nerdctl run --name
+		thatAliases        []string            // nerdctl run --network-alias
+		thatNetworkAliases map[string][]string // nerdctl run --network name=<NETWORK>,alias=<ALIAS>
+		myNetwork          string
+		expected           string
 	}
 	testCases := []testCase{
 		{
@@ -57,6 +59,31 @@ func TestCreateLine(t *testing.T) {
 			myNetwork:    "n1",
 			expected:     "",
 		},
+		{
+			thatIP:       "10.4.2.7",
+			thatNetwork:  "n1",
+			thatHostname: "bar",
+			thatName:     "foo",
+			thatAliases:  []string{"baz"},
+			myNetwork:    "n1",
+			expected:     "bar bar.n1 foo foo.n1 baz baz.n1",
+		},
+		{
+			thatIP:       "10.4.2.8",
+			thatNetwork:  "bridge",
+			thatHostname: "bar",
+			thatAliases:  []string{"baz"},
+			myNetwork:    "bridge",
+			expected:     "bar",
+		},
+		{
+			thatIP:             "10.4.2.9",
+			thatNetwork:        "n1",
+			thatHostname:       "bar",
+			thatNetworkAliases: map[string][]string{"n1": {"baz"}, "n2": {"qux"}},
+			myNetwork:          "n1",
+			expected:           "bar bar.n1 baz baz.n1",
+		},
 		{
 			thatIP:      "10.4.2.5",
 			thatNetwork: "n1",
@@ -90,8 +117,10 @@ func TestCreateLine(t *testing.T) {
 					},
 				},
 			},
-			Hostname: tc.thatHostname,
-			Name:     tc.thatName,
+			Hostname:       tc.thatHostname,
+			Name:           tc.thatName,
+			Aliases:        tc.thatAliases,
+			NetworkAliases: tc.thatNetworkAliases,
 		}
 
 		myNetworks := map[string]struct{}{
@@ -33,3 +33,10 @@ func IsErrConnectionRefused(err error) bool {
 	const errMessage = "connect: connection refused"
 	return strings.Contains(err.Error(), errMessage)
 }
+
+// IsErrDecryptionFailed returns whether err is an OCIcrypt error
+// "no suitable key unwrapper found or none of the private keys could be used for decryption"
+func IsErrDecryptionFailed(err error) bool {
+	const errMessage = "none of the private keys could be used for decryption"
+	return strings.Contains(err.Error(), errMessage)
+}
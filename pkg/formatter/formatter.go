@@ -50,6 +50,12 @@ func ContainerStatus(ctx context.Context, c containerd.Container) string {
 		// when it exits. So, the status will be "created" for this
 		// case.
 		if errdefs.IsNotFound(err) {
+			// the exit code is recorded when nerdctl observes the exit of the task
+			if l, err := c.Labels(ctx); err == nil {
+				if code, ok := l[labels.ExitCode]; ok {
+					return fmt.Sprintf("Exited (%s)", code)
+				}
+			}
 			return titleCaser.String(string(containerd.Created))
 		}
 		return titleCaser.String(string(containerd.Unknown))
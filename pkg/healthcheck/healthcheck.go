@@ -0,0 +1,265 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package healthcheck runs the healthcheck of containers (`nerdctl run --health-cmd`).
+//
+// As nerdctl does not have a daemon, the healthcheck is run by a nerdctl process
+// spawned by the Poststart hook of the container.
+// The process exits when the task of the container exits.
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/nerdctl/pkg/idgen"
+	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// StateFileName is the name of the file in the container state dir that holds dockercompat.Health.
+	StateFileName = "health.json"
+
+	// The defaults are the same as Docker.
+	DefaultInterval = 30 * time.Second
+	DefaultTimeout  = 30 * time.Second
+	DefaultRetries  = 3
+
+	// maxLogEntries is the number of the results kept in dockercompat.Health.
+	maxLogEntries = 5
+	// maxOutputLen is the maximum length of the output of a probe kept in dockercompat.HealthcheckResult.
+	maxOutputLen = 4096
+)
+
+// WithDefaults returns hc with the zero values replaced with the defaults.
+func WithDefaults(hc dockercompat.HealthConfig) dockercompat.HealthConfig {
+	if hc.Interval == 0 {
+		hc.Interval = DefaultInterval
+	}
+	if hc.Timeout == 0 {
+		hc.Timeout = DefaultTimeout
+	}
+	if hc.Retries == 0 {
+		hc.Retries = DefaultRetries
+	}
+	return hc
+}
+
+// ProbeArgs returns the args of the probe process for the Test of dockercompat.HealthConfig.
+//
+// {"CMD", args...} runs args directly, and {"CMD-SHELL", command} runs the command with `/bin/sh -c`.
+func ProbeArgs(test []string) ([]string, error) {
+	if len(test) == 0 {
+		return nil, errors.New("empty healthcheck test")
+	}
+	switch test[0] {
+	case "CMD":
+		if len(test) < 2 {
+			return nil, errors.New("no command in healthcheck test")
+		}
+		return test[1:], nil
+	case "CMD-SHELL":
+		if len(test) != 2 || test[1] == "" {
+			return nil, fmt.Errorf("invalid healthcheck test %v, expected {\"CMD-SHELL\", command}", test)
+		}
+		return []string{"/bin/sh", "-c", test[1]}, nil
+	case "NONE":
+		return nil, errors.New("healthcheck is disabled")
+	default:
+		return nil, fmt.Errorf("unknown healthcheck type %q", test[0])
+	}
+}
+
+// Monitor runs the probe every interval while the task with the pid is running,
+// and writes the health status to StateFileName in the stateDir.
+func Monitor(ctx context.Context, container containerd.Container, pid uint32, stateDir string, hc dockercompat.HealthConfig) error {
+	hc = WithDefaults(hc)
+	args, err := ProbeArgs(hc.Test)
+	if err != nil {
+		return err
+	}
+	statePath := filepath.Join(stateDir, StateFileName)
+	// like Docker, the status is reset on every start of the container
+	health := &dockercompat.Health{Status: dockercompat.Starting}
+	if err := writeHealth(statePath, health); err != nil {
+		return err
+	}
+	startedAt := time.Now()
+	ticker := time.NewTicker(hc.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		task, err := container.Task(ctx, nil)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if task.Pid() != pid {
+			// the container was restarted, and another monitor was spawned
+			return nil
+		}
+		status, err := task.Status(ctx)
+		if err != nil {
+			return err
+		}
+		switch status.Status {
+		case containerd.Running:
+		case containerd.Paused, containerd.Pausing:
+			continue
+		default:
+			return nil
+		}
+		result := probe(ctx, container, task, args, hc.Timeout)
+		updateHealth(health, result, hc.Retries, result.Start.Sub(startedAt) < hc.StartPeriod)
+		if err := writeHealth(statePath, health); err != nil {
+			return err
+		}
+	}
+}
+
+// probe runs args in the task, and returns the result.
+// Failures to run the process are recorded as the results with the exit code -1, as in Docker.
+func probe(ctx context.Context, container containerd.Container, task containerd.Task, args []string, timeout time.Duration) *dockercompat.HealthcheckResult {
+	result := &dockercompat.HealthcheckResult{Start: time.Now()}
+	exitCode, output, err := execProbe(ctx, container, task, args, timeout)
+	result.End = time.Now()
+	if err != nil {
+		logrus.WithError(err).Debug("failed to run the healthcheck probe")
+		exitCode, output = -1, err.Error()
+	}
+	result.ExitCode, result.Output = exitCode, output
+	return result
+}
+
+func execProbe(ctx context.Context, container containerd.Container, task containerd.Task, args []string, timeout time.Duration) (int, string, error) {
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	pspec := spec.Process
+	pspec.Terminal = false
+	pspec.Args = args
+
+	out := &limitedBuffer{limit: maxOutputLen}
+	ioCreator := cio.NewCreator(cio.WithStreams(nil, out, out))
+	process, err := task.Exec(ctx, "healthcheck-"+idgen.GenerateID(), pspec, ioCreator)
+	if err != nil {
+		return 0, "", err
+	}
+	defer process.Delete(ctx, containerd.WithProcessKill)
+	statusC, err := process.Wait(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	if err := process.Start(ctx); err != nil {
+		return 0, "", err
+	}
+	select {
+	case status := <-statusC:
+		code, _, err := status.Result()
+		if err != nil {
+			return 0, "", err
+		}
+		// Delete waits for the output to be copied
+		if _, err := process.Delete(ctx); err != nil {
+			return 0, "", err
+		}
+		return int(code), out.String(), nil
+	case <-time.After(timeout):
+		if err := process.Kill(ctx, syscall.SIGKILL); err != nil {
+			logrus.WithError(err).Debug("failed to kill the healthcheck probe")
+		}
+		return -1, fmt.Sprintf("Health check exceeded timeout (%v)", timeout), nil
+	}
+}
+
+// updateHealth updates the status with the result, in the same way as Docker.
+// The failures do not count during the start period, until the first success.
+func updateHealth(health *dockercompat.Health, result *dockercompat.HealthcheckResult, retries int, inStartPeriod bool) {
+	health.Log = append(health.Log, result)
+	if len(health.Log) > maxLogEntries {
+		health.Log = health.Log[len(health.Log)-maxLogEntries:]
+	}
+	if result.ExitCode == 0 {
+		health.FailingStreak = 0
+		health.Status = dockercompat.Healthy
+		return
+	}
+	if health.Status == dockercompat.Starting && inStartPeriod {
+		return
+	}
+	health.FailingStreak++
+	if health.FailingStreak >= retries {
+		health.Status = dockercompat.Unhealthy
+	}
+}
+
+// writeHealth writes the health status atomically, as it may be read by `nerdctl inspect` concurrently.
+func writeHealth(path string, health *dockercompat.Health) error {
+	b, err := json.Marshal(health)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// limitedBuffer keeps the first limit bytes written from the stdout and the stderr of the probe.
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   []byte
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if rest := b.limit - len(b.buf); rest > 0 {
+		if len(p) > rest {
+			b.buf = append(b.buf, p[:rest]...)
+		} else {
+			b.buf = append(b.buf, p...)
+		}
+	}
+	// the rest is discarded, but the write must not fail
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
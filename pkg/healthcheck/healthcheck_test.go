@@ -0,0 +1,115 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/inspecttypes/dockercompat"
+	"gotest.tools/v3/assert"
+)
+
+func TestProbeArgs(t *testing.T) {
+	testCases := []struct {
+		test     []string
+		expected []string
+		err      string
+	}{
+		{
+			test:     []string{"CMD", "curl", "-f", "http://localhost"},
+			expected: []string{"curl", "-f", "http://localhost"},
+		},
+		{
+			test:     []string{"CMD-SHELL", "curl -f http://localhost || exit 1"},
+			expected: []string{"/bin/sh", "-c", "curl -f http://localhost || exit 1"},
+		},
+		{
+			test: nil,
+			err:  "empty healthcheck test",
+		},
+		{
+			test: []string{"CMD"},
+			err:  "no command",
+		},
+		{
+			test: []string{"CMD-SHELL", "true", "false"},
+			err:  "invalid healthcheck test",
+		},
+		{
+			test: []string{"NONE"},
+			err:  "disabled",
+		},
+		{
+			test: []string{"FOO", "true"},
+			err:  "unknown healthcheck type",
+		},
+	}
+	for _, tc := range testCases {
+		got, err := ProbeArgs(tc.test)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, tc.expected, got)
+	}
+}
+
+func TestUpdateHealth(t *testing.T) {
+	ok := &dockercompat.HealthcheckResult{ExitCode: 0}
+	ng := &dockercompat.HealthcheckResult{ExitCode: 1}
+
+	// failures during the start period do not count
+	health := &dockercompat.Health{Status: dockercompat.Starting}
+	updateHealth(health, ng, 1, true)
+	assert.Equal(t, dockercompat.Starting, health.Status)
+	assert.Equal(t, 0, health.FailingStreak)
+
+	updateHealth(health, ok, 1, true)
+	assert.Equal(t, dockercompat.Healthy, health.Status)
+
+	// once healthy, failures count even during the start period
+	updateHealth(health, ng, 2, true)
+	assert.Equal(t, dockercompat.Healthy, health.Status)
+	assert.Equal(t, 1, health.FailingStreak)
+	updateHealth(health, ng, 2, false)
+	assert.Equal(t, dockercompat.Unhealthy, health.Status)
+	assert.Equal(t, 2, health.FailingStreak)
+
+	updateHealth(health, ok, 2, false)
+	assert.Equal(t, dockercompat.Healthy, health.Status)
+	assert.Equal(t, 0, health.FailingStreak)
+
+	// only the last results are kept
+	for i := 0; i < maxLogEntries+2; i++ {
+		updateHealth(health, &dockercompat.HealthcheckResult{ExitCode: i}, 100, false)
+	}
+	assert.Equal(t, maxLogEntries, len(health.Log))
+	assert.Equal(t, maxLogEntries+1, health.Log[maxLogEntries-1].ExitCode)
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{limit: 8}
+	n, err := b.Write([]byte("hello, "))
+	assert.NilError(t, err)
+	assert.Equal(t, 7, n)
+	n, err = b.Write([]byte(strings.Repeat("x", 10)))
+	assert.NilError(t, err)
+	assert.Equal(t, 10, n)
+	assert.Equal(t, "hello, x", b.String())
+}
@@ -28,7 +28,6 @@ import (
 	"time"
 
 	"github.com/containerd/containerd"
-	"github.com/containerd/containerd/cio"
 	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/diff"
 	"github.com/containerd/containerd/errdefs"
@@ -95,26 +94,14 @@ func Commit(ctx context.Context, client *containerd.Client, container containerd
 		return emptyDigest, err
 	}
 
-	task, err := container.Task(ctx, cio.Load)
-	if err != nil {
-		return emptyDigest, err
-	}
-
 	if opts.Pause {
-		status, err := task.Status(ctx)
+		pausedTask, err := pauseContainer(ctx, container)
 		if err != nil {
 			return emptyDigest, err
 		}
-
-		switch status.Status {
-		case containerd.Paused, containerd.Created, containerd.Stopped:
-		default:
-			if err := task.Pause(ctx); err != nil {
-				return emptyDigest, fmt.Errorf("failed to pause container: %w", err)
-			}
-
+		if pausedTask != nil {
 			defer func() {
-				if err := task.Resume(ctx); err != nil {
+				if err := pausedTask.Resume(ctx); err != nil {
 					logrus.Warnf("failed to unpause container %v: %v", id, err)
 				}
 			}()
@@ -145,7 +132,7 @@ func Commit(ctx context.Context, client *containerd.Client, container containerd
 	}
 
 	rootfsID := identity.ChainID(imageConfig.RootFS.DiffIDs).String()
-	if err := applyDiffLayer(ctx, rootfsID, baseImgConfig, sn, differ, diffLayerDesc); err != nil {
+	if err := ApplyDiffLayer(ctx, rootfsID, identity.ChainID(baseImgConfig.RootFS.DiffIDs).String(), sn, differ, diffLayerDesc); err != nil {
 		return emptyDigest, fmt.Errorf("failed to apply diff: %w", err)
 	}
 
@@ -310,8 +297,13 @@ func createDiff(ctx context.Context, name string, sn snapshots.Snapshotter, cs c
 	if err != nil {
 		return ocispec.Descriptor{}, digest.Digest(""), err
 	}
+	return LayerDescriptor(ctx, cs, newDesc, images.MediaTypeDockerSchema2LayerGzip)
+}
 
-	info, err := cs.Info(ctx, newDesc.Digest)
+// LayerDescriptor returns the descriptor of the layer created by the differ with the media type,
+// and the diffID (the digest of the uncompressed layer) of the layer.
+func LayerDescriptor(ctx context.Context, cs content.Store, desc ocispec.Descriptor, mediaType string) (ocispec.Descriptor, digest.Digest, error) {
+	info, err := cs.Info(ctx, desc.Digest)
 	if err != nil {
 		return ocispec.Descriptor{}, digest.Digest(""), err
 	}
@@ -327,18 +319,16 @@ func createDiff(ctx context.Context, name string, sn snapshots.Snapshotter, cs c
 	}
 
 	return ocispec.Descriptor{
-		MediaType: images.MediaTypeDockerSchema2LayerGzip,
-		Digest:    newDesc.Digest,
+		MediaType: mediaType,
+		Digest:    desc.Digest,
 		Size:      info.Size,
 	}, diffID, nil
 }
 
-// applyDiffLayer will apply diff layer content created by createDiff into the snapshotter.
-func applyDiffLayer(ctx context.Context, name string, baseImg ocispec.Image, sn snapshots.Snapshotter, differ diff.Applier, diffDesc ocispec.Descriptor) (retErr error) {
-	var (
-		key    = uniquePart() + "-" + name
-		parent = identity.ChainID(baseImg.RootFS.DiffIDs).String()
-	)
+// ApplyDiffLayer will apply diff layer content created by the differ into the snapshotter,
+// as the committed snapshot name on the parent snapshot (empty for no parent).
+func ApplyDiffLayer(ctx context.Context, name, parent string, sn snapshots.Snapshotter, differ diff.Applier, diffDesc ocispec.Descriptor) (retErr error) {
+	key := UniquePart() + "-" + name
 
 	mount, err := sn.Prepare(ctx, key, parent)
 	if err != nil {
@@ -368,11 +358,37 @@ func applyDiffLayer(ctx context.Context, name string, baseImg ocispec.Image, sn
 	return nil
 }
 
+// UniquePart returns a random string for the temporary snapshot keys.
+//
 // copied from github.com/containerd/containerd/rootfs/apply.go
-func uniquePart() string {
+func UniquePart() string {
 	t := time.Now()
 	var b [3]byte
 	// Ignore read failures, just decreases uniqueness
 	rand.Read(b[:])
 	return fmt.Sprintf("%d-%s", t.Nanosecond(), base64.URLEncoding.EncodeToString(b[:]))
 }
+
+// pauseContainer pauses the running task of the container, and returns the paused task.
+// The containers that are not running (i.e., created, stopped or already paused)
+// are left as-is, and nil is returned.
+func pauseContainer(ctx context.Context, container containerd.Container) (containerd.Task, error) {
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Status != containerd.Running {
+		return nil, nil
+	}
+	if err := task.Pause(ctx); err != nil {
+		return nil, fmt.Errorf("failed to pause container: %w", err)
+	}
+	return task, nil
+}
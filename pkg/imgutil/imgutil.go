@@ -19,13 +19,16 @@ package imgutil
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/content"
+	ctrderrdefs "github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/platforms"
 	refdocker "github.com/containerd/containerd/reference/docker"
@@ -40,6 +43,7 @@ import (
 	nyduslabel "github.com/containerd/nydus-snapshotter/pkg/label"
 	"github.com/containerd/stargz-snapshotter/fs/source"
 	"github.com/docker/docker/errdefs"
+	"github.com/opencontainers/image-spec/identity"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/sirupsen/logrus"
@@ -57,7 +61,9 @@ type EnsuredImage struct {
 type PullMode = string
 
 // GetExistingImage returns the specified image if exists in containerd. May return errdefs.NotFound() if not exists.
-func GetExistingImage(ctx context.Context, client *containerd.Client, snapshotter, rawRef string, platform ocispec.Platform) (*EnsuredImage, error) {
+//
+// When the image is not unpacked yet, it is unpacked using decryptPayload (may be nil).
+func GetExistingImage(ctx context.Context, client *containerd.Client, snapshotter, rawRef string, platform ocispec.Platform, decryptPayload *imgcrypt.Payload) (*EnsuredImage, error) {
 	var res *EnsuredImage
 	imagewalker := &imagewalker.ImageWalker{
 		Client: client,
@@ -66,21 +72,27 @@ func GetExistingImage(ctx context.Context, client *containerd.Client, snapshotte
 				return nil
 			}
 			image := containerd.NewImageWithPlatform(client, found.Image, platforms.OnlyStrict(platform))
-			imgConfig, err := getImageConfig(ctx, image)
+			img, err := readImage(ctx, image)
 			if err != nil {
 				// Image found but blob not found for foreign arch
 				// Ignore err and return nil, so that the walker can visit the next candidate.
 				return nil
 			}
+			// The platform matcher is not applied to an image that is not an index,
+			// so the platform of a single-platform image has to be checked here.
+			if !imageMatchesPlatform(*img, platform) {
+				logrus.Debugf("image %q (%s/%s) does not match the platform %q", found.Image.Name, img.OS, img.Architecture, platforms.Format(platform))
+				return nil
+			}
 			res = &EnsuredImage{
 				Ref:         found.Image.Name,
 				Image:       image,
-				ImageConfig: *imgConfig,
+				ImageConfig: img.Config,
 				Snapshotter: snapshotter,
 				Remote:      isStargz(snapshotter) || isOverlaybd(snapshotter),
 			}
 			if unpacked, err := image.IsUnpacked(ctx, snapshotter); err == nil && !unpacked {
-				if err := image.Unpack(ctx, snapshotter); err != nil {
+				if err := image.Unpack(ctx, snapshotter, decryptUnpackOpt(decryptPayload)); err != nil {
 					return err
 				}
 			}
@@ -104,8 +116,10 @@ func GetExistingImage(ctx context.Context, client *containerd.Client, snapshotte
 //
 // When insecure is set, skips verifying certs, and also falls back to HTTP when the registry does not speak HTTPS
 //
+// decryptPayload may contain the keys for unpacking encrypted images. Can be nil.
+//
 // FIXME: this func has too many args
-func EnsureImage(ctx context.Context, client *containerd.Client, stdout, stderr io.Writer, snapshotter, rawRef string, mode PullMode, insecure bool, hostsDirs []string, ocispecPlatforms []ocispec.Platform, unpack *bool, quiet bool) (*EnsuredImage, error) {
+func EnsureImage(ctx context.Context, client *containerd.Client, stdout, stderr io.Writer, snapshotter, rawRef string, mode PullMode, insecure bool, hostsDirs []string, ocispecPlatforms []ocispec.Platform, unpack *bool, quiet bool, decryptPayload *imgcrypt.Payload) (*EnsuredImage, error) {
 	switch mode {
 	case "always", "missing", "never":
 		// NOP
@@ -113,7 +127,7 @@ func EnsureImage(ctx context.Context, client *containerd.Client, stdout, stderr
 		return nil, fmt.Errorf("unexpected pull mode: %q", mode)
 	}
 	if mode != "always" && len(ocispecPlatforms) == 1 {
-		res, err := GetExistingImage(ctx, client, snapshotter, rawRef, ocispecPlatforms[0])
+		res, err := GetExistingImage(ctx, client, snapshotter, rawRef, ocispecPlatforms[0], decryptPayload)
 		if err == nil {
 			return res, nil
 		}
@@ -144,7 +158,7 @@ func EnsureImage(ctx context.Context, client *containerd.Client, stdout, stderr
 		return nil, err
 	}
 
-	img, err := PullImage(ctx, client, stdout, stderr, snapshotter, resolver, ref, ocispecPlatforms, unpack, quiet)
+	img, err := PullImage(ctx, client, stdout, stderr, snapshotter, resolver, ref, ocispecPlatforms, unpack, quiet, decryptPayload)
 	if err != nil {
 		// In some circumstance (e.g. people just use 80 port to support pure http), the error will contain message like "dial tcp <port>: connection refused".
 		if !errutil.IsErrHTTPResponseToHTTPSClient(err) && !errutil.IsErrConnectionRefused(err) {
@@ -157,7 +171,7 @@ func EnsureImage(ctx context.Context, client *containerd.Client, stdout, stderr
 			if err != nil {
 				return nil, err
 			}
-			return PullImage(ctx, client, stdout, stderr, snapshotter, resolver, ref, ocispecPlatforms, unpack, quiet)
+			return PullImage(ctx, client, stdout, stderr, snapshotter, resolver, ref, ocispecPlatforms, unpack, quiet, decryptPayload)
 		} else {
 			logrus.WithError(err).Errorf("server %q does not seem to support HTTPS", refDomain)
 			logrus.Info("Hint: you may want to try --insecure-registry to allow plain HTTP (if you are in a trusted network)")
@@ -167,6 +181,9 @@ func EnsureImage(ctx context.Context, client *containerd.Client, stdout, stderr
 	return img, nil
 }
 
+// ResolveDigest resolves the digest of rawRef in the registry.
+//
+// When insecure is set, skips verifying certs, and also falls back to HTTP when the registry does not speak HTTPS
 func ResolveDigest(ctx context.Context, rawRef string, insecure bool, hostsDirs []string) (string, error) {
 	named, err := refdocker.ParseDockerRef(rawRef)
 	if err != nil {
@@ -188,14 +205,25 @@ func ResolveDigest(ctx context.Context, rawRef string, insecure bool, hostsDirs
 
 	_, desc, err := resolver.Resolve(ctx, ref)
 	if err != nil {
-		return "", err
+		if !insecure || (!errutil.IsErrHTTPResponseToHTTPSClient(err) && !errutil.IsErrConnectionRefused(err)) {
+			return "", err
+		}
+		logrus.WithError(err).Warnf("server %q does not seem to support HTTPS, falling back to plain HTTP", refDomain)
+		dOpts = append(dOpts, dockerconfigresolver.WithPlainHTTP(true))
+		resolver, err = dockerconfigresolver.New(ctx, refDomain, dOpts...)
+		if err != nil {
+			return "", err
+		}
+		if _, desc, err = resolver.Resolve(ctx, ref); err != nil {
+			return "", err
+		}
 	}
 
 	return desc.Digest.String(), nil
 }
 
 // PullImage pulls an image using the specified resolver.
-func PullImage(ctx context.Context, client *containerd.Client, stdout, stderr io.Writer, snapshotter string, resolver remotes.Resolver, ref string, ocispecPlatforms []ocispec.Platform, unpack *bool, quiet bool) (*EnsuredImage, error) {
+func PullImage(ctx context.Context, client *containerd.Client, stdout, stderr io.Writer, snapshotter string, resolver remotes.Resolver, ref string, ocispecPlatforms []ocispec.Platform, unpack *bool, quiet bool, decryptPayload *imgcrypt.Payload) (*EnsuredImage, error) {
 	ctx, done, err := client.WithLease(ctx)
 	if err != nil {
 		return nil, err
@@ -225,11 +253,9 @@ func PullImage(ctx context.Context, client *containerd.Client, stdout, stderr io
 	var sgz, overlaybd, nydus bool
 	if unpackB {
 		logrus.Debugf("The image will be unpacked for platform %q, snapshotter %q.", ocispecPlatforms[0], snapshotter)
-		imgcryptPayload := imgcrypt.Payload{}
-		imgcryptUnpackOpt := encryption.WithUnpackConfigApplyOpts(encryption.WithDecryptedUnpack(&imgcryptPayload))
 		config.RemoteOpts = append(config.RemoteOpts,
 			containerd.WithPullUnpack,
-			containerd.WithUnpackOpts([]containerd.UnpackOpt{imgcryptUnpackOpt}))
+			containerd.WithUnpackOpts([]containerd.UnpackOpt{decryptUnpackOpt(decryptPayload)}))
 
 		sgz = isStargz(snapshotter)
 		if sgz {
@@ -282,6 +308,88 @@ func PullImage(ctx context.Context, client *containerd.Client, stdout, stderr io
 
 }
 
+// decryptUnpackOpt returns the UnpackOpt for decrypting encrypted layers with decryptPayload.
+// When decryptPayload is nil, only the keys configured in the daemon side are used.
+func decryptUnpackOpt(decryptPayload *imgcrypt.Payload) containerd.UnpackOpt {
+	if decryptPayload == nil {
+		decryptPayload = &imgcrypt.Payload{}
+	}
+	return encryption.WithUnpackConfigApplyOpts(encryption.WithDecryptedUnpack(decryptPayload))
+}
+
+// Unpack unpacks img into snapshotter, printing the status of each layer to w as soon as it is unpacked.
+// Layers that already exist in the snapshotter are not unpacked again.
+//
+// decryptPayload may contain the keys for unpacking encrypted images. Can be nil.
+func Unpack(ctx context.Context, client *containerd.Client, w io.Writer, img containerd.Image, snapshotter string, decryptPayload *imgcrypt.Payload) error {
+	ctx, done, err := client.WithLease(ctx)
+	if err != nil {
+		return err
+	}
+	defer done(ctx)
+
+	diffIDs, err := img.RootFS(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get diffIDs of %q (Hint: the image may not be available for the platform): %w", img.Name(), err)
+	}
+	chainIDs := identity.ChainIDs(diffIDs)
+	sn := client.SnapshotService(snapshotter)
+	// the layers before the first missing one are printed in the loop below
+	next := 0
+	printUnpacked := func() error {
+		for ; next < len(chainIDs); next++ {
+			if _, err := sn.Stat(ctx, chainIDs[next].String()); err != nil {
+				if errors.Is(err, ctrderrdefs.ErrNotFound) {
+					return nil
+				}
+				return err
+			}
+			fmt.Fprintf(w, "%s: unpacked\n", diffIDs[next])
+		}
+		return nil
+	}
+	for ; next < len(chainIDs); next++ {
+		if _, err := sn.Stat(ctx, chainIDs[next].String()); err != nil {
+			if errors.Is(err, ctrderrdefs.ErrNotFound) {
+				break
+			}
+			return err
+		}
+		fmt.Fprintf(w, "%s: already exists\n", diffIDs[next])
+	}
+
+	unpackCtx, cancel := context.WithCancel(ctx)
+	unpackDone := make(chan struct{})
+	var unpackErr error
+	go func() {
+		defer close(unpackDone)
+		unpackErr = img.Unpack(unpackCtx, snapshotter, decryptUnpackOpt(decryptPayload))
+	}()
+	// the unpacking must not outlive the lease
+	defer func() {
+		cancel()
+		<-unpackDone
+	}()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := printUnpacked(); err != nil {
+				return err
+			}
+		case <-unpackDone:
+			if err := unpackErr; err != nil {
+				if errors.Is(err, ctrderrdefs.ErrNotFound) {
+					return fmt.Errorf("failed to unpack %q (Hint: the image content may be incomplete, try `nerdctl pull` first): %w", img.Name(), err)
+				}
+				return err
+			}
+			return printUnpacked()
+		}
+	}
+}
+
 func isStargz(sn string) bool {
 	if !strings.Contains(sn, "stargz") {
 		return false
@@ -301,6 +409,28 @@ func isNydus(sn string) bool {
 }
 
 func getImageConfig(ctx context.Context, image containerd.Image) (*ocispec.ImageConfig, error) {
+	img, err := readImage(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	return &img.Config, nil
+}
+
+// imageMatchesPlatform returns true if the OS and the architecture in the image config match the platform.
+// Images that lack the OS and the architecture are considered to match any platform.
+func imageMatchesPlatform(img ocispec.Image, platform ocispec.Platform) bool {
+	if img.OS == "" && img.Architecture == "" {
+		return true
+	}
+	imgPlatform := ocispec.Platform{
+		OS:           img.OS,
+		Architecture: img.Architecture,
+		Variant:      img.Variant,
+	}
+	return platforms.OnlyStrict(platform).Match(platforms.Normalize(imgPlatform))
+}
+
+func readImage(ctx context.Context, image containerd.Image) (*ocispec.Image, error) {
 	desc, err := image.Config(ctx)
 	if err != nil {
 		return nil, err
@@ -316,7 +446,7 @@ func getImageConfig(ctx context.Context, image containerd.Image) (*ocispec.Image
 		if err := json.Unmarshal(b, &ocispecImage); err != nil {
 			return nil, err
 		}
-		return &ocispecImage.Config, nil
+		return &ocispecImage, nil
 	default:
 		return nil, fmt.Errorf("unknown media type %q", desc.MediaType)
 	}
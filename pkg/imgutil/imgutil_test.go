@@ -19,6 +19,7 @@ package imgutil
 import (
 	"testing"
 
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gotest.tools/v3/assert"
 )
 
@@ -57,3 +58,19 @@ func TestParseRepoTag(t *testing.T) {
 		assert.Equal(t, tc.tag, tag)
 	}
 }
+
+func TestImageMatchesPlatform(t *testing.T) {
+	linuxAMD64 := ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	linuxARM64 := ocispec.Platform{OS: "linux", Architecture: "arm64"}
+	linuxARMv7 := ocispec.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}
+
+	assert.Assert(t, imageMatchesPlatform(ocispec.Image{OS: "linux", Architecture: "amd64"}, linuxAMD64))
+	assert.Assert(t, !imageMatchesPlatform(ocispec.Image{OS: "linux", Architecture: "amd64"}, linuxARM64))
+	assert.Assert(t, imageMatchesPlatform(ocispec.Image{OS: "linux", Architecture: "arm64", Variant: "v8"}, linuxARM64))
+	assert.Assert(t, imageMatchesPlatform(ocispec.Image{OS: "linux", Architecture: "arm64"}, linuxARM64))
+	assert.Assert(t, imageMatchesPlatform(ocispec.Image{OS: "linux", Architecture: "arm", Variant: "v7"}, linuxARMv7))
+	assert.Assert(t, !imageMatchesPlatform(ocispec.Image{OS: "linux", Architecture: "arm", Variant: "v6"}, linuxARMv7))
+	assert.Assert(t, !imageMatchesPlatform(ocispec.Image{OS: "windows", Architecture: "amd64"}, linuxAMD64))
+	// images without the platform information match any platform
+	assert.Assert(t, imageMatchesPlatform(ocispec.Image{}, linuxARM64))
+}
@@ -0,0 +1,251 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package squash flattens the layers of an image, except the layers of the base image, into a single layer.
+package squash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/diff"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/leases"
+	"github.com/containerd/containerd/mount"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/nerdctl/pkg/imgutil"
+	"github.com/containerd/nerdctl/pkg/imgutil/commit"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/identity"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+type Opts struct {
+	// Ref is the name of the image, which is replaced with the squashed image.
+	Ref         string
+	Snapshotter string
+	Platform    platforms.MatchComparer
+	// Since is the time when the build started.
+	// The layers created before Since (the layers of the base image) are kept as they are.
+	// All the layers are squashed when Since is zero.
+	Since time.Time
+}
+
+// Squash replaces the image with the image that has the layers of the base image,
+// and a single layer containing the changes of the other layers.
+// The history entries of the squashed layers are preserved, but marked as empty layers,
+// and an entry for the squashed layer is appended.
+// Squash returns the new target descriptor of the image.
+func Squash(ctx context.Context, client *containerd.Client, opts *Opts) (ocispec.Descriptor, error) {
+	imgRecord, err := client.ImageService().Get(ctx, opts.Ref)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	img := containerd.NewImageWithPlatform(client, imgRecord, opts.Platform)
+
+	// Don't gc me and clean the dirty data after 1 hour!
+	ctx, done, err := client.WithLease(ctx, leases.WithRandomID(), leases.WithExpiration(1*time.Hour))
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to create lease for squash: %w", err)
+	}
+	defer done(ctx)
+
+	if err := img.Unpack(ctx, opts.Snapshotter); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	config, _, err := imgutil.ReadImageConfig(ctx, img)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	mfst, mfstDesc, err := imgutil.ReadManifest(ctx, img)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if mfst == nil {
+		return ocispec.Descriptor{}, fmt.Errorf("no manifest was found for image %q", opts.Ref)
+	}
+	base := baseLayers(config, opts.Since)
+	if len(config.RootFS.DiffIDs)-base <= 1 {
+		logrus.Debugf("image %q has already %d layer(s) on top of the base image, skipping squashing", opts.Ref, len(config.RootFS.DiffIDs)-base)
+		return imgRecord.Target, nil
+	}
+
+	layerMediaType := images.MediaTypeDockerSchema2LayerGzip
+	if mfstDesc.MediaType == ocispec.MediaTypeImageManifest {
+		layerMediaType = ocispec.MediaTypeImageLayerGzip
+	}
+	var (
+		cs     = client.ContentStore()
+		differ = client.DiffService()
+		sn     = client.SnapshotService(opts.Snapshotter)
+	)
+	var parent string
+	if base > 0 {
+		parent = identity.ChainID(config.RootFS.DiffIDs[:base]).String()
+	}
+	layerDesc, diffID, err := createSquashedLayer(ctx, sn, cs, differ, parent, identity.ChainID(config.RootFS.DiffIDs).String(), layerMediaType)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to create the squashed layer: %w", err)
+	}
+	newConfig := squashedConfig(config, base, diffID, time.Now())
+	chainID := identity.ChainID(newConfig.RootFS.DiffIDs).String()
+	if err := commit.ApplyDiffLayer(ctx, chainID, parent, sn, differ, layerDesc); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to apply the squashed layer: %w", err)
+	}
+
+	newConfigJSON, err := json.Marshal(newConfig)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	newConfigDesc := ocispec.Descriptor{
+		MediaType: mfst.Config.MediaType,
+		Digest:    digest.FromBytes(newConfigJSON),
+		Size:      int64(len(newConfigJSON)),
+	}
+	// config should reference to snapshotter
+	configLabels := map[string]string{
+		fmt.Sprintf("containerd.io/gc.ref.snapshot.%s", opts.Snapshotter): chainID,
+	}
+	if err := content.WriteBlob(ctx, cs, newConfigDesc.Digest.String(), bytes.NewReader(newConfigJSON), newConfigDesc, content.WithLabels(configLabels)); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	newMfst := struct {
+		MediaType string `json:"mediaType,omitempty"`
+		ocispec.Manifest
+	}{
+		MediaType: mfstDesc.MediaType,
+		Manifest:  *mfst,
+	}
+	newMfst.Config = newConfigDesc
+	newMfst.Layers = append(mfst.Layers[:base:base], layerDesc)
+	newMfstJSON, err := json.MarshalIndent(newMfst, "", "    ")
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	newMfstDesc := ocispec.Descriptor{
+		MediaType: mfstDesc.MediaType,
+		Digest:    digest.FromBytes(newMfstJSON),
+		Size:      int64(len(newMfstJSON)),
+	}
+	// new manifest should reference the layer and config content
+	mfstLabels := map[string]string{
+		"containerd.io/gc.ref.content.0": newConfigDesc.Digest.String(),
+	}
+	for i, l := range newMfst.Layers {
+		mfstLabels[fmt.Sprintf("containerd.io/gc.ref.content.%d", i+1)] = l.Digest.String()
+	}
+	if err := content.WriteBlob(ctx, cs, newMfstDesc.Digest.String(), bytes.NewReader(newMfstJSON), newMfstDesc, content.WithLabels(mfstLabels)); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	imgRecord.Target = newMfstDesc
+	if _, err := client.ImageService().Update(ctx, imgRecord, "target"); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return newMfstDesc, nil
+}
+
+// baseLayers returns the number of the leading layers whose history entries were created before since,
+// i.e., the layers of the base image.
+// BuildKit sets the creation time of the history entries of the build to the time of the export.
+func baseLayers(config ocispec.Image, since time.Time) int {
+	if since.IsZero() {
+		return 0
+	}
+	n := 0
+	for _, h := range config.History {
+		if h.EmptyLayer {
+			continue
+		}
+		if h.Created != nil && !h.Created.Before(since) {
+			break
+		}
+		n++
+	}
+	if n > len(config.RootFS.DiffIDs) {
+		// the history is inconsistent with the layers
+		return 0
+	}
+	return n
+}
+
+// squashedConfig returns the config of the image that has the first base layers of config, and the layer diffID.
+func squashedConfig(config ocispec.Image, base int, diffID digest.Digest, created time.Time) ocispec.Image {
+	diffIDs := make([]digest.Digest, base, base+1)
+	copy(diffIDs, config.RootFS.DiffIDs)
+	config.RootFS = ocispec.RootFS{
+		Type:    "layers",
+		DiffIDs: append(diffIDs, diffID),
+	}
+	history := make([]ocispec.History, len(config.History), len(config.History)+1)
+	copy(history, config.History)
+	layers, squashed := 0, 0
+	for i := range history {
+		if layers < base {
+			if !history[i].EmptyLayer {
+				layers++
+			}
+			continue
+		}
+		if !history[i].EmptyLayer {
+			squashed++
+		}
+		history[i].EmptyLayer = true
+	}
+	config.History = append(history, ocispec.History{
+		Created: &created,
+		Comment: fmt.Sprintf("squashed %d layers", squashed),
+	})
+	return config
+}
+
+// createSquashedLayer creates the layer that contains the changes from the snapshot parent to the snapshot chainID.
+// The layer contains the whole content of the snapshot chainID when parent is empty.
+func createSquashedLayer(ctx context.Context, sn snapshots.Snapshotter, cs content.Store, comparer diff.Comparer, parent, chainID, mediaType string) (ocispec.Descriptor, digest.Digest, error) {
+	key := commit.UniquePart() + "-squash"
+	var (
+		lower []mount.Mount
+		err   error
+	)
+	if parent == "" {
+		lower, err = sn.Prepare(ctx, key+"-lower", "")
+	} else {
+		lower, err = sn.View(ctx, key+"-lower", parent)
+	}
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	defer sn.Remove(ctx, key+"-lower")
+	upper, err := sn.View(ctx, key+"-upper", chainID)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	defer sn.Remove(ctx, key+"-upper")
+
+	desc, err := comparer.Compare(ctx, lower, upper, diff.WithMediaType(mediaType), diff.WithReference(key))
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	return commit.LayerDescriptor(ctx, cs, desc, mediaType)
+}
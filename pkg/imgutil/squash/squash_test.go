@@ -0,0 +1,113 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package squash
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestSquashedConfig(t *testing.T) {
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	config := ocispec.Image{
+		OS: "linux",
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{"sha256:aaa", "sha256:bbb", "sha256:ccc"},
+		},
+		History: []ocispec.History{
+			{CreatedBy: "ADD rootfs.tar /"},
+			{CreatedBy: "ENV FOO=bar", EmptyLayer: true},
+			{CreatedBy: "RUN foo"},
+			{CreatedBy: "RUN bar"},
+		},
+	}
+	got := squashedConfig(config, 0, "sha256:ddd", created)
+	assert.Equal(t, "linux", got.OS)
+	assert.DeepEqual(t, []digest.Digest{"sha256:ddd"}, got.RootFS.DiffIDs)
+	assert.Equal(t, 5, len(got.History))
+	for i, h := range got.History[:4] {
+		assert.Equal(t, config.History[i].CreatedBy, h.CreatedBy)
+		assert.Assert(t, h.EmptyLayer)
+	}
+	last := got.History[4]
+	assert.Assert(t, !last.EmptyLayer)
+	assert.Equal(t, "squashed 3 layers", last.Comment)
+	assert.Equal(t, created, *last.Created)
+
+	// the original config is not modified
+	assert.Equal(t, 3, len(config.RootFS.DiffIDs))
+	assert.Assert(t, !config.History[0].EmptyLayer)
+}
+
+func TestSquashedConfigWithBaseLayers(t *testing.T) {
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	config := ocispec.Image{
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{"sha256:aaa", "sha256:bbb", "sha256:ccc"},
+		},
+		History: []ocispec.History{
+			{CreatedBy: "ADD rootfs.tar /"},
+			{CreatedBy: "CMD [\"sh\"]", EmptyLayer: true},
+			{CreatedBy: "ENV FOO=bar", EmptyLayer: true},
+			{CreatedBy: "RUN foo"},
+			{CreatedBy: "RUN bar"},
+		},
+	}
+	got := squashedConfig(config, 1, "sha256:ddd", created)
+	assert.DeepEqual(t, []digest.Digest{"sha256:aaa", "sha256:ddd"}, got.RootFS.DiffIDs)
+	assert.Equal(t, 6, len(got.History))
+	assert.Assert(t, !got.History[0].EmptyLayer)
+	for _, h := range got.History[1:5] {
+		assert.Assert(t, h.EmptyLayer)
+	}
+	assert.Equal(t, "squashed 2 layers", got.History[5].Comment)
+
+	// the original config is not modified
+	assert.DeepEqual(t, []digest.Digest{"sha256:aaa", "sha256:bbb", "sha256:ccc"}, config.RootFS.DiffIDs)
+}
+
+func TestBaseLayers(t *testing.T) {
+	since := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+	before, after := since.Add(-time.Hour), since.Add(time.Second)
+	config := ocispec.Image{
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{"sha256:aaa", "sha256:bbb", "sha256:ccc", "sha256:ddd"},
+		},
+		History: []ocispec.History{
+			{Created: &before, CreatedBy: "ADD rootfs.tar /"},
+			{CreatedBy: "RUN foo"},
+			{Created: &before, CreatedBy: "CMD [\"sh\"]", EmptyLayer: true},
+			{Created: &after, CreatedBy: "ENV FOO=bar", EmptyLayer: true},
+			{Created: &after, CreatedBy: "RUN bar"},
+			{Created: &after, CreatedBy: "RUN baz"},
+		},
+	}
+	// the entries without the creation time are regarded as the base image
+	assert.Equal(t, 2, baseLayers(config, since))
+	// all the layers are squashed when since is zero
+	assert.Equal(t, 0, baseLayers(config, time.Time{}))
+	// inconsistent history
+	config.RootFS.DiffIDs = config.RootFS.DiffIDs[:1]
+	assert.Equal(t, 0, baseLayers(config, since))
+}
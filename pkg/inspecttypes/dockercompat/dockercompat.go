@@ -26,6 +26,7 @@ package dockercompat
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -41,7 +42,10 @@ import (
 	"github.com/containerd/nerdctl/pkg/imgutil"
 	"github.com/containerd/nerdctl/pkg/inspecttypes/native"
 	"github.com/containerd/nerdctl/pkg/labels"
+	"github.com/containerd/nerdctl/pkg/strutil"
+	types100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/docker/go-connections/nat"
+	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -109,8 +113,8 @@ type Container struct {
 	// TODO: SizeRw     *int64 `json:",omitempty"`
 	// TODO: SizeRootFs *int64 `json:",omitempty"`
 
-	Mounts []MountPoint
-	// TODO: Config          *container.Config
+	Mounts          []MountPoint
+	Config          *Config `json:",omitempty"` // Currently, only Healthcheck is set
 	NetworkSettings *NetworkSettings
 }
 
@@ -140,9 +144,9 @@ type Config struct {
 	// TODO: Tty          bool        // Attach standard streams to a tty, including stdin if it is not closed.
 	// TODO: OpenStdin    bool        // Open stdin
 	// TODO: StdinOnce    bool        // If true, close stdin after the 1 attached client disconnects.
-	Env []string // List of environment variable to set in the container
-	Cmd []string // Command to run when starting the container
-	// TODO Healthcheck     *HealthConfig       `json:",omitempty"` // Healthcheck describes how to check the container is healthy
+	Env         []string      // List of environment variable to set in the container
+	Cmd         []string      // Command to run when starting the container
+	Healthcheck *HealthConfig `json:",omitempty"` // Healthcheck describes how to check the container is healthy
 	// TODO: ArgsEscaped     bool                `json:",omitempty"` // True if command is already escaped (meaning treat as a command line) (Windows specific).
 	// TODO: Image           string              // Name of the image as it was passed by the operator (e.g. could be symbolic)
 	Volumes    map[string]struct{} // List of volumes (mounts) used for the container
@@ -157,20 +161,56 @@ type Config struct {
 	// TODO: Shell           []string            `json:",omitempty"` // Shell for shell-form of RUN, CMD, ENTRYPOINT
 }
 
+// HealthConfig is from https://github.com/moby/moby/blob/v20.10.1/api/types/container/config.go#L16-L34
+type HealthConfig struct {
+	// Test is the test to perform to check that the container is healthy.
+	// An empty slice means to inherit the default.
+	// {"NONE"} : disable healthcheck
+	Test []string `json:",omitempty"`
+
+	Interval    time.Duration `json:",omitempty"` // Interval is the time to wait between checks.
+	Timeout     time.Duration `json:",omitempty"` // Timeout is the time to wait before considering the check to have hung.
+	StartPeriod time.Duration `json:",omitempty"` // The start period for the container to initialize before the retries starts to count down.
+	Retries     int           `json:",omitempty"` // Retries is the number of consecutive failures needed to consider a container as unhealthy.
+}
+
 // ContainerState is from https://github.com/moby/moby/blob/v20.10.1/api/types/types.go#L313-L326
 type ContainerState struct {
 	Status     string // String representation of the container state. Can be one of "created", "running", "paused", "restarting", "removing", "exited", or "dead"
 	Running    bool
 	Paused     bool
 	Restarting bool
-	// TODO: OOMKilled  bool
+	OOMKilled  bool
 	// TODO:	Dead       bool
 	Pid      int
 	ExitCode int
 	// TODO: Error      string
 	// TODO: StartedAt  string
 	FinishedAt string
-	// TODO: Health     *Health `json:",omitempty"`
+	Health     *Health `json:",omitempty"`
+}
+
+// Health statuses, from https://github.com/moby/moby/blob/v20.10.1/api/types/types.go#L293-L298
+const (
+	NoHealthcheck = "none"      // Indicates there is no healthcheck
+	Starting      = "starting"  // Starting indicates that the container is not yet ready
+	Healthy       = "healthy"   // Healthy indicates that the container is running correctly
+	Unhealthy     = "unhealthy" // Unhealthy indicates that the container has a problem
+)
+
+// Health is from https://github.com/moby/moby/blob/v20.10.1/api/types/types.go#L300-L305
+type Health struct {
+	Status        string               // Status is one of Starting, Healthy or Unhealthy
+	FailingStreak int                  // FailingStreak is the number of consecutive failures
+	Log           []*HealthcheckResult // Log contains the last few results (oldest first)
+}
+
+// HealthcheckResult is from https://github.com/moby/moby/blob/v20.10.1/api/types/types.go#L307-L313
+type HealthcheckResult struct {
+	Start    time.Time // Start is the time this check started
+	End      time.Time // End is the time this check ended
+	ExitCode int       // ExitCode meanings: 0=healthy, 1=unhealthy, 2=reserved (considered unhealthy), else=error running probe
+	Output   string    // Output from last check
 }
 
 type NetworkSettings struct {
@@ -249,6 +289,14 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 		}
 	}
 
+	hc, err := healthConfigFromLabels(n.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if hc != nil {
+		c.Config = &Config{Healthcheck: hc}
+	}
+
 	if nerdctlMounts := n.Labels[labels.Mounts]; nerdctlMounts != "" {
 		mounts, err := parseMounts(nerdctlMounts)
 		if err != nil {
@@ -263,19 +311,86 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 			Running:    n.Process.Status.Status == containerd.Running,
 			Paused:     n.Process.Status.Status == containerd.Paused,
 			Restarting: n.Labels[restart.StatusLabel] == string(containerd.Running),
+			OOMKilled:  n.Process.OOMKilled,
 			Pid:        n.Process.Pid,
 			ExitCode:   int(n.Process.Status.ExitStatus),
 			FinishedAt: n.Process.Status.ExitTime.Format(time.RFC3339Nano),
 		}
+		health, err := HealthFromLabels(n.Labels, c.State.Running)
+		if err != nil {
+			return nil, err
+		}
+		c.State.Health = health
 		nSettings, err := networkSettingsFromNative(n.Process.NetNS, n.Spec.(*specs.Spec))
 		if err != nil {
 			return nil, err
 		}
 		c.NetworkSettings = nSettings
+	} else if exitCode, err := strconv.Atoi(n.Labels[labels.ExitCode]); err == nil {
+		// the task has been deleted after the exit was recorded
+		c.State = &ContainerState{
+			Status:    "exited",
+			OOMKilled: n.Labels[labels.OOMKilled] == "true",
+			ExitCode:  exitCode,
+		}
 	}
 	return c, nil
 }
 
+// HealthFromLabels returns the health of the container, using the healthcheck config in the labels
+// and the status written by the healthcheck monitor.
+// HealthFromLabels returns nil when the healthcheck is not configured, or disabled.
+func HealthFromLabels(containerLabels map[string]string, running bool) (*Health, error) {
+	hc, err := healthConfigFromLabels(containerLabels)
+	if err != nil {
+		return nil, err
+	}
+	if hc == nil || len(hc.Test) == 0 || hc.Test[0] == "NONE" {
+		return nil, nil
+	}
+	health, err := healthFromStateDir(containerLabels[labels.StateDir])
+	if err != nil {
+		return nil, err
+	}
+	if health == nil && running {
+		// the first probe has not been run yet
+		health = &Health{Status: Starting}
+	}
+	return health, nil
+}
+
+func healthConfigFromLabels(containerLabels map[string]string) (*HealthConfig, error) {
+	healthcheckJSON := containerLabels[labels.Healthcheck]
+	if healthcheckJSON == "" {
+		return nil, nil
+	}
+	var hc HealthConfig
+	if err := json.Unmarshal([]byte(healthcheckJSON), &hc); err != nil {
+		return nil, fmt.Errorf("failed to parse label %q: %w", labels.Healthcheck, err)
+	}
+	return &hc, nil
+}
+
+// healthFromStateDir reads the health status written by the healthcheck monitor.
+// healthFromStateDir returns nil when the healthcheck has never been run.
+func healthFromStateDir(nerdctlStateDir string) (*Health, error) {
+	if nerdctlStateDir == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(filepath.Join(nerdctlStateDir, "health.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var health Health
+	if err := json.Unmarshal(b, &health); err != nil {
+		return nil, fmt.Errorf("failed to parse the health status: %w", err)
+	}
+	return &health, nil
+}
+
 func ImageFromNative(n *native.Image) (*Image, error) {
 	i := &Image{}
 
@@ -289,8 +404,8 @@ func ImageFromNative(n *native.Image) (*Image, error) {
 	if len(imgoci.History) > 0 {
 		i.Comment = imgoci.History[len(imgoci.History)-1].Comment
 		i.Created = imgoci.History[len(imgoci.History)-1].Created.Format(time.RFC3339Nano)
-		i.Author = imgoci.History[len(imgoci.History)-1].Author
 	}
+	i.Author = imgoci.Author
 	i.Architecture = imgoci.Architecture
 	i.Os = imgoci.OS
 
@@ -312,13 +427,32 @@ func ImageFromNative(n *native.Image) (*Image, error) {
 
 	i.ID = n.ImageConfigDesc.Digest.String() // Docker ID (digest of platform-specific config), not containerd ID (digest of multi-platform index or manifest)
 
-	repository, tag := imgutil.ParseRepoTag(n.Image.Name)
-
-	i.RepoTags = []string{fmt.Sprintf("%s:%s", repository, tag)}
-	i.RepoDigests = []string{fmt.Sprintf("%s@%s", repository, n.Image.Target.Digest.String())}
+	i.RepoTags, i.RepoDigests = RepoTagsAndDigests([]string{n.Image.Name}, n.Image.Target.Digest)
 
 	return i, nil
 }
+
+// RepoTagsAndDigests returns RepoTags ("name:tag") and RepoDigests ("name@digest") for the names
+// of the containerd images that share the same target.
+// Digested names (e.g., "name@sha256:...") only appear in RepoDigests.
+func RepoTagsAndDigests(names []string, target digest.Digest) ([]string, []string) {
+	repoTags, repoDigests := []string{}, []string{}
+	for _, name := range names {
+		repository, tag := imgutil.ParseRepoTag(name)
+		if repository == "" {
+			continue
+		}
+		if tag != "" {
+			if repoTag := repository + ":" + tag; !strutil.InStringSlice(repoTags, repoTag) {
+				repoTags = append(repoTags, repoTag)
+			}
+		}
+		if repoDigest := repository + "@" + target.String(); !strutil.InStringSlice(repoDigests, repoDigest) {
+			repoDigests = append(repoDigests, repoDigest)
+		}
+	}
+	return repoTags, repoDigests
+}
 func statusFromNative(x containerd.Status, labels map[string]string) string {
 	switch s := x.Status; s {
 	case containerd.Stopped:
@@ -427,13 +561,23 @@ type IPAM struct {
 	Config []IPAMConfig `json:"Config,omitempty"`
 }
 
+// EndpointResource is from https://github.com/moby/moby/blob/v20.10.7/api/types/types.go#L461-L468
+type EndpointResource struct {
+	Name string `json:"Name"`
+	// EndpointID is omitted
+	MacAddress  string `json:"MacAddress"`
+	IPv4Address string `json:"IPv4Address"`
+	IPv6Address string `json:"IPv6Address"`
+}
+
 // Network mimics a `docker network inspect` object.
 // From https://github.com/moby/moby/blob/v20.10.7/api/types/types.go#L430-L448
 type Network struct {
-	Name   string            `json:"Name"`
-	ID     string            `json:"Id,omitempty"` // optional in nerdctl
-	IPAM   IPAM              `json:"IPAM,omitempty"`
-	Labels map[string]string `json:"Labels"`
+	Name       string                      `json:"Name"`
+	ID         string                      `json:"Id,omitempty"` // optional in nerdctl
+	IPAM       IPAM                        `json:"IPAM,omitempty"`
+	Containers map[string]EndpointResource `json:"Containers"`
+	Labels     map[string]string           `json:"Labels"`
 	// Scope, Driver, etc. are omitted
 }
 
@@ -470,9 +614,37 @@ func NetworkFromNative(n *native.Network) (*Network, error) {
 		res.Labels = *n.NerdctlLabels
 	}
 
+	res.Containers = make(map[string]EndpointResource, len(n.Containers))
+	for id, c := range n.Containers {
+		res.Containers[id] = endpointResourceFromCNIResult(c.Name, c.CNIResult)
+	}
+
 	return &res, nil
 }
 
+func endpointResourceFromCNIResult(name string, r *types100.Result) EndpointResource {
+	ep := EndpointResource{Name: name}
+	if r == nil {
+		return ep
+	}
+	for _, ipCfg := range r.IPs {
+		if ipCfg.Interface != nil && *ipCfg.Interface < len(r.Interfaces) {
+			// the interface in the container has the sandbox path
+			if intf := r.Interfaces[*ipCfg.Interface]; intf.Sandbox != "" && ep.MacAddress == "" {
+				ep.MacAddress = intf.Mac
+			}
+		}
+		if ipCfg.Address.IP.To4() != nil {
+			if ep.IPv4Address == "" {
+				ep.IPv4Address = ipCfg.Address.String()
+			}
+		} else if ep.IPv6Address == "" {
+			ep.IPv6Address = ipCfg.Address.String()
+		}
+	}
+	return ep
+}
+
 func parseMounts(nerdctlMounts string) ([]MountPoint, error) {
 	var mounts []MountPoint
 	err := json.Unmarshal([]byte(nerdctlMounts), &mounts)
@@ -483,7 +655,10 @@ func parseMounts(nerdctlMounts string) ([]MountPoint, error) {
 	for i := range mounts {
 		rw, propagation := parseMountProperties(mounts[i].Mode)
 		mounts[i].RW = rw
-		mounts[i].Propagation = propagation
+		// Propagation may be already recorded in the label, even when it is not specified in Mode
+		if propagation != "" {
+			mounts[i].Propagation = propagation
+		}
 	}
 
 	return mounts, nil
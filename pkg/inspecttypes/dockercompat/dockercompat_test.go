@@ -0,0 +1,54 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockercompat
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+)
+
+func TestRepoTagsAndDigests(t *testing.T) {
+	target := digest.Digest("sha256:4f4fb700ef54461cfa02571ae0db9a0dc1e0cdb5577484a6d75e68dc38e8acc1")
+	testCases := []struct {
+		names       []string
+		repoTags    []string
+		repoDigests []string
+	}{
+		{
+			names:       []string{"docker.io/library/alpine:latest"},
+			repoTags:    []string{"alpine:latest"},
+			repoDigests: []string{"alpine@" + target.String()},
+		},
+		{
+			names:       []string{"docker.io/library/alpine:latest", "docker.io/library/alpine:3.16", "example.com/foo/bar@" + target.String()},
+			repoTags:    []string{"alpine:latest", "alpine:3.16"},
+			repoDigests: []string{"alpine@" + target.String(), "example.com/foo/bar@" + target.String()},
+		},
+		{
+			names:       []string{"overlayfs@sha256:invalid"},
+			repoTags:    []string{},
+			repoDigests: []string{},
+		},
+	}
+	for _, tc := range testCases {
+		repoTags, repoDigests := RepoTagsAndDigests(tc.names, target)
+		assert.DeepEqual(t, tc.repoTags, repoTags)
+		assert.DeepEqual(t, tc.repoDigests, repoDigests)
+	}
+}
@@ -16,7 +16,11 @@
 
 package native
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	types100 "github.com/containernetworking/cni/pkg/types/100"
+)
 
 // Network corresponds to pkg/netutil.NetworkConfigList
 type Network struct {
@@ -24,4 +28,12 @@ type Network struct {
 	NerdctlID     *int               `json:"NerdctlID"`
 	NerdctlLabels *map[string]string `json:"NerdctlLabels,omitempty"`
 	File          string             `json:"File,omitempty"`
+	// Containers is the map of the IDs of the running containers attached to the network.
+	Containers map[string]NetworkContainer `json:"Containers,omitempty"`
+}
+
+// NetworkContainer is a container attached to the network.
+type NetworkContainer struct {
+	Name      string           `json:"Name,omitempty"`
+	CNIResult *types100.Result `json:"CNIResult,omitempty"`
 }
@@ -54,7 +54,7 @@ func EnsureImage(ctx context.Context, client *containerd.Client, ipfsClient ifac
 	}
 
 	if mode != "always" && len(ocispecPlatforms) == 1 {
-		res, err := imgutil.GetExistingImage(ctx, client, snapshotter, ref, ocispecPlatforms[0])
+		res, err := imgutil.GetExistingImage(ctx, client, snapshotter, ref, ocispecPlatforms[0], nil)
 		if err == nil {
 			return res, nil
 		}
@@ -72,7 +72,7 @@ func EnsureImage(ctx context.Context, client *containerd.Client, ipfsClient ifac
 	if err != nil {
 		return nil, err
 	}
-	return imgutil.PullImage(ctx, client, stdout, stderr, snapshotter, r, ref, ocispecPlatforms, unpack, quiet)
+	return imgutil.PullImage(ctx, client, stdout, stderr, snapshotter, r, ref, ocispecPlatforms, unpack, quiet, nil)
 }
 
 // Push pushes the specified image to IPFS.
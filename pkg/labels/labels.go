@@ -54,6 +54,13 @@ const (
 	// Currently, the length of the slice must be 1.
 	Networks = Prefix + "networks"
 
+	// NetworkAliases is a JSON-marshalled string of []string (`nerdctl run --network-alias`)
+	NetworkAliases = Prefix + "network-aliases"
+
+	// NetworkEndpoints is a JSON-marshalled string of map[string]netutil.EndpointOpts,
+	// the per-network IP address and aliases (`nerdctl run --network name=<NETWORK>,ip=<IP>,alias=<ALIAS>`)
+	NetworkEndpoints = Prefix + "network-endpoints"
+
 	// Ports is a JSON-marshalled string of []gocni.PortMapping .
 	Ports = Prefix + "ports"
 
@@ -84,10 +91,31 @@ const (
 
 	// StopTimeout is seconds to wait for stop a container.
 	StopTimout = Prefix + "stop-timeout"
+
+	// Healthcheck is a JSON-marshalled string of dockercompat.HealthConfig.
+	// {"Test":["NONE"]} means that the healthcheck is disabled.
+	Healthcheck = Prefix + "healthcheck"
+
+	// PruneProtect protects a stopped container from being removed by `nerdctl container prune`.
+	// Boolean value which can be parsed with strconv.ParseBool() is required.
+	// (like "nerdctl/prune=false")
+	PruneProtect = Prefix + "prune"
+
+	// AutoRemove is set to "true" for the containers created with `--rm`.
+	AutoRemove = Prefix + "auto-remove"
+
+	// ExitCode is the exit code of the last task of the container, recorded when nerdctl observes the exit.
+	// The label is used for `nerdctl ps --filter exited=<CODE>` after the task is deleted.
+	ExitCode = Prefix + "exit-code"
+
+	// OOMKilled is "true" if a process in the last task of the container was killed by the OOM killer,
+	// recorded along with ExitCode.
+	OOMKilled = Prefix + "oom-killed"
 )
 
 var ShellCompletions = []string{
 	Bypass4netns + "=true",
 	Bypass4netns + "=false",
+	PruneProtect + "=false",
 	// Other labels should not be set via CLI
 }
@@ -30,10 +30,13 @@ import (
 
 	"github.com/containerd/containerd/runtime/v2/logging"
 	"github.com/fluent/fluent-logger-golang/fluent"
+	"github.com/sirupsen/logrus"
 )
 
 type FluentdLogger struct {
 	Opts map[string]string
+	// Attrs is attached to every record
+	Attrs map[string]string
 }
 
 const (
@@ -71,7 +74,7 @@ func (f *FluentdLogger) Init(dataStore, ns, id string) error {
 	return nil
 }
 
-func (f *FluentdLogger) Process(_ string, config *logging.Config) error {
+func (f *FluentdLogger) Process(dataStore string, config *logging.Config) error {
 	if runtime.GOOS == "windows" {
 		// TODO: support fluentd on windows
 		return fmt.Errorf("logging to fluentd is not supported on windows")
@@ -80,30 +83,45 @@ func (f *FluentdLogger) Process(_ string, config *logging.Config) error {
 	if err != nil {
 		return err
 	}
+	idn := newIdentifier(dataStore, config)
+	tag, err := formatTag(f.Opts[Tag], idn)
+	if err != nil {
+		return err
+	}
 	fluentClient, err := fluent.New(fluentConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create fluent client: %w", err)
 	}
+	// Close flushes the records buffered in the async mode
+	defer fluentClient.Close()
 	var wg sync.WaitGroup
 	wg.Add(2)
-	fun := func(wg *sync.WaitGroup, reader io.Reader, id, namespace, source string) {
+	fun := func(wg *sync.WaitGroup, reader io.Reader, source string) {
 		defer wg.Done()
-		metaData := map[string]string{
-			"container_id": id,
-			"namespace":    namespace,
-			"source":       source,
+		metaData := make(map[string]string)
+		for k, v := range f.Attrs {
+			metaData[k] = v
+		}
+		metaData["container_id"] = idn.FullID
+		metaData["namespace"] = idn.Namespace
+		metaData["source"] = source
+		if idn.Name != "" {
+			metaData["container_name"] = idn.Name
 		}
 		scanner := bufio.NewScanner(reader)
 		for scanner.Scan() {
-			if scanner.Err() != nil {
-				return
-			}
 			metaData["log"] = scanner.Text()
-			fluentClient.PostWithTime(f.Opts[Tag], time.Now(), metaData)
+			// In the async mode, the record is buffered when the endpoint is unreachable
+			if err := fluentClient.PostWithTime(tag, time.Now(), metaData); err != nil {
+				logrus.WithError(err).Errorf("failed to send the log of container %q to fluentd", idn.FullID)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			logrus.WithError(err).Errorf("failed to read the %s of container %q", source, idn.FullID)
 		}
 	}
-	go fun(&wg, config.Stdout, config.ID, config.Namespace, "stdout")
-	go fun(&wg, config.Stderr, config.ID, config.Namespace, "stderr")
+	go fun(&wg, config.Stdout, "stdout")
+	go fun(&wg, config.Stderr, "stderr")
 
 	wg.Wait()
 	return nil
@@ -164,6 +182,9 @@ func ValidateFluentdLoggerOpts(config map[string]string) error {
 	for key := range config {
 		switch key {
 		case Tag:
+		case Labels:
+		case Env:
+		case EnvRegex:
 		case fluentdBufferLimit:
 		case fluentdMaxRetries:
 		case fluentdRetryWait:
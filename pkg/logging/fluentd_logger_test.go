@@ -66,6 +66,7 @@ func TestValidateFluentdLoggerOpts(t *testing.T) {
 		// TODO: Add test cases.
 		{name: "empty", args: args{config: map[string]string{}}, wantErr: false},
 		{name: "invalid", args: args{config: map[string]string{"foo": "bar"}}, wantErr: true},
+		{name: "attrs", args: args{config: map[string]string{"labels": "foo", "env": "BAR", "env-regex": "^BAZ"}}, wantErr: false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -243,3 +244,18 @@ func TestParseFluentdConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGetFluentdDriver(t *testing.T) {
+	if _, err := GetDriver("fluentd", map[string]string{"fluentd-address": "127.0.0.1:24224", "fluentd-async": "true"}); err != nil {
+		t.Errorf("GetDriver() error = %v", err)
+	}
+	for _, opts := range []map[string]string{
+		{"foo": "bar"},
+		{"fluentd-async": "foo"},
+		{"fluentd-address": "udp://127.0.0.1:24224"},
+	} {
+		if _, err := GetDriver("fluentd", opts); err == nil {
+			t.Errorf("GetDriver() with %v expected an error", opts)
+		}
+	}
+}
@@ -18,27 +18,21 @@ package logging
 
 import (
 	"bufio"
-	"bytes"
 	"errors"
 	"io"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
-	"text/template"
 
 	"github.com/containerd/containerd/runtime/v2/logging"
 	"github.com/coreos/go-systemd/v22/journal"
-	"github.com/docker/cli/templates"
 )
 
 type JournaldLogger struct {
 	Opts map[string]string
-}
-
-type identifier struct {
-	ID        string
-	FullID    string
-	Namespace string
+	// Attrs is attached to every entry as the journal fields
+	Attrs map[string]string
 }
 
 func (journaldLogger *JournaldLogger) Init(dataStore, ns, id string) error {
@@ -49,34 +43,24 @@ func (journaldLogger *JournaldLogger) Process(dataStore string, config *logging.
 	if !journal.Enabled() {
 		return errors.New("the local systemd journal is not available for logging")
 	}
-	shortID := config.ID[:12]
-	var syslogIdentifier string
-	if _, ok := journaldLogger.Opts[Tag]; !ok {
-		syslogIdentifier = shortID
-	} else {
-		var tmpl *template.Template
-		var err error
-		tmpl, err = templates.Parse(journaldLogger.Opts[Tag])
-		if err != nil {
-			return err
-		}
-
-		if tmpl != nil {
-			idn := identifier{
-				ID:        shortID,
-				FullID:    config.ID,
-				Namespace: config.Namespace,
-			}
-			var b bytes.Buffer
-			if err := tmpl.Execute(&b, idn); err != nil {
-				return err
-			}
-			syslogIdentifier = b.String()
+	idn := newIdentifier(dataStore, config)
+	syslogIdentifier, err := formatTag(journaldLogger.Opts[Tag], idn)
+	if err != nil {
+		return err
+	}
+	// construct log metadata for the container, with the same fields as Docker
+	vars := make(map[string]string)
+	for k, v := range journaldLogger.Attrs {
+		if name := journalFieldName(k); name != "" {
+			vars[name] = v
 		}
 	}
-	// construct log metadata for the container
-	vars := map[string]string{
-		"SYSLOG_IDENTIFIER": syslogIdentifier,
+	vars["SYSLOG_IDENTIFIER"] = syslogIdentifier
+	vars["CONTAINER_TAG"] = syslogIdentifier
+	vars["CONTAINER_ID"] = idn.ID
+	vars["CONTAINER_ID_FULL"] = idn.FullID
+	if idn.Name != "" {
+		vars["CONTAINER_NAME"] = idn.Name
 	}
 	var wg sync.WaitGroup
 	wg.Add(2)
@@ -98,6 +82,45 @@ func (journaldLogger *JournaldLogger) Process(dataStore string, config *logging.
 	return nil
 }
 
+// reservedJournalFields are the journal fields set by the logger itself (or by journal.Send),
+// which must not be overridden by the attributes.
+var reservedJournalFields = map[string]struct{}{
+	"MESSAGE":           {},
+	"MESSAGE_ID":        {},
+	"PRIORITY":          {},
+	"CODE_FILE":         {},
+	"CODE_LINE":         {},
+	"CODE_FUNC":         {},
+	"ERRNO":             {},
+	"SYSLOG_FACILITY":   {},
+	"SYSLOG_IDENTIFIER": {},
+	"SYSLOG_PID":        {},
+	"CONTAINER_TAG":     {},
+	"CONTAINER_ID":      {},
+	"CONTAINER_ID_FULL": {},
+	"CONTAINER_NAME":    {},
+}
+
+// journalFieldName converts the attribute key to a valid journal field name,
+// which consists of uppercase letters, digits, and underscores, and does not begin with an underscore or a digit.
+// An empty string is returned when the key cannot be used as a journal field.
+func journalFieldName(key string) string {
+	key = strings.ToUpper(strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key))
+	key = strings.TrimLeft(key, "_")
+	if key == "" || ('0' <= key[0] && key[0] <= '9') {
+		return ""
+	}
+	if _, ok := reservedJournalFields[key]; ok {
+		return ""
+	}
+	return key
+}
+
 func FetchLogs(journalctlArgs []string) error {
 	journalctl, err := exec.LookPath("journalctl")
 	if err != nil {
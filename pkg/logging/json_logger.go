@@ -31,6 +31,8 @@ import (
 
 type JSONLogger struct {
 	Opts map[string]string
+	// Attrs is attached to every entry, and printed by `nerdctl logs --details`
+	Attrs map[string]string
 }
 
 func (jsonLogger *JSONLogger) Init(dataStore, ns, id string) error {
@@ -80,5 +82,5 @@ func (jsonLogger *JSONLogger) Process(dataStore string, config *logging.Config)
 	}
 	// MaxBackups does not include file to write logs to
 	l.MaxBackups = maxFile - 1
-	return jsonfile.Encode(l, config.Stdout, config.Stderr)
+	return jsonfile.Encode(l, config.Stdout, config.Stderr, jsonLogger.Attrs)
 }
@@ -19,10 +19,10 @@ package jsonfile
 import (
 	"bufio"
 	"encoding/json"
-	"fmt"
 	"io"
+	"net/url"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -37,6 +37,8 @@ type Entry struct {
 	Log    string    `json:"log,omitempty"`    // line, including "\r\n"
 	Stream string    `json:"stream,omitempty"` // "stdout" or "stderr"
 	Time   time.Time `json:"time"`             // e.g. "2020-12-11T20:29:41.939902251Z"
+	// Attrs is the extra attributes, e.g. the labels and the env specified with `--log-opt labels=...`
+	Attrs map[string]string `json:"attrs,omitempty"`
 }
 
 func Path(dataStore, ns, id string) string {
@@ -44,7 +46,7 @@ func Path(dataStore, ns, id string) string {
 	return filepath.Join(dataStore, "containers", ns, id, id+"-json.log")
 }
 
-func Encode(w io.WriteCloser, stdout, stderr io.Reader) error {
+func Encode(w io.WriteCloser, stdout, stderr io.Reader, attrs map[string]string) error {
 	enc := json.NewEncoder(w)
 	var encMu sync.Mutex
 	var wg sync.WaitGroup
@@ -54,6 +56,7 @@ func Encode(w io.WriteCloser, stdout, stderr io.Reader) error {
 		br := bufio.NewReader(r)
 		e := &Entry{
 			Stream: name,
+			Attrs:  attrs,
 		}
 		for {
 			line, err := br.ReadString(byte('\n'))
@@ -78,9 +81,30 @@ func Encode(w io.WriteCloser, stdout, stderr io.Reader) error {
 	return nil
 }
 
-func Decode(stdout, stderr io.Writer, r io.Reader, timestamps bool, since string, until string, logsEOFChan chan<- struct{}) error {
+// ParseTimestamp parses the value of `--since` and `--until`, either a timestamp (e.g. "2013-01-02T13:23:37Z")
+// or a duration relative to now (e.g. "42m" for 42 minutes).
+// ParseTimestamp returns the zero time for an empty value.
+func ParseTimestamp(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	ts, err := timetypes.GetTimestamp(value, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, nsec, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// Decode decodes the entries and writes them to stdout and stderr.
+// When details is true, the extra attributes of each entry are printed before the log line, like Docker.
+// The entries older than since are skipped, and decoding stops at the first entry newer than until,
+// as the entries are in the chronological order. The zero time means no limit.
+func Decode(stdout, stderr io.Writer, r io.Reader, timestamps, details bool, since, until time.Time, logsEOFChan chan<- struct{}) error {
 	dec := json.NewDecoder(r)
-	now := time.Now()
 	for {
 		var e Entry
 		if err := dec.Decode(&e); err == io.EOF {
@@ -92,34 +116,11 @@ func Decode(stdout, stderr io.Writer, r io.Reader, timestamps bool, since string
 
 		output := []byte{}
 
-		if since != "" {
-			ts, err := timetypes.GetTimestamp(since, now)
-			if err != nil {
-				return fmt.Errorf("invalid value for \"since\": %w", err)
-			}
-			v := strings.Split(ts, ".")
-			i, err := strconv.ParseInt(v[0], 10, 64)
-			if err != nil {
-				return err
-			}
-			if !e.Time.After(time.Unix(i, 0)) {
-				continue
-			}
+		if !since.IsZero() && e.Time.Before(since) {
+			continue
 		}
-
-		if until != "" {
-			ts, err := timetypes.GetTimestamp(until, now)
-			if err != nil {
-				return fmt.Errorf("invalid value for \"until\": %w", err)
-			}
-			v := strings.Split(ts, ".")
-			i, err := strconv.ParseInt(v[0], 10, 64)
-			if err != nil {
-				return err
-			}
-			if !e.Time.Before(time.Unix(i, 0)) {
-				continue
-			}
+		if !until.IsZero() && e.Time.After(until) {
+			return nil
 		}
 
 		if timestamps {
@@ -127,6 +128,11 @@ func Decode(stdout, stderr io.Writer, r io.Reader, timestamps bool, since string
 			output = append(output, ' ')
 		}
 
+		if details {
+			output = append(output, []byte(formatAttrs(e.Attrs))...)
+			output = append(output, ' ')
+		}
+
 		output = append(output, []byte(e.Log)...)
 
 		switch e.Stream {
@@ -140,3 +146,17 @@ func Decode(stdout, stderr io.Writer, r io.Reader, timestamps bool, since string
 	}
 	return nil
 }
+
+// formatAttrs formats the attributes as "k1=v1,k2=v2", sorted by the keys, with the values escaped as the URL path segments (e.g., " " as "%20").
+func formatAttrs(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ss := make([]string, len(keys))
+	for i, k := range keys {
+		ss[i] = k + "=" + url.PathEscape(attrs[k])
+	}
+	return strings.Join(ss, ",")
+}
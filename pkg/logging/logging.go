@@ -17,16 +17,22 @@
 package logging
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/runtime/v2/logging"
+	"github.com/containerd/nerdctl/pkg/namestore"
+	"github.com/docker/cli/templates"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -35,6 +41,9 @@ const (
 	MaxSize    = "max-size"
 	MaxFile    = "max-file"
 	Tag        = "tag"
+	Labels     = "labels"
+	Env        = "env"
+	EnvRegex   = "env-regex"
 )
 
 type Driver interface {
@@ -75,10 +84,57 @@ func init() {
 		return &JournaldLogger{Opts: opts}, nil
 	})
 	RegisterDriver("fluentd", func(opts map[string]string) (Driver, error) {
+		// the opts are validated here, so that `nerdctl run` fails with invalid opts,
+		// rather than the logging process
+		if err := ValidateFluentdLoggerOpts(opts); err != nil {
+			return nil, err
+		}
+		if _, err := parseFluentdConfig(opts); err != nil {
+			return nil, err
+		}
 		return &FluentdLogger{Opts: opts}, nil
 	})
 }
 
+// identifier is the data for the `--log-opt tag=<TEMPLATE>` template.
+type identifier struct {
+	ID        string
+	FullID    string
+	Namespace string
+	Name      string
+}
+
+func newIdentifier(dataStore string, config *logging.Config) identifier {
+	idn := identifier{
+		ID:        config.ID[:12],
+		FullID:    config.ID,
+		Namespace: config.Namespace,
+	}
+	if namst, err := namestore.New(dataStore, config.Namespace); err != nil {
+		logrus.WithError(err).Warn("failed to open the name store")
+	} else if idn.Name, err = namst.Lookup(config.ID); err != nil {
+		logrus.WithError(err).Warnf("failed to look up the name of container %q", config.ID)
+	}
+	return idn
+}
+
+// formatTag executes the `--log-opt tag=<TEMPLATE>` template.
+// formatTag returns the short ID when the template is empty, like Docker.
+func formatTag(tag string, idn identifier) (string, error) {
+	if tag == "" {
+		return idn.ID, nil
+	}
+	tmpl, err := templates.Parse(tag)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, idn); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
 // Main is the entrypoint for the containerd runtime v2 logging plugin mode.
 //
 // Should be called only if argv1 == MagicArgv1.
@@ -95,6 +151,50 @@ func Main(argv2 string) error {
 type LogConfig struct {
 	Driver string            `json:"driver"`
 	Opts   map[string]string `json:"opts,omitempty"`
+	// Attrs is the extra attributes resolved from the "labels", "env", and "env-regex" opts.
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// ExtraAttributes returns the container labels and the environment variables
+// that are selected by the "labels", "env", and "env-regex" opts, in the same way as Docker.
+// env is a list of "KEY=VALUE" strings, the later ones take precedence.
+func ExtraAttributes(opts map[string]string, labels map[string]string, env []string) (map[string]string, error) {
+	attrs := make(map[string]string)
+	if s, ok := opts[Labels]; ok {
+		for _, k := range strings.Split(s, ",") {
+			if v, ok := labels[k]; ok {
+				attrs[k] = v
+			}
+		}
+	}
+	envMap := make(map[string]string)
+	for _, kv := range env {
+		if kv := strings.SplitN(kv, "=", 2); len(kv) == 2 {
+			envMap[kv[0]] = kv[1]
+		}
+	}
+	if s, ok := opts[Env]; ok {
+		for _, k := range strings.Split(s, ",") {
+			if v, ok := envMap[k]; ok {
+				attrs[k] = v
+			}
+		}
+	}
+	if s, ok := opts[EnvRegex]; ok {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log opt %q: %w", EnvRegex, err)
+		}
+		for k, v := range envMap {
+			if re.MatchString(k) {
+				attrs[k] = v
+			}
+		}
+	}
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+	return attrs, nil
 }
 
 // LogConfigFilePath returns the path of log-config.json
@@ -124,6 +224,14 @@ func getLoggerFunc(dataStore string) (logging.LoggerFunc, error) {
 			if err != nil {
 				return err
 			}
+			switch d := driver.(type) {
+			case *JSONLogger:
+				d.Attrs = logConfig.Attrs
+			case *JournaldLogger:
+				d.Attrs = logConfig.Attrs
+			case *FluentdLogger:
+				d.Attrs = logConfig.Attrs
+			}
 			if err := ready(); err != nil {
 				return err
 			}
@@ -0,0 +1,72 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logging
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExtraAttributes(t *testing.T) {
+	labels := map[string]string{"app": "web", "tier": "front"}
+	env := []string{"PATH=/bin", "OS=linux", "APP_VERSION=1", "OS=alpine", "NOVALUE"}
+	testCases := []struct {
+		opts     map[string]string
+		expected map[string]string
+	}{
+		{opts: map[string]string{MaxSize: "1m"}, expected: nil},
+		{opts: map[string]string{Labels: "app,missing"}, expected: map[string]string{"app": "web"}},
+		{opts: map[string]string{Env: "OS,NOVALUE"}, expected: map[string]string{"OS": "alpine"}},
+		{opts: map[string]string{EnvRegex: "^APP_"}, expected: map[string]string{"APP_VERSION": "1"}},
+		{
+			opts:     map[string]string{Labels: "tier", Env: "PATH", EnvRegex: "^O"},
+			expected: map[string]string{"tier": "front", "PATH": "/bin", "OS": "alpine"},
+		},
+	}
+	for _, tc := range testCases {
+		attrs, err := ExtraAttributes(tc.opts, labels, env)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, tc.expected, attrs)
+	}
+
+	_, err := ExtraAttributes(map[string]string{EnvRegex: "("}, labels, env)
+	assert.ErrorContains(t, err, EnvRegex)
+}
+
+func TestJournalFieldName(t *testing.T) {
+	assert.Equal(t, "FOO", journalFieldName("foo"))
+	assert.Equal(t, "COM_EXAMPLE_FOO_BAR", journalFieldName("com.example.foo-bar"))
+	assert.Equal(t, "FOO", journalFieldName("_foo"))
+	assert.Equal(t, "", journalFieldName("1foo"))
+	assert.Equal(t, "", journalFieldName("_"))
+	assert.Equal(t, "", journalFieldName("message"))
+	assert.Equal(t, "", journalFieldName("priority"))
+	assert.Equal(t, "", journalFieldName("container.name"))
+}
+
+func TestFormatTag(t *testing.T) {
+	idn := identifier{ID: "0123456789ab", FullID: "0123456789abcdef", Namespace: "default", Name: "foo"}
+	tag, err := formatTag("", idn)
+	assert.NilError(t, err)
+	assert.Equal(t, "0123456789ab", tag)
+	tag, err = formatTag("{{.Namespace}}.{{.Name}}.{{.FullID}}", idn)
+	assert.NilError(t, err)
+	assert.Equal(t, "default.foo.0123456789abcdef", tag)
+	_, err = formatTag("{{.Unknown}}", idn)
+	assert.ErrorContains(t, err, "Unknown")
+}
@@ -19,6 +19,7 @@ package mountutil
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -29,6 +30,7 @@ import (
 	"github.com/containerd/nerdctl/pkg/idgen"
 	"github.com/containerd/nerdctl/pkg/mountutil/volumestore"
 	"github.com/containerd/nerdctl/pkg/strutil"
+	"github.com/containerd/nerdctl/pkg/volumeplugin"
 	"github.com/opencontainers/runtime-spec/specs-go"
 
 	"github.com/sirupsen/logrus"
@@ -45,21 +47,43 @@ type Processed struct {
 	Mount           specs.Mount
 	Name            string // name
 	AnonymousVolume string // anonymous volume name
+	Driver          string // volume driver, set for volumes
 	Mode            string
 	Opts            []oci.SpecOpts
 }
 
-func ProcessFlagV(s string, volStore volumestore.VolumeStore) (*Processed, error) {
+// ProcessFlagV parses the value of `--volume`.
+// containerID is used for mounting volumes that are managed by volume driver plugins.
+// A missing bind source directory is created, as in Docker.
+// When expandEnv is true, the environment variables and the leading `~` in the paths are expanded (see expandPath).
+func ProcessFlagV(s string, volStore volumestore.VolumeStore, containerID string, expandEnv bool) (*Processed, error) {
+	return processFlagV(s, volStore, containerID, true, expandEnv)
+}
+
+// processFlagV parses the value of `--volume`, or the `--mount` value converted to the `--volume` form.
+// When createBindSource is false, a missing bind source results in an error.
+func processFlagV(s string, volStore volumestore.VolumeStore, containerID string, createBindSource, expandEnv bool) (_ *Processed, retErr error) {
 	var (
 		res      Processed
 		src, dst string
 		options  []string
 	)
 
+	expand := func(p string) (string, error) {
+		if !expandEnv {
+			return p, nil
+		}
+		return expandPath(p)
+	}
+
 	split := strings.Split(s, ":")
 	switch len(split) {
 	case 1:
-		dst = s
+		var err error
+		dst, err = expand(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", s, err)
+		}
 		res.AnonymousVolume = idgen.GenerateID()
 		logrus.Debugf("creating anonymous volume %q, for %q", res.AnonymousVolume, s)
 		anonVol, err := volStore.Create(res.AnonymousVolume, []string{})
@@ -68,9 +92,16 @@ func ProcessFlagV(s string, volStore volumestore.VolumeStore) (*Processed, error
 		}
 		src = anonVol.Mountpoint
 		res.Type = Volume
+		res.Driver = anonVol.Driver
 	case 2, 3:
 		res.Type = Bind
-		src, dst = split[0], split[1]
+		var err error
+		if src, err = expand(split[0]); err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", s, err)
+		}
+		if dst, err = expand(split[1]); err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", s, err)
+		}
 		if !strings.Contains(src, "/") {
 			// assume src is a volume name
 			res.Name = src
@@ -87,7 +118,21 @@ func ProcessFlagV(s string, volStore volumestore.VolumeStore) (*Processed, error
 			}
 			// src is now full path
 			src = vol.Mountpoint
+			if vol.Driver != volumeplugin.LocalDriver {
+				src, err = volStore.Mount(vol.Name, containerID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to mount volume %q with driver %q: %w", vol.Name, vol.Driver, err)
+				}
+				defer func() {
+					if retErr != nil {
+						if err := volStore.Unmount(vol.Name, containerID); err != nil {
+							logrus.WithError(err).Warnf("failed to unmount volume %q", vol.Name)
+						}
+					}
+				}()
+			}
 			res.Type = Volume
+			res.Driver = vol.Driver
 		}
 		if !filepath.IsAbs(src) {
 			logrus.Warnf("expected an absolute path, got a relative path %q (allowed for nerdctl, but disallowed for Docker, so unrecommended)", src)
@@ -100,6 +145,11 @@ func ProcessFlagV(s string, volStore volumestore.VolumeStore) (*Processed, error
 		if !filepath.IsAbs(dst) {
 			return nil, fmt.Errorf("expected an absolute path, got %q", dst)
 		}
+		if res.Type == Bind {
+			if err := ensureBindSource(src, createBindSource); err != nil {
+				return nil, err
+			}
+		}
 		rawOpts := ""
 		if len(split) == 3 {
 			rawOpts = split[2]
@@ -107,7 +157,6 @@ func ProcessFlagV(s string, volStore volumestore.VolumeStore) (*Processed, error
 		res.Mode = rawOpts
 
 		// always call parseVolumeOptions for bind mount to allow the parser to add some default options
-		var err error
 		var specOpts []oci.SpecOpts
 		options, specOpts, err = parseVolumeOptions(res.Type, src, rawOpts)
 		if err != nil {
@@ -151,3 +200,49 @@ func ProcessFlagV(s string, volStore volumestore.VolumeStore) (*Processed, error
 	}
 	return &res, nil
 }
+
+// ensureBindSource checks that the bind source exists, and creates it as a directory
+// when create is true.
+func ensureBindSource(src string, create bool) error {
+	if _, err := os.Stat(src); err == nil || !os.IsNotExist(err) {
+		return err
+	}
+	if !create {
+		return fmt.Errorf("bind source path does not exist: %s", src)
+	}
+	logrus.Debugf("creating the bind source directory %q", src)
+	if err := os.MkdirAll(src, 0755); err != nil {
+		return fmt.Errorf("failed to create the bind source directory %q: %w", src, err)
+	}
+	return nil
+}
+
+// expandPath expands the environment variables like `${VAR}` and the leading `~` (the home directory) in the path.
+// The expansion is done after splitting the flag value, so the expanded values may contain colons.
+//
+// An unset variable is an error rather than an empty string, so that `-v ${UNSET}/foo:/foo` does not mount `/foo`.
+// `$$` is expanded to a literal `$`. A `$` that is not followed by a variable name is kept as it is.
+func expandPath(p string) (string, error) {
+	var unset []string
+	p = os.Expand(p, func(k string) string {
+		if k == "$" {
+			return "$"
+		}
+		v, ok := os.LookupEnv(k)
+		if !ok {
+			unset = append(unset, k)
+		}
+		return v
+	})
+	if len(unset) > 0 {
+		return "", fmt.Errorf("environment variable %q is not set", unset[0])
+	}
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %q: %w", p, err)
+		}
+		p = home + p[1:]
+	}
+	return p, nil
+}
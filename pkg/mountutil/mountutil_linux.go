@@ -284,7 +284,7 @@ func ProcessFlagTmpfs(s string) (*Processed, error) {
 	return res, nil
 }
 
-func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, error) {
+func ProcessFlagMount(s string, volStore volumestore.VolumeStore, containerID string, expandEnv bool) (*Processed, error) {
 	fields := strings.Split(s, ",")
 	var (
 		mountType        string
@@ -292,6 +292,7 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 		dst              string
 		bindPropagation  string
 		bindNonRecursive bool
+		createSource     bool
 		rwOption         string
 		tmpfsSize        int64
 		tmpfsMode        os.FileMode
@@ -308,6 +309,7 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 	// --mount type=volume,src=vol-1,dst=/app,readonly
 	// if type not specified, default will be set to volume
 	// --mount src=`pwd`/tmp,target=/app
+	// unlike `--volume`, a missing bind source is not created unless create-source=true is specified
 
 	for _, field := range fields {
 		parts := strings.SplitN(field, "=", 2)
@@ -321,6 +323,9 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 			case "bind-nonrecursive":
 				bindNonRecursive = true
 				continue
+			case "create-source":
+				createSource = true
+				continue
 			}
 		}
 
@@ -361,6 +366,11 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 			if err != nil {
 				return nil, fmt.Errorf("invalid value for %s: %s", key, value)
 			}
+		case "create-source":
+			createSource, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %s", key, value)
+			}
 		case "tmpfs-size":
 			tmpfsSize, err = units.RAMInBytes(value)
 			if err != nil {
@@ -378,7 +388,7 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 	}
 
 	// compose new fileds and join into a string
-	// to call legacy ProcessFlagTmpfs or ProcessFlagV function
+	// to call legacy ProcessFlagTmpfs or processFlagV function
 	fields = []string{}
 	options := []string{}
 	if rwOption != "" {
@@ -423,7 +433,7 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 	case Tmpfs:
 		return ProcessFlagTmpfs(fieldsStr)
 	case Volume, Bind:
-		return ProcessFlagV(fieldsStr, volStore)
+		return processFlagV(fieldsStr, volStore, containerID, createSource, expandEnv)
 	}
 	return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs", mountType)
 }
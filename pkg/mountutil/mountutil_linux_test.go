@@ -18,6 +18,8 @@ package mountutil
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -206,3 +208,77 @@ func TestProcessTmpfs(t *testing.T) {
 		assert.DeepEqual(t, expected, x.Mount.Options)
 	}
 }
+
+func TestProcessBindSourceCreation(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// --volume creates the missing source
+	src := filepath.Join(tempDir, "volume", "src")
+	_, err := ProcessFlagV(src+":/dst", nil, "", false)
+	assert.NilError(t, err)
+	st, err := os.Stat(src)
+	assert.NilError(t, err)
+	assert.Assert(t, st.IsDir())
+
+	// --mount type=bind does not create the missing source by default
+	src = filepath.Join(tempDir, "mount", "src")
+	_, err = ProcessFlagMount("type=bind,src="+src+",dst=/dst", nil, "", false)
+	assert.ErrorContains(t, err, "bind source path does not exist")
+	_, err = os.Stat(src)
+	assert.Assert(t, os.IsNotExist(err))
+
+	_, err = ProcessFlagMount("type=bind,src="+src+",dst=/dst,create-source=true", nil, "", false)
+	assert.NilError(t, err)
+	st, err = os.Stat(src)
+	assert.NilError(t, err)
+	assert.Assert(t, st.IsDir())
+
+	// the existing source is used as is
+	_, err = ProcessFlagMount("type=bind,src="+src+",dst=/dst", nil, "", false)
+	assert.NilError(t, err)
+}
+
+func TestProcessFlagVExpandEnv(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("NERDCTL_TEST_MOUNT_SRC", tempDir)
+	t.Setenv("NERDCTL_TEST_MOUNT_DST", "/mnt/dst")
+	home, err := os.UserHomeDir()
+	assert.NilError(t, err)
+
+	x, err := ProcessFlagV("${NERDCTL_TEST_MOUNT_SRC}/src:$NERDCTL_TEST_MOUNT_DST:ro", nil, "", true)
+	assert.NilError(t, err)
+	assert.Equal(t, filepath.Join(tempDir, "src"), x.Mount.Source)
+	assert.Equal(t, "/mnt/dst", x.Mount.Destination)
+
+	x, err = ProcessFlagMount("type=bind,src=${NERDCTL_TEST_MOUNT_SRC},dst=${NERDCTL_TEST_MOUNT_DST}/sub", nil, "", true)
+	assert.NilError(t, err)
+	assert.Equal(t, tempDir, x.Mount.Source)
+	assert.Equal(t, "/mnt/dst/sub", x.Mount.Destination)
+
+	for _, tc := range []struct {
+		input    string
+		expected string
+	}{
+		{"~", home},
+		{"~/foo", filepath.Join(home, "foo")},
+		{"/foo/~", "/foo/~"},
+		{"/foo/$$NERDCTL_TEST_MOUNT_DST", "/foo/$NERDCTL_TEST_MOUNT_DST"},
+		{"/foo$/bar", "/foo$/bar"},
+		{"/foo/$", "/foo/$"},
+	} {
+		p, err := expandPath(tc.input)
+		assert.NilError(t, err, tc.input)
+		assert.Equal(t, tc.expected, p)
+	}
+
+	_, err = expandPath("/foo/${NERDCTL_TEST_UNSET_VAR}/bar")
+	assert.ErrorContains(t, err, `"NERDCTL_TEST_UNSET_VAR" is not set`)
+	_, err = ProcessFlagV("/foo/${NERDCTL_TEST_UNSET_VAR}:/bar", nil, "", true)
+	assert.ErrorContains(t, err, `"NERDCTL_TEST_UNSET_VAR" is not set`)
+
+	// the paths are taken literally unless expandEnv is true
+	x, err = ProcessFlagV(tempDir+"/$NERDCTL_TEST_UNSET_VAR:/mnt/$foo", nil, "", false)
+	assert.NilError(t, err)
+	assert.Equal(t, tempDir+"/$NERDCTL_TEST_UNSET_VAR", x.Mount.Source)
+	assert.Equal(t, "/mnt/$foo", x.Mount.Destination)
+}
@@ -65,6 +65,6 @@ func ProcessFlagTmpfs(s string) (*Processed, error) {
 	return nil, errdefs.ErrNotImplemented
 }
 
-func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, error) {
+func ProcessFlagMount(s string, volStore volumestore.VolumeStore, containerID string, expandEnv bool) (*Processed, error) {
 	return nil, errdefs.ErrNotImplemented
 }
@@ -21,12 +21,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/identifiers"
 	"github.com/containerd/nerdctl/pkg/inspecttypes/native"
 	"github.com/containerd/nerdctl/pkg/lockutil"
 	"github.com/containerd/nerdctl/pkg/strutil"
+	"github.com/containerd/nerdctl/pkg/volumeplugin"
+	"github.com/sirupsen/logrus"
 )
 
 // Path returns a string like `/var/lib/nerdctl/1935db59/volumes/default`.
@@ -61,10 +64,28 @@ const volumeJSONFileName = "volume.json"
 type VolumeStore interface {
 	Dir() string
 	Create(name string, labels []string) (*native.Volume, error)
+	// CreateWithDriver creates a volume with a volume driver plugin.
+	// The "local" driver (or an empty driver) is the same as Create.
+	CreateWithDriver(name, driver string, driverOpts map[string]string, labels []string) (*native.Volume, error)
 	// Get may return ErrNotFound
 	Get(name string) (*native.Volume, error)
 	List() (map[string]native.Volume, error)
-	Remove(names []string) (removedNames []string, err error)
+	// Remove removes the volumes, with the driver plugins for the volumes created with plugins.
+	// When force is true, the volumes are removed from the store even if their plugins are unavailable.
+	Remove(names []string, force bool) (removedNames []string, err error)
+	// Mount returns the host path of the volume, after mounting the volume with the driver plugin for the container id.
+	// The volume that is already mounted for the container id is not mounted again, unless the host has been rebooted.
+	Mount(name, id string) (string, error)
+	// Unmount unmounts the volume mounted for the container id.
+	// Unmount is a no-op for local volumes, and for the volumes that are not mounted.
+	Unmount(name, id string) error
+}
+
+// volumeOpts is the content of volume.json
+type volumeOpts struct {
+	Labels     map[string]string `json:"labels"`
+	Driver     string            `json:"driver,omitempty"`
+	DriverOpts map[string]string `json:"driverOpts,omitempty"`
 }
 
 type volumeStore struct {
@@ -78,27 +99,47 @@ func (vs *volumeStore) Dir() string {
 }
 
 func (vs *volumeStore) Create(name string, labels []string) (*native.Volume, error) {
+	return vs.CreateWithDriver(name, "", nil, labels)
+}
+
+func (vs *volumeStore) CreateWithDriver(name, driver string, driverOpts map[string]string, labels []string) (*native.Volume, error) {
 	if err := identifiers.Validate(name); err != nil {
 		return nil, fmt.Errorf("malformed name %s: %w", name, err)
 	}
+	if driver == volumeplugin.LocalDriver {
+		driver = ""
+	}
+	var plugin *volumeplugin.Plugin
+	if driver != "" {
+		var err error
+		plugin, err = volumeplugin.Get(driver)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(driverOpts) > 0 {
+		return nil, fmt.Errorf("driver options are not supported for the local driver: %w", errdefs.ErrInvalidArgument)
+	}
 	volPath := filepath.Join(vs.dir, name)
 	volDataPath := filepath.Join(volPath, DataDirName)
 	fn := func() error {
 		if err := os.Mkdir(volPath, 0700); err != nil {
 			return err
 		}
-		if err := os.Mkdir(volDataPath, 0755); err != nil {
+		if plugin != nil {
+			if err := plugin.Create(name, driverOpts); err != nil {
+				os.Remove(volPath)
+				return err
+			}
+		} else if err := os.Mkdir(volDataPath, 0755); err != nil {
 			return err
 		}
 
-		type volumeOpts struct {
-			Labels map[string]string `json:"labels"`
-		}
-
 		labelsMap := strutil.ConvertKVStringsToMap(labels)
 
 		volOpts := volumeOpts{
-			Labels: labelsMap,
+			Labels:     labelsMap,
+			Driver:     driver,
+			DriverOpts: driverOpts,
 		}
 
 		labelsJSON, err := json.MarshalIndent(volOpts, "", "    ")
@@ -119,8 +160,13 @@ func (vs *volumeStore) Create(name string, labels []string) (*native.Volume, err
 
 	vol := &native.Volume{
 		Name:       name,
+		Driver:     volumeplugin.LocalDriver,
 		Mountpoint: volDataPath,
 	}
+	if plugin != nil {
+		vol.Driver = driver
+		vol.Mountpoint = ""
+	}
 	return vol, nil
 }
 
@@ -128,14 +174,6 @@ func (vs *volumeStore) Get(name string) (*native.Volume, error) {
 	if err := identifiers.Validate(name); err != nil {
 		return nil, fmt.Errorf("malformed name %s: %w", name, err)
 	}
-	dataPath := filepath.Join(vs.dir, name, DataDirName)
-	if _, err := os.Stat(dataPath); err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("volume %q not found: %w", name, errdefs.ErrNotFound)
-		}
-		return nil, err
-	}
-
 	volFilePath := filepath.Join(vs.dir, name, volumeJSONFileName)
 	volumeDataBytes, err := os.ReadFile(volFilePath)
 	if err != nil {
@@ -146,14 +184,98 @@ func (vs *volumeStore) Get(name string) (*native.Volume, error) {
 		}
 	}
 
+	// the volume created with a driver plugin does not have the data dir
+	if driver := Driver(volumeDataBytes); driver != "" {
+		entry := native.Volume{
+			Name:   name,
+			Driver: driver,
+			Labels: Labels(volumeDataBytes),
+		}
+		return &entry, nil
+	}
+
+	dataPath := filepath.Join(vs.dir, name, DataDirName)
+	if _, err := os.Stat(dataPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("volume %q not found: %w", name, errdefs.ErrNotFound)
+		}
+		return nil, err
+	}
+
 	entry := native.Volume{
 		Name:       name,
+		Driver:     volumeplugin.LocalDriver,
 		Mountpoint: dataPath,
 		Labels:     Labels(volumeDataBytes),
 	}
 	return &entry, nil
 }
 
+func (vs *volumeStore) Mount(name, id string) (string, error) {
+	vol, err := vs.Get(name)
+	if err != nil {
+		return "", err
+	}
+	if vol.Driver == volumeplugin.LocalDriver {
+		return vol.Mountpoint, nil
+	}
+	var mountpoint string
+	fn := func() error {
+		recPath := vs.mountRecordPath(name, id)
+		// the volume is already mounted for the container, unless the host has been rebooted since then
+		if rec, err := readMountRecord(recPath); err == nil && rec.BootID == bootID() {
+			mountpoint = rec.Mountpoint
+			return nil
+		}
+		plugin, err := volumeplugin.Get(vol.Driver)
+		if err != nil {
+			return err
+		}
+		mountpoint, err = plugin.Mount(name, id)
+		if err != nil {
+			return err
+		}
+		return writeMountRecord(recPath, mountRecord{BootID: bootID(), Mountpoint: mountpoint})
+	}
+	if err := lockutil.WithDirLock(vs.dir, fn); err != nil {
+		return "", err
+	}
+	return mountpoint, nil
+}
+
+func (vs *volumeStore) Unmount(name, id string) error {
+	vol, err := vs.Get(name)
+	if err != nil {
+		return err
+	}
+	if vol.Driver == volumeplugin.LocalDriver {
+		return nil
+	}
+	fn := func() error {
+		recPath := vs.mountRecordPath(name, id)
+		rec, err := readMountRecord(recPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// not mounted
+				return nil
+			}
+			return err
+		}
+		// the mount does not survive the reboot
+		if rec.BootID == bootID() {
+			plugin, err := volumeplugin.Get(vol.Driver)
+			if err != nil {
+				return err
+			}
+			if err := plugin.Unmount(name, id); err != nil {
+				return err
+			}
+		}
+		return os.Remove(recPath)
+	}
+	return lockutil.WithDirLock(vs.dir, fn)
+}
+
 func (vs *volumeStore) List() (map[string]native.Volume, error) {
 	dEnts, err := os.ReadDir(vs.dir)
 	if err != nil {
@@ -172,7 +294,7 @@ func (vs *volumeStore) List() (map[string]native.Volume, error) {
 	return res, nil
 }
 
-func (vs *volumeStore) Remove(names []string) ([]string, error) {
+func (vs *volumeStore) Remove(names []string, force bool) ([]string, error) {
 	var removed []string
 	fn := func() error {
 		for _, name := range names {
@@ -180,6 +302,16 @@ func (vs *volumeStore) Remove(names []string) ([]string, error) {
 				return fmt.Errorf("malformed name %s: %w", name, err)
 			}
 			dir := filepath.Join(vs.dir, name)
+			if b, err := os.ReadFile(filepath.Join(dir, volumeJSONFileName)); err == nil {
+				if driver := Driver(b); driver != "" {
+					if err := removeWithPlugin(name, driver); err != nil {
+						if !force {
+							return fmt.Errorf("%w (the volume can be removed from nerdctl without the plugin with --force)", err)
+						}
+						logrus.WithError(err).Warnf("removing volume %q without the volume driver plugin %q", name, driver)
+					}
+				}
+			}
 			if err := os.RemoveAll(dir); err != nil {
 				return err
 			}
@@ -191,6 +323,17 @@ func (vs *volumeStore) Remove(names []string) ([]string, error) {
 	return removed, err
 }
 
+func removeWithPlugin(name, driver string) error {
+	plugin, err := volumeplugin.Get(driver)
+	if err != nil {
+		return fmt.Errorf("failed to remove volume %q: %w", name, err)
+	}
+	if err := plugin.Remove(name); err != nil {
+		return fmt.Errorf("failed to remove volume %q with volume driver plugin %q: %w", name, driver, err)
+	}
+	return nil
+}
+
 func Labels(b []byte) *map[string]string {
 	type volumeOpts struct {
 		Labels *map[string]string `json:"labels,omitempty"`
@@ -201,3 +344,60 @@ func Labels(b []byte) *map[string]string {
 	}
 	return vo.Labels
 }
+
+// Driver returns the volume driver plugin recorded in volume.json.
+// Driver returns an empty string for local volumes.
+func Driver(b []byte) string {
+	var vo volumeOpts
+	if err := json.Unmarshal(b, &vo); err != nil {
+		return ""
+	}
+	return vo.Driver
+}
+
+// mountsDirName is the directory in the volume dir that contains the mount records of a plugin volume
+const mountsDirName = "mounts"
+
+// mountRecord records that a plugin volume is mounted for a container,
+// so that the volume is mounted only once per container, and mounted again after reboot.
+type mountRecord struct {
+	BootID     string `json:"bootID"`
+	Mountpoint string `json:"mountpoint"`
+}
+
+func (vs *volumeStore) mountRecordPath(name, id string) string {
+	return filepath.Join(vs.dir, name, mountsDirName, id+".json")
+}
+
+func readMountRecord(p string) (*mountRecord, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var rec mountRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func writeMountRecord(p string, rec mountRecord) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0644)
+}
+
+// bootID returns the boot ID of the host, or an empty string when it is unknown (e.g., on non-Linux hosts).
+// The boot ID changes on every boot.
+func bootID() string {
+	b, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,161 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/containerd/nerdctl/pkg/volumeplugin"
+	"gotest.tools/v3/assert"
+)
+
+// mockPlugin is a volume driver plugin that records the calls
+type mockPlugin struct {
+	mu      sync.Mutex
+	volumes map[string]map[string]string // name -> opts
+	mounts  map[string]string            // id -> name
+	// mountCalls is the number of VolumeDriver.Mount calls
+	mountCalls int
+}
+
+func (mp *mockPlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	var req struct {
+		Name string
+		ID   string
+		Opts map[string]string
+	}
+	if r.URL.Path != "/Plugin.Activate" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	resp := map[string]interface{}{}
+	switch r.URL.Path {
+	case "/Plugin.Activate":
+		resp["Implements"] = []string{"VolumeDriver"}
+	case "/VolumeDriver.Create":
+		mp.volumes[req.Name] = req.Opts
+	case "/VolumeDriver.Remove":
+		delete(mp.volumes, req.Name)
+	case "/VolumeDriver.Mount":
+		if _, ok := mp.volumes[req.Name]; !ok {
+			resp["Err"] = "no such volume"
+			break
+		}
+		mp.mounts[req.ID] = req.Name
+		mp.mountCalls++
+		resp["Mountpoint"] = "/mnt/mock/" + req.Name
+	case "/VolumeDriver.Unmount":
+		delete(mp.mounts, req.ID)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestVolumeStoreWithDriverPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires unix sockets")
+	}
+	// unix socket paths are limited to 108 bytes, so t.TempDir() is not used here
+	pluginDir, err := os.MkdirTemp("", "nerdctl-plugins")
+	assert.NilError(t, err)
+	defer os.RemoveAll(pluginDir)
+	origSocketDirs, origSpecDirs := volumeplugin.SocketDirs, volumeplugin.SpecDirs
+	defer func() {
+		volumeplugin.SocketDirs, volumeplugin.SpecDirs = origSocketDirs, origSpecDirs
+	}()
+	volumeplugin.SocketDirs = []string{pluginDir}
+	volumeplugin.SpecDirs = []string{pluginDir}
+
+	l, err := net.Listen("unix", filepath.Join(pluginDir, "mock.sock"))
+	assert.NilError(t, err)
+	mp := &mockPlugin{volumes: make(map[string]map[string]string), mounts: make(map[string]string)}
+	srv := &http.Server{Handler: mp}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	vs, err := New(t.TempDir(), "default")
+	assert.NilError(t, err)
+
+	vol, err := vs.CreateWithDriver("foo", "mock", map[string]string{"size": "1G"}, []string{"key=val"})
+	assert.NilError(t, err)
+	assert.Equal(t, "mock", vol.Driver)
+	assert.DeepEqual(t, map[string]string{"size": "1G"}, mp.volumes["foo"])
+
+	vol, err = vs.Get("foo")
+	assert.NilError(t, err)
+	assert.Equal(t, "mock", vol.Driver)
+	assert.DeepEqual(t, map[string]string{"key": "val"}, *vol.Labels)
+
+	mountpoint, err := vs.Mount("foo", "container-1")
+	assert.NilError(t, err)
+	assert.Equal(t, "/mnt/mock/foo", mountpoint)
+	assert.Equal(t, "foo", mp.mounts["container-1"])
+	// the volume is mounted only once per container
+	mountpoint, err = vs.Mount("foo", "container-1")
+	assert.NilError(t, err)
+	assert.Equal(t, "/mnt/mock/foo", mountpoint)
+	assert.Equal(t, 1, mp.mountCalls)
+	assert.NilError(t, vs.Unmount("foo", "container-1"))
+	assert.Equal(t, 0, len(mp.mounts))
+	// the volume can be mounted again after unmounting
+	_, err = vs.Mount("foo", "container-1")
+	assert.NilError(t, err)
+	assert.Equal(t, 2, mp.mountCalls)
+	assert.NilError(t, vs.Unmount("foo", "container-1"))
+	// unmounting the volume that is not mounted is a no-op
+	assert.NilError(t, vs.Unmount("foo", "container-1"))
+
+	removed, err := vs.Remove([]string{"foo"}, false)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"foo"}, removed)
+	assert.Equal(t, 0, len(mp.volumes))
+
+	// unknown drivers are rejected
+	_, err = vs.CreateWithDriver("bar", "unknown", nil, nil)
+	assert.ErrorContains(t, err, "not found")
+
+	// no driver falls back to local
+	vol, err = vs.CreateWithDriver("baz", "", nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, volumeplugin.LocalDriver, vol.Driver)
+	mountpoint, err = vs.Mount("baz", "container-2")
+	assert.NilError(t, err)
+	assert.Equal(t, filepath.Join(vs.Dir(), "baz", DataDirName), mountpoint)
+	assert.NilError(t, vs.Unmount("baz", "container-2"))
+
+	// the volume cannot be removed without force while the plugin is unavailable
+	_, err = vs.CreateWithDriver("qux", "mock", nil, nil)
+	assert.NilError(t, err)
+	srv.Close()
+	_, err = vs.Remove([]string{"qux"}, false)
+	assert.ErrorContains(t, err, "--force")
+	removed, err = vs.Remove([]string{"qux"}, true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"qux"}, removed)
+}
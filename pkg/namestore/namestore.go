@@ -41,6 +41,8 @@ type NameStore interface {
 	Acquire(name, id string) error
 	Release(name, id string) error
 	Rename(oldName, id, newName string) error
+	// Lookup returns the name used by the ID, or an empty string when the ID has no name.
+	Lookup(id string) (string, error)
 }
 
 type nameStore struct {
@@ -96,7 +98,7 @@ func (x *nameStore) Rename(oldName, id, newName string) error {
 		return nil
 	}
 	if err := identifiers.Validate(newName); err != nil {
-		return fmt.Errorf("invalid name %q: %w", oldName, err)
+		return fmt.Errorf("invalid name %q: %w", newName, err)
 	}
 	fn := func() error {
 		oldFileName := filepath.Join(x.dir, oldName)
@@ -115,3 +117,29 @@ func (x *nameStore) Rename(oldName, id, newName string) error {
 	}
 	return lockutil.WithDirLock(x.dir, fn)
 }
+
+func (x *nameStore) Lookup(id string) (string, error) {
+	var name string
+	fn := func() error {
+		entries, err := os.ReadDir(x.dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			b, err := os.ReadFile(filepath.Join(x.dir, e.Name()))
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(string(b)) == id {
+				name = e.Name()
+				return nil
+			}
+		}
+		return nil
+	}
+	err := lockutil.WithDirLock(x.dir, fn)
+	return name, err
+}
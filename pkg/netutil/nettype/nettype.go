@@ -16,7 +16,10 @@
 
 package nettype
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Type int
 
@@ -25,13 +28,19 @@ const (
 	None
 	Host
 	CNI
+	Container
 )
 
+// ContainerPrefix is the prefix of the network name like "container:<ID>",
+// to share the network namespace of another container.
+const ContainerPrefix = "container:"
+
 var netTypeToName = map[interface{}]string{
-	Invalid: "invalid",
-	None:    "none",
-	Host:    "host",
-	CNI:     "cni",
+	Invalid:   "invalid",
+	None:      "none",
+	Host:      "host",
+	CNI:       "cni",
+	Container: "container",
 }
 
 func Detect(names []string) (Type, error) {
@@ -45,6 +54,13 @@ func Detect(names []string) (Type, error) {
 		case "host":
 			tmp = Host
 		default:
+			if strings.HasPrefix(name, ContainerPrefix) {
+				if len(names) > 1 {
+					return Invalid, fmt.Errorf("container network %q cannot be combined with other networks", name)
+				}
+				tmp = Container
+				break
+			}
 			tmp = CNI
 		}
 		if res != Invalid && res != tmp {
@@ -53,6 +53,18 @@ func TestDetect(t *testing.T) {
 			names:    []string{"foo", "bar", "bridge"},
 			expected: CNI,
 		},
+		{
+			names:    []string{"container:foo"},
+			expected: Container,
+		},
+		{
+			names: []string{"container:foo", "bar"},
+			err:   "cannot be combined",
+		},
+		{
+			names: []string{"container:foo", "container:bar"},
+			err:   "cannot be combined",
+		},
 		{
 			names: []string{"none", "host"},
 			err:   "mixed network types",
@@ -18,6 +18,7 @@ package netutil
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -216,6 +217,46 @@ func nerdctlLabels(b []byte) *map[string]string {
 	return ncl.NerdctlLabels
 }
 
+// EndpointOpts is the per-network options of `nerdctl run --network name=<NETWORK>,ip=<IP>,alias=<ALIAS>`.
+type EndpointOpts struct {
+	IP      string   `json:",omitempty"`
+	Aliases []string `json:",omitempty"`
+}
+
+// WithStaticIP returns the network config list with the IP address requested to the IPAM plugin
+// as `args.cni.ips` (supported by host-local), so that the address applies only to this network,
+// unlike CNI_ARGS that apply to all the networks of the container.
+func WithStaticIP(confList []byte, ip string) ([]byte, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(confList, &m); err != nil {
+		return nil, err
+	}
+	plugins, ok := m["plugins"].([]interface{})
+	if !ok {
+		return nil, errors.New("invalid network config: no plugins")
+	}
+	found := false
+	for _, p := range plugins {
+		plugin, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := plugin["ipam"]; !ok {
+			continue
+		}
+		plugin["args"] = map[string]interface{}{
+			"cni": map[string]interface{}{
+				"ips": []string{ip},
+			},
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("cannot assign IP address %s: the network config has no IPAM plugin", ip)
+	}
+	return json.Marshal(m)
+}
+
 func GetBridgeName(id int) string {
 	return fmt.Sprintf("nerdctl%d", id)
 }
@@ -17,6 +17,7 @@
 package netutil
 
 import (
+	"encoding/json"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -105,3 +106,20 @@ func TestParseIPAMRange(t *testing.T) {
 		}
 	}
 }
+
+func TestWithStaticIP(t *testing.T) {
+	t.Parallel()
+	confList := []byte(`{"cniVersion":"0.4.0","name":"foo","plugins":[{"type":"bridge","ipam":{"type":"host-local"}},{"type":"portmap"}]}`)
+	b, err := WithStaticIP(confList, "10.4.1.10")
+	assert.NilError(t, err)
+	var m struct {
+		Plugins []map[string]interface{} `json:"plugins"`
+	}
+	assert.NilError(t, json.Unmarshal(b, &m))
+	assert.DeepEqual(t, map[string]interface{}{"cni": map[string]interface{}{"ips": []interface{}{"10.4.1.10"}}}, m.Plugins[0]["args"])
+	_, ok := m.Plugins[1]["args"]
+	assert.Assert(t, !ok)
+
+	_, err = WithStaticIP([]byte(`{"cniVersion":"0.4.0","name":"foo","plugins":[{"type":"portmap"}]}`), "10.4.1.10")
+	assert.ErrorContains(t, err, "no IPAM plugin")
+}
@@ -105,6 +105,19 @@ func newHandlerOpts(state *specs.State, dataStore, cniPath, cniNetconfPath strin
 	}
 	o.extraHosts = extraHosts
 
+	if networkAliasesJSON, ok := state.Annotations[labels.NetworkAliases]; ok {
+		if err := json.Unmarshal([]byte(networkAliasesJSON), &o.networkAliases); err != nil {
+			return nil, err
+		}
+	}
+
+	var endpoints map[string]netutil.EndpointOpts
+	if endpointsJSON, ok := state.Annotations[labels.NetworkEndpoints]; ok {
+		if err := json.Unmarshal([]byte(endpointsJSON), &endpoints); err != nil {
+			return nil, err
+		}
+	}
+
 	hs, err := loadSpec(o.state.Bundle)
 	if err != nil {
 		return nil, err
@@ -135,7 +148,7 @@ func newHandlerOpts(state *specs.State, dataStore, cniPath, cniNetconfPath strin
 	}
 
 	switch netType {
-	case nettype.Host, nettype.None:
+	case nettype.Host, nettype.None, nettype.Container:
 		// NOP
 	case nettype.CNI:
 		e, err := netutil.NewCNIEnv(cniPath, cniNetconfPath)
@@ -151,8 +164,24 @@ func newHandlerOpts(state *specs.State, dataStore, cniPath, cniNetconfPath strin
 			if !ok {
 				return nil, fmt.Errorf("no such network: %q", netstr)
 			}
-			cniOpts = append(cniOpts, gocni.WithConfListBytes(net.Bytes))
+			confList := net.Bytes
+			if ep := endpoints[netstr]; ep.IP != "" {
+				if rootlessutil.IsRootlessChild() {
+					return nil, fmt.Errorf("containerIP assignment is not supported in rootless mode")
+				}
+				confList, err = netutil.WithStaticIP(confList, ep.IP)
+				if err != nil {
+					return nil, err
+				}
+			}
+			cniOpts = append(cniOpts, gocni.WithConfListBytes(confList))
 			o.cniNames = append(o.cniNames, netstr)
+			if ep := endpoints[netstr]; len(ep.Aliases) > 0 {
+				if o.endpointAliases == nil {
+					o.endpointAliases = make(map[string][]string)
+				}
+				o.endpointAliases[netstr] = ep.Aliases
+			}
 		}
 		o.cni, err = gocni.New(cniOpts...)
 		if err != nil {
@@ -212,6 +241,8 @@ type handlerOpts struct {
 	rootlessKitClient rlkclient.Client
 	bypassClient      b4nndclient.Client
 	extraHosts        map[string]string // host:ip
+	networkAliases    []string
+	endpointAliases   map[string][]string
 	containerIP       string
 }
 
@@ -365,12 +396,14 @@ func onCreateRuntime(opts *handlerOpts) error {
 		namespaceOpts = append(namespaceOpts, portMapOpts...)
 		namespaceOpts = append(namespaceOpts, ipAddressOpts...)
 		hsMeta := hostsstore.Meta{
-			Namespace:  opts.state.Annotations[labels.Namespace],
-			ID:         opts.state.ID,
-			Networks:   make(map[string]*types100.Result, len(opts.cniNames)),
-			Hostname:   opts.state.Annotations[labels.Hostname],
-			ExtraHosts: opts.extraHosts,
-			Name:       opts.state.Annotations[labels.Name],
+			Namespace:      opts.state.Annotations[labels.Namespace],
+			ID:             opts.state.ID,
+			Networks:       make(map[string]*types100.Result, len(opts.cniNames)),
+			Hostname:       opts.state.Annotations[labels.Hostname],
+			ExtraHosts:     opts.extraHosts,
+			Name:           opts.state.Annotations[labels.Name],
+			Aliases:        opts.networkAliases,
+			NetworkAliases: opts.endpointAliases,
 		}
 		cniRes, err := opts.cni.Setup(ctx, opts.fullID, nsPath, namespaceOpts...)
 		if err != nil {
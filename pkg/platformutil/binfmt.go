@@ -19,6 +19,7 @@ package platformutil
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 
 	"github.com/containerd/containerd/platforms"
@@ -40,6 +41,22 @@ func qemuArchFromOCIArch(ociArch string) (string, error) {
 	return "", fmt.Errorf("unknown OCI architecture string: %q", ociArch)
 }
 
+// binfmtMiscDir is a variable for testing
+var binfmtMiscDir = "/proc/sys/fs/binfmt_misc"
+
+func hasQEMUHandler(qemuArch string) bool {
+	candidates := []string{
+		filepath.Join(binfmtMiscDir, "qemu-"+qemuArch),
+		filepath.Join(binfmtMiscDir, "buildkit-qemu-"+qemuArch),
+	}
+	for _, cand := range candidates {
+		if _, err := os.Stat(cand); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 func canExecProbably(s string) (bool, error) {
 	if s == "" {
 		return true, nil
@@ -56,15 +73,7 @@ func canExecProbably(s string) (bool, error) {
 		if err != nil {
 			return false, err
 		}
-		candidates := []string{
-			"/proc/sys/fs/binfmt_misc/qemu-" + qemuArch,
-			"/proc/sys/fs/binfmt_misc/buildkit-qemu-" + qemuArch,
-		}
-		for _, cand := range candidates {
-			if _, err := os.Stat(cand); err == nil {
-				return true, nil
-			}
-		}
+		return hasQEMUHandler(qemuArch), nil
 	}
 	return false, nil
 }
@@ -81,3 +90,37 @@ func CanExecProbably(ss ...string) (bool, error) {
 	}
 	return true, nil
 }
+
+// CheckBinfmt returns an error with a hint to register QEMU when the platform
+// cannot be executed on the host because binfmt_misc has no handler for it.
+//
+// CheckBinfmt returns nil when binfmt_misc is not mounted, or when the architecture
+// is not known to QEMU (e.g., loong64), as the handler cannot be detected in those cases.
+// The runtime decides whether such a platform can be executed.
+func CheckBinfmt(s string) error {
+	if s == "" || runtime.GOOS != "linux" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(binfmtMiscDir, "status")); err != nil {
+		return nil
+	}
+	p, err := platforms.Parse(s)
+	if err != nil {
+		return err
+	}
+	if platforms.Default().Match(p) {
+		return nil
+	}
+	qemuArch, err := qemuArchFromOCIArch(p.Architecture)
+	if err != nil {
+		// may run natively, or with a non-QEMU handler
+		return nil
+	}
+	if hasQEMUHandler(qemuArch) {
+		return nil
+	}
+	return fmt.Errorf("platform %q cannot be executed on the host platform %q: no binfmt_misc handler for %q is registered in %s "+
+		"(hint: run `nerdctl run --privileged --rm tonistiigi/binfmt --install %s` to register QEMU, "+
+		"see https://github.com/containerd/nerdctl/blob/master/docs/multi-platform.md)",
+		platforms.Format(p), platforms.DefaultString(), qemuArch, binfmtMiscDir, p.Architecture)
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package platformutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckBinfmt(t *testing.T) {
+	foreign, qemuArch := "linux/s390x", "s390x"
+	if runtime.GOARCH == "s390x" {
+		foreign, qemuArch = "linux/arm64", "aarch64"
+	}
+
+	orig := binfmtMiscDir
+	defer func() { binfmtMiscDir = orig }()
+
+	// binfmt_misc is not mounted
+	binfmtMiscDir = filepath.Join(t.TempDir(), "binfmt_misc")
+	assert.NilError(t, CheckBinfmt(foreign))
+
+	// binfmt_misc is mounted, but the handler is missing
+	assert.NilError(t, os.MkdirAll(binfmtMiscDir, 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(binfmtMiscDir, "status"), []byte("enabled\n"), 0644))
+	assert.NilError(t, CheckBinfmt(""))
+	assert.NilError(t, CheckBinfmt(runtime.GOOS+"/"+runtime.GOARCH))
+	assert.ErrorContains(t, CheckBinfmt(foreign), "tonistiigi/binfmt")
+	// unknown architectures are left to the runtime
+	assert.NilError(t, CheckBinfmt("linux/loong64"))
+
+	// the handler is registered
+	assert.NilError(t, os.WriteFile(filepath.Join(binfmtMiscDir, "qemu-"+qemuArch), []byte("enabled\n"), 0644))
+	assert.NilError(t, CheckBinfmt(foreign))
+}
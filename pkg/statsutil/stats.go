@@ -42,14 +42,15 @@ type StatsEntry struct {
 
 // FormattedStatsEntry represents a formatted StatsEntry
 type FormattedStatsEntry struct {
-	Name     string
-	ID       string
-	CPUPerc  string
-	MemUsage string
-	MemPerc  string
-	NetIO    string
-	BlockIO  string
-	PIDs     string
+	Container string
+	Name      string
+	ID        string
+	CPUPerc   string
+	MemUsage  string
+	MemPerc   string
+	NetIO     string
+	BlockIO   string
+	PIDs      string
 }
 
 // Stats represents an entity to store containers statistics synchronously
@@ -125,14 +126,15 @@ func calculateMemPercent(limit float64, usedNo float64) float64 {
 // Rendering a FormattedStatsEntry from StatsEntry
 func RenderEntry(in *StatsEntry, noTrunc bool) FormattedStatsEntry {
 	return FormattedStatsEntry{
-		Name:     in.EntryName(),
-		ID:       in.EntryID(noTrunc),
-		CPUPerc:  in.CPUPerc(),
-		MemUsage: in.MemUsage(),
-		MemPerc:  in.MemPerc(),
-		NetIO:    in.NetIO(),
-		BlockIO:  in.BlockIO(),
-		PIDs:     in.PIDs(),
+		Container: in.Container,
+		Name:      in.EntryName(),
+		ID:        in.EntryID(noTrunc),
+		CPUPerc:   in.CPUPerc(),
+		MemUsage:  in.MemUsage(),
+		MemPerc:   in.MemPerc(),
+		NetIO:     in.NetIO(),
+		BlockIO:   in.BlockIO(),
+		PIDs:      in.PIDs(),
 	}
 }
 
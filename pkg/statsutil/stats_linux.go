@@ -17,11 +17,20 @@
 package statsutil
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unsafe"
 
 	v1 "github.com/containerd/cgroups/stats/v1"
 	v2 "github.com/containerd/cgroups/v2/stats"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 func SetCgroupStatsFields(previousCgroupCPU, previousCgroupSystem uint64, data *v1.Metrics, links []netlink.Link) (StatsEntry, error) {
@@ -137,6 +146,9 @@ func calculateCgroupBlockIO(metrics *v1.Metrics) (uint64, uint64) {
 
 func calculateCgroup2IO(metrics *v2.Metrics) (uint64, uint64) {
 	var ioRead, ioWrite uint64
+	if metrics.Io == nil {
+		return ioRead, ioWrite
+	}
 
 	for _, iOEntry := range metrics.Io.Usage {
 		if iOEntry.Rios == 0 && iOEntry.Wios == 0 {
@@ -167,3 +179,167 @@ func calculateCgroupNetwork(links []netlink.Link) (float64, float64) {
 	}
 	return rx, tx
 }
+
+// ProcBlockIO returns the sum of read_bytes and write_bytes in /proc/<pid>/io of the processes.
+//
+// ProcBlockIO is used when the io controller is not enabled for the cgroup v2 of the container,
+// which is typical for rootless containers, as systemd does not delegate the io controller by default.
+// The IO of the exited processes is not counted.
+func ProcBlockIO(pids []int) (uint64, uint64) {
+	var read, write uint64
+	for _, pid := range pids {
+		f, err := os.Open(fmt.Sprintf("/proc/%d/io", pid))
+		if err != nil {
+			// the process may have exited
+			continue
+		}
+		r, w, err := parseProcIO(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		read += r
+		write += w
+	}
+	return read, write
+}
+
+// parseProcIO parses /proc/<pid>/io and returns read_bytes and write_bytes.
+func parseProcIO(r io.Reader) (uint64, uint64, error) {
+	var read, write uint64
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		kv := strings.SplitN(sc.Text(), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		k, v := kv[0], kv[1]
+		var dst *uint64
+		switch k {
+		case "read_bytes":
+			dst = &read
+		case "write_bytes":
+			dst = &write
+		default:
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid line %q: %w", sc.Text(), err)
+		}
+		*dst = n
+	}
+	return read, write, sc.Err()
+}
+
+// BypassedNetIO returns the sum of the received and the acknowledged bytes of the TCP sockets
+// of the processes that do not belong to the network namespace of pid.
+//
+// BypassedNetIO is used for counting the traffic bypassed with bypass4netns, which replaces the sockets
+// of the container with the sockets created in the host network namespace.
+// The traffic of the closed sockets is not counted.
+func BypassedNetIO(pid int, pids []int) (uint64, uint64) {
+	netnsInodes := make(map[string]struct{})
+	for _, f := range []string{"tcp", "tcp6"} {
+		if err := procNetTCPInodes(fmt.Sprintf("/proc/%d/net/%s", pid, f), netnsInodes); err != nil {
+			return 0, 0
+		}
+	}
+	var rx, tx uint64
+	seen := make(map[string]struct{})
+	for _, p := range pids {
+		fdDir := fmt.Sprintf("/proc/%d/fd", p)
+		ents, err := os.ReadDir(fdDir)
+		if err != nil {
+			// the process may have exited
+			continue
+		}
+		pidfd := -1
+		for _, ent := range ents {
+			link, err := os.Readlink(filepath.Join(fdDir, ent.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+			if _, ok := netnsInodes[inode]; ok {
+				continue
+			}
+			if _, ok := seen[inode]; ok {
+				continue
+			}
+			seen[inode] = struct{}{}
+			targetFd, err := strconv.Atoi(ent.Name())
+			if err != nil {
+				continue
+			}
+			if pidfd < 0 {
+				if pidfd, err = unix.PidfdOpen(p, 0); err != nil {
+					break
+				}
+			}
+			r, t, err := tcpBytes(pidfd, targetFd)
+			if err != nil {
+				continue
+			}
+			rx += r
+			tx += t
+		}
+		if pidfd >= 0 {
+			unix.Close(pidfd)
+		}
+	}
+	return rx, tx
+}
+
+// procNetTCPInodes adds the socket inodes listed in /proc/<pid>/net/{tcp,tcp6} to inodes.
+func procNetTCPInodes(path string, inodes map[string]struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	// skip the header
+	sc.Scan()
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		inodes[fields[9]] = struct{}{}
+	}
+	return sc.Err()
+}
+
+// tcpInfo is struct tcp_info (include/uapi/linux/tcp.h) up to tcpi_bytes_received (Linux 4.1+).
+// unix.TCPInfo only covers the fields up to tcpi_total_retrans.
+type tcpInfo struct {
+	unix.TCPInfo
+	PacingRate    uint64
+	MaxPacingRate uint64
+	BytesAcked    uint64
+	BytesReceived uint64
+}
+
+// tcpBytes returns tcpi_bytes_received and tcpi_bytes_acked of the TCP socket targetFd of the process pidfd.
+func tcpBytes(pidfd, targetFd int) (uint64, uint64, error) {
+	fd, err := unix.PidfdGetfd(pidfd, targetFd, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unix.Close(fd)
+	if proto, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_PROTOCOL); err != nil || proto != unix.IPPROTO_TCP {
+		return 0, 0, fmt.Errorf("fd %d is not a TCP socket", targetFd)
+	}
+	// unix.GetsockoptTCPInfo cannot be used, as it truncates tcp_info to unix.TCPInfo
+	var info tcpInfo
+	l := uint32(unsafe.Sizeof(info))
+	if _, _, errno := unix.Syscall6(unix.SYS_GETSOCKOPT, uintptr(fd), unix.IPPROTO_TCP, unix.TCP_INFO,
+		uintptr(unsafe.Pointer(&info)), uintptr(unsafe.Pointer(&l)), 0); errno != 0 {
+		return 0, 0, errno
+	}
+	if l < uint32(unsafe.Sizeof(info)) {
+		return 0, 0, fmt.Errorf("tcp_info is too short (%d bytes)", l)
+	}
+	return info.BytesReceived, info.BytesAcked, nil
+}
@@ -0,0 +1,120 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package statsutil
+
+import (
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseProcIO(t *testing.T) {
+	const procIO = `rchar: 323934931
+wchar: 323929600
+syscr: 632687
+syscw: 632675
+read_bytes: 4096
+write_bytes: 323932160
+cancelled_write_bytes: 0
+`
+	read, write, err := parseProcIO(strings.NewReader(procIO))
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(4096), read)
+	assert.Equal(t, uint64(323932160), write)
+
+	_, _, err = parseProcIO(strings.NewReader("read_bytes: foo\n"))
+	assert.ErrorContains(t, err, "invalid line")
+}
+
+func TestProcBlockIO(t *testing.T) {
+	if _, err := os.Stat("/proc/self/io"); err != nil {
+		t.Skip(err)
+	}
+	_, writeBefore := ProcBlockIO([]int{os.Getpid()})
+
+	const size = 4 * 1024 * 1024
+	f, err := os.CreateTemp(t.TempDir(), "procblockio")
+	assert.NilError(t, err)
+	defer f.Close()
+	_, err = f.Write(make([]byte, size))
+	assert.NilError(t, err)
+	assert.NilError(t, f.Sync())
+
+	// nonexistent processes are ignored
+	_, writeAfter := ProcBlockIO([]int{os.Getpid(), -1})
+	if writeAfter == writeBefore {
+		t.Skip("write_bytes is not accounted for the filesystem of the temp dir (e.g., tmpfs)")
+	}
+	assert.Assert(t, writeAfter-writeBefore >= size, "expected at least %d bytes, got %d", size, writeAfter-writeBefore)
+}
+
+func TestTCPInfoLayout(t *testing.T) {
+	// the offsets in struct tcp_info
+	assert.Equal(t, uintptr(104), unsafe.Sizeof(unix.TCPInfo{}))
+	assert.Equal(t, uintptr(120), unsafe.Offsetof(tcpInfo{}.BytesAcked))
+	assert.Equal(t, uintptr(128), unsafe.Offsetof(tcpInfo{}.BytesReceived))
+}
+
+func TestTCPBytes(t *testing.T) {
+	pidfd, err := unix.PidfdOpen(os.Getpid(), 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer unix.Close(pidfd)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer l.Close()
+	client, err := net.Dial("tcp", l.Addr().String())
+	assert.NilError(t, err)
+	defer client.Close()
+	server, err := l.Accept()
+	assert.NilError(t, err)
+	defer server.Close()
+
+	const size = 12345
+	_, err = client.Write(make([]byte, size))
+	assert.NilError(t, err)
+	_, err = io.ReadFull(server, make([]byte, size))
+	assert.NilError(t, err)
+
+	f, err := server.(*net.TCPConn).File()
+	assert.NilError(t, err)
+	defer f.Close()
+	rx, _, err := tcpBytes(pidfd, int(f.Fd()))
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(size), rx)
+
+	lf, err := l.(*net.TCPListener).File()
+	assert.NilError(t, err)
+	defer lf.Close()
+	rx, tx, err := tcpBytes(pidfd, int(lf.Fd()))
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(0), rx)
+	assert.Equal(t, uint64(0), tx)
+
+	// the sockets in the network namespace of the process are not counted
+	rx, tx = BypassedNetIO(os.Getpid(), []int{os.Getpid()})
+	assert.Equal(t, uint64(0), rx)
+	assert.Equal(t, uint64(0), tx)
+}
@@ -0,0 +1,58 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package statsutil
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRenderEntry(t *testing.T) {
+	entry := StatsEntry{
+		Container:        "1234567890abcdef1234567890abcdef",
+		Name:             "foo",
+		ID:               "1234567890abcdef1234567890abcdef",
+		CPUPercentage:    12.345,
+		Memory:           1024 * 1024,
+		MemoryLimit:      4 * 1024 * 1024,
+		MemoryPercentage: 25,
+		NetworkRx:        1000,
+		NetworkTx:        2000,
+		BlockRead:        3000,
+		BlockWrite:       4000,
+		PidsCurrent:      3,
+	}
+	assert.DeepEqual(t, FormattedStatsEntry{
+		Container: "1234567890abcdef1234567890abcdef",
+		Name:      "foo",
+		ID:        "1234567890ab",
+		CPUPerc:   "12.35%",
+		MemUsage:  "1MiB / 4MiB",
+		MemPerc:   "25.00%",
+		NetIO:     "1kB / 2kB",
+		BlockIO:   "3kB / 4kB",
+		PIDs:      "3",
+	}, RenderEntry(&entry, false))
+	assert.Equal(t, entry.ID, RenderEntry(&entry, true).ID)
+
+	entry.IsInvalid = true
+	rc := RenderEntry(&entry, false)
+	assert.Equal(t, "--", rc.CPUPerc)
+	assert.Equal(t, "-- / --", rc.MemUsage)
+	assert.Equal(t, "--", rc.PIDs)
+}
@@ -19,6 +19,7 @@ package taskutil
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/url"
 	"os"
@@ -31,26 +32,51 @@ import (
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/cio"
 	"github.com/containerd/nerdctl/pkg/infoutil"
+	"github.com/containerd/nerdctl/pkg/strutil"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/term"
 )
 
 // NewTask is from https://github.com/containerd/containerd/blob/v1.4.3/cmd/ctr/commands/tasks/tasks_unix.go#L70-L108
-func NewTask(ctx context.Context, client *containerd.Client, container containerd.Container, flagI, flagT, flagD bool, con console.Console, logURI string) (containerd.Task, error) {
+//
+// attachStreams is the list of the streams ("STDIN", "STDOUT", "STDERR") to attach, or nil for all the streams.
+// The stdin is attached when flagI is true and STDIN is in attachStreams.
+// With flagI, the stdin that is not attached is kept open, without writing anything, like Docker.
+func NewTask(ctx context.Context, client *containerd.Client, container containerd.Container, flagI, flagT, flagD bool, attachStreams []string, con console.Console, logURI string) (containerd.Task, error) {
 	var ioCreator cio.Creator
-	if flagT {
+	if flagT && flagD {
+		// Detached TTY: the terminal output is sent to the logging binary, without attaching nerdctl to the console.
+		if logURI == "" {
+			return nil, errors.New("got empty logURI with flagT=true and flagD=true")
+		}
+		u, err := url.Parse(logURI)
+		if err != nil {
+			return nil, err
+		}
+		ioCreator, err = TerminalLogURI(u)
+		if err != nil {
+			return nil, err
+		}
+	} else if flagT {
 		if con == nil {
 			return nil, errors.New("got nil con with flagT=true")
 		}
 		var in io.Reader
-		if flagI {
+		if flagI && !attached(attachStreams, "STDIN") {
+			in = unattachedStdin()
+		} else if flagI {
 			// FIXME: check IsTerminal on Windows too
 			if runtime.GOOS != "windows" && !term.IsTerminal(0) {
 				return nil, errors.New("the input device is not a TTY")
 			}
 			in = con
 		}
-		ioCreator = cio.NewCreator(cio.WithStreams(in, con, nil), cio.WithTerminal)
+		// the TTY combines stdout and stderr
+		var out io.Writer = con
+		if !attached(attachStreams, "STDOUT") && !attached(attachStreams, "STDERR") {
+			out = io.Discard
+		}
+		ioCreator = cio.NewCreator(cio.WithStreams(in, out, nil), cio.WithTerminal)
 	} else if flagD && logURI != "" {
 		// TODO: support logURI for `nerdctl run -it`
 		u, err := url.Parse(logURI)
@@ -60,7 +86,9 @@ func NewTask(ctx context.Context, client *containerd.Client, container container
 		ioCreator = cio.LogURI(u)
 	} else {
 		var in io.Reader
-		if flagI {
+		if flagI && !attached(attachStreams, "STDIN") {
+			in = unattachedStdin()
+		} else if flagI {
 			if sv, err := infoutil.ServerSemVer(ctx, client); err != nil {
 				logrus.Warn(err)
 			} else if sv.LessThan(semver.MustParse("1.6.0-0")) {
@@ -78,7 +106,14 @@ func NewTask(ctx context.Context, client *containerd.Client, container container
 			}
 			in = stdinC
 		}
-		ioCreator = cio.NewCreator(cio.WithStreams(in, os.Stdout, os.Stderr))
+		var stdout, stderr io.Writer = os.Stdout, os.Stderr
+		if !attached(attachStreams, "STDOUT") {
+			stdout = io.Discard
+		}
+		if !attached(attachStreams, "STDERR") {
+			stderr = io.Discard
+		}
+		ioCreator = cio.NewCreator(cio.WithStreams(in, stdout, stderr))
 	}
 	t, err := container.NewTask(ctx, ioCreator)
 	if err != nil {
@@ -87,6 +122,33 @@ func NewTask(ctx context.Context, client *containerd.Client, container container
 	return t, nil
 }
 
+// attached returns whether the stream is in attachStreams. All the streams are attached when attachStreams is nil.
+func attached(attachStreams []string, stream string) bool {
+	return attachStreams == nil || strutil.InStringSlice(attachStreams, stream)
+}
+
+// unattachedStdin returns the reader that blocks forever, for keeping the stdin of the container open
+// without attaching the stdin of nerdctl (`-i` without `-a STDIN`).
+func unattachedStdin() io.Reader {
+	r, _ := io.Pipe()
+	return r
+}
+
+// TerminalLogURI is like cio.LogURI, but for a task with a TTY.
+// Only the "binary" scheme is supported.
+func TerminalLogURI(u *url.URL) (cio.Creator, error) {
+	if u.Scheme != "binary" {
+		return nil, fmt.Errorf("unsupported log URI scheme %q for a detached TTY", u.Scheme)
+	}
+	args := make(map[string]string)
+	for k, vs := range u.Query() {
+		if len(vs) > 0 {
+			args[k] = vs[0]
+		}
+	}
+	return cio.TerminalBinaryIO(u.Path, args), nil
+}
+
 // StdinCloser is from https://github.com/containerd/containerd/blob/v1.4.3/cmd/ctr/commands/tasks/exec.go#L181-L194
 type StdinCloser struct {
 	mu     sync.Mutex
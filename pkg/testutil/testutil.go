@@ -309,6 +309,17 @@ func (c *Cmd) AssertCombinedOutContains(s string) {
 	assert.Assert(c.Base.T, strings.Contains(res.Combined(), s))
 }
 
+// AssertErrContains asserts that the command fails with the exit code 1
+// and that the stderr contains s.
+func (c *Cmd) AssertErrContains(s string) {
+	c.Base.T.Helper()
+	expected := icmd.Expected{
+		ExitCode: 1,
+		Err:      s,
+	}
+	c.Assert(expected)
+}
+
 func (c *Cmd) AssertOutNotContains(s string) {
 	c.AssertOutWithFunc(func(stdout string) error {
 		if strings.Contains(stdout, s) {
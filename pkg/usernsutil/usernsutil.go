@@ -0,0 +1,184 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package usernsutil provides the user namespace mappings for `--userns-remap`.
+package usernsutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// SubUIDPath and SubGIDPath are variables for testing
+var (
+	SubUIDPath = "/etc/subuid"
+	SubGIDPath = "/etc/subgid"
+)
+
+// Remap is the parsed value of `--userns-remap`.
+type Remap struct {
+	UIDMaps []specs.LinuxIDMapping
+	GIDMaps []specs.LinuxIDMapping
+}
+
+// HostUID returns the host uid that the container root is mapped to.
+func (r *Remap) HostUID() uint32 {
+	return r.UIDMaps[0].HostID
+}
+
+// HostGID returns the host gid that the container root is mapped to.
+func (r *Remap) HostGID() uint32 {
+	return r.GIDMaps[0].HostID
+}
+
+// Parse parses `--userns-remap` value ("USER[:GROUP]") and loads the subordinate id ranges
+// of the user (and the group) from /etc/subuid and /etc/subgid.
+//
+// USER and GROUP can be either names or numeric ids.
+// When GROUP is omitted, the subordinate gid range of USER is used.
+//
+// Only the first range of each file is used, so that the ownership of the snapshot
+// can be shifted by a constant offset.
+func Parse(s string) (*Remap, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty userns-remap value")
+	}
+	userPart, groupPart := s, ""
+	if i := strings.Index(s, ":"); i >= 0 {
+		userPart, groupPart = s[:i], s[i+1:]
+	}
+	if userPart == "" {
+		return nil, fmt.Errorf("invalid userns-remap value %q, expected USER[:GROUP]", s)
+	}
+	userKeys, err := lookupUserKeys(userPart)
+	if err != nil {
+		return nil, err
+	}
+	groupKeys := userKeys
+	if groupPart != "" {
+		groupKeys, err = lookupGroupKeys(groupPart)
+		if err != nil {
+			return nil, err
+		}
+	}
+	uidMap, err := loadFirstRange(SubUIDPath, userKeys)
+	if err != nil {
+		return nil, err
+	}
+	gidMap, err := loadFirstRange(SubGIDPath, groupKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &Remap{
+		UIDMaps: []specs.LinuxIDMapping{uidMap},
+		GIDMaps: []specs.LinuxIDMapping{gidMap},
+	}, nil
+}
+
+// lookupUserKeys returns the name and the numeric uid of the user, when they are resolvable.
+func lookupUserKeys(s string) ([]string, error) {
+	if _, err := strconv.ParseUint(s, 10, 32); err == nil {
+		if u, err := user.LookupId(s); err == nil {
+			return []string{u.Username, s}, nil
+		}
+		return []string{s}, nil
+	}
+	u, err := user.Lookup(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up userns-remap user %q: %w", s, err)
+	}
+	return []string{s, u.Uid}, nil
+}
+
+// lookupGroupKeys returns the name and the numeric gid of the group, when they are resolvable.
+func lookupGroupKeys(s string) ([]string, error) {
+	if _, err := strconv.ParseUint(s, 10, 32); err == nil {
+		if g, err := user.LookupGroupId(s); err == nil {
+			return []string{g.Name, s}, nil
+		}
+		return []string{s}, nil
+	}
+	g, err := user.LookupGroup(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up userns-remap group %q: %w", s, err)
+	}
+	return []string{s, g.Gid}, nil
+}
+
+func loadFirstRange(path string, keys []string) (specs.LinuxIDMapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return specs.LinuxIDMapping{}, err
+	}
+	defer f.Close()
+	m, ok, err := parseFirstRange(f, keys)
+	if err != nil {
+		return specs.LinuxIDMapping{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if !ok {
+		return specs.LinuxIDMapping{}, fmt.Errorf("no subordinate id range for %q was found in %s", keys[0], path)
+	}
+	return m, nil
+}
+
+// parseFirstRange parses the subid(5) file and returns the first range that belongs to any of keys.
+func parseFirstRange(r io.Reader, keys []string) (specs.LinuxIDMapping, bool, error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) != 3 {
+			return specs.LinuxIDMapping{}, false, fmt.Errorf("invalid line %q", line)
+		}
+		matched := false
+		for _, k := range keys {
+			if fields[0] == k {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		start, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return specs.LinuxIDMapping{}, false, fmt.Errorf("invalid line %q: %w", line, err)
+		}
+		count, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return specs.LinuxIDMapping{}, false, fmt.Errorf("invalid line %q: %w", line, err)
+		}
+		if count == 0 {
+			continue
+		}
+		return specs.LinuxIDMapping{
+			ContainerID: 0,
+			HostID:      uint32(start),
+			Size:        uint32(count),
+		}, true, nil
+	}
+	return specs.LinuxIDMapping{}, false, sc.Err()
+}
@@ -0,0 +1,37 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package usernsutil
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ShiftOwnership shifts the ownership of the files under root by the remapped host IDs,
+// in the same way as containerd.WithRemappedSnapshot does for the snapshot.
+func (r *Remap) ShiftOwnership(root string) error {
+	uidInc, gidInc := r.HostUID(), r.HostGID()
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		stat := info.Sys().(*syscall.Stat_t)
+		// lchown the path so as not to dereference the symlink to a host file
+		return os.Lchown(path, int(stat.Uid+uidInc), int(stat.Gid+gidInc))
+	})
+}
@@ -0,0 +1,58 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package usernsutil
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+)
+
+func TestShiftOwnership(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("test requires root")
+	}
+	root := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(root, "file"), nil, 0644))
+	assert.NilError(t, os.Lchown(filepath.Join(root, "file"), 1, 2))
+	assert.NilError(t, os.Symlink("/etc/passwd", filepath.Join(root, "symlink")))
+
+	remap := &Remap{
+		UIDMaps: []specs.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+		GIDMaps: []specs.LinuxIDMapping{{ContainerID: 0, HostID: 200000, Size: 65536}},
+	}
+	assert.NilError(t, remap.ShiftOwnership(root))
+
+	for path, expected := range map[string][2]uint32{
+		root:                           {100000, 200000},
+		filepath.Join(root, "file"):    {100001, 200002},
+		filepath.Join(root, "symlink"): {100000, 200000},
+	} {
+		st, err := os.Lstat(path)
+		assert.NilError(t, err)
+		stat := st.Sys().(*syscall.Stat_t)
+		assert.Equal(t, expected, [2]uint32{stat.Uid, stat.Gid}, path)
+	}
+	// the symlink is not followed
+	st, err := os.Stat("/etc/passwd")
+	assert.NilError(t, err)
+	assert.Equal(t, uint32(0), st.Sys().(*syscall.Stat_t).Uid)
+}
@@ -0,0 +1,54 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package usernsutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseFirstRange(t *testing.T) {
+	const subid = `# comment
+alice:100000:65536
+1001:165536:65536
+bob:231072:0
+bob:296608:65536
+bob:362144:65536
+`
+	testCases := []struct {
+		keys     []string
+		expected specs.LinuxIDMapping
+		ok       bool
+	}{
+		{[]string{"alice", "1000"}, specs.LinuxIDMapping{ContainerID: 0, HostID: 100000, Size: 65536}, true},
+		{[]string{"carol", "1001"}, specs.LinuxIDMapping{ContainerID: 0, HostID: 165536, Size: 65536}, true},
+		{[]string{"bob"}, specs.LinuxIDMapping{ContainerID: 0, HostID: 296608, Size: 65536}, true},
+		{[]string{"dave", "1003"}, specs.LinuxIDMapping{}, false},
+	}
+	for _, tc := range testCases {
+		m, ok, err := parseFirstRange(strings.NewReader(subid), tc.keys)
+		assert.NilError(t, err)
+		assert.Equal(t, tc.ok, ok, "keys %v", tc.keys)
+		assert.DeepEqual(t, tc.expected, m)
+	}
+
+	_, _, err := parseFirstRange(strings.NewReader("alice:100000\n"), []string{"alice"})
+	assert.ErrorContains(t, err, "invalid line")
+}
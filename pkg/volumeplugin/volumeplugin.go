@@ -0,0 +1,221 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package volumeplugin implements the client of the Docker volume plugin protocol.
+//
+// See https://docs.docker.com/engine/extend/plugins_volume/
+package volumeplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+)
+
+// LocalDriver is the name of the built-in driver, which is not a plugin.
+const LocalDriver = "local"
+
+// SocketDirs and SpecDirs are the directories to discover the plugins, in the same way as Docker.
+// They are variables for testing.
+var (
+	SocketDirs = []string{"/run/docker/plugins"}
+	SpecDirs   = []string{"/etc/docker/plugins", "/usr/lib/docker/plugins"}
+)
+
+const (
+	mediaType = "application/vnd.docker.plugins.v1.2+json"
+	timeout   = 30 * time.Second
+)
+
+// Plugin is a volume driver plugin.
+type Plugin struct {
+	Name   string
+	addr   string
+	client *http.Client
+}
+
+// Get discovers the plugin and activates it.
+// Get returns ErrNotFound when the plugin is not found.
+func Get(name string) (*Plugin, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid volume driver name %q: %w", name, errdefs.ErrInvalidArgument)
+	}
+	addr, err := discover(name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := newPlugin(name, addr)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Implements []string
+	}
+	if err := p.call("/Plugin.Activate", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to activate volume driver plugin %q: %w", name, err)
+	}
+	for _, s := range resp.Implements {
+		if s == "VolumeDriver" {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("plugin %q does not implement VolumeDriver (implements %v)", name, resp.Implements)
+}
+
+// discover returns the address like "unix:///run/docker/plugins/foo.sock" of the plugin.
+func discover(name string) (string, error) {
+	for _, dir := range SocketDirs {
+		for _, p := range []string{filepath.Join(dir, name+".sock"), filepath.Join(dir, name, name+".sock")} {
+			if st, err := os.Stat(p); err == nil && st.Mode()&os.ModeSocket != 0 {
+				return "unix://" + p, nil
+			}
+		}
+	}
+	for _, dir := range SpecDirs {
+		if b, err := os.ReadFile(filepath.Join(dir, name+".spec")); err == nil {
+			return strings.TrimSpace(string(b)), nil
+		}
+		if b, err := os.ReadFile(filepath.Join(dir, name+".json")); err == nil {
+			var spec struct {
+				Addr string
+			}
+			if err := json.Unmarshal(b, &spec); err != nil {
+				return "", fmt.Errorf("failed to parse the spec of plugin %q: %w", name, err)
+			}
+			return spec.Addr, nil
+		}
+	}
+	return "", fmt.Errorf("volume driver plugin %q not found in %v and %v: %w", name, SocketDirs, SpecDirs, errdefs.ErrNotFound)
+}
+
+func newPlugin(name, addr string) (*Plugin, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q of plugin %q: %w", addr, name, err)
+	}
+	tr := &http.Transport{}
+	switch u.Scheme {
+	case "unix":
+		sock := u.Path
+		tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", sock)
+		}
+		addr = "http://plugin"
+	case "tcp":
+		addr = "http://" + u.Host
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported address %q of plugin %q", addr, name)
+	}
+	return &Plugin{
+		Name:   name,
+		addr:   addr,
+		client: &http.Client{Transport: tr, Timeout: timeout},
+	}, nil
+}
+
+func (p *Plugin) call(method string, req, resp interface{}) error {
+	var body bytes.Buffer
+	if req != nil {
+		if err := json.NewEncoder(&body).Encode(req); err != nil {
+			return err
+		}
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, p.addr+method, &body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", mediaType)
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	var errResp struct {
+		Err string
+	}
+	b := new(bytes.Buffer)
+	if _, err := b.ReadFrom(httpResp.Body); err != nil {
+		return err
+	}
+	// Err is set even on errors with non-200 status
+	if err := json.Unmarshal(b.Bytes(), &errResp); err == nil && errResp.Err != "" {
+		return fmt.Errorf("%s: %s", method, errResp.Err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %d: %s", method, httpResp.StatusCode, strings.TrimSpace(b.String()))
+	}
+	if resp != nil {
+		return json.Unmarshal(b.Bytes(), resp)
+	}
+	return nil
+}
+
+// Create calls VolumeDriver.Create.
+func (p *Plugin) Create(name string, opts map[string]string) error {
+	req := struct {
+		Name string
+		Opts map[string]string
+	}{name, opts}
+	return p.call("/VolumeDriver.Create", req, nil)
+}
+
+// Remove calls VolumeDriver.Remove.
+func (p *Plugin) Remove(name string) error {
+	req := struct {
+		Name string
+	}{name}
+	return p.call("/VolumeDriver.Remove", req, nil)
+}
+
+// Mount calls VolumeDriver.Mount and returns the host path of the volume.
+// id is a unique ID of the caller, i.e., the container ID.
+func (p *Plugin) Mount(name, id string) (string, error) {
+	req := struct {
+		Name string
+		ID   string
+	}{name, id}
+	var resp struct {
+		Mountpoint string
+	}
+	if err := p.call("/VolumeDriver.Mount", req, &resp); err != nil {
+		return "", err
+	}
+	if resp.Mountpoint == "" {
+		return "", fmt.Errorf("volume driver plugin %q returned an empty mountpoint for volume %q", p.Name, name)
+	}
+	return resp.Mountpoint, nil
+}
+
+// Unmount calls VolumeDriver.Unmount.
+func (p *Plugin) Unmount(name, id string) error {
+	req := struct {
+		Name string
+		ID   string
+	}{name, id}
+	return p.call("/VolumeDriver.Unmount", req, nil)
+}